@@ -13,12 +13,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -27,10 +29,12 @@ import (
 
 	gonostr "github.com/nbd-wtf/go-nostr"
 	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/bridge"
 	"github.com/klppl/klistr/internal/bsky"
 	"github.com/klppl/klistr/internal/config"
 	"github.com/klppl/klistr/internal/db"
 	nostrpkg "github.com/klppl/klistr/internal/nostr"
+	"github.com/klppl/klistr/internal/notify"
 	"github.com/klppl/klistr/internal/server"
 )
 
@@ -46,6 +50,10 @@ func (a *relayManagerAdapter) Relays() []string { return a.publisher.Relays() }
 
 func (a *relayManagerAdapter) RelayStatuses() []server.RelayStatus {
 	src := a.publisher.RelayStatuses()
+	conns := make(map[string]nostrpkg.RelayConnState)
+	for _, c := range a.pool.ConnStates() {
+		conns[c.URL] = c
+	}
 	out := make([]server.RelayStatus, len(src))
 	for i, s := range src {
 		out[i] = server.RelayStatus{
@@ -53,6 +61,17 @@ func (a *relayManagerAdapter) RelayStatuses() []server.RelayStatus {
 			CircuitOpen:       s.CircuitOpen,
 			FailCount:         s.FailCount,
 			CooldownRemaining: s.CooldownRemaining,
+			AvgLatencyMs:      s.AvgLatency.Milliseconds(),
+		}
+		if !s.LastSuccess.IsZero() {
+			out[i].LastSuccessAt = s.LastSuccess.Unix()
+		}
+		if c, ok := conns[s.URL]; ok {
+			out[i].Subscribed = c.Subscribed
+			out[i].Connected = c.Connected
+			if !c.LastEventAt.IsZero() {
+				out[i].LastEventAt = c.LastEventAt.Unix()
+			}
 		}
 	}
 	return out
@@ -76,6 +95,15 @@ func (a *relayManagerAdapter) RemoveRelay(url string) bool {
 	return removed
 }
 
+func (a *relayManagerAdapter) SetRelays(urls []string) (added, removed []string) {
+	added, removed = a.publisher.SetRelays(urls)
+	if len(added) > 0 || len(removed) > 0 {
+		a.pool.SetRelays(a.publisher.Relays())
+		a.persist()
+	}
+	return added, removed
+}
+
 func (a *relayManagerAdapter) ResetCircuit(url string) { a.publisher.ResetCircuit(url) }
 
 func (a *relayManagerAdapter) TestRelay(ctx context.Context, url string) error {
@@ -113,28 +141,93 @@ func (a *followPublisherAdapter) Publish(ctx context.Context, event *gonostr.Eve
 	return a.publisher.Publish(ctx, event)
 }
 
+// deliveryQueueAdapter satisfies ap.DeliveryQueue, converting db rows to the
+// ap package's QueuedDelivery type.
+type deliveryQueueAdapter struct {
+	store *db.Store
+}
+
+func (a *deliveryQueueAdapter) EnqueueDelivery(inbox, activityID, activity string, attempts int, nextRetry time.Time, lastError string) error {
+	return a.store.EnqueueDelivery(inbox, activityID, activity, attempts, nextRetry, lastError)
+}
+func (a *deliveryQueueAdapter) GetDueDeliveries(now time.Time, limit int) ([]ap.QueuedDelivery, error) {
+	rows, err := a.store.GetDueDeliveries(now, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ap.QueuedDelivery, len(rows))
+	for i, r := range rows {
+		out[i] = ap.QueuedDelivery{Inbox: r.Inbox, ActivityID: r.ActivityID, Activity: r.Activity, Attempts: r.Attempts}
+	}
+	return out, nil
+}
+func (a *deliveryQueueAdapter) RescheduleDelivery(inbox, activityID string, attempts int, nextRetry time.Time, lastError string) error {
+	return a.store.RescheduleDelivery(inbox, activityID, attempts, nextRetry, lastError)
+}
+func (a *deliveryQueueAdapter) DeleteDelivery(inbox, activityID string) error {
+	return a.store.DeleteDelivery(inbox, activityID)
+}
+
+// fanOutTrigger forwards each signal on in to every channel in outs, without
+// blocking when one already has a poll queued.
+func fanOutTrigger(ctx context.Context, in <-chan struct{}, outs []chan<- struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-in:
+			for _, out := range outs {
+				select {
+				case out <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
 func main() {
-	// Health check mode: invoked by the Docker healthcheck as "/klistr -health".
+	// Health check mode: invoked by the Docker healthcheck as
+	// "/klistr -health quick", which only checks that the HTTP server answers.
 	// Runs before config.Load() so it works even without NOSTR_PRIVATE_KEY set
-	// in the exec context. Exits 0 on HTTP 200, 1 on any error.
+	// in the exec context. Exits 0 on HTTP 200, 1 on any error — with the full
+	// "-health", including a 503 from a failed database.
 	if len(os.Args) > 1 && (os.Args[1] == "-health" || os.Args[1] == "--health") {
 		port := os.Getenv("PORT")
 		if port == "" {
 			port = "8000"
 		}
-		resp, err := http.Get("http://localhost:" + port + "/api/healthcheck")
+		healthURL := "http://localhost:" + port + "/api/healthcheck"
+		if len(os.Args) > 2 && os.Args[2] == "quick" {
+			healthURL += "?quick=true"
+		}
+		resp, err := http.Get(healthURL)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "unhealthy:", err)
 			os.Exit(1)
 		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
 		resp.Body.Close()
 		if resp.StatusCode == http.StatusOK {
 			os.Exit(0)
 		}
-		fmt.Fprintf(os.Stderr, "unhealthy: HTTP %d\n", resp.StatusCode)
+		fmt.Fprintf(os.Stderr, "unhealthy: HTTP %d %s\n", resp.StatusCode, body)
 		os.Exit(1)
 	}
 
+	// Backfill mode: "klistr -backfill N" maps the N most recent Nostr posts
+	// to AP objects, then exits without starting the bridge.
+	backfillN := 0
+	if len(os.Args) > 1 && (os.Args[1] == "-backfill" || os.Args[1] == "--backfill") {
+		if len(os.Args) > 2 {
+			backfillN, _ = strconv.Atoi(os.Args[2])
+		}
+		if backfillN <= 0 {
+			fmt.Fprintln(os.Stderr, "usage: klistr -backfill N")
+			os.Exit(2)
+		}
+	}
+
 	// Structured JSON logging. When WEB_ADMIN is set, a LogBroadcaster wraps
 	// os.Stdout so the live log stream at /web/log/stream can fan out entries.
 	logLevel := slog.LevelInfo
@@ -167,7 +260,15 @@ func main() {
 	// ─── Tunable constants ────────────────────────────────────────────────────
 	// Applied before any component is created so they take effect from the start.
 	ap.SetObjectCacheTTL(cfg.APCacheTTL)
+	ap.SetObjectCacheSize(cfg.APCacheMaxEntries)
+	ap.SetOutboundHeaders(cfg.OutboundHeaders)
+	ap.SetClientIdentity(cfg.UserAgent, cfg.LocalDomain, cfg.OperatorEmail)
+	bsky.SetUserAgent(ap.UserAgent())
+	notify.SetUserAgent(ap.UserAgent())
+	bridge.SetEchoTTL(cfg.EchoTTL)
+	bridge.SetMaxNoteLength(cfg.MaxNoteLength)
 	nostrpkg.SetCircuitBreakerThreshold(cfg.RelayCBThreshold)
+	nostrpkg.SetCircuitBreakerMaxCooldown(cfg.RelayCBMaxCooldown)
 
 	// ─── Database ─────────────────────────────────────────────────────────────
 	store, err := db.Open(cfg.DatabaseURL)
@@ -220,6 +321,9 @@ func main() {
 	if v, ok := store.GetKV("setting_zap_pubkey"); ok {
 		cfg.ZapPubkey = v
 	}
+	if v, ok := store.GetKV("setting_lightning_address"); ok {
+		cfg.LightningAddress = v
+	}
 	if v, ok := store.GetKV("setting_zap_split"); ok && v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil {
 			cfg.ZapSplit = f
@@ -231,6 +335,16 @@ func main() {
 	showSourceLink.Store(cfg.ShowSourceLink)
 	autoAcceptFollowsBool := &atomic.Bool{}
 	autoAcceptFollowsBool.Store(autoAcceptFollowsVal)
+	// Event kinds federated to the Fediverse — updated live by the admin
+	// settings API. Absent from kv means every kind.
+	bridgedKinds := &nostrpkg.BridgedKinds{}
+	if v, ok := store.GetKV("setting_bridged_kinds"); ok {
+		bridgedKinds.Set(nostrpkg.ParseKinds(v))
+	}
+	// Authors whose posts are not bridged — loaded and updated by the server.
+	bridgeExclusions := &bridge.AuthorExclusions{}
+	// Keyword/regex filters on inbound posts — loaded and updated by the server.
+	contentFilter := &bridge.ContentFilter{}
 
 	// ─── RSA Key Pair (auto-generated if missing) ─────────────────────────────
 	keyPair, err := ap.LoadOrGenerateKeyPair(cfg.RSAPrivateKeyPath, cfg.RSAPublicKeyPath)
@@ -239,33 +353,114 @@ func main() {
 		os.Exit(1)
 	}
 	slog.Info("RSA key pair ready")
+	if cfg.Ed25519PrivateKeyPath != "" {
+		if err := keyPair.LoadOrGenerateEd25519(cfg.Ed25519PrivateKeyPath); err != nil {
+			slog.Error("failed to load/generate Ed25519 key", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Ed25519 key ready")
+	}
+	keyPair.RetireGrace = cfg.KeyRotationGrace
+	if err := keyPair.LoadPending(cfg.RSAPrivateKeyPath, cfg.RSAPublicKeyPath); err != nil {
+		slog.Warn("ignoring unreadable prepared RSA key", "error", err)
+	}
+	if v, ok := store.GetKV("rsa_retired_keys"); ok {
+		var retired []ap.RetiredKey
+		if err := json.Unmarshal([]byte(v), &retired); err != nil {
+			slog.Warn("ignoring unreadable retired RSA keys", "error", err)
+		} else {
+			keyPair.SetRetired(retired)
+		}
+	}
+	ap.SetFetchSigner(cfg.BaseURL("/actor#main-key"), keyPair, cfg.SignedFetch)
 
 	// ─── Nostr Signer ─────────────────────────────────────────────────────────
 	signer := nostrpkg.NewSigner(cfg.NostrPrivateKey, cfg.NostrPublicKey)
+	signer.SetNotificationRecipient(cfg.NotificationPubkey)
+	signer.SetDMFormat(cfg.DMFormat)
+	signer.SetDelegation(cfg.Delegation)
 
 	// ─── Nostr Publisher ──────────────────────────────────────────────────────
 	publisher := nostrpkg.NewPublisher(cfg.NostrRelays)
+	publisher.Timeout = cfg.PublishTimeout
+	publisher.Concurrency = cfg.PublishConcurrency
+	publisher.Quorum = cfg.PublishQuorum
+	publisher.SetCircuitStore(store)
+
+	// ─── Webhook (optional) ───────────────────────────────────────────────────
+	webhook := notify.New(cfg.WebhookURL, cfg.WebhookSecret)
+	if webhook != nil {
+		slog.Info("webhook notifications enabled")
+	}
+	publisher.Notifier = webhook
 
 	// ─── AP Transmute Context ─────────────────────────────────────────────────
 	localActorURL := cfg.BaseURL("/users/" + cfg.NostrUsername)
 	tc := &ap.TransmuteContext{
 		LocalDomain:   cfg.LocalDomain,
 		LocalActorURL: localActorURL,
-		PublicKeyPem:  keyPair.PublicPEM,
+		Keys:          keyPair,
 		GetAPIDForObject: func(nostrID string) (string, bool) {
 			return store.GetAPIDForObject(nostrID)
 		},
+		GetActorForKey: func(pubkey string) (string, bool) {
+			return store.GetActorForKey(pubkey)
+		},
+		MaxNoteLength:  cfg.LongNoteThreshold,
+		LongNoteMode:   cfg.LongNoteMode,
+		BridgeLocation: cfg.BridgeLocation,
+		Discoverable:   cfg.ActorDiscoverable,
+		Indexable:      cfg.ActorIndexable,
+
+		UnlistedTag:      cfg.UnlistedTag,
+		FollowersOnlyTag: cfg.FollowersOnlyTag,
+		ExternalBaseURL:  cfg.ExternalBaseURL,
+	}
+
+	if backfillN > 0 {
+		result, err := server.Backfill(context.Background(), cfg.NostrRelays, cfg.NostrPublicKey, store, tc, backfillN, nil)
+		if err != nil {
+			slog.Error("backfill failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Println(result)
+		return
 	}
 
 	// ─── AP Federator ─────────────────────────────────────────────────────────
 	federator := &ap.Federator{
-		LocalDomain: cfg.LocalDomain,
-		KeyID:       localActorURL + "#main-key",
-		PrivateKey:  keyPair.Private,
-		Concurrency: cfg.APFederationConcurrency,
+		LocalDomain:     cfg.LocalDomain,
+		KeyID:           localActorURL + "#main-key",
+		Keys:            keyPair,
+		Ed25519Key:      keyPair.Ed25519,
+		Concurrency:     cfg.APFederationConcurrency,
+		DeliveryTimeout: cfg.FederationTimeout,
+		HostCBThreshold: cfg.FederationCBThreshold,
+		HostCBCooldown:  cfg.FederationCBCooldown,
 		GetFollowers: func(actorURL string) ([]string, error) {
 			return store.GetFollowers(actorURL)
 		},
+		Queue: &deliveryQueueAdapter{store: store},
+		PruneFollower: func(actorID, reason string) {
+			n, err := store.RemoveFollower(actorID)
+			if err != nil {
+				slog.Warn("failed to prune gone follower", "actor", actorID, "error", err)
+				return
+			}
+			if n > 0 {
+				_ = store.WriteAuditLog("follower_pruned", actorID+" ("+reason+")")
+			}
+		},
+		FollowSent: func(followerID, followedID string) {
+			// Only the local actor's follows await an Accept that
+			// handleAccept settles; anything else would never be cleared.
+			if followerID != localActorURL {
+				return
+			}
+			if err := store.MarkFollowRequested(followerID, followedID); err != nil {
+				slog.Warn("failed to record follow request", "followed", followedID, "error", err)
+			}
+		},
 	}
 
 	// ─── AP Handler (incoming ActivityPub → Nostr) ────────────────────────────
@@ -278,7 +473,25 @@ func main() {
 		Federator:      federator,
 		NostrRelay:        cfg.PrimaryRelay(),
 		ShowSourceLink:    showSourceLink,
+		SensitiveCW:       cfg.SensitiveCW,
+		ContentFormat:     cfg.ContentFormat,
+		ReplyReactions:    cfg.ReplyReactions,
+		MaxAncestorDepth:  cfg.ThreadMaxDepth,
+		NoteEditMode:      cfg.NoteEditMode,
+		BridgeLocation:    cfg.BridgeLocation,
+		Notifier:          webhook,
 		AutoAcceptFollows: autoAcceptFollowsBool,
+		Exclusions:        bridgeExclusions,
+		ContentFilter:     contentFilter,
+		FollowFilter: &ap.FollowFilter{
+			MinFollowers:   cfg.FollowMinFollowers,
+			MaxFollowRatio: cfg.FollowMaxRatio,
+			MinAccountAge:  cfg.FollowMinAccountAge,
+			Reject:         cfg.FollowFilterAction == "reject",
+		},
+	}
+	if cfg.RelayHintDynamic {
+		apHandler.RelayHint = publisher.PreferredRelay
 	}
 
 	// ─── Nostr Handler (incoming Nostr → ActivityPub) ─────────────────────────
@@ -287,6 +500,16 @@ func main() {
 		TC:        tc,
 		Federator: federator,
 		Store:     store,
+		Tags:      store,
+		Addresses: store,
+		Decrypt:   signer.DecryptFromSelf,
+
+		BridgedKinds: bridgedKinds,
+		LocalPubKey:  cfg.NostrPublicKey,
+		Notifier:     webhook,
+		ProfileZaps:  cfg.ProfileZaps,
+
+		ZapReceiptSigner: server.ZapReceiptSigner(cfg),
 	}
 
 	// ─── Graceful shutdown ────────────────────────────────────────────────────
@@ -297,6 +520,8 @@ func main() {
 	// ─── Bluesky bridge (optional) ────────────────────────────────────────────
 	var bskyTrigger chan struct{}
 	var activeBskyClient *bsky.Client
+	var activeBskyPoller *bsky.Poller
+	var activeBskyPollers []*bsky.Poller
 	if cfg.BskyEnabled() {
 		bskyClient := bsky.NewClient(cfg.BskyIdentifier, cfg.BskyAppPassword)
 		bskyClient.PDSURL = cfg.BskyPDSURL // override default bsky.social for third-party PDS
@@ -305,27 +530,75 @@ func main() {
 		} else {
 			activeBskyClient = bskyClient
 			nostrHandler.BskyPoster = &bsky.Poster{
-				Client:          bskyClient,
-				Store:           store,
-				LocalDomain:     cfg.LocalDomain,
-				ExternalBaseURL: cfg.ExternalBaseURL,
+				Client:           bskyClient,
+				Store:            store,
+				LocalDomain:      cfg.LocalDomain,
+				ExternalBaseURL:  cfg.ExternalBaseURL,
+				ReplyGate:        cfg.BskyReplyGate,
+				FollowersOnlyTag: cfg.FollowersOnlyTag,
 			}
 			bskyTrigger = make(chan struct{}, 1)
-			poller := &bsky.Poller{
-				Client:         bskyClient,
-				Publisher:      publisher,
-				Signer:         signer,
-				Store:          store,
-				LocalPubKey:    cfg.NostrPublicKey,
-				LocalActorURL:  localActorURL,
-				LocalDomain:    cfg.LocalDomain,
-				Interval:       cfg.BskyPollInterval,
-				ShowSourceLink: showSourceLink,
-				BridgeTimeline: cfg.BskyBridgeTimeline,
-				TriggerCh:      bskyTrigger,
+			newPoller := func(client *bsky.Client, trigger <-chan struct{}) *bsky.Poller {
+				return &bsky.Poller{
+					Client:         client,
+					Publisher:      publisher,
+					Signer:         signer,
+					Store:          store,
+					LocalPubKey:    cfg.NostrPublicKey,
+					LocalActorURL:  localActorURL,
+					LocalDomain:    cfg.LocalDomain,
+					Interval:       cfg.BskyPollInterval,
+					MaxInterval:    cfg.BskyPollMaxInterval,
+					Jitter:         cfg.BskyPollJitter,
+					ShowSourceLink: showSourceLink,
+					BridgeTimeline: cfg.BskyBridgeTimeline,
+					TimelineReplies: cfg.BskyTimelineReplies,
+					TimelineReposts: cfg.BskyTimelineReposts,
+					TimelineQuotes:  cfg.BskyTimelineQuotes,
+					BridgeChat:      cfg.BskyBridgeChat,
+					TriggerCh:      trigger,
+					Notifier:       webhook,
+					Exclusions:     bridgeExclusions,
+					ContentFilter:  contentFilter,
+					MaxAncestorDepth: cfg.ThreadMaxDepth,
+					MaxAncestorWalks: cfg.ThreadMaxBreadth,
+				}
 			}
-			go poller.Start(ctx)
+			poller := newPoller(bskyClient, bskyTrigger)
+			activeBskyPoller = poller
+			activeBskyPollers = append(activeBskyPollers, poller)
 			slog.Info("bsky bridge enabled", "identifier", cfg.BskyIdentifier)
+
+			// Additional accounts only bridge their home timelines, each
+			// under its own poll state; the Poster stays on the primary.
+			if len(cfg.BskyExtraAccounts) > 0 && !cfg.BskyBridgeTimeline {
+				slog.Warn("BSKY_EXTRA_ACCOUNTS ignored: additional accounts only bridge their timeline, and BSKY_BRIDGE_TIMELINE is false")
+			} else {
+				for _, acct := range cfg.BskyExtraAccounts {
+					client := bsky.NewClient(acct.Identifier, acct.AppPassword)
+					client.PDSURL = cfg.BskyPDSURL
+					if err := client.Authenticate(ctx); err != nil {
+						slog.Warn("bsky auth failed for additional account, skipping", "identifier", acct.Identifier, "error", err)
+						continue
+					}
+					extra := newPoller(client, make(chan struct{}, 1))
+					extra.PrimaryDID = bskyClient.DID()
+					activeBskyPollers = append(activeBskyPollers, extra)
+					slog.Info("bsky bridge enabled for additional account", "identifier", acct.Identifier)
+				}
+			}
+			if len(activeBskyPollers) > 1 {
+				// Fan the admin UI's sync trigger out to every poller.
+				triggers := make([]chan<- struct{}, len(activeBskyPollers))
+				for i, p := range activeBskyPollers {
+					ch := make(chan struct{}, 1)
+					p.TriggerCh, triggers[i] = ch, ch
+				}
+				go fanOutTrigger(ctx, bskyTrigger, triggers)
+			}
+			for _, p := range activeBskyPollers {
+				go p.Start(ctx)
+			}
 		}
 	}
 
@@ -338,11 +611,24 @@ func main() {
 		LocalDomain: cfg.LocalDomain,
 		Interval:    cfg.ResyncInterval,
 		TriggerCh:   resyncTrigger,
+		Concurrency: cfg.ResyncConcurrency,
 	}
 	go resyncer.Start(ctx)
 
+	// ─── Delivery retry queue ─────────────────────────────────────────────────
+	go federator.RunRetryQueue(ctx)
+
 	// ─── Start relay subscription ─────────────────────────────────────────────
 	pool := nostrpkg.NewRelayPool(cfg.NostrRelays, cfg.NostrRelays, cfg.NostrPublicKey, nostrHandler.Handle)
+	pool.MaxConnections = cfg.RelayMaxConnections
+	pool.IdleTimeout = cfg.RelayIdleTimeout
+	pool.Kinds = cfg.RelaySubscriptionKinds
+	pool.MentionKinds = cfg.RelayMentionKinds
+	// Profile zap receipts are authored by the zapper's wallet service and
+	// only p-tag the user, so they arrive through the mention subscription.
+	if cfg.ProfileZaps != "off" && !slices.Contains(pool.MentionKinds, 9735) {
+		pool.MentionKinds = append(pool.MentionKinds, 9735)
+	}
 	go pool.Start(ctx)
 
 	// Wire relay manager now that pool exists. Shared between nostrHandler (kind-10002
@@ -361,11 +647,52 @@ func main() {
 	if activeBskyClient != nil {
 		srv.SetBskyClient(activeBskyClient)
 	}
+	if activeBskyPoller != nil {
+		srv.SetBskyBridger(activeBskyPoller)
+	}
+	for _, p := range activeBskyPollers {
+		srv.AddBskyAccount(p)
+	}
 	srv.SetResyncTrigger(resyncTrigger)
 	srv.SetFollowPublisher(&followPublisherAdapter{signer: signer, publisher: publisher})
+	srv.SetTransmuteContext(tc)
 	srv.SetRelayManager(relayMgr)
 	srv.SetShowSourceLink(showSourceLink)
 	srv.SetAutoAcceptFollows(autoAcceptFollowsBool)
+	srv.SetBridgedKinds(bridgedKinds)
+	srv.SetBridgeExclusions(bridgeExclusions)
+	srv.SetContentFilter(contentFilter)
+	if cfg.FollowReconcileInterval > 0 {
+		go srv.RunFollowReconciler(ctx, cfg.FollowReconcileInterval)
+	}
+
+	// ─── NIP-89 handler information ───────────────────────────────────────────
+	// Lets NIP-89-aware clients attribute bridged events to klistr and open
+	// them at their source via the /nostr/<bech32> redirect.
+	if cfg.NIP89Handler {
+		go func() {
+			pubCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			if err := nostrpkg.PublishHandlerInfo(pubCtx, signer, publisher, cfg.BaseURL("/actor"), cfg.BaseURL("/nostr"), cfg.URL().Host); err != nil {
+				slog.Warn("failed to publish NIP-89 handler information", "error", err)
+				return
+			}
+			slog.Info("published NIP-89 handler information")
+		}()
+	}
+
+	// ─── Hashtag feed ─────────────────────────────────────────────────────────
+	if len(cfg.HashtagFollows) > 0 && len(cfg.HashtagServers) > 0 {
+		hashtagFeed := &ap.HashtagFeed{
+			Handler:     apHandler,
+			Store:       store,
+			Tags:        cfg.HashtagFollows,
+			Servers:     cfg.HashtagServers,
+			Interval:    cfg.HashtagPollInterval,
+			HostAllowed: srv.InstanceAllowed,
+		}
+		go hashtagFeed.Start(ctx)
+	}
 	srv.Start(ctx) // blocks until ctx is cancelled
 
 	slog.Info("klistr bridge stopped")
@@ -0,0 +1,151 @@
+package ap
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCacheMaxEntries bounds each of the object and WebFinger caches
+// unless overridden via SetObjectCacheSize.
+const defaultCacheMaxEntries = 10000
+
+// lruCache is a TTL cache bounded to maxEntries, evicting the least recently
+// used entry when full. Expired entries are dropped on lookup and by sweep.
+type lruCache[V any] struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List // front = most recently used
+	items      map[string]*list.Element
+	hits       atomic.Int64
+	misses     atomic.Int64
+}
+
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+func newLRUCache[V any](maxEntries int) *lruCache[V] {
+	return &lruCache[V]{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// get returns the unexpired value for key, marking it recently used.
+func (c *lruCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry[V])
+		if time.Now().Before(e.expires) {
+			c.ll.MoveToFront(el)
+			c.hits.Add(1)
+			return e.value, true
+		}
+		c.removeElement(el)
+	}
+	c.misses.Add(1)
+	var zero V
+	return zero, false
+}
+
+// put stores value under key for ttl, evicting the least recently used
+// entries beyond maxEntries.
+func (c *lruCache[V]) put(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := time.Now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry[V])
+		e.value, e.expires = value, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry[V]{key: key, value: value, expires: expires})
+	c.evict()
+}
+
+// delete removes key from the cache.
+func (c *lruCache[V]) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// sweep removes every entry expired at now.
+func (c *lruCache[V]) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.ll.Back(); el != nil; {
+		prev := el.Prev()
+		if now.After(el.Value.(*lruEntry[V]).expires) {
+			c.removeElement(el)
+		}
+		el = prev
+	}
+}
+
+// setMaxEntries changes the bound, evicting entries beyond it.
+func (c *lruCache[V]) setMaxEntries(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxEntries = n
+	c.evict()
+}
+
+// evict drops least recently used entries beyond maxEntries. Caller must
+// hold mu.
+func (c *lruCache[V]) evict() {
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// removeElement unlinks el. Caller must hold mu.
+func (c *lruCache[V]) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry[V]).key)
+}
+
+// CacheStats is a snapshot of one in-memory cache.
+type CacheStats struct {
+	Entries    int     `json:"entries"`
+	MaxEntries int     `json:"max_entries"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	HitRate    float64 `json:"hit_rate"` // hits / lookups, 0 before the first lookup
+}
+
+func (c *lruCache[V]) stats() CacheStats {
+	c.mu.Lock()
+	st := CacheStats{Entries: c.ll.Len(), MaxEntries: c.maxEntries}
+	c.mu.Unlock()
+	st.Hits, st.Misses = c.hits.Load(), c.misses.Load()
+	if total := st.Hits + st.Misses; total > 0 {
+		st.HitRate = float64(st.Hits) / float64(total)
+	}
+	return st
+}
+
+// SetObjectCacheSize overrides the maximum number of entries kept in each of
+// the AP object cache and the WebFinger handle cache. Call once at startup,
+// before any concurrent use.
+func SetObjectCacheSize(n int) {
+	if n > 0 {
+		objectCache.setMaxEntries(n)
+		wfCache.setMaxEntries(n)
+	}
+}
+
+// ObjectCacheStats returns the size and hit rate of the AP object cache.
+func ObjectCacheStats() CacheStats { return objectCache.stats() }
+
+// WebFingerCacheStats returns the size and hit rate of the WebFinger cache.
+func WebFingerCacheStats() CacheStats { return wfCache.stats() }
@@ -3,14 +3,16 @@ package ap
 import (
 	"bytes"
 	"context"
-	"crypto/rsa"
+	"crypto"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +33,113 @@ var httpClient = &http.Client{
 	Timeout: 10 * time.Second,
 }
 
+// deliveryClient sends activities to remote inboxes. It has no client-wide
+// timeout: each delivery is bounded by the Federator's DeliveryTimeout.
+var deliveryClient = &http.Client{}
+
+// defaultUserAgent identifies the bridge software until SetClientIdentity is
+// called.
+const defaultUserAgent = "klistr/1.0 (https://github.com/klppl/klistr)"
+
+// userAgent is sent on every outbound AP request.
+var userAgent = defaultUserAgent
+
+// fromHeader is the operator contact sent as the From header on every outbound
+// AP request. Empty (no header) by default.
+var fromHeader string
+
+// SetClientIdentity sets how outbound AP requests identify this bridge, so
+// remote admins can tell who is fetching from or delivering to them. A
+// non-empty userAgent is used verbatim; otherwise the User-Agent names the
+// software, instanceURL and contact (when set). contact is also sent as the
+// From header. Call once at startup, before any concurrent use.
+func SetClientIdentity(ua, instanceURL, contact string) {
+	fromHeader = contact
+	if ua != "" {
+		userAgent = ua
+		return
+	}
+	ua = "klistr/1.0 (https://github.com/klppl/klistr"
+	if instanceURL != "" {
+		ua += "; +" + strings.TrimRight(instanceURL, "/")
+	}
+	if contact != "" {
+		ua += "; " + contact
+	}
+	userAgent = ua + ")"
+}
+
+// UserAgent returns the User-Agent of outbound AP requests, as set by
+// SetClientIdentity, so the bridge's other HTTP clients can send the same.
+func UserAgent() string {
+	return userAgent
+}
+
+// outboundHeaders holds operator-configured headers added to every outbound
+// AP request (e.g. a token a CDN/WAF in front of a remote server expects).
+// Empty by default.
+var outboundHeaders http.Header
+
+// SetOutboundHeaders configures extra headers applied to outbound FetchObject,
+// FetchActor, WebFingerResolve and DeliverActivity requests. Call once at
+// startup, before any concurrent use.
+func SetOutboundHeaders(headers map[string]string) {
+	h := make(http.Header, len(headers))
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	outboundHeaders = h
+}
+
+// newRequest builds an outbound AP request with the common headers applied
+// (see setCommonHeaders).
+func newRequest(ctx context.Context, method, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	setCommonHeaders(req)
+	return req, nil
+}
+
+// setCommonHeaders applies the User-Agent, the From contact and any configured
+// custom headers. Callers set protocol headers (Accept, Content-Type, Date,
+// Host) afterwards so custom headers can never clobber them.
+func setCommonHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", userAgent)
+	if fromHeader != "" {
+		req.Header.Set("From", fromHeader)
+	}
+	for k, vs := range outboundHeaders {
+		for _, v := range vs {
+			req.Header.Set(k, v)
+		}
+	}
+}
+
+// fetchSigning holds the key FetchObject signs GET requests with.
+type fetchSigning struct {
+	keyID  string
+	keys   *KeyPair
+	always bool
+}
+
+// signedFetch is nil until SetFetchSigner is called; fetches are then unsigned.
+var signedFetch *fetchSigning
+
+// signedHosts records origins that answered an unsigned GET with 401, so later
+// fetches from them are signed straight away.
+var signedHosts sync.Map // origin → struct{}
+
+// SetFetchSigner enables HTTP-signed GETs in FetchObject, signed with keyID
+// (the service actor's key). Servers in authorized-fetch (secure) mode answer
+// unsigned GETs with 401; such fetches are retried signed. With always set,
+// every fetch is signed up front. Call once at startup, before any concurrent
+// use.
+func SetFetchSigner(keyID string, keys *KeyPair, always bool) {
+	signedFetch = &fetchSigning{keyID: keyID, keys: keys, always: always}
+}
+
 // objectCacheTTL is a var (not const) so it can be overridden at startup via
 // SetObjectCacheTTL for deployments that want a longer or shorter cache window.
 var (
@@ -46,45 +155,26 @@ func SetObjectCacheTTL(d time.Duration) {
 	}
 }
 
-type cacheEntry struct {
-	obj     map[string]interface{}
-	expires time.Time
-}
-
-// objectCache is a TTL-bounded in-memory cache for fetched AP objects.
-var objectCache sync.Map // url → cacheEntry
+// objectCache is a TTL- and size-bounded in-memory cache for fetched AP
+// objects, keyed by URL.
+var objectCache = newLRUCache[map[string]interface{}](defaultCacheMaxEntries)
 
 // wfCache caches WebFinger handle → AP actor URL resolutions.
-// Key is the lowercased handle ("alice@mastodon.social"); value is wfCacheEntry.
+// Key is the lowercased handle ("alice@mastodon.social").
 // Prevents redundant outbound WebFinger requests during batch follow imports and
 // repeated NIP-05 lookups for the same remote actor.
-type wfCacheEntry struct {
-	actorURL string
-	expires  time.Time
-}
-
-var wfCache sync.Map // lowercased handle → wfCacheEntry
+var wfCache = newLRUCache[string](defaultCacheMaxEntries)
 
 func init() {
-	// Background sweeper: evicts expired entries from both caches so they don't
-	// grow unbounded over long runtimes with many distinct URLs / handles.
+	// Background sweeper: evicts expired entries from both caches so memory is
+	// released without waiting for them to be pushed out by newer entries.
 	go func() {
 		ticker := time.NewTicker(objectCacheSweepInterval)
 		defer ticker.Stop()
 		for range ticker.C {
 			now := time.Now()
-			objectCache.Range(func(k, v any) bool {
-				if now.After(v.(cacheEntry).expires) {
-					objectCache.Delete(k)
-				}
-				return true
-			})
-			wfCache.Range(func(k, v any) bool {
-				if now.After(v.(wfCacheEntry).expires) {
-					wfCache.Delete(k)
-				}
-				return true
-			})
+			objectCache.sweep(now)
+			wfCache.sweep(now)
 		}
 	}()
 }
@@ -93,24 +183,27 @@ func init() {
 // Returns the raw JSON or an error. Results are cached.
 func FetchObject(ctx context.Context, rawURL string) (map[string]interface{}, error) {
 	// Check cache first (skip if expired).
-	if cached, ok := objectCache.Load(rawURL); ok {
-		entry := cached.(cacheEntry)
-		if time.Now().Before(entry.expires) {
-			return entry.obj, nil
-		}
-		objectCache.Delete(rawURL)
+	if obj, ok := objectCache.get(rawURL); ok {
+		return obj, nil
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	origin := extractOrigin(rawURL)
+	_, knownSigned := signedHosts.Load(origin)
+	sign := signedFetch != nil && (signedFetch.always || knownSigned)
+	resp, err := getObject(ctx, rawURL, sign)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Accept", `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`)
-	req.Header.Set("User-Agent", "klistr/1.0 (https://github.com/klppl/klistr)")
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	if resp.StatusCode == http.StatusUnauthorized && signedFetch != nil && !sign {
+		// Authorized-fetch (secure mode) server: retry with a signature.
+		resp.Body.Close()
+		if resp, err = getObject(ctx, rawURL, true); err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized {
+			signedHosts.Store(origin, struct{}{})
+			slog.Debug("host requires signed fetches", "origin", origin)
+		}
 	}
 	defer resp.Body.Close()
 
@@ -126,10 +219,58 @@ func FetchObject(ctx context.Context, rawURL string) (map[string]interface{}, er
 		return nil, fmt.Errorf("decode response from %s: %w", rawURL, err)
 	}
 
-	objectCache.Store(rawURL, cacheEntry{obj: obj, expires: time.Now().Add(objectCacheTTL)})
+	objectCache.put(rawURL, obj, objectCacheTTL)
 	return obj, nil
 }
 
+// getObject issues the GET for FetchObject, signed with the signedFetch key
+// when sign is set. The caller closes the response body.
+func getObject(ctx context.Context, rawURL string, sign bool) (*http.Response, error) {
+	req, err := newRequest(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`)
+
+	if sign {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		req.Header.Set("Host", req.URL.Host)
+		signer, _, err := httpsig.NewSigner(
+			[]httpsig.Algorithm{httpsig.RSA_SHA256},
+			httpsig.DigestSha256,
+			[]string{httpsig.RequestTarget, "host", "date"},
+			httpsig.Signature,
+			0,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("create signer: %w", err)
+		}
+		if err := signer.SignRequest(signedFetch.keys.Signing(), signedFetch.keyID, req, nil); err != nil {
+			return nil, fmt.Errorf("sign request: %w", err)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	return resp, nil
+}
+
+// FetchObjectAuthor returns the attributedTo actor URL of the AP object at
+// rawURL. The object is fetched through FetchObject's cache.
+func FetchObjectAuthor(ctx context.Context, rawURL string) (string, error) {
+	obj, err := FetchObject(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+	note := mapToNote(obj)
+	if note == nil || note.AttributedTo == "" {
+		return "", fmt.Errorf("object %s has no author", rawURL)
+	}
+	return note.AttributedTo, nil
+}
+
 // FetchActor fetches and parses an AP Actor object.
 func FetchActor(ctx context.Context, actorURL string) (*Actor, error) {
 	obj, err := FetchObject(ctx, actorURL)
@@ -139,9 +280,51 @@ func FetchActor(ctx context.Context, actorURL string) (*Actor, error) {
 	return mapToActor(obj), nil
 }
 
+// ActorStats holds the signals the follow spam filter looks at. Followers and
+// Following are -1 when the server hides them; Published is zero when unknown.
+type ActorStats struct {
+	Followers int
+	Following int
+	Published time.Time
+}
+
+// FetchActorStats fetches an actor and the totalItems of its followers and
+// following collections. All three documents go through FetchObject's cache.
+func FetchActorStats(ctx context.Context, actorURL string) (ActorStats, error) {
+	obj, err := FetchObject(ctx, actorURL)
+	if err != nil {
+		return ActorStats{}, err
+	}
+	stats := ActorStats{
+		Followers: collectionSize(ctx, getID(obj, "followers")),
+		Following: collectionSize(ctx, getID(obj, "following")),
+	}
+	if t, err := time.Parse(time.RFC3339, getString(obj, "published")); err == nil {
+		stats.Published = t
+	}
+	return stats, nil
+}
+
+// collectionSize returns the totalItems of the collection at rawURL, or -1
+// when it cannot be fetched or does not disclose its size.
+func collectionSize(ctx context.Context, rawURL string) int {
+	if rawURL == "" {
+		return -1
+	}
+	obj, err := FetchObject(ctx, rawURL)
+	if err != nil {
+		return -1
+	}
+	n, ok := obj["totalItems"].(float64)
+	if !ok {
+		return -1
+	}
+	return int(n)
+}
+
 // InvalidateCache removes a URL from the object cache.
 func InvalidateCache(rawURL string) {
-	objectCache.Delete(rawURL)
+	objectCache.delete(rawURL)
 }
 
 // WebFingerResolve resolves a Fediverse handle (e.g. "alice@mastodon.social")
@@ -156,31 +339,26 @@ func WebFingerResolve(ctx context.Context, handle string) (string, error) {
 
 	// Check cache. Handles are lowercased so "Alice@X" and "alice@X" share one entry.
 	cacheKey := strings.ToLower(handle)
-	if cached, ok := wfCache.Load(cacheKey); ok {
-		entry := cached.(wfCacheEntry)
-		if time.Now().Before(entry.expires) {
-			return entry.actorURL, nil
-		}
-		wfCache.Delete(cacheKey)
+	if actorURL, ok := wfCache.get(cacheKey); ok {
+		return actorURL, nil
 	}
 
 	wfURL := "https://" + domain + "/.well-known/webfinger?resource=acct:" + handle
 
-	req, err := http.NewRequestWithContext(ctx, "GET", wfURL, nil)
+	req, err := newRequest(ctx, "GET", wfURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("webfinger request: %w", err)
 	}
 	req.Header.Set("Accept", "application/jrd+json, application/json")
-	req.Header.Set("User-Agent", "klistr/1.0 (https://github.com/klppl/klistr)")
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("webfinger fetch: %w", err)
+		return "", &WebFingerError{Handle: handle, Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("webfinger returned HTTP %d for %s", resp.StatusCode, handle)
+		return "", &WebFingerError{Handle: handle, StatusCode: resp.StatusCode}
 	}
 
 	var wf struct {
@@ -196,32 +374,61 @@ func WebFingerResolve(ctx context.Context, handle string) (string, error) {
 
 	for _, link := range wf.Links {
 		if link.Rel == "self" && isAPMediaType(link.Type) {
-			wfCache.Store(cacheKey, wfCacheEntry{actorURL: link.Href, expires: time.Now().Add(objectCacheTTL)})
+			wfCache.put(cacheKey, link.Href, objectCacheTTL)
 			return link.Href, nil
 		}
 	}
 	return "", fmt.Errorf("no ActivityPub actor link found for %s", handle)
 }
 
-// DeliverActivity sends an ActivityPub activity to a remote inbox using HTTP signatures.
-func DeliverActivity(ctx context.Context, inbox string, activity map[string]interface{}, keyID string, privKey *rsa.PrivateKey) error {
+// WebFingerError is returned by WebFingerResolve when the lookup request
+// fails. StatusCode is 0 for transport errors.
+type WebFingerError struct {
+	Handle     string
+	StatusCode int
+	Err        error
+}
+
+func (e *WebFingerError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("webfinger returned HTTP %d for %s", e.StatusCode, e.Handle)
+	}
+	return fmt.Sprintf("webfinger fetch: %v", e.Err)
+}
+
+func (e *WebFingerError) Unwrap() error { return e.Err }
+
+// IsRetryableWebFinger reports whether a WebFinger lookup failed transiently
+// (transport error, 5xx, 408 or 429), using the same policy as
+// DeliveryError.Retryable.
+func IsRetryableWebFinger(err error) bool {
+	var we *WebFingerError
+	if !errors.As(err, &we) {
+		return false
+	}
+	return (&DeliveryError{StatusCode: we.StatusCode}).Retryable()
+}
+
+// DeliverActivity sends an ActivityPub activity to a remote inbox using HTTP
+// signatures. privKey is an *rsa.PrivateKey (RSA-SHA256) or an
+// ed25519.PrivateKey (ed25519).
+func DeliverActivity(ctx context.Context, inbox string, activity map[string]interface{}, keyID string, privKey crypto.PrivateKey) error {
 	body, err := json.Marshal(activity)
 	if err != nil {
 		return fmt.Errorf("marshal activity: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", inbox, bytes.NewReader(body))
+	req, err := newRequest(ctx, "POST", inbox, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/activity+json")
-	req.Header.Set("User-Agent", "klistr/1.0 (https://github.com/klppl/klistr)")
 	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
 	req.Header.Set("Host", req.URL.Host)
 
 	// Sign the request.
 	signer, _, err := httpsig.NewSigner(
-		[]httpsig.Algorithm{httpsig.RSA_SHA256},
+		[]httpsig.Algorithm{signatureAlgorithm(privKey)},
 		httpsig.DigestSha256,
 		[]string{httpsig.RequestTarget, "host", "date", "digest"},
 		httpsig.Signature,
@@ -234,20 +441,62 @@ func DeliverActivity(ctx context.Context, inbox string, activity map[string]inte
 		return fmt.Errorf("sign request: %w", err)
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := deliveryClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("deliver to %s: %w", inbox, err)
+		return &DeliveryError{Inbox: inbox, Err: err}
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusGone {
+		return &DeliveryError{Inbox: inbox, StatusCode: resp.StatusCode, Err: ErrGone}
+	}
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("deliver to %s: HTTP %d", inbox, resp.StatusCode)
+		return &DeliveryError{Inbox: inbox, StatusCode: resp.StatusCode}
 	}
 
 	slog.Debug("delivered activity", "inbox", inbox, "status", resp.StatusCode)
 	return nil
 }
 
+// DeliveryError is returned by DeliverActivity when the remote inbox could not
+// be reached or rejected the activity. StatusCode is 0 for transport errors.
+type DeliveryError struct {
+	Inbox      string
+	StatusCode int
+	Err        error
+}
+
+func (e *DeliveryError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("deliver to %s: HTTP %d", e.Inbox, e.StatusCode)
+	}
+	return fmt.Sprintf("deliver to %s: %v", e.Inbox, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error { return e.Err }
+
+// Retryable reports whether a later attempt might succeed. Transport errors
+// (timeouts, refused connections), 5xx responses, 408 and 429 are transient;
+// any other 4xx — notably 410 Gone — is permanent and must not be retried.
+func (e *DeliveryError) Retryable() bool {
+	switch {
+	case e.StatusCode == 0:
+		return true
+	case e.StatusCode >= 500:
+		return true
+	case e.StatusCode == http.StatusRequestTimeout, e.StatusCode == http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+// IsRetryableDelivery reports whether err is a DeliveryError worth retrying.
+// Errors raised before the request is sent (marshalling, signing) are not.
+func IsRetryableDelivery(err error) bool {
+	var de *DeliveryError
+	return errors.As(err, &de) && de.Retryable()
+}
+
 // maxDateSkew is the maximum allowed difference between the request's Date
 // header and the server's current time. Mastodon enforces the same window.
 // Requests outside this window are rejected to prevent signature replay attacks.
@@ -282,8 +531,26 @@ func VerifyDigest(body []byte, digestHeader string) error {
 	return nil
 }
 
+// SignatureKeyID returns the keyId named in req's HTTP signature, without
+// verifying it, or "" when the request is not signed.
+func SignatureKeyID(req *http.Request) string {
+	verifier, err := httpsig.NewVerifier(req)
+	if err != nil {
+		return ""
+	}
+	return verifier.KeyId()
+}
+
+// KeyOwner returns the actor a signature keyId belongs to: the keyId without
+// its fragment, which is the document VerifySignature takes the key from.
+func KeyOwner(keyID string) string {
+	owner, _, _ := strings.Cut(keyID, "#")
+	return owner
+}
+
 // VerifySignature verifies an incoming HTTP signature.
-// Returns the keyID if valid, or an error.
+// Returns the keyID if valid, or an error. With ErrActorGone the keyID is
+// returned too, unverified.
 func VerifySignature(req *http.Request) (string, error) {
 	// Reject replayed requests by checking the Date header age before doing
 	// any cryptographic work. A captured signed request (Date + signature
@@ -308,7 +575,7 @@ func VerifySignature(req *http.Request) (string, error) {
 	keyID := verifier.KeyId()
 
 	// Fetch the actor to get their public key.
-	actorURL := strings.Split(keyID, "#")[0]
+	actorURL := KeyOwner(keyID)
 	actor, err := FetchActor(req.Context(), actorURL)
 	if err != nil {
 		if errors.Is(err, ErrGone) {
@@ -321,23 +588,20 @@ func VerifySignature(req *http.Request) (string, error) {
 		return "", fmt.Errorf("fetch actor for key %s: %w", keyID, err)
 	}
 
-	if actor.PublicKey == nil {
-		return "", fmt.Errorf("actor %s has no public key", actorURL)
-	}
-
-	pubKey, err := parsePublicKeyPEM(actor.PublicKey.PublicKeyPem)
+	// The keyId selects the RSA publicKey or an Ed25519 assertionMethod key.
+	pubKey, err := actorVerificationKey(actor, keyID)
 	if err != nil {
-		return "", fmt.Errorf("parse public key for %s: %w", actorURL, err)
+		return "", fmt.Errorf("public key for %s: %w", keyID, err)
 	}
 
-	if err := verifier.Verify(pubKey, httpsig.RSA_SHA256); err != nil {
+	if err := verifier.Verify(pubKey, signatureAlgorithm(pubKey)); err != nil {
 		return "", fmt.Errorf("signature verification failed: %w", err)
 	}
 
 	return keyID, nil
 }
 
-func parsePublicKeyPEM(pemStr string) (*rsa.PublicKey, error) {
+func parsePublicKeyPEM(pemStr string) (crypto.PublicKey, error) {
 	// Use the same PEM parsing as keys.go
 	block, _ := decodePEM([]byte(pemStr))
 	if block == nil {
@@ -377,6 +641,26 @@ func mapToActor(m map[string]interface{}) *Actor {
 		}
 	}
 
+	// Extract Multikeys from assertionMethod (FEP-521a); a single key may be
+	// given as an object instead of an array.
+	var methods []interface{}
+	switch am := m["assertionMethod"].(type) {
+	case []interface{}:
+		methods = am
+	case map[string]interface{}:
+		methods = []interface{}{am}
+	}
+	for _, v := range methods {
+		if mk, ok := v.(map[string]interface{}); ok {
+			actor.AssertionMethod = append(actor.AssertionMethod, Multikey{
+				ID:                 getString(mk, "id"),
+				Type:               getString(mk, "type"),
+				Controller:         getString(mk, "controller"),
+				PublicKeyMultibase: getString(mk, "publicKeyMultibase"),
+			})
+		}
+	}
+
 	// Extract endpoints
 	if ep, ok := m["endpoints"].(map[string]interface{}); ok {
 		actor.Endpoints = &Endpoints{
@@ -392,6 +676,30 @@ func mapToActor(m map[string]interface{}) *Actor {
 		}
 	}
 
+	// Extract profile fields (PropertyValue attachments; Mastodon's verified
+	// links live here) and account aliases.
+	if atts, ok := m["attachment"].([]interface{}); ok {
+		for _, v := range atts {
+			if pv, ok := v.(map[string]interface{}); ok && getString(pv, "type") == "PropertyValue" {
+				actor.Attachment = append(actor.Attachment, PropertyValue{
+					Type:  "PropertyValue",
+					Name:  getString(pv, "name"),
+					Value: getString(pv, "value"),
+				})
+			}
+		}
+	}
+	switch aka := m["alsoKnownAs"].(type) {
+	case string:
+		actor.AlsoKnownAs = []string{aka}
+	case []interface{}:
+		for _, v := range aka {
+			if id := idOf(v); id != "" {
+				actor.AlsoKnownAs = append(actor.AlsoKnownAs, id)
+			}
+		}
+	}
+
 	return actor
 }
 
@@ -404,11 +712,11 @@ func mapToNote(m map[string]interface{}) *Note {
 		ID:           getString(m, "id"),
 		Type:         getString(m, "type"),
 		Name:         getString(m, "name"),
-		AttributedTo: getString(m, "attributedTo"),
+		AttributedTo: getID(m, "attributedTo"),
 		Content:      getString(m, "content"),
 		Published:    getString(m, "published"),
 		URL:          getString(m, "url"),
-		InReplyTo:    getString(m, "inReplyTo"),
+		InReplyTo:    getID(m, "inReplyTo"),
 		QuoteURL:     getString(m, "quoteUrl"),
 		Summary:      getString(m, "summary"),
 	}
@@ -451,10 +759,15 @@ func mapToNote(m map[string]interface{}) *Note {
 			}
 			width, _ := a["width"].(float64)
 			height, _ := a["height"].(float64)
+			attURL, linkMediaType := attachmentURL(a["url"])
+			mediaType := getString(a, "mediaType")
+			if mediaType == "" {
+				mediaType = linkMediaType
+			}
 			note.Attachment = append(note.Attachment, Attachment{
 				Type:      getString(a, "type"),
-				URL:       getString(a, "url"),
-				MediaType: getString(a, "mediaType"),
+				URL:       attURL,
+				MediaType: mediaType,
 				Name:      getString(a, "name"), // alt text / image description
 				Blurhash:  getString(a, "blurhash"),
 				Width:     int(width),
@@ -463,6 +776,8 @@ func mapToNote(m map[string]interface{}) *Note {
 		}
 	}
 
+	note.Location = extractPlace(m["location"])
+
 	// Extract poll fields (AP Question).
 	note.OneOf = extractQuestionOptions(m, "oneOf")
 	note.AnyOf = extractQuestionOptions(m, "anyOf")
@@ -475,6 +790,51 @@ func mapToNote(m map[string]interface{}) *Note {
 	return note
 }
 
+// extractPlace parses an AP location, which may be a single Place or an
+// array of them (the first usable one wins). Returns nil when there is neither
+// a name nor coordinates.
+func extractPlace(v interface{}) *Place {
+	switch loc := v.(type) {
+	case []interface{}:
+		for _, item := range loc {
+			if p := extractPlace(item); p != nil {
+				return p
+			}
+		}
+	case map[string]interface{}:
+		p := &Place{
+			Type:      getString(loc, "type"),
+			Name:      strings.TrimSpace(getString(loc, "name")),
+			Latitude:  getFloat(loc, "latitude"),
+			Longitude: getFloat(loc, "longitude"),
+		}
+		if !p.HasCoordinates() {
+			p.Latitude, p.Longitude = 0, 0
+		}
+		if p.Name == "" && !p.HasCoordinates() {
+			return nil
+		}
+		if p.Type == "" {
+			p.Type = "Place"
+		}
+		return p
+	}
+	return nil
+}
+
+// getFloat returns a numeric field that may be encoded as a JSON number or a
+// numeric string.
+func getFloat(m map[string]interface{}, key string) float64 {
+	switch v := m[key].(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	}
+	return 0
+}
+
 // extractQuestionOptions parses a oneOf/anyOf array from an AP Question map.
 func extractQuestionOptions(m map[string]interface{}, key string) []QuestionOption {
 	arr, ok := m[key].([]interface{})
@@ -533,6 +893,79 @@ func getString(m map[string]interface{}, key string) string {
 	return ""
 }
 
+// getID returns the object ID referenced by m[key]. Unlike getString it
+// accepts every shape servers use for references; see idOf.
+func getID(m map[string]interface{}, key string) string {
+	return idOf(m[key])
+}
+
+// idOf extracts an object ID from a JSON-LD reference, which may be a bare IRI
+// string, an embedded object carrying "id" (or "href" for Link objects), or an
+// array of either — in which case the first resolvable element wins. PeerTube,
+// for example, sends attributedTo as [Person, Group] objects.
+func idOf(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		if id, _ := t["id"].(string); id != "" {
+			return id
+		}
+		href, _ := t["href"].(string)
+		return href
+	case []interface{}:
+		for _, item := range t {
+			if id := idOf(item); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// attachmentURL returns the URL of an attachment's "url" field and, when it is
+// given as a Link, the Link's mediaType. Mastodon uses a plain string; PeerTube
+// and some others send a Link or an array of Links (one per rendition), in
+// which case the first one with a media type is preferred.
+func attachmentURL(v interface{}) (string, string) {
+	switch t := v.(type) {
+	case string:
+		return t, ""
+	case map[string]interface{}:
+		href := getString(t, "href")
+		if href == "" {
+			href = getString(t, "id")
+		}
+		return href, getString(t, "mediaType")
+	case []interface{}:
+		var first string
+		for _, item := range t {
+			href, mediaType := attachmentURL(item)
+			if href == "" {
+				continue
+			}
+			if mediaType != "" {
+				return href, mediaType
+			}
+			if first == "" {
+				first = href
+			}
+		}
+		return first, ""
+	}
+	return "", ""
+}
+
+// parseObjectID extracts the ID of an activity's object field, which may be an
+// IRI, an embedded object (e.g. a Tombstone) or a single-element array.
+func parseObjectID(raw json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+	return idOf(v)
+}
+
 // isAPMediaType reports whether a WebFinger link content-type string represents
 // an ActivityPub actor document. MIME types are case-insensitive per RFC 2045,
 // and some servers add extra whitespace around the profile parameter — both are
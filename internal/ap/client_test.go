@@ -0,0 +1,93 @@
+package ap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetID(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"IRI", `{"attributedTo":"https://a.example/users/alice"}`, "https://a.example/users/alice"},
+		{"object", `{"attributedTo":{"type":"Person","id":"https://a.example/users/alice"}}`, "https://a.example/users/alice"},
+		{"link", `{"attributedTo":{"type":"Link","href":"https://a.example/users/alice"}}`, "https://a.example/users/alice"},
+		{"array of objects", `{"attributedTo":[{"type":"Person","id":"https://a.example/accounts/alice"},{"type":"Group","id":"https://a.example/video-channels/cats"}]}`, "https://a.example/accounts/alice"},
+		{"array of IRIs", `{"attributedTo":["https://a.example/users/alice","https://a.example/users/bob"]}`, "https://a.example/users/alice"},
+		{"array skips unresolvable", `{"attributedTo":[{"type":"Person"},"https://a.example/users/alice"]}`, "https://a.example/users/alice"},
+		{"empty array", `{"attributedTo":[]}`, ""},
+		{"object without id", `{"attributedTo":{"type":"Person"}}`, ""},
+		{"missing", `{}`, ""},
+		{"number", `{"attributedTo":42}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.json), &m); err != nil {
+				t.Fatal(err)
+			}
+			if got := getID(m, "attributedTo"); got != tt.want {
+				t.Errorf("getID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapToNoteReferences(t *testing.T) {
+	tests := []struct {
+		name         string
+		json         string
+		attributedTo string
+		inReplyTo    string
+	}{
+		{
+			"IRIs",
+			`{"id":"https://a.example/notes/1","type":"Note","attributedTo":"https://a.example/users/alice","inReplyTo":"https://b.example/notes/0"}`,
+			"https://a.example/users/alice", "https://b.example/notes/0",
+		},
+		{
+			"embedded objects",
+			`{"id":"https://a.example/notes/1","type":"Note","attributedTo":{"type":"Person","id":"https://a.example/users/alice"},"inReplyTo":{"type":"Note","id":"https://b.example/notes/0"}}`,
+			"https://a.example/users/alice", "https://b.example/notes/0",
+		},
+		{
+			"PeerTube arrays",
+			`{"id":"https://a.example/videos/1","type":"Video","attributedTo":[{"type":"Person","id":"https://a.example/accounts/alice"},{"type":"Group","id":"https://a.example/video-channels/cats"}],"inReplyTo":["https://b.example/notes/0"]}`,
+			"https://a.example/accounts/alice", "https://b.example/notes/0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.json), &m); err != nil {
+				t.Fatal(err)
+			}
+			note := mapToNote(m)
+			if note.AttributedTo != tt.attributedTo {
+				t.Errorf("AttributedTo = %q, want %q", note.AttributedTo, tt.attributedTo)
+			}
+			if note.InReplyTo != tt.inReplyTo {
+				t.Errorf("InReplyTo = %q, want %q", note.InReplyTo, tt.inReplyTo)
+			}
+		})
+	}
+}
+
+func TestParseObjectID(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`"https://a.example/notes/1"`, "https://a.example/notes/1"},
+		{`{"type":"Tombstone","id":"https://a.example/notes/1"}`, "https://a.example/notes/1"},
+		{`["https://a.example/notes/1"]`, "https://a.example/notes/1"},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		if got := parseObjectID(json.RawMessage(tt.raw)); got != tt.want {
+			t.Errorf("parseObjectID(%s) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
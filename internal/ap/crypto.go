@@ -1,6 +1,8 @@
 package ap
 
 import (
+	"crypto"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -11,14 +13,15 @@ func decodePEM(data []byte) (*pem.Block, []byte) {
 	return pem.Decode(data)
 }
 
-func parsePublicKey(b []byte) (*rsa.PublicKey, error) {
+// parsePublicKey parses a PKIX public key, accepting RSA and Ed25519 keys.
+func parsePublicKey(b []byte) (crypto.PublicKey, error) {
 	pub, err := x509.ParsePKIXPublicKey(b)
 	if err != nil {
 		return nil, fmt.Errorf("parse PKIX public key: %w", err)
 	}
-	rsaPub, ok := pub.(*rsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("not an RSA public key")
+	switch pub.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return pub, nil
 	}
-	return rsaPub, nil
+	return nil, fmt.Errorf("not an RSA or Ed25519 public key")
 }
@@ -0,0 +1,61 @@
+package ap
+
+import (
+	"sync"
+	"time"
+)
+
+// activityDedupTTL is how long a processed activity ID is remembered.
+// Remote servers that fan out the same activity to both the personal inbox and
+// the shared inbox do so within seconds; an hour comfortably covers their
+// retry windows without holding IDs forever.
+const activityDedupTTL = time.Hour
+
+// seenActivities records the IDs of activities currently being handled or
+// recently handled. Value is the expiry time.Time.
+var seenActivities sync.Map // activity ID → time.Time
+
+func init() {
+	// Background sweeper: evicts expired activity IDs so the set stays bounded.
+	go func() {
+		ticker := time.NewTicker(objectCacheSweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now()
+			seenActivities.Range(func(k, v any) bool {
+				if now.After(v.(time.Time)) {
+					seenActivities.Delete(k)
+				}
+				return true
+			})
+		}
+	}()
+}
+
+// markActivitySeen records id as processed and reports whether this is the
+// first time it has been seen within activityDedupTTL. Activities without an
+// ID are always treated as new.
+func markActivitySeen(id string) bool {
+	if id == "" {
+		return true
+	}
+	now := time.Now()
+	prev, loaded := seenActivities.LoadOrStore(id, now.Add(activityDedupTTL))
+	if !loaded {
+		return true
+	}
+	if now.After(prev.(time.Time)) {
+		// Expired but not yet swept — refresh and treat as new.
+		seenActivities.Store(id, now.Add(activityDedupTTL))
+		return true
+	}
+	return false
+}
+
+// forgetActivity removes id from the seen set so a remote retry of an activity
+// whose handling failed is processed again.
+func forgetActivity(id string) {
+	if id != "" {
+		seenActivities.Delete(id)
+	}
+}
@@ -0,0 +1,132 @@
+package ap
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/go-fed/httpsig"
+)
+
+// ed25519MulticodecPrefix is the multicodec header of an Ed25519 public key
+// (0xed, varint-encoded) in a FEP-521a publicKeyMultibase value.
+var ed25519MulticodecPrefix = []byte{0xed, 0x01}
+
+// LoadOrGenerateEd25519 loads the Ed25519 signing key from a PKCS#8 PEM file
+// at path, generating and saving one if the file does not exist. The key is
+// advertised next to the RSA key and used for deliveries to servers that
+// reject RSA signatures.
+func (kp *KeyPair) LoadOrGenerateEd25519(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return fmt.Errorf("generate Ed25519 key: %w", err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return fmt.Errorf("marshal Ed25519 key: %w", err)
+		}
+		data = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return fmt.Errorf("write Ed25519 key: %w", err)
+		}
+		slog.Info("generated Ed25519 key", "path", path)
+	} else if err != nil {
+		return fmt.Errorf("read Ed25519 key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("failed to decode Ed25519 key PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parse Ed25519 key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return fmt.Errorf("%s is not an Ed25519 key", path)
+	}
+	kp.Ed25519 = priv
+	return nil
+}
+
+// Ed25519Multibase returns the Ed25519 public key as a publicKeyMultibase
+// value, or "" when no Ed25519 key is configured.
+func (kp *KeyPair) Ed25519Multibase() string {
+	if kp == nil || kp.Ed25519 == nil {
+		return ""
+	}
+	return encodeEd25519Multibase(kp.Ed25519.Public().(ed25519.PublicKey))
+}
+
+// Ed25519AssertionMethod returns the assertionMethod advertising the Ed25519
+// key multibase for actorURL, or nil when multibase is "".
+func Ed25519AssertionMethod(actorURL, multibase string) []Multikey {
+	if multibase == "" {
+		return nil
+	}
+	return []Multikey{{
+		ID:                 ed25519KeyID(actorURL + "#main-key"),
+		Type:               "Multikey",
+		Controller:         actorURL,
+		PublicKeyMultibase: multibase,
+	}}
+}
+
+// ed25519KeyID returns the Ed25519 key ID matching an RSA "#main-key" key ID.
+func ed25519KeyID(rsaKeyID string) string {
+	return strings.TrimSuffix(rsaKeyID, "#main-key") + "#ed25519-key"
+}
+
+// encodeEd25519Multibase encodes pub as a base58btc multibase Multikey value.
+func encodeEd25519Multibase(pub ed25519.PublicKey) string {
+	return "z" + base58.Encode(append(append([]byte{}, ed25519MulticodecPrefix...), pub...))
+}
+
+// decodeEd25519Multibase parses a base58btc multibase Ed25519 Multikey value.
+func decodeEd25519Multibase(s string) (ed25519.PublicKey, error) {
+	if !strings.HasPrefix(s, "z") {
+		return nil, fmt.Errorf("unsupported multibase encoding")
+	}
+	b := base58.Decode(s[1:])
+	if len(b) != len(ed25519MulticodecPrefix)+ed25519.PublicKeySize ||
+		b[0] != ed25519MulticodecPrefix[0] || b[1] != ed25519MulticodecPrefix[1] {
+		return nil, fmt.Errorf("not an Ed25519 multikey")
+	}
+	return ed25519.PublicKey(b[len(ed25519MulticodecPrefix):]), nil
+}
+
+// signatureAlgorithm returns the HTTP signature algorithm for a private or
+// public key: Ed25519 keys sign with ed25519, anything else with RSA-SHA256.
+func signatureAlgorithm(key any) httpsig.Algorithm {
+	switch key.(type) {
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return httpsig.ED25519
+	}
+	return httpsig.RSA_SHA256
+}
+
+// actorVerificationKey returns the public key of actor that keyID names: the
+// RSA (or Ed25519) publicKeyPem of publicKey, or an Ed25519 Multikey from
+// assertionMethod. A keyID matching neither falls back to publicKey, which is
+// what RSA-only servers sign with.
+func actorVerificationKey(actor *Actor, keyID string) (crypto.PublicKey, error) {
+	for _, mk := range actor.AssertionMethod {
+		if mk.ID == keyID && mk.Type == "Multikey" {
+			return decodeEd25519Multibase(mk.PublicKeyMultibase)
+		}
+	}
+	if actor.PublicKey == nil {
+		return nil, fmt.Errorf("actor %s has no public key", actor.ID)
+	}
+	return parsePublicKeyPEM(actor.PublicKey.PublicKeyPem)
+}
@@ -2,11 +2,14 @@ package ap
 
 import (
 	"context"
-	"crypto/rsa"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -15,13 +18,73 @@ import (
 type Federator struct {
 	LocalDomain string
 	KeyID       string // e.g. "https://example.com/actor#main-key"
-	PrivateKey  *rsa.PrivateKey
+	// Keys holds the RSA signing key, read on every delivery so a rotation
+	// (KeyPair.PromoteNext) takes effect at once.
+	Keys *KeyPair
+	// Ed25519Key, if non-nil, signs deliveries to origins that reject RSA
+	// signatures (see deliverSigned).
+	Ed25519Key ed25519.PrivateKey
 	// GetFollowers returns AP follower IDs for a local AP actor URL.
 	GetFollowers func(actorURL string) ([]string, error)
 	// Concurrency caps simultaneous outbound HTTP requests. 0 uses the package default (10).
 	Concurrency int
+	// Queue, if non-nil, persists deliveries that fail with a retryable error
+	// so RunRetryQueue can re-attempt them with exponential backoff.
+	Queue DeliveryQueue
+	// DeliveryTimeout bounds each first delivery attempt; queued retries get at
+	// least deliveryRetryHTTPTimeout. 0 uses the default (10s).
+	DeliveryTimeout time.Duration
+	// HostCBThreshold is the number of consecutive transient failures after
+	// which deliveries to a host are skipped. 0 uses the default (5).
+	HostCBThreshold int
+	// HostCBCooldown is how long a tripped host is skipped. 0 uses the default (10m).
+	HostCBCooldown time.Duration
+	// PruneFollower, if non-nil, removes a follower whose account is gone:
+	// its personal inbox answered 410 Gone, or 404 on notFoundPruneThreshold
+	// consecutive deliveries spanning notFoundPruneSpan (see prune.go).
+	// reason is for the audit log.
+	PruneFollower func(actorID, reason string)
+	// FollowSent, if non-nil, is called with the actor and object of every
+	// Follow before it is delivered, so the follow can be tracked as pending
+	// until the Accept arrives. It is called for every sender; the callback
+	// decides which follows to track.
+	FollowSent func(followerID, followedID string)
 	// perHostLimiter holds per-origin *rate.Limiter values (keyed by origin string).
 	perHostLimiter sync.Map
+	// hostCircuits holds per-origin *hostCircuit values (keyed by origin string).
+	hostCircuits sync.Map
+	// ed25519Hosts holds origins that accepted an Ed25519 signature after
+	// rejecting RSA (keyed by origin string).
+	ed25519Hosts sync.Map
+	// notFound tracks consecutive 404 deliveries per personal inbox
+	// (*notFoundStreak keyed by inbox URL).
+	notFound sync.Map
+}
+
+// deliverSigned sends activity to inbox signed with the RSA key, or with the
+// Ed25519 key for origins known to reject RSA. An RSA delivery refused with
+// 401 is retried once with the Ed25519 key; if that succeeds the origin is
+// remembered for later deliveries. Without an Ed25519 key this is a plain
+// RSA delivery, so RSA-only servers are unaffected.
+func (f *Federator) deliverSigned(ctx context.Context, inbox string, activity map[string]interface{}) error {
+	keyID := f.KeyID
+	origin := extractOrigin(inbox)
+	if f.Ed25519Key != nil {
+		if _, ok := f.ed25519Hosts.Load(origin); ok {
+			return DeliverActivity(ctx, inbox, activity, ed25519KeyID(keyID), f.Ed25519Key)
+		}
+	}
+	err := DeliverActivity(ctx, inbox, activity, keyID, f.Keys.Signing())
+	var de *DeliveryError
+	if f.Ed25519Key == nil || !errors.As(err, &de) || de.StatusCode != http.StatusUnauthorized {
+		return err
+	}
+	if edErr := DeliverActivity(ctx, inbox, activity, ed25519KeyID(keyID), f.Ed25519Key); edErr != nil {
+		return err
+	}
+	f.ed25519Hosts.Store(origin, struct{}{})
+	slog.Info("destination rejected RSA signature, using Ed25519", "host", origin)
+	return nil
 }
 
 // concurrency returns the effective concurrency limit for this Federator.
@@ -59,12 +122,21 @@ func (f *Federator) Federate(ctx context.Context, activity map[string]interface{
 	id, _ := activity["id"].(string)
 	activityType, _ := activity["type"].(string)
 
+	if activityType == "Follow" && f.FollowSent != nil {
+		follower, _ := activity["actor"].(string)
+		followed, _ := activity["object"].(string)
+		if follower != "" && followed != "" {
+			f.FollowSent(follower, followed)
+		}
+	}
+
 	recipients := f.collectRecipients(ctx, activity)
-	inboxes := f.resolveInboxes(ctx, recipients)
+	inboxes, owners := f.resolveInboxes(ctx, recipients)
 
 	slog.Debug("federating activity",
 		"id", id,
 		"type", activityType,
+		"recipients", len(recipients),
 		"inboxes", len(inboxes),
 	)
 
@@ -80,6 +152,16 @@ func (f *Federator) Federate(ctx context.Context, activity map[string]interface{
 		wg.Add(1)
 		go func(inbox string) {
 			defer func() { <-sem; wg.Done() }()
+			// Skip hosts whose circuit is open without waiting on their rate
+			// limiter; the delivery goes straight to the retry queue.
+			if f.HostCircuitOpen(inbox) {
+				slog.Debug("federation skipped: destination circuit open", "inbox", inbox)
+				f.enqueueRetry(inbox, activity, &DeliveryError{Inbox: inbox, Err: errHostCircuitOpen})
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				return
+			}
 			// Respect per-origin rate limit before sending.
 			if err := f.hostLimiter(inbox).Wait(ctx); err != nil {
 				slog.Debug("federation rate limit cancelled", "inbox", inbox)
@@ -88,8 +170,13 @@ func (f *Federator) Federate(ctx context.Context, activity map[string]interface{
 				mu.Unlock()
 				return
 			}
-			if err := DeliverActivity(ctx, inbox, activity, f.KeyID, f.PrivateKey); err != nil {
+			err := f.deliver(ctx, inbox, activity, f.deliveryTimeout())
+			f.checkGone(inbox, owners[inbox], err)
+			if err != nil {
 				slog.Warn("federation failed", "inbox", inbox, "error", err)
+				if IsRetryableDelivery(err) {
+					f.enqueueRetry(inbox, activity, err)
+				}
 				mu.Lock()
 				failed++
 				mu.Unlock()
@@ -155,10 +242,17 @@ func (f *Federator) collectRecipients(ctx context.Context, activity map[string]i
 	return recipients
 }
 
-// resolveInboxes converts recipient IDs to inbox URLs, deduplicating by origin.
-// Actor fetches are performed concurrently (bounded by federationConcurrency)
-// so a large follower list doesn't serialize into N sequential 10s HTTP calls.
-func (f *Federator) resolveInboxes(ctx context.Context, recipients map[string]struct{}) map[string]struct{} {
+// resolveInboxes converts recipient IDs to inbox URLs, deduplicating by origin:
+// every server that advertises a shared inbox gets exactly one delivery there,
+// and personal inboxes are only used on servers that advertise none. Actor
+// fetches (cached by FetchActor) are performed concurrently (bounded by
+// federationConcurrency) so a large follower list doesn't serialize into N
+// sequential 10s HTTP calls.
+//
+// owners maps each personal inbox to the actor it belongs to, so a delivery
+// failure there can be attributed to that one account (see checkGone).
+// Recipients whose actor document answers 410 Gone are pruned.
+func (f *Federator) resolveInboxes(ctx context.Context, recipients map[string]struct{}) (inboxes map[string]struct{}, owners map[string]string) {
 	// Filter to only the IDs that need an outbound fetch.
 	var toResolve []string
 	for recipientID := range recipients {
@@ -168,12 +262,14 @@ func (f *Federator) resolveInboxes(ctx context.Context, recipients map[string]st
 		toResolve = append(toResolve, recipientID)
 	}
 
+	inboxes = make(map[string]struct{})
+	owners = make(map[string]string)
 	var (
-		mu      sync.Mutex
-		inboxes = make(map[string]struct{})
-		seen    = make(map[string]struct{}) // origins already covered by a shared inbox
-		sem     = make(chan struct{}, f.concurrency())
-		wg      sync.WaitGroup
+		mu            sync.Mutex
+		seen          = make(map[string]struct{}) // origins already covered by a shared inbox
+		sharedInboxes = make(map[string]bool)
+		sem           = make(chan struct{}, f.concurrency())
+		wg            sync.WaitGroup
 	)
 
 	for _, recipientID := range toResolve {
@@ -185,6 +281,9 @@ func (f *Federator) resolveInboxes(ctx context.Context, recipients map[string]st
 			actor, err := FetchActor(ctx, id)
 			if err != nil {
 				slog.Debug("failed to fetch actor for federation", "actor", id, "error", err)
+				if errors.Is(err, ErrGone) {
+					f.pruneFollower(id, "actor returned 410 Gone")
+				}
 				return
 			}
 
@@ -203,18 +302,32 @@ func (f *Federator) resolveInboxes(ctx context.Context, recipients map[string]st
 					return // another goroutine already claimed this origin
 				}
 				inbox = actor.Endpoints.SharedInbox
+				mu.Lock()
+				sharedInboxes[inbox] = true
+				mu.Unlock()
 			}
 
 			if inbox != "" {
 				mu.Lock()
 				inboxes[inbox] = struct{}{}
+				if inbox == actor.Inbox {
+					owners[inbox] = id
+				}
 				mu.Unlock()
 			}
 		}(recipientID)
 	}
 
 	wg.Wait()
-	return inboxes
+
+	// A personal inbox is redundant when its server is also reached through
+	// a shared inbox advertised by another follower there.
+	for inbox := range inboxes {
+		if _, shared := seen[extractOrigin(inbox)]; shared && !sharedInboxes[inbox] {
+			delete(inboxes, inbox)
+		}
+	}
+	return inboxes, owners
 }
 
 // GetActorInbox returns the inbox URL for an AP actor.
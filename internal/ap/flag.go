@@ -0,0 +1,92 @@
+package ap
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/klppl/klistr/internal/bridge"
+)
+
+// maxFlagReasonLength caps the report comment quoted in the notification DM.
+const maxFlagReasonLength = 500
+
+// handleFlag records an inbound moderation report (Flag) about the local
+// user or their posts in the audit log and notifies the user by DM. Nothing
+// is actioned automatically. Reports that target nothing local are ignored.
+func (h *APHandler) handleFlag(ctx context.Context, activity IncomingActivity) error {
+	var targets []string
+	for _, id := range flagObjectIDs(activity.Object) {
+		if IsLocalID(id, h.LocalDomain) {
+			targets = append(targets, id)
+		}
+	}
+	if len(targets) == 0 {
+		slog.Debug("ignoring Flag with no local targets", "id", activity.ID, "actor", activity.Actor)
+		return nil
+	}
+
+	reporter := bridge.ExtractHost(activity.Actor)
+	if reporter == "" {
+		reporter = activity.Actor
+	}
+	reason := strings.TrimSpace(htmlToText(activity.Content))
+	if len(reason) > maxFlagReasonLength {
+		reason = bridge.TruncateAtWord(reason, maxFlagReasonLength) + "…"
+	}
+	slog.Info("report received", "reporter", reporter, "targets", len(targets))
+
+	detail := reporter + " " + strings.Join(targets, " ")
+	if reason != "" {
+		detail += " — " + reason
+	}
+	if err := h.Store.WriteAuditLog("report_received", detail); err != nil {
+		slog.Warn("failed to record report in audit log", "error", err)
+	}
+
+	go h.sendFlagNotification(reporter, targets, reason)
+	return nil
+}
+
+// flagObjectIDs returns the IDs in a Flag's object field, which is usually
+// an array of the reported actor and the reported posts.
+func flagObjectIDs(raw json.RawMessage) []string {
+	var items []interface{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		if id := parseObjectID(raw); id != "" {
+			return []string{id}
+		}
+		return nil
+	}
+	var ids []string
+	for _, item := range items {
+		if id := idOf(item); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sendFlagNotification delivers a DM to the local user summarising a report
+// received from the Fediverse.
+func (h *APHandler) sendFlagNotification(reporter string, targets []string, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	message := "🚩 Report received from " + reporter
+	if reason != "" {
+		message += "\nReason: " + reason
+	}
+	message += "\nReported:\n" + strings.Join(targets, "\n")
+
+	event, err := h.Signer.CreateNotificationDM(message)
+	if err != nil {
+		slog.Warn("failed to create report notification DM", "error", err)
+		return
+	}
+	if err := h.Publisher.Publish(ctx, event); err != nil {
+		slog.Warn("failed to publish report notification DM", "error", err)
+	}
+}
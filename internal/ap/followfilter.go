@@ -0,0 +1,97 @@
+package ap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// FollowFilter holds the spam heuristics applied to inbound follows before
+// they are auto-accepted. A zero value disables the corresponding check.
+type FollowFilter struct {
+	MinFollowers   int           // minimum follower count
+	MaxFollowRatio float64       // maximum following/followers ratio
+	MinAccountAge  time.Duration // minimum time since the actor's "published" date
+	// Reject rejects follows that fail the filter instead of holding them
+	// in pending_follows for manual approval.
+	Reject bool
+}
+
+func (f *FollowFilter) enabled() bool {
+	return f != nil && (f.MinFollowers > 0 || f.MaxFollowRatio > 0 || f.MinAccountAge > 0)
+}
+
+// check returns why an actor fails the filter, or "" when it passes. Signals
+// the actor's server does not disclose are not held against it.
+func (f *FollowFilter) check(stats ActorStats, now time.Time) string {
+	if f.MinFollowers > 0 && stats.Followers >= 0 && stats.Followers < f.MinFollowers {
+		return fmt.Sprintf("%d followers (minimum %d)", stats.Followers, f.MinFollowers)
+	}
+	if f.MaxFollowRatio > 0 && stats.Followers >= 0 && stats.Following >= 0 {
+		ratio := float64(stats.Following) / float64(max(stats.Followers, 1))
+		if ratio > f.MaxFollowRatio {
+			return fmt.Sprintf("follows %d, followed by %d (ratio %.1f, maximum %.1f)",
+				stats.Following, stats.Followers, ratio, f.MaxFollowRatio)
+		}
+	}
+	if f.MinAccountAge > 0 && !stats.Published.IsZero() {
+		if age := now.Sub(stats.Published); age < f.MinAccountAge {
+			return fmt.Sprintf("account is %s old (minimum %s)", age.Round(time.Hour), f.MinAccountAge)
+		}
+	}
+	return ""
+}
+
+// screenFollow applies FollowFilter to the actor of an inbound follow and
+// returns why it should not be auto-accepted, or "". A follower whose actor
+// cannot be fetched passes: the filter is a heuristic, not a gate.
+func (h *APHandler) screenFollow(ctx context.Context, actorID string) string {
+	if !h.FollowFilter.enabled() {
+		return ""
+	}
+	stats, err := FetchActorStats(ctx, actorID)
+	if err != nil {
+		slog.Warn("follow filter: failed to fetch follower, accepting", "actor", actorID, "error", err)
+		return ""
+	}
+	return h.FollowFilter.check(stats, time.Now())
+}
+
+// Follows held for approval are bounded, so a flood of spam follows cannot
+// grow pending_follows without limit: a held follow left undecided for
+// pendingFollowTTL is dropped, and once maxPendingFollows are held further
+// follows failing the filter are rejected instead.
+const (
+	pendingFollowTTL  = 30 * 24 * time.Hour
+	maxPendingFollows = 500
+)
+
+// canHoldFollow drops the expired held follows and reports whether there is
+// room to hold another. On a store error it answers true, leaving the
+// decision to the admin as usual.
+func (h *APHandler) canHoldFollow() bool {
+	if n, err := h.Store.PrunePendingFollows(time.Now().Add(-pendingFollowTTL)); err != nil {
+		slog.Warn("follow filter: failed to prune expired held follows", "error", err)
+	} else if n > 0 {
+		slog.Info("follow filter: dropped expired held follows", "count", n)
+	}
+	n, err := h.Store.CountPendingFollows()
+	if err != nil {
+		slog.Warn("follow filter: failed to count held follows", "error", err)
+		return true
+	}
+	return n < maxPendingFollows
+}
+
+// ApproveFollow accepts a follow held by the spam filter: the follow is
+// stored and an Accept is sent, as for an auto-accepted follow.
+func (h *APHandler) ApproveFollow(activityID, actorID, followedID string) {
+	h.acceptFollow(activityID, actorID, followedID)
+}
+
+// RejectFollow sends a Reject for a follow held by the spam filter.
+func (h *APHandler) RejectFollow(activityID, actorID, followedID string) {
+	reject := BuildReject(followObject(activityID, actorID, followedID), followedID, actorID)
+	go h.Federator.Federate(context.Background(), reject)
+}
@@ -0,0 +1,77 @@
+package ap
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+)
+
+// ignoredActivities counts inbound activities of the types in
+// isIgnoredActivity since startup.
+var ignoredActivities atomic.Int64
+
+// isIgnoredActivity reports whether activityType is a known activity with no
+// Nostr equivalent: View, Listen and Read are read/play receipts (sent by
+// e.g. Funkwhale and some Misskey forks) and are dropped without logging.
+func isIgnoredActivity(activityType string) bool {
+	switch activityType {
+	case "View", "Listen", "Read":
+		return true
+	}
+	return false
+}
+
+// IgnoredActivities returns the number of inbound read/play receipts dropped
+// since startup.
+func IgnoredActivities() int64 {
+	return ignoredActivities.Load()
+}
+
+// groupAnnouncedActivity returns the activity embedded in an Announce when
+// the Announce is a threadiverse (Lemmy, Kbin/Mbin) community relaying it,
+// e.g. {"type":"Announce","actor":<Group>,"object":{"type":"Create",...}}.
+// Returns nil for ordinary boosts, whose object is a Note or an IRI.
+func groupAnnouncedActivity(ctx context.Context, activity IncomingActivity) map[string]interface{} {
+	var inner map[string]interface{}
+	if err := json.Unmarshal(activity.Object, &inner); err != nil {
+		return nil
+	}
+	switch getString(inner, "type") {
+	case "Create", "Update", "Delete", "Undo", "Like", "Dislike", "Remove", "Add", "Lock", "Block":
+	default:
+		return nil
+	}
+	actor, err := FetchActor(ctx, activity.Actor)
+	if err != nil || actor.Type != "Group" {
+		return nil
+	}
+	return inner
+}
+
+// handleGroupAnnounce bridges a post a community relays to its followers.
+// Only Creates are bridged: the post (a Page, or a Note for comments) is
+// fetched from its origin rather than trusted from the embedded copy, then
+// bridged with its ancestors as the author's own post. No repost is
+// published: the community is distributing the post, not boosting it.
+// Votes, edits and moderation activities are not bridged.
+func (h *APHandler) handleGroupAnnounce(ctx context.Context, activity IncomingActivity, inner map[string]interface{}) error {
+	innerType := getString(inner, "type")
+	if innerType != "Create" {
+		slog.Debug("group announce: inner activity not bridged", "group", activity.Actor, "type", innerType)
+		return nil
+	}
+	objectID := idOf(inner["object"])
+	if objectID == "" {
+		slog.Debug("group announce: inner Create has no object", "group", activity.Actor)
+		return nil
+	}
+	if _, ok := h.resolveNostrID(objectID); ok {
+		return nil
+	}
+	h.ensureAncestorsBridged(ctx, objectID)
+	if _, ok := h.resolveNostrID(objectID); !ok {
+		slog.Debug("group announce: could not bridge community post", "group", activity.Actor, "object", objectID)
+	}
+	return nil
+}
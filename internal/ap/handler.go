@@ -3,18 +3,26 @@ package ap
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"path"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
+	"unicode"
 
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip19"
+	"github.com/rivo/uniseg"
 	"golang.org/x/net/html"
 
 	"github.com/klppl/klistr/internal/bridge"
+	"github.com/klppl/klistr/internal/notify"
 )
 
 // APHandler handles incoming ActivityPub activities and converts them to Nostr events.
@@ -26,7 +34,7 @@ type APHandler struct {
 		Sign(event *nostr.Event, apID string) error
 		PublicKey(apID string) (string, error)
 		LocalPublicKey() string
-		CreateDMToSelf(message string) (*nostr.Event, error)
+		CreateNotificationDM(message string) (*nostr.Event, error)
 	}
 	Publisher interface {
 		Publish(ctx context.Context, event *nostr.Event) error
@@ -34,17 +42,63 @@ type APHandler struct {
 	Store interface {
 		AddObject(apID, nostrID string) error
 		DeleteObject(apID, nostrID string) error
+		// Used by handleNoteUpdate to move a note to its edited event.
+		ReplaceObject(apID, oldNostrID, newNostrID string) error
 		AddFollow(followerID, followedID string) error
 		RemoveFollow(followerID, followedID string) error
+		// Used by the Accept and Reject handlers to track outbound follows
+		// awaiting approval.
+		MarkFollowRequested(followerID, followedID string) error
+		MarkFollowAccepted(followerID, followedID string) error
+		GetFollowRequests(followerID string) (map[string]string, error)
+		PruneFollowRequests(before time.Time) (int64, error)
 		GetNostrIDForObject(apID string) (string, bool)
+		AddObjectAlias(aliasID, nostrID string) error
+		// Used to bridge replies to articles as NIP-22 comments.
+		SetEventAddress(eventID, address string) error
+		GetEventAddress(eventID string) (string, bool)
 		// Used by Move handler to check and update follow relationships.
 		GetAPFollowing(followerID string) ([]string, error)
 		StoreActorKey(pubkey, actorURL string) error
+		// Used by the Delete handler to clean up deleted remote accounts.
+		GetActorForKey(pubkey string) (string, bool)
+		DeleteActorKey(actorURL string) error
+		RemoveAllFollowsFor(actorID string) error
+		// Used by the follow spam filter to hold follows for approval.
+		AddPendingFollow(activityID, actorID, followedID, reason string) error
+		DeletePendingFollow(actorID, followedID string) error
+		CountPendingFollows() (int, error)
+		PrunePendingFollows(before time.Time) (int64, error)
+		// Used to record inbound moderation reports (Flag).
+		WriteAuditLog(action, detail string) error
+		// Used to remember the actor behind each advertised NIP-05 name.
+		SetNIP05Name(name, actorURL string) error
 	}
 	Federator         *Federator
-	NostrRelay        string
-	ShowSourceLink    *atomic.Bool // append original post URL at the bottom of bridged notes
-	AutoAcceptFollows *atomic.Bool // when false, incoming follows are rejected instead of accepted
+	NostrRelay        string                   // static relay hint for e/q/r tags
+	RelayHint         func() string            // optional; returns the current preferred relay hint, overriding NostrRelay
+	ShowSourceLink    *atomic.Bool             // append original post URL at the bottom of bridged notes
+	SensitiveCW       string                   // content warning for sensitive notes without a summary
+	ContentFormat     string                   // "markdown" renders note HTML as Markdown; anything else as plain text
+	ReplyReactions    bool                     // bridge emoji-only replies (Misskey-style reactions) as kind-7 reactions
+	NoteEditMode      string                   // "replace" (default), "reply" or "off"; see handleNoteUpdate
+	BridgeLocation    bool                     // map AP location (Place) to Nostr location/g tags
+	Notifier          *notify.Webhook          // optional; receives follow/move events (nil-safe)
+	AutoAcceptFollows *atomic.Bool             // when false, incoming follows are rejected instead of accepted
+	FollowFilter      *FollowFilter            // optional spam heuristics applied before auto-accepting a follow
+	Exclusions        *bridge.AuthorExclusions // optional; actors whose posts and boosts are not bridged (nil-safe)
+	ContentFilter     *bridge.ContentFilter    // optional; keyword/regex filters that skip notes or add a content warning (nil-safe)
+	MaxAncestorDepth  int                      // levels of an inReplyTo chain walked when bridging replies; defaultMaxAncestorDepth if zero
+}
+
+// relayHint returns the relay URL to reference in tags of bridged events.
+func (h *APHandler) relayHint() string {
+	if h.RelayHint != nil {
+		if r := h.RelayHint(); r != "" {
+			return r
+		}
+	}
+	return h.NostrRelay
 }
 
 // HandleActivity processes an incoming ActivityPub activity.
@@ -60,9 +114,33 @@ func (h *APHandler) HandleActivity(ctx context.Context, raw json.RawMessage) err
 		"actor", activity.Actor,
 	)
 
+	// Idempotency: servers with several of our followers may deliver the same
+	// activity to both the personal and the shared inbox. Handle it once.
+	if !markActivitySeen(activity.ID) {
+		slog.Debug("skipping duplicate AP activity", "id", activity.ID, "type", activity.Type)
+		return nil
+	}
+
+	err := h.dispatchActivity(ctx, activity)
+	if err != nil {
+		// Allow a remote retry to be processed again.
+		forgetActivity(activity.ID)
+	}
+	return err
+}
+
+// dispatchActivity routes an activity to its type-specific handler.
+func (h *APHandler) dispatchActivity(ctx context.Context, activity IncomingActivity) error {
+	if isIgnoredActivity(activity.Type) {
+		ignoredActivities.Add(1)
+		return nil
+	}
+
 	// Fetch and cache the actor so they're available in Nostr.
 	// Use context.Background() so this goroutine outlives the HTTP handler's context.
-	if activity.Type != "Update" {
+	// Skipped for Delete: an account deletion must not race a profile re-publish.
+	// Skipped for Flag: reports come from instance actors, not people.
+	if activity.Type != "Update" && activity.Type != "Delete" && activity.Type != "Flag" {
 		go h.fetchAndCacheActor(context.Background(), activity.Actor)
 	}
 
@@ -89,6 +167,8 @@ func (h *APHandler) HandleActivity(ctx context.Context, raw json.RawMessage) err
 		return h.handleReject(ctx, activity)
 	case "Move":
 		return h.handleMove(ctx, activity)
+	case "Flag":
+		return h.handleFlag(ctx, activity)
 	default:
 		slog.Debug("unhandled activity type", "type", activity.Type)
 		return nil
@@ -97,46 +177,77 @@ func (h *APHandler) HandleActivity(ctx context.Context, raw json.RawMessage) err
 
 func (h *APHandler) handleFollow(ctx context.Context, activity IncomingActivity) error {
 	// The object of a Follow is the actor being followed.
-	var followedID string
-	if err := json.Unmarshal(activity.Object, &followedID); err != nil {
-		return fmt.Errorf("parse follow object: %w", err)
-	}
-
-	followObj := map[string]interface{}{
-		"id":     activity.ID,
-		"type":   "Follow",
-		"actor":  activity.Actor,
-		"object": followedID,
+	followedID := parseObjectID(activity.Object)
+	if followedID == "" {
+		return fmt.Errorf("parse follow object: no id")
 	}
 
 	// If auto-accept is disabled, reject and do not store the follower.
 	if h.AutoAcceptFollows != nil && !h.AutoAcceptFollows.Load() {
-		reject := BuildReject(followObj, followedID, activity.Actor)
-		go h.Federator.Federate(context.Background(), reject)
+		h.RejectFollow(activity.ID, activity.Actor, followedID)
 		slog.Info("follow rejected (auto-accept disabled)", "actor", activity.Actor)
 		return nil
 	}
 
+	// Spam filter: reject the follow or hold it for manual approval.
+	if reason := h.screenFollow(ctx, activity.Actor); reason != "" {
+		if h.FollowFilter.Reject {
+			h.RejectFollow(activity.ID, activity.Actor, followedID)
+			slog.Info("follow rejected by spam filter", "actor", activity.Actor, "reason", reason)
+			return nil
+		}
+		if !h.canHoldFollow() {
+			h.RejectFollow(activity.ID, activity.Actor, followedID)
+			slog.Warn("follow rejected by spam filter: too many follows held for approval",
+				"actor", activity.Actor, "reason", reason, "max", maxPendingFollows)
+			return nil
+		}
+		if err := h.Store.AddPendingFollow(activity.ID, activity.Actor, followedID, reason); err != nil {
+			return fmt.Errorf("hold follow: %w", err)
+		}
+		slog.Info("follow held for approval", "actor", activity.Actor, "reason", reason)
+		return nil
+	}
+
+	h.acceptFollow(activity.ID, activity.Actor, followedID)
+	return nil
+}
+
+// followObject rebuilds the Follow activity an Accept or Reject refers to.
+func followObject(activityID, actorID, followedID string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     activityID,
+		"type":   "Follow",
+		"actor":  actorID,
+		"object": followedID,
+	}
+}
+
+// acceptFollow stores a follow, sends the Accept and notifies the local user.
+func (h *APHandler) acceptFollow(activityID, actorID, followedID string) {
 	// Store the follow relationship.
-	if err := h.Store.AddFollow(activity.Actor, followedID); err != nil {
+	if err := h.Store.AddFollow(actorID, followedID); err != nil {
 		slog.Warn("failed to store follow", "error", err)
 	}
 
 	// Send Accept back to the follower.
-	accept := BuildAccept(followObj, followedID, activity.Actor)
+	accept := BuildAccept(followObject(activityID, actorID, followedID), followedID, actorID)
 
 	// Use context.Background() for fire-and-forget goroutines: handleFollow returns nil
 	// immediately, which causes the HTTP handler goroutine (and its 30s ctx) to exit,
 	// cancelling the context before these goroutines can complete their work.
 	go h.Federator.Federate(context.Background(), accept)
 
-	// Notify local user of the new Fediverse follower via a DM to self.
-	go h.sendFollowNotification(context.Background(), activity.Actor)
-
-	return nil
+	// Notify local user of the new Fediverse follower via a notification DM.
+	go h.sendFollowNotification(context.Background(), actorID)
 }
 
 func (h *APHandler) handleCreate(ctx context.Context, activity IncomingActivity) error {
+	if h.Exclusions.Excluded(activity.Actor) {
+		slog.Debug("skipping Create from excluded author", "actor", activity.Actor)
+		return nil
+	}
+
 	// Parse the embedded object.
 	var objMap map[string]interface{}
 	if err := json.Unmarshal(activity.Object, &objMap); err != nil {
@@ -145,6 +256,15 @@ func (h *APHandler) handleCreate(ctx context.Context, activity IncomingActivity)
 
 	objType, _ := objMap["type"].(string)
 
+	// Object-level idempotency: the same object may arrive wrapped in distinct
+	// Create activities (or may already have been fetched as a reply parent).
+	if objID, _ := objMap["id"].(string); objID != "" {
+		if _, ok := h.Store.GetNostrIDForObject(objID); ok {
+			slog.Debug("skipping already-bridged object", "id", objID, "type", objType)
+			return nil
+		}
+	}
+
 	vis := h.postVisibility(activity)
 
 	switch objType {
@@ -152,7 +272,7 @@ func (h *APHandler) handleCreate(ctx context.Context, activity IncomingActivity)
 		note := mapToNote(objMap)
 
 		// Direct messages (addressed specifically to the local actor) are
-		// delivered as NIP-04 encrypted self-DMs so the user is notified
+		// delivered as NIP-04 encrypted notification DMs so the user is notified
 		// without broadcasting private content as a public Nostr event.
 		if vis == "direct" {
 			return h.bridgeDirectNote(ctx, note, activity.Actor)
@@ -165,17 +285,28 @@ func (h *APHandler) handleCreate(ctx context.Context, activity IncomingActivity)
 			return nil
 		}
 
-		// Synchronously fetch parent posts before converting so that reply and
-		// quote e/q-tags can be resolved. Without this, a thread reply would
-		// arrive before its parent is cached and the tag would be silently dropped.
+		// Synchronously fetch parent posts (and their unbridged ancestors)
+		// before converting so that reply and quote e/q-tags can be resolved.
+		// Without this, a thread reply would arrive before its parent is cached
+		// and the tag would be silently dropped.
 		if note.InReplyTo != "" {
 			if _, ok := h.resolveNostrID(note.InReplyTo); !ok {
-				h.fetchAndCacheObject(ctx, note.InReplyTo)
+				h.ensureAncestorsBridged(ctx, note.InReplyTo)
+			}
+		}
+
+		// Some servers model reactions as a reply whose content is a single
+		// emoji; bridge those as reactions rather than cluttering the thread.
+		if h.ReplyReactions {
+			if reaction, ok := replyReaction(note); ok {
+				if parentID, ok := h.resolveNostrID(note.InReplyTo); ok {
+					return h.bridgeReplyReaction(ctx, note, parentID, reaction, activity.Actor)
+				}
 			}
 		}
 		if note.QuoteURL != "" {
 			if _, ok := h.resolveNostrID(note.QuoteURL); !ok {
-				h.fetchAndCacheObject(ctx, note.QuoteURL)
+				h.ensureAncestorsBridged(ctx, note.QuoteURL)
 			}
 		}
 
@@ -186,11 +317,16 @@ func (h *APHandler) handleCreate(ctx context.Context, activity IncomingActivity)
 		if event == nil {
 			return nil
 		}
+		if bridge.IsEcho(event.Content) {
+			slog.Info("suppressed echoed note", "id", note.ID, "actor", activity.Actor)
+			return nil
+		}
 
 		// Store the ID mapping.
 		if err := h.Store.AddObject(note.ID, event.ID); err != nil {
 			slog.Warn("failed to store object mapping", "error", err)
 		}
+		h.storeObjectAliases(note, event.ID, "")
 
 		return h.Publisher.Publish(ctx, event)
 
@@ -206,6 +342,7 @@ func (h *APHandler) handleCreate(ctx context.Context, activity IncomingActivity)
 		if err := h.Store.AddObject(note.ID, event.ID); err != nil {
 			slog.Warn("failed to store article mapping", "error", err)
 		}
+		h.storeEventAddress(event)
 		return h.Publisher.Publish(ctx, event)
 
 	case "Question":
@@ -228,7 +365,7 @@ func (h *APHandler) handleCreate(ctx context.Context, activity IncomingActivity)
 }
 
 // bridgeDirectNote converts an AP Note that was directly addressed to the local
-// actor (a DM) into a NIP-04 encrypted self-DM so the user is notified without
+// actor (a DM) into a NIP-04 encrypted notification DM so the user is notified without
 // the content being published as a public Nostr event.
 func (h *APHandler) bridgeDirectNote(ctx context.Context, note *Note, actorURL string) error {
 	// Best-effort: resolve actor handle for a human-readable prefix.
@@ -243,7 +380,7 @@ func (h *APHandler) bridgeDirectNote(ctx context.Context, note *Note, actorURL s
 		msg += "\n\n" + note.URL
 	}
 
-	event, err := h.Signer.CreateDMToSelf(msg)
+	event, err := h.Signer.CreateNotificationDM(msg)
 	if err != nil {
 		return fmt.Errorf("bridge direct note: create DM: %w", err)
 	}
@@ -255,45 +392,62 @@ func (h *APHandler) handleAnnounce(ctx context.Context, activity IncomingActivit
 		return nil
 	}
 
-	var objectID string
-	if err := json.Unmarshal(activity.Object, &objectID); err != nil {
-		// Object might be embedded.
-		var objMap map[string]interface{}
-		if err2 := json.Unmarshal(activity.Object, &objMap); err2 != nil {
-			return fmt.Errorf("parse announce object: %w", err)
-		}
-		objectID, _ = objMap["id"].(string)
-	}
-
-	// Synchronously fetch the announced object so we can reference its Nostr ID.
-	// Without this, the async goroutine would race against GetNostrIDForObject
-	// and the repost would always be silently dropped.
-	if _, ok := h.Store.GetNostrIDForObject(objectID); !ok {
-		h.fetchAndCacheObject(ctx, objectID)
+	// A community (Group) relaying a member's activity, not a boost.
+	if inner := groupAnnouncedActivity(ctx, activity); inner != nil {
+		return h.handleGroupAnnounce(ctx, activity, inner)
 	}
 
-	// Create a Nostr kind-6 repost event.
-	nostrID, ok := h.Store.GetNostrIDForObject(objectID)
-	if !ok {
-		slog.Debug("announce: cannot resolve Nostr ID for announced object", "object", objectID)
+	if h.Exclusions.Excluded(activity.Actor) {
+		slog.Debug("skipping Announce from excluded author", "actor", activity.Actor)
 		return nil
 	}
 
-	event := &nostr.Event{
-		Kind:      6,
-		Content:   "",
-		CreatedAt: parseNostrTimestamp(activity.Published),
-		Tags: nostr.Tags{
-			{"e", nostrID, h.NostrRelay},
-			{"proxy", activity.ID, "activitypub"},
-		},
-	}
+	// Object might be an IRI or embedded.
+	objectID := parseObjectID(activity.Object)
+	if objectID == "" {
+		return fmt.Errorf("parse announce object: no id")
+	}
+
+	// Create a Nostr kind-6 repost event once the announced object is
+	// bridged. Local objects resolve straight from their URL
+	// (resolveNostrID), so they are never fetched.
+	return h.withBridgedTarget(ctx, "announce", objectID, func(ctx context.Context, nostrID string) error {
+		event := &nostr.Event{
+			Kind:      6,
+			Content:   "",
+			CreatedAt: parseNostrTimestamp(activity.Published),
+			Tags: nostr.Tags{
+				{"e", nostrID, h.relayHint()},
+				{"proxy", activity.ID, "activitypub"},
+			},
+		}
 
-	if err := h.signEvent(event, activity.Actor); err != nil {
-		return fmt.Errorf("sign event: %w", err)
-	}
+		if err := h.signEvent(event, activity.Actor); err != nil {
+			return fmt.Errorf("sign event: %w", err)
+		}
 
-	return h.Publisher.Publish(ctx, event)
+		return h.Publisher.Publish(ctx, event)
+	})
+}
+
+// withBridgedTarget calls publish with the Nostr ID of objectID, the target of
+// a Like, EmojiReact or Announce. A target that is not bridged yet is fetched
+// and bridged on its own, as a partial thread when it is a reply (see
+// Note.partialThread): its ancestors are not walked, since a walk can take up
+// to maxAncestorDepth fetches inside the inbox handler. Only Create walks
+// ancestors, since its own event needs the thread tags.
+func (h *APHandler) withBridgedTarget(ctx context.Context, what, objectID string, publish func(ctx context.Context, nostrID string) error) error {
+	nostrID, ok := h.resolveNostrID(objectID)
+	if !ok {
+		if event := h.bridgeThreadObject(ctx, objectID, true); event != nil {
+			nostrID, ok = event.ID, true
+		}
+	}
+	if !ok {
+		slog.Debug(what+": cannot resolve Nostr ID for target object", "object", objectID)
+		return nil
+	}
+	return publish(ctx, nostrID)
 }
 
 func (h *APHandler) handleUpdate(ctx context.Context, activity IncomingActivity) error {
@@ -305,6 +459,9 @@ func (h *APHandler) handleUpdate(ctx context.Context, activity IncomingActivity)
 	// Article/Page updates: re-publish as a new kind-30023 (addressable event,
 	// same d-tag → naturally replaces the previous version on relays).
 	objType, _ := objMap["type"].(string)
+	if objType == "Note" {
+		return h.handleNoteUpdate(ctx, activity, objMap)
+	}
 	if objType == "Article" || objType == "Page" {
 		note := mapToNote(objMap)
 		InvalidateCache(note.ID)
@@ -333,14 +490,15 @@ func (h *APHandler) handleUpdate(ctx context.Context, activity IncomingActivity)
 		Kind:      0,
 		Content:   meta,
 		CreatedAt: nostr.Now(),
-		Tags: nostr.Tags{
+		Tags: append(nostr.Tags{
 			{"proxy", actor.ID, "activitypub"},
-		},
+		}, identityTags(actor)...),
 	}
 
 	if err := h.signEvent(event, actor.ID); err != nil {
 		return fmt.Errorf("sign metadata event: %w", err)
 	}
+	recordNIP05Name(h.Store, actor)
 
 	// Also publish relay list.
 	relayEvent := &nostr.Event{
@@ -348,7 +506,7 @@ func (h *APHandler) handleUpdate(ctx context.Context, activity IncomingActivity)
 		Content:   "",
 		CreatedAt: nostr.Now(),
 		Tags: nostr.Tags{
-			{"r", h.NostrRelay},
+			{"r", h.relayHint()},
 			{"proxy", actor.ID, "activitypub"},
 		},
 	}
@@ -359,116 +517,274 @@ func (h *APHandler) handleUpdate(ctx context.Context, activity IncomingActivity)
 	return h.Publisher.Publish(ctx, event)
 }
 
-func (h *APHandler) handleLike(ctx context.Context, activity IncomingActivity) error {
-	if !isPublic(activity) {
+// handleNoteUpdate bridges an edited Note. Kind-1 events cannot be edited in
+// place, so in "replace" mode (the default) the edited note is published as a
+// fresh kind-1 — same proxy tag and created_at as a Create would give it — the
+// old event is retracted with a kind-5, and the objects mapping moves to the
+// new event. In "reply" mode the edited text is published as a reply to the
+// original instead; "off" ignores edits. Only the note's author may edit it,
+// and notes that were never bridged are left alone.
+func (h *APHandler) handleNoteUpdate(ctx context.Context, activity IncomingActivity, objMap map[string]interface{}) error {
+	if h.NoteEditMode == "off" {
 		return nil
 	}
-
-	// Idempotency: skip if this Like activity was already processed (e.g. retry).
-	if _, ok := h.Store.GetNostrIDForObject(activity.ID); ok {
+	note := mapToNote(objMap)
+	if note.AttributedTo != activity.Actor {
+		slog.Debug("ignoring note update from non-author", "note", note.ID, "actor", activity.Actor)
 		return nil
 	}
-
-	var objectID string
-	if err := json.Unmarshal(activity.Object, &objectID); err != nil {
-		return fmt.Errorf("parse like object: %w", err)
+	oldID, ok := h.Store.GetNostrIDForObject(note.ID)
+	if !ok {
+		return nil
 	}
+	InvalidateCache(note.ID)
 
-	// Synchronously fetch the liked object if not yet cached so the Nostr ID
-	// lookup succeeds even when the Like arrives before the target post.
-	if _, ok := h.Store.GetNostrIDForObject(objectID); !ok {
-		h.fetchAndCacheObject(ctx, objectID)
+	if h.NoteEditMode == "reply" {
+		updated, _ := objMap["updated"].(string)
+		return h.bridgeEditReply(ctx, activity, note, updated)
 	}
 
-	nostrID, ok := h.Store.GetNostrIDForObject(objectID)
-	if !ok {
-		slog.Debug("like: cannot resolve Nostr ID for liked object", "object", objectID)
+	event, err := h.noteToEvent(ctx, note)
+	if err != nil {
+		return fmt.Errorf("convert note update to event: %w", err)
+	}
+	// Unchanged text and tags produce the same event (e.g. a redelivered
+	// Update, or an edit that only touched a poll or media description).
+	if event == nil || event.ID == oldID {
 		return nil
 	}
 
-	event := &nostr.Event{
-		Kind:      7,
-		Content:   "+",
+	// The old event stays an alias of the note, so Nostr replies and
+	// reactions to it still federate to the note.
+	if err := h.Store.ReplaceObject(note.ID, oldID, event.ID); err != nil {
+		slog.Warn("note update: failed to move mapping", "apID", note.ID, "error", err)
+	}
+	h.storeObjectAliases(note, event.ID, "")
+	if err := h.Publisher.Publish(ctx, event); err != nil {
+		return err
+	}
+
+	deletion := &nostr.Event{
+		Kind:      5,
+		Content:   "edited",
 		CreatedAt: nostr.Now(),
 		Tags: nostr.Tags{
-			{"e", nostrID},
+			{"e", oldID},
 			{"proxy", activity.ID, "activitypub"},
 		},
 	}
-
-	if err := h.signEvent(event, activity.Actor); err != nil {
+	if err := h.signEvent(deletion, activity.Actor); err != nil {
 		return err
 	}
-	if err := h.Publisher.Publish(ctx, event); err != nil {
-		return err
+	return h.Publisher.Publish(ctx, deletion)
+}
+
+// bridgeEditReply publishes an edited note as a reply to its original event,
+// keyed by the Update activity's ID so a redelivery is not bridged twice.
+// updated is the note's "updated" timestamp, used as the reply's created_at.
+func (h *APHandler) bridgeEditReply(ctx context.Context, activity IncomingActivity, note *Note, updated string) error {
+	editID := activity.ID
+	if editID == "" {
+		editID = note.ID + "#updates/" + updated
 	}
-	if err := h.Store.AddObject(activity.ID, event.ID); err != nil {
-		slog.Warn("like: failed to store activity mapping", "error", err)
+	if _, ok := h.Store.GetNostrIDForObject(editID); ok {
+		return nil
 	}
-	return nil
+
+	edit := *note
+	edit.ID = editID
+	edit.InReplyTo = note.ID
+	edit.Content = "<p>✏️ Edited:</p>" + note.Content
+	if updated != "" {
+		edit.Published = updated
+	}
+	event, err := h.noteToEvent(ctx, &edit)
+	if err != nil {
+		return fmt.Errorf("convert note update to event: %w", err)
+	}
+	if event == nil {
+		return nil
+	}
+	if err := h.Store.AddObject(editID, event.ID); err != nil {
+		slog.Warn("note update: failed to store mapping", "error", err)
+	}
+	return h.Publisher.Publish(ctx, event)
 }
 
-func (h *APHandler) handleEmojiReact(ctx context.Context, activity IncomingActivity) error {
+func (h *APHandler) handleLike(ctx context.Context, activity IncomingActivity) error {
 	if !isPublic(activity) {
 		return nil
 	}
 
-	// Idempotency: skip if this EmojiReact activity was already processed.
+	// Idempotency: skip if this Like activity was already processed (e.g. retry).
 	if _, ok := h.Store.GetNostrIDForObject(activity.ID); ok {
 		return nil
 	}
 
-	var objectID string
-	if err := json.Unmarshal(activity.Object, &objectID); err != nil {
-		return fmt.Errorf("parse emoji react object: %w", err)
+	objectID := parseObjectID(activity.Object)
+	if objectID == "" {
+		return fmt.Errorf("parse like object: no id")
 	}
 
-	// Synchronously fetch the target object if not yet cached, same as handleLike.
-	if _, ok := h.Store.GetNostrIDForObject(objectID); !ok {
-		h.fetchAndCacheObject(ctx, objectID)
+	// The liked object is bridged first if needed, so the reaction is not
+	// lost when the Like arrives before the target post.
+	return h.withBridgedTarget(ctx, "like", objectID, func(ctx context.Context, nostrID string) error {
+		event := &nostr.Event{
+			Kind:      7,
+			Content:   "+",
+			CreatedAt: nostr.Now(),
+			Tags: nostr.Tags{
+				{"e", nostrID},
+				{"proxy", activity.ID, "activitypub"},
+			},
+		}
+
+		if err := h.signEvent(event, activity.Actor); err != nil {
+			return err
+		}
+		if err := h.Publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+		if err := h.Store.AddObject(activity.ID, event.ID); err != nil {
+			slog.Warn("like: failed to store activity mapping", "error", err)
+		}
+		return nil
+	})
+}
+
+func (h *APHandler) handleEmojiReact(ctx context.Context, activity IncomingActivity) error {
+	if !isPublic(activity) {
+		return nil
 	}
 
-	nostrID, ok := h.Store.GetNostrIDForObject(objectID)
-	if !ok {
-		slog.Debug("emoji react: cannot resolve Nostr ID for target object", "object", objectID)
+	// Idempotency: skip if this EmojiReact activity was already processed.
+	if _, ok := h.Store.GetNostrIDForObject(activity.ID); ok {
 		return nil
 	}
 
+	objectID := parseObjectID(activity.Object)
+	if objectID == "" {
+		return fmt.Errorf("parse emoji react object: no id")
+	}
+
+	// The target object is bridged first if needed, same as handleLike.
+	return h.withBridgedTarget(ctx, "emoji react", objectID, func(ctx context.Context, nostrID string) error {
+		event := &nostr.Event{
+			Kind:      7,
+			Content:   activity.Content,
+			CreatedAt: nostr.Now(),
+			Tags: nostr.Tags{
+				{"e", nostrID},
+				{"proxy", activity.ID, "activitypub"},
+			},
+		}
+
+		if err := h.signEvent(event, activity.Actor); err != nil {
+			return err
+		}
+		if err := h.Publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+		if err := h.Store.AddObject(activity.ID, event.ID); err != nil {
+			slog.Warn("emoji react: failed to store activity mapping", "error", err)
+		}
+		return nil
+	})
+}
+
+// bridgeReplyReaction publishes an emoji-only reply Note as a kind-7 reaction
+// to parentID. The Note ID is mapped to the reaction so a later Delete of the
+// Note retracts it. NIP-25 asks for a p tag naming the parent's author, so
+// clients can notify them.
+func (h *APHandler) bridgeReplyReaction(ctx context.Context, note *Note, parentID, reaction, actorURL string) error {
 	event := &nostr.Event{
 		Kind:      7,
-		Content:   activity.Content,
-		CreatedAt: nostr.Now(),
+		Content:   reaction,
+		CreatedAt: parseNostrTimestamp(note.Published),
 		Tags: nostr.Tags{
-			{"e", nostrID},
-			{"proxy", activity.ID, "activitypub"},
+			{"e", parentID},
 		},
 	}
-
-	if err := h.signEvent(event, activity.Actor); err != nil {
+	if pubkey := h.authorPubkey(ctx, note.InReplyTo); pubkey != "" {
+		event.Tags = append(event.Tags, nostr.Tag{"p", pubkey})
+	}
+	event.Tags = append(event.Tags, nostr.Tag{"proxy", note.ID, "activitypub"})
+	if err := h.signEvent(event, actorURL); err != nil {
 		return err
 	}
 	if err := h.Publisher.Publish(ctx, event); err != nil {
 		return err
 	}
-	if err := h.Store.AddObject(activity.ID, event.ID); err != nil {
-		slog.Warn("emoji react: failed to store activity mapping", "error", err)
+	slog.Debug("bridged reply as reaction", "id", note.ID, "reaction", reaction)
+	if err := h.Store.AddObject(note.ID, event.ID); err != nil {
+		slog.Warn("reply reaction: failed to store object mapping", "error", err)
 	}
 	return nil
 }
 
-func (h *APHandler) handleDelete(ctx context.Context, activity IncomingActivity) error {
-	var objectID string
-	if err := json.Unmarshal(activity.Object, &objectID); err != nil {
-		// Object may be a Tombstone: {"type": "Tombstone", "id": "https://..."}
-		var objMap map[string]interface{}
-		if err2 := json.Unmarshal(activity.Object, &objMap); err2 != nil {
-			return fmt.Errorf("parse delete object: %w", err)
+// customEmojiRe matches a lone :shortcode: custom emoji.
+var customEmojiRe = regexp.MustCompile(`^:[A-Za-z0-9_+-]+:$`)
+
+// replyReaction reports whether note is a reaction disguised as a reply: an
+// inReplyTo with no media or quote, whose text, once leading @mentions are
+// stripped, is a single emoji or :shortcode:. Returns the reaction content.
+func replyReaction(note *Note) (string, bool) {
+	if note.InReplyTo == "" || note.QuoteURL != "" || len(note.Attachment) > 0 {
+		return "", false
+	}
+	words := strings.Fields(htmlToText(note.Content))
+	for len(words) > 0 && strings.HasPrefix(words[0], "@") {
+		words = words[1:]
+	}
+	if len(words) != 1 {
+		return "", false
+	}
+	text := words[0]
+	if customEmojiRe.MatchString(text) {
+		return text, true
+	}
+	if uniseg.GraphemeClusterCount(text) != 1 || isASCII(text) {
+		return "", false
+	}
+	for _, r := range text {
+		switch {
+		case r == '\u200d', r == '\ufe0f', r == '\ufe0e':
+		case r == '#', r == '*', r >= '0' && r <= '9': // keycap bases
+		case unicode.In(r, unicode.So, unicode.Sk, unicode.Me):
+		default:
+			return "", false
 		}
-		id, _ := objMap["id"].(string)
-		if id == "" {
-			return fmt.Errorf("parse delete object: no id in tombstone")
+	}
+	return text, true
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
 		}
-		objectID = id
+	}
+	return true
+}
+
+func (h *APHandler) handleDelete(ctx context.Context, activity IncomingActivity) error {
+	// Object may be an IRI or a Tombstone: {"type": "Tombstone", "id": "https://..."}
+	objectID := parseObjectID(activity.Object)
+	if objectID == "" {
+		return fmt.Errorf("parse delete object: no id")
+	}
+
+	// Account deletion: the object is the actor itself (Mastodon sends
+	// actor == object), or an actor we hold a derived key for. A Delete of a
+	// gone actor is accepted without a verifiable signature, so either way
+	// the deletion is only honoured once the actor's own server confirms it
+	// is gone.
+	if objectID == activity.Actor || h.isKnownActor(objectID) {
+		if !actorGone(ctx, objectID) {
+			slog.Warn("ignoring Delete of an actor that still exists", "actor", objectID, "by", activity.Actor)
+			return nil
+		}
+		return h.handleActorDelete(ctx, objectID)
 	}
 
 	nostrID, ok := h.Store.GetNostrIDForObject(objectID)
@@ -498,6 +814,65 @@ func (h *APHandler) handleDelete(ctx context.Context, activity IncomingActivity)
 	return h.Publisher.Publish(ctx, event)
 }
 
+// isKnownActor reports whether actorURL has a row in actor_keys.
+func (h *APHandler) isKnownActor(actorURL string) bool {
+	pubkey, err := h.Signer.PublicKey(actorURL)
+	if err != nil {
+		return false
+	}
+	stored, ok := h.Store.GetActorForKey(pubkey)
+	return ok && stored == actorURL
+}
+
+// actorGone re-fetches actorURL, bypassing the cache, and reports whether its
+// server answers 410 Gone or serves a Tombstone.
+func actorGone(ctx context.Context, actorURL string) bool {
+	InvalidateCache(actorURL)
+	obj, err := FetchObject(ctx, actorURL)
+	if err != nil {
+		return errors.Is(err, ErrGone)
+	}
+	return getString(obj, "type") == "Tombstone"
+}
+
+// handleActorDelete cleans up after a remote account deletion: the bridged
+// profile is blanked on Nostr with an empty kind-0 signed by the derived key,
+// and the actor's key mapping, follow relationships and cached actor document
+// are removed so nothing further is published for it.
+func (h *APHandler) handleActorDelete(ctx context.Context, actorURL string) error {
+	if actorURL == h.LocalActorURL {
+		return nil
+	}
+	slog.Info("remote actor deleted, cleaning up", "actor", actorURL)
+
+	InvalidateCache(actorURL)
+
+	if err := h.Store.RemoveAllFollowsFor(actorURL); err != nil {
+		slog.Warn("handleActorDelete: failed to remove follows", "actor", actorURL, "error", err)
+	}
+
+	// Only publish the empty kind-0 if this actor was ever bridged; otherwise
+	// there is no profile on the Nostr side to overwrite.
+	known := h.isKnownActor(actorURL)
+	if err := h.Store.DeleteActorKey(actorURL); err != nil {
+		slog.Warn("handleActorDelete: failed to remove actor key", "actor", actorURL, "error", err)
+	}
+	if !known {
+		return nil
+	}
+
+	event := &nostr.Event{
+		Kind:      0,
+		Content:   "{}",
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{{"proxy", actorURL, "activitypub"}},
+	}
+	if err := h.Signer.Sign(event, actorURL); err != nil {
+		return err
+	}
+	return h.Publisher.Publish(ctx, event)
+}
+
 func (h *APHandler) handleUndo(ctx context.Context, activity IncomingActivity) error {
 	// Parse the undone activity.
 	var inner IncomingActivity
@@ -506,14 +881,17 @@ func (h *APHandler) handleUndo(ctx context.Context, activity IncomingActivity) e
 	}
 
 	if inner.Type == "Follow" {
-		var followedID string
-		if err := json.Unmarshal(inner.Object, &followedID); err != nil {
+		followedID := parseObjectID(inner.Object)
+		if followedID == "" {
 			return nil
 		}
 		slog.Debug("handling unfollow", "actor", activity.Actor, "followed", followedID)
 		if err := h.Store.RemoveFollow(activity.Actor, followedID); err != nil {
 			slog.Warn("failed to remove follow", "error", err)
 		}
+		if err := h.Store.DeletePendingFollow(activity.Actor, followedID); err != nil {
+			slog.Warn("failed to remove pending follow", "error", err)
+		}
 	}
 
 	return nil
@@ -524,6 +902,11 @@ func (h *APHandler) handleUndo(ctx context.Context, activity IncomingActivity) e
 // signEvent signs a Nostr event. If the actorID matches the local AP actor,
 // it signs with the user's real key; otherwise it uses a derived key.
 func (h *APHandler) signEvent(event *nostr.Event, actorID string) error {
+	if actorID == "" {
+		// Never sign with the key derived from an empty ID — it would be
+		// shared by every object whose author could not be determined.
+		return fmt.Errorf("sign event: missing author")
+	}
 	if actorID == h.LocalActorURL {
 		return h.Signer.SignAsUser(event)
 	}
@@ -531,8 +914,8 @@ func (h *APHandler) signEvent(event *nostr.Event, actorID string) error {
 }
 
 func (h *APHandler) noteToEvent(ctx context.Context, note *Note) (*nostr.Event, error) {
-	// Convert AP Note content (HTML) to plain text.
-	content := htmlToText(note.Content)
+	// Convert AP Note content (HTML) to plain text or Markdown (CONTENT_FORMAT).
+	content := h.contentText(note.Content)
 
 	// Extract mentions: collect pubkeys for p-tags and actor URLs for href filtering.
 	mentionHrefs := make(map[string]bool)
@@ -572,30 +955,28 @@ func (h *APHandler) noteToEvent(ctx context.Context, note *Note) (*nostr.Event,
 
 	// Resolve reply threading and the NIP-10 thread root.
 	var replyToEventID, rootEventID string
+	var comment *bridge.CommentScope
 	if note.InReplyTo != "" {
 		if id, ok := h.resolveNostrID(note.InReplyTo); ok {
 			replyToEventID = id
 
-			// Determine the thread root for the NIP-10 "root" marker.
-			// The parent AP object is almost always already in the cache because
-			// handleCreate pre-fetches it just before calling noteToEvent, so
-			// this is typically a zero-latency cache hit.
-			if parentObj, err := FetchObject(ctx, note.InReplyTo); err == nil {
-				parentNote := mapToNote(parentObj)
-				if parentNote != nil && parentNote.InReplyTo != "" {
-					// Parent is itself a reply; resolve its InReplyTo as our root.
-					if rootID, ok2 := h.resolveNostrID(parentNote.InReplyTo); ok2 {
-						rootEventID = rootID
-					}
-				}
-			}
-			// If root is still empty (parent is the root, or grandparent is
-			// not in our DB), set root = direct parent so BuildKind1Event
+			// Determine the thread root for the NIP-10 "root" marker. When the
+			// parent starts the thread, root = direct parent so BuildKind1Event
 			// emits a single "reply" e-tag instead of a bare positional tag.
-			if rootEventID == "" {
-				rootEventID = replyToEventID
+			rootEventID = h.threadRoot(ctx, note.InReplyTo, replyToEventID)
+
+			// Replies in a thread rooted at an article become NIP-22 comments.
+			if addr, ok := h.Store.GetEventAddress(rootEventID); ok {
+				comment = &bridge.CommentScope{
+					RootAddress:   addr,
+					RootEventID:   rootEventID,
+					ParentEventID: replyToEventID,
+				}
+				if replyToEventID != rootEventID {
+					comment.ParentPubkey = h.authorPubkey(ctx, note.InReplyTo)
+				}
 			}
-		} else {
+		} else if !note.partialThread {
 			// Parent is unresolvable even after the pre-fetch in handleCreate.
 			// Drop the reply rather than publishing it without thread context,
 			// which would make it appear as a top-level post in the main feed.
@@ -669,34 +1050,62 @@ func (h *APHandler) noteToEvent(ctx context.Context, note *Note) (*nostr.Event,
 		}
 	}
 
-	// Content warning.
+	// Content warning. Pleroma/Akkoma often mark media sensitive without a
+	// summary; fall back to the configured text so clients still collapse it.
 	var contentWarning string
-	if note.Sensitive && note.Summary != "" {
+	if note.Sensitive {
 		contentWarning = note.Summary
+		if contentWarning == "" {
+			contentWarning = h.SensitiveCW
+		}
 	}
 
-	// Media attachments: images → ImageInfo for imeta tags; link cards → append
-	// URL to content (imeta is for actual media, not HTML link previews).
+	// Content filters (admin API): skip the note, or hide it behind a
+	// content warning unless it already has one.
+	switch action, pattern := h.ContentFilter.Check(contentFilterText(note, content)); action {
+	case bridge.FilterSkip:
+		slog.Info("skipped note matching a content filter", "id", note.ID, "pattern", pattern)
+		return nil, nil
+	case bridge.FilterWarn:
+		if contentWarning == "" {
+			contentWarning = "Filtered: " + pattern
+		}
+	}
+
+	// Media attachments (images, videos, audio) → ImageInfo for imeta tags, in
+	// the author's order; BuildKind1Event appends their URLs after the body.
+	// Link cards and text attachments are not media: their URLs are appended
+	// to the body first, deduped against each other and the visible content.
 	var images []bridge.ImageInfo
+	var links []string
+	mediaSeen := make(map[string]bool)
 	for _, att := range note.Attachment {
 		if att.URL == "" {
 			continue
 		}
 		if att.Type == "Link" || strings.HasPrefix(att.MediaType, "text/") {
-			if !strings.Contains(content, att.URL) {
-				content += "\n\n" + att.URL
+			if !seen[att.URL] && !strings.Contains(content, att.URL) {
+				seen[att.URL] = true
+				links = append(links, att.URL)
 			}
 			continue
 		}
+		if mediaSeen[att.URL] {
+			continue
+		}
+		mediaSeen[att.URL] = true
 		images = append(images, bridge.ImageInfo{
 			URL:      att.URL,
 			Alt:      att.Name, // AP "name" field is the alt text / description
-			MimeType: att.MediaType,
+			MimeType: attachmentMediaType(att),
 			Blurhash: att.Blurhash,
 			Width:    att.Width,
 			Height:   att.Height,
 		})
 	}
+	for _, link := range links {
+		content += "\n\n" + link
+	}
 
 	// Source URL for attribution (note.URL is the canonical web URL of the post).
 	sourceURL := note.URL
@@ -704,6 +1113,15 @@ func (h *APHandler) noteToEvent(ctx context.Context, note *Note) (*nostr.Event,
 		sourceURL = note.ID
 	}
 
+	// Location: Place name → location tag, coordinates → g (geohash) tag.
+	var locationName, geohash string
+	if h.BridgeLocation && note.Location != nil {
+		locationName = note.Location.Name
+		if note.Location.HasCoordinates() {
+			geohash = bridge.EncodeGeohash(note.Location.Latitude, note.Location.Longitude, bridge.GeohashPrecision)
+		}
+	}
+
 	// NIP-40: map AP endTime to an expiration timestamp.
 	var expiresAt int64
 	if note.EndTime != "" {
@@ -718,18 +1136,27 @@ func (h *APHandler) noteToEvent(ctx context.Context, note *Note) (*nostr.Event,
 		Images:         images,
 		ReplyToEventID: replyToEventID,
 		RootEventID:    rootEventID,
-		RelayHint:      h.NostrRelay,
+		RelayHint:      h.relayHint(),
 		MentionPubkeys: mentionPubkeys,
 		QuoteEventID:   quoteEventID,
 		Hashtags:       hashtags,
 		ContentWarning: contentWarning,
+		LocationName:   locationName,
+		Geohash:        geohash,
 		SourceURL:      sourceURL,
-		ShowSourceLink: h.ShowSourceLink.Load(),
+		// A partial thread whose parent is not bridged links to its source,
+		// where the rest can be read.
+		ShowSourceLink: h.ShowSourceLink.Load() || (note.partialThread && replyToEventID == ""),
 		ExpiresAt:      expiresAt,
 		ProxyID:        note.ID,
 		ProxyProtocol:  "activitypub",
 	}
-	event := bridge.BuildKind1Event(np)
+	var event *nostr.Event
+	if comment != nil {
+		event = bridge.BuildCommentEvent(np, *comment)
+	} else {
+		event = bridge.BuildKind1Event(np)
+	}
 
 	if err := h.signEvent(event, note.AttributedTo); err != nil {
 		return nil, fmt.Errorf("sign event: %w", err)
@@ -737,15 +1164,42 @@ func (h *APHandler) noteToEvent(ctx context.Context, note *Note) (*nostr.Event,
 	return event, nil
 }
 
+// authorPubkey returns the Nostr pubkey of the author of the AP object
+// objectID, or "" when it cannot be resolved.
+func (h *APHandler) authorPubkey(ctx context.Context, objectID string) string {
+	if IsLocalID(objectID, h.LocalDomain) {
+		return h.Signer.LocalPublicKey()
+	}
+	author, err := FetchObjectAuthor(ctx, objectID)
+	if err != nil {
+		return ""
+	}
+	pubkey, _ := h.Signer.PublicKey(author)
+	return pubkey
+}
+
+// storeEventAddress records the NIP-01 address of a bridged addressable event
+// so replies to it can be scoped as NIP-22 comments.
+func (h *APHandler) storeEventAddress(event *nostr.Event) {
+	if !nostr.IsAddressableKind(event.Kind) {
+		return
+	}
+	addr := fmt.Sprintf("%d:%s:%s", event.Kind, event.PubKey, event.Tags.GetD())
+	if err := h.Store.SetEventAddress(event.ID, addr); err != nil {
+		slog.Warn("failed to store event address", "id", event.ID, "error", err)
+	}
+}
+
 // resolveNostrID returns the Nostr event ID for an AP object URL.
 // For local objects (https://domain/objects/<nostr-id>) the ID is extracted
 // directly from the URL — no DB lookup needed, and crucially this works even
 // for events that were never explicitly stored (e.g. outbound Nostr posts).
-// For remote objects it falls back to the DB.
+// For remote objects it falls back to the DB. Local URLs that don't carry a
+// valid event ID do not resolve.
 func (h *APHandler) resolveNostrID(apObjectID string) (string, bool) {
 	localPrefix := strings.TrimRight(h.LocalDomain, "/") + "/objects/"
-	if strings.HasPrefix(apObjectID, localPrefix) {
-		return strings.TrimPrefix(apObjectID, localPrefix), true
+	if id, ok := strings.CutPrefix(apObjectID, localPrefix); ok {
+		return id, nostr.IsValid32ByteHex(id)
 	}
 	return h.Store.GetNostrIDForObject(apObjectID)
 }
@@ -809,7 +1263,7 @@ func (h *APHandler) questionToEvent(note *Note) (*nostr.Event, error) {
 // The AP object URL is used as the `d` tag identifier so that subsequent
 // updates (via AP Update activity) replace the same addressable event on relays.
 func (h *APHandler) articleToEvent(note *Note) (*nostr.Event, error) {
-	content := htmlToText(note.Content)
+	content := h.contentText(note.Content)
 
 	tags := nostr.Tags{
 		{"proxy", note.ID, "activitypub"},
@@ -879,27 +1333,40 @@ func (h *APHandler) fetchAndCacheActor(ctx context.Context, actorID string) {
 		Kind:      0,
 		Content:   meta,
 		CreatedAt: nostr.Now(),
-		Tags: nostr.Tags{
+		Tags: append(nostr.Tags{
 			{"proxy", actorID, "activitypub"},
-		},
+		}, identityTags(actor)...),
 	}
 	if err := h.Signer.Sign(event, actorID); err == nil {
+		recordNIP05Name(h.Store, actor)
 		h.Publisher.Publish(ctx, event)
 	}
 }
 
-func (h *APHandler) fetchAndCacheObject(ctx context.Context, objectID string) {
+// bridgeObject fetches the remote AP object objectID, converts it and
+// publishes the result. Returns the published event, or nil when the object
+// could not be fetched or bridged.
+func (h *APHandler) bridgeObject(ctx context.Context, objectID string) *nostr.Event {
+	return h.bridgeThreadObject(ctx, objectID, false)
+}
+
+// bridgeThreadObject is bridgeObject for an object bridged without its
+// ancestors: the top of a chain cut off by ensureAncestorsBridged, or the
+// target of an interaction. partialThread marks a reply among them as a
+// partial thread; it has no effect on a note that is not a reply.
+func (h *APHandler) bridgeThreadObject(ctx context.Context, objectID string, partialThread bool) *nostr.Event {
 	if IsLocalID(objectID, h.LocalDomain) {
-		return
+		return nil
 	}
 	obj, err := FetchObject(ctx, objectID)
 	if err != nil {
-		return
+		return nil
 	}
 	note := mapToNote(obj)
 	if note == nil {
-		return
+		return nil
 	}
+	note.partialThread = partialThread && note.InReplyTo != ""
 	// Fetch the original author's actor so their NIP-05 handle is published
 	// as a kind-0 event. This matters for reposts (Announce) where the
 	// booster's metadata is fetched via HandleActivity but the boosted post's
@@ -907,43 +1374,246 @@ func (h *APHandler) fetchAndCacheObject(ctx context.Context, objectID string) {
 	if note.AttributedTo != "" {
 		go h.fetchAndCacheActor(context.Background(), note.AttributedTo)
 	}
-	event, err := h.noteToEvent(ctx, note)
+	var event *nostr.Event
+	if note.Type == "Article" || note.Type == "Page" {
+		event, err = h.articleToEvent(note)
+	} else {
+		event, err = h.noteToEvent(ctx, note)
+	}
 	if err != nil || event == nil {
-		return
+		return nil
 	}
-	if err := h.Store.AddObject(note.ID, event.ID); err == nil {
-		h.Publisher.Publish(ctx, event)
+	if err := h.Store.AddObject(note.ID, event.ID); err != nil {
+		return nil
 	}
+	h.storeObjectAliases(note, event.ID, objectID)
+	h.storeEventAddress(event)
+	h.Publisher.Publish(ctx, event)
+	return event
 }
 
-func (h *APHandler) handleAccept(ctx context.Context, activity IncomingActivity) error {
+// BridgeURL bridges the remote post at objectURL (an AP object ID or the
+// post's web URL) on demand, together with any unbridged ancestors, and
+// returns its Nostr event ID and the author's actor URL. Posts that are
+// already bridged return their existing event ID.
+func (h *APHandler) BridgeURL(ctx context.Context, objectURL string) (eventID, author string, err error) {
+	if IsLocalID(objectURL, h.LocalDomain) {
+		return "", "", fmt.Errorf("%s is a local object", objectURL)
+	}
+	obj, err := FetchObject(ctx, objectURL)
+	if err != nil {
+		return "", "", err
+	}
+	note := mapToNote(obj)
+	if note == nil || note.ID == "" {
+		return "", "", fmt.Errorf("%s is not an ActivityPub object", objectURL)
+	}
+	if id, ok := h.resolveNostrID(note.ID); ok {
+		h.storeObjectAliases(note, id, objectURL)
+		return id, note.AttributedTo, nil
+	}
+	if note.InReplyTo != "" {
+		h.ensureAncestorsBridged(ctx, note.InReplyTo)
+	}
+	if event := h.bridgeObject(ctx, objectURL); event != nil {
+		return event.ID, note.AttributedTo, nil
+	}
+	return "", "", fmt.Errorf("%s could not be bridged (unresolvable parent, filtered or unsupported type)", objectURL)
+}
+
+// defaultMaxAncestorDepth is the default APHandler.MaxAncestorDepth.
+const defaultMaxAncestorDepth = 20
+
+// maxAncestorDepth caps how many levels of an inReplyTo chain
+// ensureAncestorsBridged and threadRoot walk.
+func (h *APHandler) maxAncestorDepth() int {
+	if h.MaxAncestorDepth > 0 {
+		return h.MaxAncestorDepth
+	}
+	return defaultMaxAncestorDepth
+}
+
+// ensureAncestorsBridged bridges objectID and any of its ancestors not yet in
+// the DB, oldest-first, so each reply can thread to its own parent — the AP
+// counterpart of the Bluesky poller's ensureAncestorsBridged. The walk stops
+// at the first ancestor already bridged, at the thread root, or after
+// maxAncestorDepth objects. Fetches go through FetchObject's cache, so
+// ancestors shared by several replies are only fetched once. When the walk is
+// cut short by the depth cap or a reply loop, the topmost ancestor is bridged
+// as a partial thread (see Note.partialThread) so the replies below it still
+// thread.
+func (h *APHandler) ensureAncestorsBridged(ctx context.Context, objectID string) {
+	maxDepth := h.maxAncestorDepth()
+	var chain []string
+	visited := make(map[string]bool)
+	cut := false
+	for id := objectID; id != ""; {
+		if _, ok := h.resolveNostrID(id); ok || IsLocalID(id, h.LocalDomain) {
+			break
+		}
+		if visited[id] || len(chain) == maxDepth {
+			slog.Info("ancestor walk cut short, bridging a partial thread", "object", objectID, "at", id, "loop", visited[id])
+			cut = true
+			break
+		}
+		visited[id] = true
+		chain = append(chain, id)
+		obj, err := FetchObject(ctx, id)
+		if err != nil {
+			break
+		}
+		note := mapToNote(obj)
+		if note == nil {
+			break
+		}
+		id = note.InReplyTo
+	}
+	slices.Reverse(chain)
+	for i, id := range chain {
+		h.bridgeThreadObject(ctx, id, cut && i == 0)
+	}
+}
+
+// threadRoot returns the Nostr ID of the topmost bridged ancestor of the
+// parent object parentURL, or parentID (the parent's own Nostr ID) when the
+// parent starts the thread or no ancestor is bridged. Local objects end the
+// walk: their thread lives on Nostr, not in inReplyTo chains. So do reply
+// loops and the maxAncestorDepth cap.
+func (h *APHandler) threadRoot(ctx context.Context, parentURL, parentID string) string {
+	root := parentID
+	id := parentURL
+	visited := make(map[string]bool)
+	for depth := 0; depth < h.maxAncestorDepth() && !IsLocalID(id, h.LocalDomain) && !visited[id]; depth++ {
+		visited[id] = true
+		obj, err := FetchObject(ctx, id)
+		if err != nil {
+			break
+		}
+		note := mapToNote(obj)
+		if note == nil || note.InReplyTo == "" {
+			break
+		}
+		id = note.InReplyTo
+		if nostrID, ok := h.resolveNostrID(id); ok {
+			root = nostrID
+		}
+	}
+	return root
+}
+
+// storeObjectAliases records the other AP IDs a note is known by — its
+// human-readable url and, for fetched notes, the URL it was fetched from — as
+// aliases of nostrID, so replies and quotes referencing those still thread.
+func (h *APHandler) storeObjectAliases(note *Note, nostrID, fetchedAs string) {
+	for _, alias := range []string{note.URL, fetchedAs} {
+		if alias == "" || alias == note.ID || !strings.HasPrefix(alias, "http") {
+			continue
+		}
+		if err := h.Store.AddObjectAlias(alias, nostrID); err != nil {
+			slog.Warn("failed to store object alias", "alias", alias, "error", err)
+		}
+	}
+}
+
+// ownFollow returns the followed account of the Follow answered by an Accept
+// or Reject, when that Follow was sent by the local actor and the answer
+// comes from the followed account itself — nobody else may settle it.
+// Answers carrying only the Follow's ID are matched by its #follow- fragment
+// (see BuildFollow), with the answering actor as the followed account.
+func (h *APHandler) ownFollow(activity IncomingActivity) (string, bool) {
 	followActor, followObject, err := parseFollowFromObject(activity.Object)
 	if err != nil {
-		return nil // Ignore malformed or string-only Accept objects
+		if !strings.HasPrefix(parseObjectID(activity.Object), h.LocalActorURL+"#follow-") {
+			return "", false
+		}
+		return activity.Actor, true
 	}
+	if followActor != h.LocalActorURL || followObject != activity.Actor {
+		return "", false
+	}
+	return followObject, true
+}
+
+// rejectedFollowTTL is how long a rejected follow request stays open for a
+// late approval (see handleReject).
+const rejectedFollowTTL = 30 * 24 * time.Hour
+
+// handleAccept marks an outbound follow as active. A locked account may
+// approve a follow after first rejecting it (handleReject keeps the request
+// open for rejectedFollowTTL); the follow removed then is restored and the
+// local user notified.
+func (h *APHandler) handleAccept(ctx context.Context, activity IncomingActivity) error {
 	// Only care about accepts for follows *we* sent.
-	if followActor != h.LocalActorURL {
+	followObject, ok := h.ownFollow(activity)
+	if !ok {
 		return nil
 	}
 	slog.Info("outbound follow accepted", "actor", activity.Actor, "followed", followObject)
+
+	following, err := h.Store.GetAPFollowing(h.LocalActorURL)
+	if err != nil {
+		return fmt.Errorf("accept: get following list: %w", err)
+	}
+	if !slices.Contains(following, followObject) {
+		requests, err := h.Store.GetFollowRequests(h.LocalActorURL)
+		if err != nil {
+			return fmt.Errorf("accept: get follow requests: %w", err)
+		}
+		// Without an open request the follow was undone locally since; the
+		// late Accept must not bring it back. Neither may anyone but the
+		// followed account (ownFollow already ensures this).
+		requestedAt, requested := requests[followObject]
+		if !requested || activity.Actor != followObject {
+			return nil
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, requestedAt); err != nil || time.Since(ts) > rejectedFollowTTL {
+			slog.Debug("accept: follow request expired, not restoring", "followed", followObject)
+			if err := h.Store.MarkFollowAccepted(h.LocalActorURL, followObject); err != nil {
+				slog.Warn("accept: failed to clear expired follow request", "error", err)
+			}
+			return nil
+		}
+		if err := h.Store.AddFollow(h.LocalActorURL, followObject); err != nil {
+			return fmt.Errorf("accept: restore follow: %w", err)
+		}
+		if pubkey, err := h.Signer.PublicKey(followObject); err == nil {
+			if err := h.Store.StoreActorKey(pubkey, followObject); err != nil {
+				slog.Warn("accept: failed to store actor key", "actor", followObject, "error", err)
+			}
+		}
+		slog.Info("outbound follow approved after rejection, follow restored", "followed", followObject)
+		go h.sendApprovedNotification(context.Background(), followObject)
+	}
+
+	if err := h.Store.MarkFollowAccepted(h.LocalActorURL, followObject); err != nil {
+		slog.Warn("accept: failed to clear follow request", "error", err)
+	}
+	h.Notifier.Notify(notify.EventFollowAccepted, map[string]string{"actor": activity.Actor})
 	return nil
 }
 
 func (h *APHandler) handleReject(ctx context.Context, activity IncomingActivity) error {
-	followActor, followObject, err := parseFollowFromObject(activity.Object)
-	if err != nil {
-		return nil // Ignore malformed or string-only Reject objects
-	}
 	// Only care about rejects for follows *we* sent.
-	if followActor != h.LocalActorURL {
+	followObject, ok := h.ownFollow(activity)
+	if !ok {
 		return nil
 	}
 	slog.Info("outbound follow rejected", "actor", activity.Actor, "followed", followObject)
 
-	// Remove the follow so the local DB reflects reality.
+	// Remove the follow so the local DB reflects reality, but keep the
+	// request open: locked accounts can still approve it later. Requests of
+	// earlier rejections that were never approved are dropped.
 	if err := h.Store.RemoveFollow(h.LocalActorURL, followObject); err != nil {
 		slog.Warn("reject: failed to remove follow", "error", err)
 	}
+	if err := h.Store.MarkFollowRequested(h.LocalActorURL, followObject); err != nil {
+		slog.Warn("reject: failed to keep follow request", "error", err)
+	}
+	if n, err := h.Store.PruneFollowRequests(time.Now().Add(-rejectedFollowTTL)); err != nil {
+		slog.Warn("reject: failed to prune expired follow requests", "error", err)
+	} else if n > 0 {
+		slog.Debug("reject: pruned expired follow requests", "count", n)
+	}
 
 	// Notify local user via NIP-04 DM.
 	go h.sendRejectNotification(context.Background(), activity.Actor)
@@ -964,11 +1634,9 @@ func (h *APHandler) handleMove(ctx context.Context, activity IncomingActivity) e
 	}
 
 	// object = the old actor (who is moving); target = their new identity.
-	var oldActorURL, newActorURL string
-	if err := json.Unmarshal(activity.Object, &oldActorURL); err != nil {
-		return nil
-	}
-	if err := json.Unmarshal(activity.Target, &newActorURL); err != nil {
+	oldActorURL := parseObjectID(activity.Object)
+	newActorURL := parseObjectID(activity.Target)
+	if oldActorURL == "" || newActorURL == "" {
 		return nil
 	}
 
@@ -1041,9 +1709,16 @@ func (h *APHandler) sendMoveNotification(ctx context.Context, oldActorURL, newAc
 		return actorURL
 	}
 
-	message := "📦 Followed account moved: " + resolve(oldActorURL) + " → " + resolve(newActorURL)
+	oldHandle, newHandle := resolve(oldActorURL), resolve(newActorURL)
+	message := "📦 Followed account moved: " + oldHandle + " → " + newHandle
+	h.Notifier.Notify(notify.EventActorMoved, map[string]string{
+		"old_actor":  oldActorURL,
+		"old_handle": oldHandle,
+		"new_actor":  newActorURL,
+		"new_handle": newHandle,
+	})
 
-	event, err := h.Signer.CreateDMToSelf(message)
+	event, err := h.Signer.CreateNotificationDM(message)
 	if err != nil {
 		slog.Warn("failed to create move notification DM", "error", err)
 		return
@@ -1058,8 +1733,8 @@ func parseFollowFromObject(raw json.RawMessage) (followActor, followObject strin
 	if err = json.Unmarshal(raw, &inner); err != nil || inner.Type != "Follow" {
 		return "", "", fmt.Errorf("object is not an embedded Follow activity")
 	}
-	if err = json.Unmarshal(inner.Object, &followObject); err != nil {
-		return "", "", fmt.Errorf("parse follow object: %w", err)
+	if followObject = parseObjectID(inner.Object); followObject == "" {
+		return "", "", fmt.Errorf("parse follow object: no id")
 	}
 	return inner.Actor, followObject, nil
 }
@@ -1080,8 +1755,9 @@ func (h *APHandler) sendRejectNotification(ctx context.Context, actorURL string)
 	}
 
 	message := "🚫 Follow rejected by " + handle
+	h.Notifier.Notify(notify.EventFollowRejected, map[string]string{"actor": actorURL, "handle": handle})
 
-	event, err := h.Signer.CreateDMToSelf(message)
+	event, err := h.Signer.CreateNotificationDM(message)
 	if err != nil {
 		slog.Warn("failed to create reject notification DM", "error", err)
 		return
@@ -1091,6 +1767,31 @@ func (h *APHandler) sendRejectNotification(ctx context.Context, actorURL string)
 	}
 }
 
+// sendApprovedNotification delivers a NIP-04 DM to the local user when a
+// follow that was rejected is approved after all.
+func (h *APHandler) sendApprovedNotification(ctx context.Context, actorURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	handle := actorURL
+	if actor, err := FetchActor(ctx, actorURL); err == nil && actor != nil {
+		if actor.PreferredUsername != "" {
+			if domain := bridge.ExtractHost(actorURL); domain != "" {
+				handle = "@" + actor.PreferredUsername + "@" + domain
+			}
+		}
+	}
+
+	event, err := h.Signer.CreateNotificationDM("✅ Follow approved by " + handle)
+	if err != nil {
+		slog.Warn("failed to create follow approval notification DM", "error", err)
+		return
+	}
+	if err := h.Publisher.Publish(ctx, event); err != nil {
+		slog.Warn("failed to publish follow approval notification DM", "error", err)
+	}
+}
+
 // sendFollowNotification delivers a NIP-04 DM to the local user when a
 // Fediverse account follows them.
 func (h *APHandler) sendFollowNotification(ctx context.Context, followerActorURL string) {
@@ -1108,8 +1809,9 @@ func (h *APHandler) sendFollowNotification(ctx context.Context, followerActorURL
 	}
 
 	message := "🔔 New Fediverse follower: " + handle
+	h.Notifier.Notify(notify.EventFediverseFollower, map[string]string{"actor": followerActorURL, "handle": handle})
 
-	event, err := h.Signer.CreateDMToSelf(message)
+	event, err := h.Signer.CreateNotificationDM(message)
 	if err != nil {
 		slog.Warn("failed to create follow notification DM", "error", err)
 		return
@@ -1224,6 +1926,223 @@ func htmlToText(h string) string {
 	return strings.TrimSpace(text)
 }
 
+// contentFilterText returns the text of note that content filters are matched
+// against: its summary, its converted content and the alt text of its
+// attachments, so a filtered word cannot hide in an image description.
+func contentFilterText(note *Note, content string) string {
+	parts := []string{note.Summary, content}
+	for _, att := range note.Attachment {
+		if att.Name != "" {
+			parts = append(parts, att.Name)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// contentText converts a post body to Nostr content according to
+// ContentFormat: markdown when set to "markdown", plain text otherwise.
+func (h *APHandler) contentText(html string) string {
+	if h.ContentFormat == "markdown" {
+		return htmlToMarkdown(html)
+	}
+	return htmlToText(html)
+}
+
+// htmlToMarkdown converts AP HTML content to Markdown, keeping the structure
+// htmlToText flattens: links become [text](url), <strong>/<b> **bold**,
+// <em>/<i> *italic*, <code> `code`, <pre> fenced blocks, headings #-prefixed,
+// blockquotes "> "-prefixed and list items "- " (or "1. " in ordered lists).
+// Mention and hashtag links, and links whose text is the URL itself, are
+// emitted as plain text so they read naturally. Markdown punctuation in the
+// text itself is backslash-escaped, except inside code. Like htmlToText it
+// decodes every entity reference and drops <script>/<style> content.
+func htmlToMarkdown(h string) string {
+	z := html.NewTokenizer(strings.NewReader(h))
+
+	// Blockquotes render into their own buffer so every line can be prefixed.
+	bufs := []*strings.Builder{{}}
+	out := func() *strings.Builder { return bufs[len(bufs)-1] }
+
+	var (
+		skipContent bool
+		inPre       bool
+		inCode      bool
+		link        *strings.Builder // text of the <a> being collected
+		linkRaw     *strings.Builder // the same text, unescaped and without markup
+		linkHref    string
+		linkPlain   bool  // emit the link text without markup
+		olCounters  []int // per open list; -1 for <ul>
+	)
+	write := func(s string) {
+		if link != nil {
+			link.WriteString(s)
+			return
+		}
+		out().WriteString(s)
+	}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		switch tt {
+		case html.TextToken:
+			if skipContent {
+				continue
+			}
+			text := html.UnescapeString(string(z.Raw()))
+			if !inPre {
+				// Source newlines are not significant outside <pre>.
+				text = strings.ReplaceAll(text, "\n", " ")
+			}
+			if link != nil {
+				linkRaw.WriteString(text)
+			}
+			if !inPre && !inCode {
+				text = markdownEscaper.Replace(text)
+			}
+			write(text)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch tag := string(name); tag {
+			case "script", "style":
+				skipContent = true
+			case "p", "div":
+				write("\n\n")
+			case "br":
+				write("\n")
+			case "strong", "b":
+				write("**")
+			case "em", "i":
+				write("*")
+			case "code":
+				if !inPre {
+					inCode = true
+					write("`")
+				}
+			case "pre":
+				inPre = true
+				write("\n\n```\n")
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				write("\n\n" + strings.Repeat("#", int(tag[1]-'0')) + " ")
+			case "blockquote":
+				bufs = append(bufs, &strings.Builder{})
+			case "ul":
+				olCounters = append(olCounters, -1)
+			case "ol":
+				olCounters = append(olCounters, 0)
+			case "li":
+				marker := "- "
+				if n := len(olCounters); n > 0 && olCounters[n-1] >= 0 {
+					olCounters[n-1]++
+					marker = strconv.Itoa(olCounters[n-1]) + ". "
+				}
+				write("\n" + marker)
+			case "a":
+				var class string
+				linkHref = ""
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					switch string(key) {
+					case "href":
+						linkHref = string(val)
+					case "class":
+						class = string(val)
+					}
+				}
+				linkPlain = strings.Contains(class, "mention") || strings.Contains(class, "hashtag")
+				if link == nil {
+					link, linkRaw = &strings.Builder{}, &strings.Builder{}
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script", "style":
+				skipContent = false
+			case "p", "div", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6":
+				if n := len(olCounters); n > 0 && (string(name) == "ul" || string(name) == "ol") {
+					olCounters = olCounters[:n-1]
+				}
+				write("\n\n")
+			case "strong", "b":
+				write("**")
+			case "em", "i":
+				write("*")
+			case "code":
+				if !inPre {
+					inCode = false
+					write("`")
+				}
+			case "pre":
+				inPre = false
+				write("\n```\n\n")
+			case "blockquote":
+				if len(bufs) > 1 {
+					quoted := collapseBlankLines(strings.TrimSpace(out().String()))
+					bufs = bufs[:len(bufs)-1]
+					lines := strings.Split(quoted, "\n")
+					for i, line := range lines {
+						lines[i] = strings.TrimRight("> "+line, " ")
+					}
+					write("\n\n" + strings.Join(lines, "\n") + "\n\n")
+				}
+			case "a":
+				if link == nil {
+					continue
+				}
+				text := strings.TrimSpace(link.String())
+				raw := strings.TrimSpace(linkRaw.String())
+				link, linkRaw = nil, nil
+				switch {
+				case linkHref == "" || linkPlain || strings.HasPrefix(raw, "@") || strings.HasPrefix(raw, "#"):
+					// Mentions and hashtags keep their text verbatim, so
+					// @some_user is not written as @some\_user.
+					write(raw)
+				case raw == "" || raw == linkHref || strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://") ||
+					strings.TrimPrefix(strings.TrimPrefix(linkHref, "https://"), "http://") == raw:
+					write(linkHref)
+				default:
+					write("[" + text + "](" + linkHref + ")")
+				}
+			}
+		}
+	}
+	for len(bufs) > 1 { // unclosed blockquote
+		inner := out().String()
+		bufs = bufs[:len(bufs)-1]
+		out().WriteString(inner)
+	}
+	return strings.TrimSpace(collapseBlankLines(out().String()))
+}
+
+// markdownEscaper backslash-escapes the characters htmlToMarkdown emits as
+// markup, so literal text cannot open emphasis, code or a link.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+)
+
+// collapseBlankLines reduces runs of blank lines to a single blank line and
+// trims trailing spaces from each line.
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	s = strings.Join(lines, "\n")
+	for strings.Contains(s, "\n\n\n") {
+		s = strings.ReplaceAll(s, "\n\n\n", "\n\n")
+	}
+	return s
+}
+
 func buildMetadataContent(actor *Actor, localDomain string) string {
 	// Prefer the human-readable URL (e.g. https://mastodon.social/@alice) over
 	// the AP actor ID URL so Nostr clients can link back to the original profile.
@@ -1261,12 +2180,8 @@ func buildMetadataContent(actor *Actor, localDomain string) string {
 
 	// NIP-05: build a verifiable bridge identifier so Nostr clients show
 	// "username_at_domain@localdomain" with a ✓ badge instead of a raw npub.
-	if localDomain != "" && actor.PreferredUsername != "" {
-		actorHost := bridge.ExtractHost(actor.ID)
-		localHost := bridge.ExtractHost(localDomain)
-		if actorHost != "" && localHost != "" {
-			meta.NIP05 = actor.PreferredUsername + "_at_" + actorHost + "@" + localHost
-		}
+	if name, localHost := nip05Name(actor), bridge.ExtractHost(localDomain); name != "" && localHost != "" {
+		meta.NIP05 = name + "@" + localHost
 	}
 
 	b, err := json.Marshal(meta)
@@ -1280,6 +2195,60 @@ func buildMetadataContentFromActor(actor *Actor, localDomain string) string {
 	return buildMetadataContent(actor, localDomain)
 }
 
+// nip05Name returns the local part of the NIP-05 identifier advertised in a
+// bridged actor's kind-0 ("username_at_host"), or "" when it has none.
+func nip05Name(actor *Actor) string {
+	actorHost := bridge.ExtractHost(actor.ID)
+	if actor.PreferredUsername == "" || actorHost == "" {
+		return ""
+	}
+	return actor.PreferredUsername + "_at_" + actorHost
+}
+
+// nip05NameStore is the Store method used by recordNIP05Name.
+type nip05NameStore interface {
+	SetNIP05Name(name, actorURL string) error
+}
+
+// recordNIP05Name remembers which actor a published kind-0's NIP-05 name
+// belongs to (the nip05_names table), so /.well-known/nostr.json resolves the
+// name even when WebFinger for it fails or the handle's server is
+// unreachable.
+func recordNIP05Name(store nip05NameStore, actor *Actor) {
+	name := nip05Name(actor)
+	if name == "" {
+		return
+	}
+	if err := store.SetNIP05Name(name, actor.ID); err != nil {
+		slog.Warn("failed to record NIP-05 name", "name", name, "error", err)
+	}
+}
+
+// mediaExtTypes maps common media file extensions to MIME types, for
+// attachments that arrive without a mediaType.
+var mediaExtTypes = map[string]string{
+	".jpg": "image/jpeg", ".jpeg": "image/jpeg", ".png": "image/png",
+	".gif": "image/gif", ".webp": "image/webp", ".avif": "image/avif",
+	".mp4": "video/mp4", ".m4v": "video/mp4", ".webm": "video/webm",
+	".mov": "video/quicktime", ".mp3": "audio/mpeg", ".m4a": "audio/mp4",
+	".ogg": "audio/ogg", ".opus": "audio/opus", ".wav": "audio/wav",
+	".flac": "audio/flac",
+}
+
+// attachmentMediaType returns the MIME type of a media attachment: its
+// mediaType when set, otherwise one guessed from the URL's file extension so
+// that videos and audio get an "m video/…" / "m audio/…" imeta entry too.
+func attachmentMediaType(att Attachment) string {
+	if att.MediaType != "" {
+		return att.MediaType
+	}
+	p := att.URL
+	if u, err := url.Parse(att.URL); err == nil {
+		p = u.Path
+	}
+	return mediaExtTypes[strings.ToLower(path.Ext(p))]
+}
+
 // anchorHrefRe matches the href attribute value inside an <a> tag,
 // restricted to http/https URLs (skips mailto:, javascript:, etc.).
 var anchorHrefRe = regexp.MustCompile(`(?i)<a\s[^>]*\bhref\s*=\s*["'](https?://[^"']+)["']`)
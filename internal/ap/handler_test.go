@@ -0,0 +1,169 @@
+package ap
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/bridge"
+)
+
+// testSigner signs every event with one generated key.
+type testSigner struct{ sk string }
+
+func newTestSigner() *testSigner { return &testSigner{sk: nostr.GeneratePrivateKey()} }
+
+func (s *testSigner) SignAsUser(event *nostr.Event) error        { return event.Sign(s.sk) }
+func (s *testSigner) Sign(event *nostr.Event, apID string) error { return event.Sign(s.sk) }
+func (s *testSigner) PublicKey(apID string) (string, error)      { return nostr.GetPublicKey(s.sk) }
+func (s *testSigner) LocalPublicKey() string {
+	pk, _ := nostr.GetPublicKey(s.sk)
+	return pk
+}
+func (s *testSigner) CreateNotificationDM(message string) (*nostr.Event, error) {
+	return nil, nil
+}
+
+func newTestAPHandler() *APHandler {
+	return &APHandler{
+		LocalDomain:    "https://bridge.example",
+		LocalActorURL:  "https://bridge.example/users/alice",
+		Signer:         newTestSigner(),
+		SensitiveCW:    "Sensitive content",
+		ShowSourceLink: new(atomic.Bool),
+	}
+}
+
+func TestNoteToEventContentWarning(t *testing.T) {
+	tests := []struct {
+		name      string
+		sensitive bool
+		summary   string
+		want      string // "" means no content-warning tag
+	}{
+		{"not sensitive", false, "", ""},
+		{"summary without sensitive flag", false, "spoilers", ""},
+		{"sensitive with summary", true, "spoilers", "spoilers"},
+		{"sensitive without summary", true, "", "Sensitive content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note := &Note{
+				ID:           "https://a.example/notes/1",
+				Type:         "Note",
+				AttributedTo: "https://a.example/users/bob",
+				Content:      "<p>hello</p>",
+				Sensitive:    tt.sensitive,
+				Summary:      tt.summary,
+			}
+			event, err := newTestAPHandler().noteToEvent(context.Background(), note)
+			if err != nil || event == nil {
+				t.Fatalf("noteToEvent = %v, %v", event, err)
+			}
+			got := ""
+			if tag := event.Tags.GetFirst([]string{"content-warning"}); tag != nil {
+				got = (*tag)[1]
+			}
+			if got != tt.want {
+				t.Errorf("content-warning = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplyReaction(t *testing.T) {
+	const parent = "https://a.example/notes/0"
+	tests := []struct {
+		name    string
+		note    Note
+		want    string
+		isReact bool
+	}{
+		{"emoji", Note{InReplyTo: parent, Content: "<p>👍</p>"}, "👍", true},
+		{"emoji after mentions", Note{InReplyTo: parent, Content: `<p><span class="h-card"><a href="https://a.example/users/bob">@bob</a></span> ❤️</p>`}, "❤️", true},
+		{"ZWJ sequence", Note{InReplyTo: parent, Content: "👩‍💻"}, "👩‍💻", true},
+		{"flag", Note{InReplyTo: parent, Content: "🇸🇪"}, "🇸🇪", true},
+		{"custom emoji", Note{InReplyTo: parent, Content: ":blobcat_thumbsup:"}, ":blobcat_thumbsup:", true},
+		{"not a reply", Note{Content: "👍"}, "", false},
+		{"text", Note{InReplyTo: parent, Content: "nice"}, "", false},
+		{"emoji with text", Note{InReplyTo: parent, Content: "👍 nice"}, "", false},
+		{"two emoji", Note{InReplyTo: parent, Content: "👍 👍"}, "", false},
+		{"ASCII symbol", Note{InReplyTo: parent, Content: "+"}, "", false},
+		{"digit", Note{InReplyTo: parent, Content: "1"}, "", false},
+		{"letter", Note{InReplyTo: parent, Content: "é"}, "", false},
+		{"only mentions", Note{InReplyTo: parent, Content: "@bob"}, "", false},
+		{"with media", Note{InReplyTo: parent, Content: "👍", Attachment: []Attachment{{Type: "Image", URL: "https://a.example/1.png"}}}, "", false},
+		{"quote", Note{InReplyTo: parent, Content: "👍", QuoteURL: "https://a.example/notes/2"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := replyReaction(&tt.note)
+			if got != tt.want || ok != tt.isReact {
+				t.Errorf("replyReaction = %q, %v, want %q, %v", got, ok, tt.want, tt.isReact)
+			}
+		})
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"paragraphs", "<p>one</p><p>two</p>", "one\n\ntwo"},
+		{"emphasis", "<p><strong>bold</strong> and <em>it</em></p>", "**bold** and *it*"},
+		{"literal asterisks", "<p>5 * 3 = 15, *not italic*</p>", `5 \* 3 = 15, \*not italic\*`},
+		{"literal underscores", "<p>snake_case_name</p>", `snake\_case\_name`},
+		{"literal brackets", "<p>[citation needed]</p>", `\[citation needed\]`},
+		{"literal backticks", "<p>use `ls`</p>", "use \\`ls\\`"},
+		{"literal backslash", `<p>C:\temp</p>`, `C:\\temp`},
+		{"entities decoded then escaped", "<p>&#42;&#95;</p>", `\*\_`},
+		{"inline code kept verbatim", "<p><code>a_b * c</code></p>", "`a_b * c`"},
+		{"pre kept verbatim", "<pre>x = a_b * [c]</pre>", "```\nx = a_b * [c]\n```"},
+		{"link", `<p><a href="https://example.com/">the [best] site</a></p>`, `[the \[best\] site](https://example.com/)`},
+		{"link with markup", `<p><a href="https://example.com/"><strong>bold</strong></a></p>`, "[**bold**](https://example.com/)"},
+		{"bare URL with underscore", `<p><a href="https://example.com/a_b">https://example.com/a_b</a></p>`, "https://example.com/a_b"},
+		{"mention kept verbatim", `<p><a href="https://social.example/@some_user" class="u-url mention">@<span>some_user</span></a></p>`, "@some_user"},
+		{"hashtag kept verbatim", `<p><a href="https://social.example/tags/snake_case" class="mention hashtag">#<span>snake_case</span></a></p>`, "#snake_case"},
+		{"list", "<ul><li>a_1</li><li>b</li></ul>", `- a\_1` + "\n- b"},
+		{"blockquote", "<blockquote><p>quoted *text*</p></blockquote>", `> quoted \*text\*`},
+		{"script dropped", "<p>hi</p><script>alert('_')</script>", "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToMarkdown(tt.html); got != tt.want {
+				t.Errorf("htmlToMarkdown(%q) = %q, want %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoteToEventContentFilterAltText(t *testing.T) {
+	h := newTestAPHandler()
+	h.ContentFilter = &bridge.ContentFilter{}
+	if err := h.ContentFilter.Set([]bridge.ContentFilterRule{{Pattern: "spoiler", Action: bridge.FilterSkip}}); err != nil {
+		t.Fatal(err)
+	}
+	note := &Note{
+		ID:           "https://a.example/notes/1",
+		Type:         "Note",
+		AttributedTo: "https://a.example/users/bob",
+		Content:      "<p>look at this</p>",
+		Attachment: []Attachment{{
+			Type:      "Document",
+			MediaType: "image/png",
+			URL:       "https://a.example/media/1.png",
+			Name:      "Screenshot of the finale, major spoiler",
+		}},
+	}
+	event, err := h.noteToEvent(context.Background(), note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event != nil {
+		t.Errorf("note whose alt text matches a skip filter was bridged: %q", event.Content)
+	}
+}
@@ -0,0 +1,239 @@
+package ap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// kvHashtagCursorPrefix prefixes the per-server, per-tag cursor: the ID of the
+// newest status seen in that server's Mastodon tag timeline.
+const kvHashtagCursorPrefix = "hashtag_cursor_"
+
+// hashtagPageSize is how many posts are requested per server and tag each poll.
+const hashtagPageSize = 20
+
+// HashtagFeedStore is the DB interface used by HashtagFeed.
+type HashtagFeedStore interface {
+	GetKV(key string) (string, bool)
+	SetKV(key, value string) error
+}
+
+// HashtagFeed bridges public Fediverse posts carrying followed hashtags.
+// ActivityPub has no hashtag subscription, so it polls the public tag
+// timelines of a few servers instead: Mastodon's /api/v1/timelines/tag/{tag},
+// falling back to the AP /tags/{tag} collection for servers without that API.
+// Each new post is fetched from its origin and bridged like a fetched object;
+// posts already in the objects table are skipped, so overlapping servers and
+// repeated polls never publish a post twice.
+type HashtagFeed struct {
+	Handler *APHandler
+	Store   HashtagFeedStore
+	Tags    []string // lowercase, without '#'
+	Servers []string // hosts whose public tag timelines are polled
+	// Interval between polls. Defaults to 10m if zero.
+	Interval time.Duration
+	// HostAllowed, if set, reports whether posts from a host may be bridged
+	// (the instance block/allow lists).
+	HostAllowed func(host string) bool
+}
+
+// Start polls immediately and then every Interval. Blocks until ctx is
+// cancelled.
+func (f *HashtagFeed) Start(ctx context.Context) {
+	interval := f.Interval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	slog.Info("hashtag feed started", "tags", f.Tags, "servers", f.Servers, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		f.poll(ctx)
+		select {
+		case <-ctx.Done():
+			slog.Info("hashtag feed stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks every tag on every server once.
+func (f *HashtagFeed) poll(ctx context.Context) {
+	bridged := 0
+	for _, server := range f.Servers {
+		for _, tag := range f.Tags {
+			if ctx.Err() != nil {
+				return
+			}
+			ids, err := f.tagTimeline(ctx, server, tag)
+			if err != nil {
+				slog.Warn("hashtag feed: failed to fetch tag timeline", "server", server, "tag", tag, "error", err)
+				continue
+			}
+			for _, id := range ids {
+				if f.bridge(ctx, id) {
+					bridged++
+				}
+			}
+		}
+	}
+	if bridged > 0 {
+		slog.Info("hashtag feed: bridged posts", "count", bridged)
+	}
+}
+
+// bridge publishes the post objectID unless it is already bridged, not
+// public, or from a blocked host or excluded author. Reports whether it was
+// published.
+func (f *HashtagFeed) bridge(ctx context.Context, objectID string) bool {
+	h := f.Handler
+	if _, ok := h.resolveNostrID(objectID); ok {
+		return false
+	}
+	if f.HostAllowed != nil {
+		if u, err := url.Parse(objectID); err != nil || !f.HostAllowed(u.Host) {
+			return false
+		}
+	}
+	obj, err := FetchObject(ctx, objectID)
+	if err != nil {
+		slog.Debug("hashtag feed: failed to fetch post", "id", objectID, "error", err)
+		return false
+	}
+	note := mapToNote(obj)
+	// Replies are left out: the feed is for the tagged posts themselves, not
+	// the threads under them.
+	if note == nil || note.ID == "" || note.InReplyTo != "" {
+		return false
+	}
+	if !slices.Contains(note.To, PublicURI) && !slices.Contains(note.CC, PublicURI) {
+		return false
+	}
+	if h.Exclusions.Excluded(note.AttributedTo) {
+		return false
+	}
+	return h.bridgeObject(ctx, note.ID) != nil
+}
+
+// tagTimeline returns the AP IDs of the newest public posts tagged tag on
+// server, via the Mastodon API when the server has it and the AP tag
+// collection otherwise.
+func (f *HashtagFeed) tagTimeline(ctx context.Context, server, tag string) ([]string, error) {
+	ids, err := f.mastodonTagTimeline(ctx, server, tag)
+	if err == nil {
+		return ids, nil
+	}
+	if ids, apErr := apTagCollection(ctx, server, tag); apErr == nil {
+		return ids, nil
+	}
+	return nil, err
+}
+
+// mastodonStatus is the part of a Mastodon API status the feed uses.
+type mastodonStatus struct {
+	ID  string `json:"id"`
+	URI string `json:"uri"` // the AP object ID
+}
+
+// mastodonTagTimeline fetches server's public timeline for tag, newer than
+// the stored cursor. It pages forward with min_id, so a burst of more than
+// hashtagPageSize posts is caught up over the following polls instead of
+// skipping the posts between the cursor and the newest page, as since_id
+// would. Statuses are returned oldest first.
+func (f *HashtagFeed) mastodonTagTimeline(ctx context.Context, server, tag string) ([]string, error) {
+	cursorKey := kvHashtagCursorPrefix + server + "_" + tag
+	params := url.Values{}
+	params.Set("limit", fmt.Sprint(hashtagPageSize))
+	if cursor, ok := f.Store.GetKV(cursorKey); ok {
+		params.Set("min_id", cursor)
+	}
+	rawURL := "https://" + server + "/api/v1/timelines/tag/" + url.PathEscape(tag) + "?" + params.Encode()
+
+	req, err := newRequest(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: HTTP %d", rawURL, resp.StatusCode)
+	}
+	var statuses []mastodonStatus
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, fmt.Errorf("decode response from %s: %w", rawURL, err)
+	}
+
+	// Statuses come newest first, min_id pages included.
+	ids := make([]string, 0, len(statuses))
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].URI != "" {
+			ids = append(ids, statuses[i].URI)
+		}
+	}
+	if len(statuses) > 0 {
+		_ = f.Store.SetKV(cursorKey, statuses[0].ID)
+	}
+	return ids, nil
+}
+
+// apTagCollection reads the AP collection at https://server/tags/{tag},
+// following its first page when the items are not inline.
+func apTagCollection(ctx context.Context, server, tag string) ([]string, error) {
+	rawURL := "https://" + server + "/tags/" + url.PathEscape(tag)
+	// A tag collection changes with every post; never serve it from the cache.
+	InvalidateCache(rawURL)
+	coll, err := FetchObject(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	items := collectionItems(coll)
+	if items == nil {
+		first, _ := coll["first"].(map[string]interface{})
+		if first == nil {
+			firstID := getID(coll, "first")
+			if firstID == "" {
+				return nil, fmt.Errorf("%s is not a collection", rawURL)
+			}
+			InvalidateCache(firstID)
+			if first, err = FetchObject(ctx, firstID); err != nil {
+				return nil, err
+			}
+		}
+		items = collectionItems(first)
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		if id := idOf(item); id != "" {
+			ids = append(ids, id)
+		}
+		if len(ids) == hashtagPageSize {
+			break
+		}
+	}
+	slices.Reverse(ids)
+	return ids, nil
+}
+
+// collectionItems returns the orderedItems (or items) of an AP collection or
+// collection page, or nil when it has neither.
+func collectionItems(coll map[string]interface{}) []interface{} {
+	for _, key := range []string{"orderedItems", "items"} {
+		if items, ok := coll[key].([]interface{}); ok {
+			return items
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package ap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults for the per-destination circuit breaker and delivery timeout.
+const (
+	defaultHostCBThreshold = 5
+	defaultHostCBCooldown  = 10 * time.Minute
+	defaultDeliveryTimeout = 10 * time.Second
+)
+
+// errHostCircuitOpen is returned for deliveries skipped because the
+// destination's circuit breaker is open. It is retryable, so skipped
+// deliveries land in the retry queue instead of being lost.
+var errHostCircuitOpen = errors.New("destination circuit open")
+
+// hostCircuit is the delivery circuit breaker for one remote origin, mirroring
+// the relay circuit breaker in nostr.Publisher. It opens after threshold
+// consecutive transient failures (transport errors, 5xx, 408, 429) and stays
+// open for the cooldown, after which the next delivery is let through as a
+// probe. Permanent rejections (other 4xx) say nothing about host health and
+// are not counted.
+type hostCircuit struct {
+	mu          sync.Mutex
+	failCount   int
+	open        bool
+	openedAt    time.Time
+	delivered   int64
+	failed      int64
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+// isOpen reports whether deliveries should be skipped. Closes the circuit
+// once cooldown has elapsed (half-open probe).
+func (c *hostCircuit) isOpen(cooldown time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.open {
+		return false
+	}
+	if time.Since(c.openedAt) >= cooldown {
+		c.open = false
+		c.failCount = 0
+		return false
+	}
+	return true
+}
+
+// reopensAt returns when an open circuit will let deliveries through again.
+func (c *hostCircuit) reopensAt(cooldown time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.openedAt.Add(cooldown)
+}
+
+// record updates the breaker with a delivery outcome. Returns true the first
+// time the circuit opens.
+func (c *hostCircuit) record(err error, threshold int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		c.delivered++
+		c.failCount = 0
+		c.open = false
+		c.lastSuccess = time.Now()
+		return false
+	}
+	if !IsRetryableDelivery(err) {
+		return false
+	}
+	c.failed++
+	c.failCount++
+	c.lastFailure = time.Now()
+	c.lastError = err.Error()
+	if !c.open && c.failCount >= threshold {
+		c.open = true
+		c.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+// HostHealth is a snapshot of delivery health for one remote origin.
+type HostHealth struct {
+	Host                string `json:"host"`
+	CircuitOpen         bool   `json:"circuit_open"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	CooldownRemaining   int    `json:"cooldown_remaining_secs,omitempty"`
+	Delivered           int64  `json:"delivered"`
+	Failed              int64  `json:"failed"`
+	LastSuccess         int64  `json:"last_success,omitempty"` // unix ts
+	LastFailure         int64  `json:"last_failure,omitempty"` // unix ts
+	LastError           string `json:"last_error,omitempty"`
+}
+
+func (f *Federator) hostThreshold() int {
+	if f.HostCBThreshold > 0 {
+		return f.HostCBThreshold
+	}
+	return defaultHostCBThreshold
+}
+
+func (f *Federator) hostCooldown() time.Duration {
+	if f.HostCBCooldown > 0 {
+		return f.HostCBCooldown
+	}
+	return defaultHostCBCooldown
+}
+
+func (f *Federator) deliveryTimeout() time.Duration {
+	if f.DeliveryTimeout > 0 {
+		return f.DeliveryTimeout
+	}
+	return defaultDeliveryTimeout
+}
+
+// hostCircuit returns (creating if necessary) the circuit breaker for the
+// origin of inbox.
+func (f *Federator) hostCircuit(inbox string) *hostCircuit {
+	v, _ := f.hostCircuits.LoadOrStore(extractOrigin(inbox), &hostCircuit{})
+	return v.(*hostCircuit)
+}
+
+// deliver sends activity to inbox through the destination's circuit breaker,
+// bounded by timeout. Returns errHostCircuitOpen without sending while the
+// breaker is open.
+func (f *Federator) deliver(ctx context.Context, inbox string, activity map[string]interface{}, timeout time.Duration) error {
+	cb := f.hostCircuit(inbox)
+	if cb.isOpen(f.hostCooldown()) {
+		return &DeliveryError{Inbox: inbox, Err: errHostCircuitOpen}
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := f.deliverSigned(sendCtx, inbox, activity)
+	cancel()
+	if ctx.Err() != nil {
+		return err // shutdown, not the host's fault
+	}
+	if cb.record(err, f.hostThreshold()) {
+		slog.Warn("delivery circuit opened for destination",
+			"host", extractOrigin(inbox), "cooldown", f.hostCooldown(), "error", err)
+	}
+	return err
+}
+
+// HostHealth returns delivery health for every origin delivered to since
+// startup, unhealthiest first.
+func (f *Federator) HostHealth() []HostHealth {
+	cooldown := f.hostCooldown()
+	var out []HostHealth
+	f.hostCircuits.Range(func(k, v any) bool {
+		c := v.(*hostCircuit)
+		open := c.isOpen(cooldown)
+		c.mu.Lock()
+		h := HostHealth{
+			Host:                k.(string),
+			CircuitOpen:         open,
+			ConsecutiveFailures: c.failCount,
+			Delivered:           c.delivered,
+			Failed:              c.failed,
+			LastError:           c.lastError,
+		}
+		if !c.lastSuccess.IsZero() {
+			h.LastSuccess = c.lastSuccess.Unix()
+		}
+		if !c.lastFailure.IsZero() {
+			h.LastFailure = c.lastFailure.Unix()
+		}
+		if open {
+			h.CooldownRemaining = int(time.Until(c.openedAt.Add(cooldown)).Seconds())
+		}
+		c.mu.Unlock()
+		out = append(out, h)
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CircuitOpen != out[j].CircuitOpen {
+			return out[i].CircuitOpen
+		}
+		if out[i].ConsecutiveFailures != out[j].ConsecutiveFailures {
+			return out[i].ConsecutiveFailures > out[j].ConsecutiveFailures
+		}
+		return out[i].Failed > out[j].Failed
+	})
+	return out
+}
+
+// ResetHost clears the circuit breaker for host (an origin such as
+// "https://example.com"). Returns false when the host is unknown.
+func (f *Federator) ResetHost(host string) bool {
+	v, ok := f.hostCircuits.Load(host)
+	if !ok {
+		return false
+	}
+	c := v.(*hostCircuit)
+	c.mu.Lock()
+	c.open = false
+	c.failCount = 0
+	c.mu.Unlock()
+	return true
+}
+
+// hostHealthy reports whether the origin of inbox is known to be working:
+// its circuit is closed and it has accepted a delivery since startup. Used by
+// checkGone so a broken server answering 404 for everything does not get its
+// followers pruned.
+func (f *Federator) hostHealthy(inbox string) bool {
+	v, ok := f.hostCircuits.Load(extractOrigin(inbox))
+	if !ok {
+		return false
+	}
+	c := v.(*hostCircuit)
+	if c.isOpen(f.hostCooldown()) {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.lastSuccess.IsZero()
+}
+
+// HostCircuitOpen reports whether deliveries to the origin of rawURL are
+// currently being skipped.
+func (f *Federator) HostCircuitOpen(rawURL string) bool {
+	v, ok := f.hostCircuits.Load(extractOrigin(rawURL))
+	return ok && v.(*hostCircuit).isOpen(f.hostCooldown())
+}
@@ -0,0 +1,92 @@
+package ap
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// identityTags returns NIP-39 external identity tags ("i") for a bridged
+// actor's kind-0: the profile links in its PropertyValue attachments (the
+// verified links on a Mastodon profile) and its alsoKnownAs aliases. Only
+// URLs naming an account on a platform NIP-39 knows are mapped. The proof
+// element is the profile URL itself: the AP profile is the only attestation,
+// so clients can link the account but will not find the npub there.
+func identityTags(actor *Actor) nostr.Tags {
+	type link struct {
+		url       string
+		fediverse bool // an ActivityPub actor, so /@user paths are Fediverse accounts
+	}
+	var links []link
+	for _, pv := range actor.Attachment {
+		if hrefs := extractHrefsFromHTML(pv.Value); len(hrefs) > 0 {
+			for _, href := range hrefs {
+				links = append(links, link{url: href})
+			}
+		} else if v := strings.TrimSpace(pv.Value); strings.HasPrefix(v, "https://") {
+			links = append(links, link{url: v})
+		}
+	}
+	for _, alias := range actor.AlsoKnownAs {
+		if alias != actor.ID {
+			links = append(links, link{url: alias, fediverse: true})
+		}
+	}
+
+	var tags nostr.Tags
+	seen := make(map[string]bool)
+	for _, l := range links {
+		identity := externalIdentity(l.url, l.fediverse)
+		if identity == "" || seen[identity] {
+			continue
+		}
+		seen[identity] = true
+		tags = append(tags, nostr.Tag{"i", identity, l.url})
+	}
+	return tags
+}
+
+// fediverseUserPathRe matches Mastodon-style profile and actor paths:
+// /@alice and /users/alice.
+var fediverseUserPathRe = regexp.MustCompile(`^/(?:@|users/)([A-Za-z0-9_.-]+)/?$`)
+
+// identityNameRe restricts platform usernames to characters they allow.
+var identityNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// externalIdentity maps a profile URL to a NIP-39 "platform:identity" string,
+// or "" when it is not an account on a known platform. When fediverse is set
+// the URL is known to be an ActivityPub actor, and a Mastodon-style path on
+// any other host maps to the "mastodon:host/@user" form NIP-39 specifies.
+// Plain profile links are not mapped that way, since many non-Fediverse sites
+// (YouTube, Medium, TikTok, …) use /@user paths too.
+func externalIdentity(raw string, fediverse bool) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.TrimSuffix(u.Path, "/")
+	user := strings.TrimPrefix(path, "/")
+
+	switch host {
+	case "github.com":
+		if identityNameRe.MatchString(user) {
+			return "github:" + user
+		}
+	case "twitter.com", "x.com":
+		if identityNameRe.MatchString(user) {
+			return "twitter:" + user
+		}
+	case "t.me":
+		if identityNameRe.MatchString(user) {
+			return "telegram:" + user
+		}
+	default:
+		if m := fediverseUserPathRe.FindStringSubmatch(path); fediverse && m != nil {
+			return "mastodon:" + host + "/@" + m[1]
+		}
+	}
+	return ""
+}
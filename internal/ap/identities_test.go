@@ -0,0 +1,61 @@
+package ap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestExternalIdentity(t *testing.T) {
+	tests := []struct {
+		raw       string
+		fediverse bool
+		want      string
+	}{
+		{"https://github.com/alice", false, "github:alice"},
+		{"https://www.github.com/alice/", false, "github:alice"},
+		{"https://github.com/alice/repo", false, ""},
+		{"https://twitter.com/alice", false, "twitter:alice"},
+		{"https://x.com/alice", false, "twitter:alice"},
+		{"https://t.me/alice", false, "telegram:alice"},
+		{"https://t.me/alice/123", false, ""},
+		{"https://social.example/@alice", true, "mastodon:social.example/@alice"},
+		{"https://social.example/users/alice", true, "mastodon:social.example/@alice"},
+		{"https://social.example/@alice", false, ""},
+		{"https://www.youtube.com/@alice", false, ""},
+		{"https://medium.com/@alice", false, ""},
+		{"https://social.example/@alice/123", true, ""},
+		{"ftp://github.com/alice", false, ""},
+		{"not a url", false, ""},
+		{"https:///alice", false, ""},
+	}
+	for _, tt := range tests {
+		if got := externalIdentity(tt.raw, tt.fediverse); got != tt.want {
+			t.Errorf("externalIdentity(%q, %v) = %q, want %q", tt.raw, tt.fediverse, got, tt.want)
+		}
+	}
+}
+
+func TestIdentityTags(t *testing.T) {
+	actor := &Actor{
+		ID: "https://social.example/users/alice",
+		Attachment: []PropertyValue{
+			{Type: "PropertyValue", Name: "GitHub", Value: `<a href="https://github.com/alice" rel="me">github.com/alice</a>`},
+			{Type: "PropertyValue", Name: "Code", Value: "https://github.com/alice"},
+			{Type: "PropertyValue", Name: "YouTube", Value: `<a href="https://www.youtube.com/@alice">youtube</a>`},
+			{Type: "PropertyValue", Name: "Pronouns", Value: "she/her"},
+		},
+		AlsoKnownAs: []string{
+			"https://social.example/users/alice",
+			"https://old.example/users/alice",
+		},
+	}
+	want := nostr.Tags{
+		{"i", "github:alice", "https://github.com/alice"},
+		{"i", "mastodon:old.example/@alice", "https://old.example/users/alice"},
+	}
+	if got := identityTags(actor); !reflect.DeepEqual(got, want) {
+		t.Errorf("identityTags = %v, want %v", got, want)
+	}
+}
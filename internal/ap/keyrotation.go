@@ -0,0 +1,288 @@
+package ap
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// RSA key rotation is staged, so remote servers learn the new key before any
+// signature depends on it and can still verify the old one afterwards:
+//
+//  1. PrepareRotation generates the next key pair and advertises its public
+//     key in the actors' assertionMethod; #main-key keeps signing.
+//  2. PromoteNext makes the prepared key the #main-key. The old public key
+//     becomes a RetiredKey, still advertised in assertionMethod.
+//  3. A retired key drops out of assertionMethod once RetireGrace has passed.
+
+// rsaMulticodecPrefix is the multicodec header (rsa-pub, 0x1205 as a varint)
+// of a PKCS#1 RSA public key in a Multikey.
+var rsaMulticodecPrefix = []byte{0x85, 0x24}
+
+// nextKeySuffix is appended to the key file paths for the prepared key.
+const nextKeySuffix = ".next"
+
+var (
+	// ErrRotationPending is returned by PrepareRotation when a prepared key
+	// has not been promoted yet.
+	ErrRotationPending = errors.New("a rotation is already prepared")
+	// ErrNoPendingRotation is returned by PromoteNext when no key is prepared.
+	ErrNoPendingRotation = errors.New("no rotation prepared")
+)
+
+// RetiredKey is an RSA public key replaced by PromoteNext. It stays in the
+// actors' assertionMethod, under its own key ID, until RetireGrace has passed
+// since RetiredAt.
+type RetiredKey struct {
+	Fragment  string    `json:"fragment"` // key ID fragment, e.g. "rsa-key-1760000000"
+	PublicPEM string    `json:"public_pem"`
+	RetiredAt time.Time `json:"retired_at"`
+}
+
+// pendingKey is a key pair prepared by PrepareRotation, waiting for
+// PromoteNext.
+type pendingKey struct {
+	keys       *KeyPair
+	preparedAt time.Time
+}
+
+// fragment returns the key ID fragment the pending key is advertised under.
+func (p *pendingKey) fragment() string {
+	return fmt.Sprintf("rsa-key-%d", p.preparedAt.Unix())
+}
+
+// Signing returns the current RSA private key.
+func (kp *KeyPair) Signing() *rsa.PrivateKey {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return kp.Private
+}
+
+// CurrentPEM returns the PEM of the current RSA public key, the #main-key.
+func (kp *KeyPair) CurrentPEM() string {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return kp.PublicPEM
+}
+
+// Retired returns the retired RSA keys still within their grace period.
+func (kp *KeyPair) Retired() []RetiredKey {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return kp.activeRetired()
+}
+
+// SetRetired replaces the retired keys, as persisted by the caller after a
+// PromoteNext. Call once at startup, before any concurrent use.
+func (kp *KeyPair) SetRetired(keys []RetiredKey) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	kp.retired = keys
+}
+
+// activeRetired returns the retired keys whose grace period has not ended.
+// The caller must hold kp.mu.
+func (kp *KeyPair) activeRetired() []RetiredKey {
+	return slices.DeleteFunc(slices.Clone(kp.retired), func(k RetiredKey) bool {
+		return time.Since(k.RetiredAt) > kp.RetireGrace
+	})
+}
+
+// Pending reports when the prepared key, if any, was generated.
+func (kp *KeyPair) Pending() (preparedAt time.Time, ok bool) {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	if kp.next == nil {
+		return time.Time{}, false
+	}
+	return kp.next.preparedAt, true
+}
+
+// LoadPending loads a key pair prepared by an earlier PrepareRotation from
+// the ".next" files beside privatePath and publicPath. Missing files mean no
+// rotation is pending. Call once at startup, before any concurrent use.
+func (kp *KeyPair) LoadPending(privatePath, publicPath string) error {
+	privPEM, err := os.ReadFile(privatePath + nextKeySuffix)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read prepared private key: %w", err)
+	}
+	info, err := os.Stat(publicPath + nextKeySuffix)
+	if err != nil {
+		return fmt.Errorf("stat prepared public key: %w", err)
+	}
+	pubPEM, err := os.ReadFile(publicPath + nextKeySuffix)
+	if err != nil {
+		return fmt.Errorf("read prepared public key: %w", err)
+	}
+	next, err := parseKeyPair(privPEM, pubPEM)
+	if err != nil {
+		return fmt.Errorf("prepared key: %w", err)
+	}
+
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	kp.next = &pendingKey{keys: next, preparedAt: info.ModTime().UTC()}
+	return nil
+}
+
+// PrepareRotation generates the next RSA key pair, writes it to the ".next"
+// files beside privatePath and publicPath, and advertises it in
+// assertionMethod until PromoteNext. The #main-key is unchanged.
+func (kp *KeyPair) PrepareRotation(privatePath, publicPath string) error {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	if kp.next != nil {
+		return ErrRotationPending
+	}
+
+	privPEM, pubPEM, err := generateRSAPEM()
+	if err != nil {
+		return err
+	}
+	next, err := parseKeyPair(privPEM, pubPEM)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(privatePath+nextKeySuffix, privPEM, 0600); err != nil {
+		return fmt.Errorf("write prepared private key: %w", err)
+	}
+	if err := os.WriteFile(publicPath+nextKeySuffix, pubPEM, 0644); err != nil {
+		os.Remove(privatePath + nextKeySuffix)
+		return fmt.Errorf("write prepared public key: %w", err)
+	}
+
+	kp.next = &pendingKey{keys: next, preparedAt: time.Now().UTC()}
+	slog.Info("prepared RSA key rotation", "fragment", kp.next.fragment())
+	return nil
+}
+
+// PromoteNext makes the prepared key the #main-key: its ".next" files replace
+// privatePath and publicPath, and the old public key becomes a RetiredKey.
+// Returns the retired keys still within their grace period, for the caller to
+// persist.
+func (kp *KeyPair) PromoteNext(privatePath, publicPath string) ([]RetiredKey, error) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	if kp.next == nil {
+		return nil, ErrNoPendingRotation
+	}
+
+	if err := swapKeyFiles(privatePath, publicPath); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	kp.retired = append(kp.activeRetired(), RetiredKey{
+		Fragment:  fmt.Sprintf("rsa-key-%d", now.Unix()),
+		PublicPEM: kp.PublicPEM,
+		RetiredAt: now,
+	})
+	next := kp.next.keys
+	kp.Private, kp.Public, kp.PublicPEM = next.Private, next.Public, next.PublicPEM
+	kp.next = nil
+	slog.Info("promoted RSA key pair", "private", privatePath, "retired_keys", len(kp.retired))
+	return slices.Clone(kp.retired), nil
+}
+
+// swapKeyFiles moves the ".next" key files over privatePath and publicPath.
+// The current files are first moved aside to ".old" and only removed once
+// both new files are in place; any failure moves them back, so the files on
+// disk always hold a matching pair.
+func swapKeyFiles(privatePath, publicPath string) error {
+	paths := []string{privatePath, publicPath}
+	var moved []string // paths whose current file is at path+".old"
+	var replaced []string
+	rollback := func() {
+		for _, p := range replaced {
+			os.Rename(p, p+nextKeySuffix)
+		}
+		for _, p := range moved {
+			os.Rename(p+".old", p)
+		}
+	}
+	for _, p := range paths {
+		if err := os.Rename(p, p+".old"); err != nil {
+			rollback()
+			return fmt.Errorf("back up %s: %w", p, err)
+		}
+		moved = append(moved, p)
+	}
+	for _, p := range paths {
+		if err := os.Rename(p+nextKeySuffix, p); err != nil {
+			rollback()
+			return fmt.Errorf("replace %s: %w", p, err)
+		}
+		replaced = append(replaced, p)
+	}
+	for _, p := range paths {
+		os.Remove(p + ".old")
+	}
+	return nil
+}
+
+// ActorPublicKey returns the publicKey of actorURL: the current RSA key as
+// #main-key.
+func (kp *KeyPair) ActorPublicKey(actorURL string) *PublicKey {
+	return &PublicKey{
+		ID:           actorURL + "#main-key",
+		Owner:        actorURL,
+		PublicKeyPem: kp.CurrentPEM(),
+	}
+}
+
+// AssertionMethod returns the assertionMethod of actorURL: the Ed25519 key
+// when configured, the prepared RSA key while a rotation is pending, then the
+// retired RSA keys still within their grace period.
+func (kp *KeyPair) AssertionMethod(actorURL string) []Multikey {
+	keys := Ed25519AssertionMethod(actorURL, kp.Ed25519Multibase())
+
+	kp.mu.RLock()
+	rsaKeys := kp.activeRetired()
+	if kp.next != nil {
+		rsaKeys = append([]RetiredKey{{
+			Fragment:  kp.next.fragment(),
+			PublicPEM: kp.next.keys.PublicPEM,
+		}}, rsaKeys...)
+	}
+	kp.mu.RUnlock()
+
+	for _, k := range rsaKeys {
+		multibase, err := encodeRSAMultibase(k.PublicPEM)
+		if err != nil {
+			slog.Warn("skipping unreadable RSA key", "fragment", k.Fragment, "error", err)
+			continue
+		}
+		keys = append(keys, Multikey{
+			ID:                 actorURL + "#" + k.Fragment,
+			Type:               "Multikey",
+			Controller:         actorURL,
+			PublicKeyMultibase: multibase,
+		})
+	}
+	return keys
+}
+
+// encodeRSAMultibase encodes a PEM RSA public key as a base58btc multibase
+// Multikey value.
+func encodeRSAMultibase(publicPEM string) (string, error) {
+	pub, err := parsePublicKeyPEM(publicPEM)
+	if err != nil {
+		return "", err
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("not an RSA public key")
+	}
+	der := x509.MarshalPKCS1PublicKey(rsaPub)
+	return "z" + base58.Encode(append(append([]byte{}, rsaMulticodecPrefix...), der...)), nil
+}
@@ -0,0 +1,123 @@
+package ap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestKeyFiles(t *testing.T) (kp *KeyPair, privPath, pubPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	privPath, pubPath = filepath.Join(dir, "private.pem"), filepath.Join(dir, "public.pem")
+	kp, err := LoadOrGenerateKeyPair(privPath, pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.RetireGrace = time.Hour
+	return kp, privPath, pubPath
+}
+
+func TestKeyRotationStages(t *testing.T) {
+	kp, privPath, pubPath := newTestKeyFiles(t)
+	const actor = "https://example.com/users/alice"
+	oldPEM := kp.CurrentPEM()
+
+	if _, err := kp.PromoteNext(privPath, pubPath); !errors.Is(err, ErrNoPendingRotation) {
+		t.Fatalf("PromoteNext without prepare: err = %v, want ErrNoPendingRotation", err)
+	}
+
+	if err := kp.PrepareRotation(privPath, pubPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := kp.PrepareRotation(privPath, pubPath); !errors.Is(err, ErrRotationPending) {
+		t.Fatalf("second PrepareRotation: err = %v, want ErrRotationPending", err)
+	}
+	if kp.CurrentPEM() != oldPEM {
+		t.Fatal("PrepareRotation changed #main-key")
+	}
+	am := kp.AssertionMethod(actor)
+	if len(am) != 1 || !strings.HasPrefix(am[0].ID, actor+"#rsa-key-") {
+		t.Fatalf("assertionMethod after prepare = %+v, want the prepared key", am)
+	}
+
+	// A restart picks the prepared key up again.
+	reloaded, err := LoadOrGenerateKeyPair(privPath, pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.LoadPending(privPath, pubPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded.Pending(); !ok {
+		t.Fatal("LoadPending did not find the prepared key")
+	}
+
+	retired, err := kp.PromoteNext(privPath, pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kp.CurrentPEM() == oldPEM {
+		t.Fatal("PromoteNext kept the old #main-key")
+	}
+	if len(retired) != 1 || retired[0].PublicPEM != oldPEM {
+		t.Fatalf("retired = %+v, want the old key", retired)
+	}
+	if _, ok := kp.Pending(); ok {
+		t.Fatal("rotation still pending after PromoteNext")
+	}
+	for _, p := range []string{privPath, pubPath} {
+		if _, err := os.Stat(p + nextKeySuffix); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after PromoteNext", p+nextKeySuffix)
+		}
+	}
+
+	onDisk, err := LoadOrGenerateKeyPair(privPath, pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onDisk.CurrentPEM() != kp.CurrentPEM() || !onDisk.Private.PublicKey.Equal(onDisk.Public) {
+		t.Fatal("key files on disk do not hold the promoted pair")
+	}
+
+	am = kp.AssertionMethod(actor)
+	if len(am) != 1 || am[0].ID != actor+"#"+retired[0].Fragment {
+		t.Fatalf("assertionMethod after promote = %+v, want the retired key", am)
+	}
+	kp.RetireGrace = 0
+	if am := kp.AssertionMethod(actor); len(am) != 0 {
+		t.Fatalf("assertionMethod after the grace period = %+v, want none", am)
+	}
+}
+
+func TestPromoteNextRollsBack(t *testing.T) {
+	kp, privPath, pubPath := newTestKeyFiles(t)
+	oldPEM := kp.CurrentPEM()
+	if err := kp.PrepareRotation(privPath, pubPath); err != nil {
+		t.Fatal(err)
+	}
+	// The private key renames cleanly, the public one cannot.
+	if err := os.Remove(pubPath + nextKeySuffix); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := kp.PromoteNext(privPath, pubPath); err == nil {
+		t.Fatal("PromoteNext succeeded without the prepared public key")
+	}
+	if kp.CurrentPEM() != oldPEM {
+		t.Fatal("failed PromoteNext changed #main-key")
+	}
+	onDisk, err := LoadOrGenerateKeyPair(privPath, pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onDisk.CurrentPEM() != oldPEM || !onDisk.Private.PublicKey.Equal(onDisk.Public) {
+		t.Fatal("key files on disk no longer hold the original pair")
+	}
+	if _, err := os.Stat(privPath + nextKeySuffix); err != nil {
+		t.Errorf("prepared private key not restored: %v", err)
+	}
+}
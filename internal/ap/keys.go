@@ -1,6 +1,7 @@
 package ap
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -8,6 +9,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sync"
+	"time"
 )
 
 // KeyPair holds the RSA key pair used for ActivityPub HTTP signatures.
@@ -15,6 +18,19 @@ type KeyPair struct {
 	Private    *rsa.PrivateKey
 	Public     *rsa.PublicKey
 	PublicPEM  string
+	// Ed25519 is the optional second signing key (see LoadOrGenerateEd25519);
+	// nil when only RSA is configured.
+	Ed25519 ed25519.PrivateKey
+	// RetireGrace is how long an RSA key replaced by PromoteNext stays
+	// advertised in assertionMethod (see RetiredKey).
+	RetireGrace time.Duration
+
+	// mu guards the RSA key, next and retired against PrepareRotation and
+	// PromoteNext. Once the server is running, read the key through Signing
+	// and CurrentPEM.
+	mu      sync.RWMutex
+	next    *pendingKey
+	retired []RetiredKey
 }
 
 // LoadOrGenerateKeyPair loads an RSA key pair from PEM files, or generates
@@ -38,22 +54,11 @@ func LoadOrGenerateKeyPair(privatePath, publicPath string) (*KeyPair, error) {
 }
 
 func generateAndSaveKeyPair(privatePath, publicPath string) (*KeyPair, error) {
-	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	privPEM, pubPEM, err := generateRSAPEM()
 	if err != nil {
-		return nil, fmt.Errorf("generate RSA key: %w", err)
+		return nil, err
 	}
 
-	// Encode private key.
-	privBytes := x509.MarshalPKCS1PrivateKey(privKey)
-	privPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: privBytes})
-
-	// Encode public key.
-	pubBytes, err := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
-	if err != nil {
-		return nil, fmt.Errorf("marshal public key: %w", err)
-	}
-	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})
-
 	if err := os.WriteFile(privatePath, privPEM, 0600); err != nil {
 		return nil, fmt.Errorf("write private key: %w", err)
 	}
@@ -65,6 +70,27 @@ func generateAndSaveKeyPair(privatePath, publicPath string) (*KeyPair, error) {
 	return parseKeyPair(privPEM, pubPEM)
 }
 
+// generateRSAPEM generates a 2048-bit RSA key and returns its private
+// (PKCS#1) and public (PKIX) PEM encodings.
+func generateRSAPEM() (privPEM, pubPEM []byte, err error) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate RSA key: %w", err)
+	}
+
+	// Encode private key.
+	privBytes := x509.MarshalPKCS1PrivateKey(privKey)
+	privPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: privBytes})
+
+	// Encode public key.
+	pubBytes, err := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal public key: %w", err)
+	}
+	pubPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})
+	return privPEM, pubPEM, nil
+}
+
 func parseKeyPair(privPEM, pubPEM []byte) (*KeyPair, error) {
 	privBlock, _ := pem.Decode(privPEM)
 	if privBlock == nil {
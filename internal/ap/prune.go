@@ -0,0 +1,71 @@
+package ap
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// notFoundPruneThreshold is the number of consecutive 404 deliveries to
+	// a personal inbox after which its owner is treated as gone. A single 404
+	// can be a misconfigured proxy or a briefly broken server; a 410 is
+	// conclusive. 404s only count while the host is otherwise healthy (see
+	// hostHealthy).
+	notFoundPruneThreshold = 3
+	// notFoundPruneSpan is how long an inbox must keep answering 404 before
+	// its owner is pruned, so a burst of deliveries during one outage or
+	// botched migration cannot reach the threshold within minutes.
+	notFoundPruneSpan = 24 * time.Hour
+)
+
+// notFoundStreak tracks the consecutive 404 deliveries to one inbox.
+type notFoundStreak struct {
+	mu    sync.Mutex
+	count int
+	since time.Time // first 404 of the streak
+}
+
+// checkGone records the outcome of a delivery to inbox, the personal inbox of
+// actorID ("" for shared inboxes, which say nothing about any one follower),
+// and prunes actorID once the inbox reports the account gone.
+func (f *Federator) checkGone(inbox, actorID string, err error) {
+	if actorID == "" {
+		return
+	}
+	var de *DeliveryError
+	switch {
+	case err == nil:
+		f.notFound.Delete(inbox)
+	case errors.Is(err, ErrGone):
+		f.notFound.Delete(inbox)
+		f.pruneFollower(actorID, "inbox returned 410 Gone")
+	case errors.As(err, &de) && de.StatusCode == http.StatusNotFound:
+		if !f.hostHealthy(inbox) {
+			return
+		}
+		v, _ := f.notFound.LoadOrStore(inbox, &notFoundStreak{since: time.Now()})
+		streak := v.(*notFoundStreak)
+		streak.mu.Lock()
+		streak.count++
+		gone := streak.count >= notFoundPruneThreshold && time.Since(streak.since) >= notFoundPruneSpan
+		streak.mu.Unlock()
+		if gone {
+			f.notFound.Delete(inbox)
+			f.pruneFollower(actorID, "inbox returned 404 on consecutive deliveries")
+		}
+	}
+}
+
+// pruneFollower drops actorID from the follower lists via PruneFollower and
+// forgets its cached actor document.
+func (f *Federator) pruneFollower(actorID, reason string) {
+	if f.PruneFollower == nil {
+		return
+	}
+	slog.Info("pruning follower whose account is gone", "actor", actorID, "reason", reason)
+	InvalidateCache(actorID)
+	f.PruneFollower(actorID, reason)
+}
@@ -0,0 +1,96 @@
+package ap
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newPruneTestFederator returns a Federator whose host a.example has accepted
+// a delivery, and the list of actors it pruned.
+func newPruneTestFederator() (*Federator, *[]string) {
+	var pruned []string
+	f := &Federator{PruneFollower: func(actorID, reason string) {
+		pruned = append(pruned, actorID)
+	}}
+	f.hostCircuit("https://a.example/inbox").record(nil, f.hostThreshold())
+	return f, &pruned
+}
+
+func TestCheckGone410(t *testing.T) {
+	const inbox, actor = "https://a.example/users/bob/inbox", "https://a.example/users/bob"
+	f, pruned := newPruneTestFederator()
+
+	f.checkGone("https://a.example/inbox", "", fmt.Errorf("deliver: %w", ErrGone))
+	if len(*pruned) != 0 {
+		t.Fatalf("shared inbox 410 pruned %v", *pruned)
+	}
+	f.checkGone(inbox, actor, fmt.Errorf("deliver: %w", ErrGone))
+	if len(*pruned) != 1 || (*pruned)[0] != actor {
+		t.Fatalf("pruned = %v, want [%s]", *pruned, actor)
+	}
+}
+
+func TestCheckGone404(t *testing.T) {
+	const inbox, actor = "https://a.example/users/bob/inbox", "https://a.example/users/bob"
+	notFound := &DeliveryError{Inbox: inbox, StatusCode: http.StatusNotFound}
+	// backdate moves the start of inbox's 404 streak into the past.
+	backdate := func(f *Federator, d time.Duration) {
+		v, ok := f.notFound.Load(inbox)
+		if !ok {
+			t.Fatal("no 404 streak recorded")
+		}
+		v.(*notFoundStreak).since = time.Now().Add(-d)
+	}
+
+	t.Run("threshold within the span", func(t *testing.T) {
+		f, pruned := newPruneTestFederator()
+		for range notFoundPruneThreshold + 2 {
+			f.checkGone(inbox, actor, notFound)
+		}
+		if len(*pruned) != 0 {
+			t.Fatalf("pruned %v after a burst of 404s", *pruned)
+		}
+	})
+
+	t.Run("threshold over the span", func(t *testing.T) {
+		f, pruned := newPruneTestFederator()
+		f.checkGone(inbox, actor, notFound)
+		backdate(f, notFoundPruneSpan+time.Minute)
+		for range notFoundPruneThreshold - 2 {
+			f.checkGone(inbox, actor, notFound)
+		}
+		if len(*pruned) != 0 {
+			t.Fatalf("pruned %v below the threshold", *pruned)
+		}
+		f.checkGone(inbox, actor, notFound)
+		if len(*pruned) != 1 || (*pruned)[0] != actor {
+			t.Fatalf("pruned = %v, want [%s]", *pruned, actor)
+		}
+	})
+
+	t.Run("success resets the streak", func(t *testing.T) {
+		f, pruned := newPruneTestFederator()
+		for range notFoundPruneThreshold - 1 {
+			f.checkGone(inbox, actor, notFound)
+		}
+		backdate(f, notFoundPruneSpan+time.Minute)
+		f.checkGone(inbox, actor, nil)
+		f.checkGone(inbox, actor, notFound)
+		if len(*pruned) != 0 {
+			t.Fatalf("pruned %v after a successful delivery", *pruned)
+		}
+	})
+
+	t.Run("unhealthy host", func(t *testing.T) {
+		var pruned []string
+		f := &Federator{PruneFollower: func(actorID, reason string) { pruned = append(pruned, actorID) }}
+		for range notFoundPruneThreshold {
+			f.checkGone(inbox, actor, notFound)
+		}
+		if _, ok := f.notFound.Load(inbox); ok || len(pruned) != 0 {
+			t.Fatalf("404s from a host never seen working were counted (pruned %v)", pruned)
+		}
+	})
+}
@@ -2,19 +2,69 @@ package ap
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/nbd-wtf/go-nostr"
 )
 
+const (
+	defaultResyncConcurrency  = 4
+	defaultResyncFetchTimeout = 20 * time.Second
+	// maxResyncReportResults caps the failed/gone entries kept in a ResyncReport.
+	maxResyncReportResults = 500
+)
+
+// KVResyncReport is the kv key holding the JSON ResyncReport of the latest
+// resync, served by GET /web/api/resync-report.
+const KVResyncReport = "last_resync_report"
+
+// ResyncStatus is the outcome of resyncing one actor.
+type ResyncStatus string
+
+const (
+	ResyncFailed ResyncStatus = "failed" // fetch, sign or publish failed, including after the retry
+	ResyncGone   ResyncStatus = "gone"   // the actor answered 410 Gone; its key was removed
+)
+
+// ResyncResult is an actor that did not resync successfully.
+type ResyncResult struct {
+	Actor  string       `json:"actor"`
+	Status ResyncStatus `json:"status"`
+	Error  string       `json:"error"`
+}
+
+// ResyncReport is the outcome of one resync run. Results lists only the actors
+// that failed or were gone, capped at maxResyncReportResults; the counts
+// always cover every actor.
+type ResyncReport struct {
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Total      int            `json:"total"`
+	OK         int            `json:"ok"`
+	Failed     int            `json:"failed"`
+	Gone       int            `json:"gone"`
+	Results    []ResyncResult `json:"results"`
+}
+
+func (rep *ResyncReport) addResult(actorURL string, status ResyncStatus, err error) {
+	if len(rep.Results) < maxResyncReportResults {
+		rep.Results = append(rep.Results, ResyncResult{Actor: actorURL, Status: status, Error: err.Error()})
+	}
+}
+
 // AccountResyncStore is the DB interface used by AccountResyncer.
 type AccountResyncStore interface {
 	GetAllActorURLs() ([]string, error)
+	DeleteActorKey(apActorURL string) error
 	SetKV(key, value string) error
+	SetNIP05Name(name, actorURL string) error
 }
 
 // AccountResyncSigner can derive and use keys for AP actors.
@@ -39,6 +89,11 @@ type AccountResyncer struct {
 	Interval time.Duration
 	// TriggerCh, if non-nil, causes an immediate resync when sent to.
 	TriggerCh <-chan struct{}
+	// Concurrency is how many actors are fetched at once. Defaults to 4 if zero.
+	Concurrency int
+	// FetchTimeout bounds the fetch and publish of a single actor. Defaults
+	// to 20s if zero.
+	FetchTimeout time.Duration
 
 	// running is set to true while a resync is in progress.
 	// CompareAndSwap(false, true) at the top of resyncAll prevents a second
@@ -92,7 +147,10 @@ func (r *AccountResyncer) drainTrigger(ch <-chan struct{}) {
 	}
 }
 
-// resyncAll iterates all known AP actor URLs and re-publishes their kind-0.
+// resyncAll re-fetches all known AP actor URLs, Concurrency at a time, and
+// re-publishes their kind-0. Actors that fail are retried once after the first
+// pass; actors that answer 410 Gone are not retried and their key is removed.
+// The outcome is stored as a ResyncReport under KVResyncReport.
 func (r *AccountResyncer) resyncAll(ctx context.Context) {
 	// Guard against concurrent or back-to-back invocations (e.g. a ticker fire
 	// and a manual trigger arriving in quick succession). If a resync is already
@@ -117,44 +175,111 @@ func (r *AccountResyncer) resyncAll(ctx context.Context) {
 		}
 	}
 
+	report := ResyncReport{StartedAt: time.Now().UTC(), Total: len(apURLs)}
 	if len(apURLs) == 0 {
 		slog.Debug("resync: no AP actors to sync")
-		_ = r.Store.SetKV("last_resync_at", time.Now().UTC().Format(time.RFC3339))
-		_ = r.Store.SetKV("last_resync_count", "0/0")
+		r.saveReport(report)
 		return
 	}
 
 	slog.Info("resync: starting actor refresh", "count", len(apURLs))
 
-	ok, failed := 0, 0
+	results := r.resyncBatch(ctx, apURLs)
+	var retry []string
+	for actorURL, err := range results {
+		if err != nil && !errors.Is(err, ErrGone) {
+			retry = append(retry, actorURL)
+		}
+	}
+	if len(retry) > 0 && ctx.Err() == nil {
+		slog.Debug("resync: retrying failed actors", "count", len(retry))
+		for actorURL, err := range r.resyncBatch(ctx, retry) {
+			results[actorURL] = err
+		}
+	}
+	if ctx.Err() != nil {
+		slog.Info("resync: interrupted", "done", len(results), "total", len(apURLs))
+		return
+	}
+
 	for _, actorURL := range apURLs {
-		select {
-		case <-ctx.Done():
-			slog.Info("resync: interrupted", "ok", ok, "failed", failed)
-			return
+		err := results[actorURL]
+		switch {
+		case err == nil:
+			report.OK++
+		case errors.Is(err, ErrGone):
+			report.Gone++
+			if delErr := r.Store.DeleteActorKey(actorURL); delErr != nil {
+				slog.Warn("resync: failed to remove key of gone actor", "actor", actorURL, "error", delErr)
+			}
+			report.addResult(actorURL, ResyncGone, err)
 		default:
+			report.Failed++
+			report.addResult(actorURL, ResyncFailed, err)
 		}
+	}
 
-		if err := r.resyncOne(ctx, actorURL); err != nil {
-			slog.Debug("resync: actor fetch failed", "actor", actorURL, "error", err)
-			failed++
-		} else {
-			ok++
-		}
+	slog.Info("resync: complete", "ok", report.OK, "failed", report.Failed, "gone", report.Gone, "total", report.Total)
+	r.saveReport(report)
+}
+
+// resyncBatch runs resyncOne for every actor URL with at most Concurrency
+// in flight, each bounded by FetchTimeout. Returns each URL's error (nil on
+// success); URLs not reached before ctx is cancelled are left out.
+func (r *AccountResyncer) resyncBatch(ctx context.Context, actorURLs []string) map[string]error {
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultResyncConcurrency
+	}
+	timeout := r.FetchTimeout
+	if timeout <= 0 {
+		timeout = defaultResyncFetchTimeout
+	}
 
-		// Small pause between fetches to avoid hammering remote servers.
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(actorURLs))
+		sem     = make(chan struct{}, concurrency)
+	)
+	for _, actorURL := range actorURLs {
 		select {
 		case <-ctx.Done():
-			return
-		case <-time.After(300 * time.Millisecond):
+			wg.Wait()
+			return results
+		case sem <- struct{}{}:
 		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			actorCtx, cancel := context.WithTimeout(ctx, timeout)
+			err := r.resyncOne(actorCtx, actorURL)
+			cancel()
+			if err != nil {
+				slog.Debug("resync: actor fetch failed", "actor", actorURL, "error", err)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			mu.Lock()
+			results[actorURL] = err
+			mu.Unlock()
+		}()
 	}
+	wg.Wait()
+	return results
+}
 
-	total := ok + failed
-	slog.Info("resync: complete", "ok", ok, "failed", failed, "total", total)
-
-	_ = r.Store.SetKV("last_resync_at", time.Now().UTC().Format(time.RFC3339))
-	_ = r.Store.SetKV("last_resync_count", fmt.Sprintf("%d/%d", ok, total))
+// saveReport stores report as the latest resync outcome, along with the
+// last_resync_at / last_resync_count summary shown on the dashboard.
+func (r *AccountResyncer) saveReport(report ResyncReport) {
+	report.FinishedAt = time.Now().UTC()
+	if data, err := json.Marshal(report); err == nil {
+		_ = r.Store.SetKV(KVResyncReport, string(data))
+	}
+	_ = r.Store.SetKV("last_resync_at", report.FinishedAt.Format(time.RFC3339))
+	_ = r.Store.SetKV("last_resync_count", fmt.Sprintf("%d/%d", report.OK, report.Total))
 }
 
 // resyncOne re-fetches a single AP actor and publishes an updated kind-0.
@@ -169,12 +294,13 @@ func (r *AccountResyncer) resyncOne(ctx context.Context, actorURL string) error
 		Kind:      0,
 		Content:   meta,
 		CreatedAt: nostr.Now(),
-		Tags:      nostr.Tags{{"proxy", actorURL, "activitypub"}},
+		Tags:      append(nostr.Tags{{"proxy", actorURL, "activitypub"}}, identityTags(actor)...),
 	}
 
 	if err := r.Signer.Sign(event, actorURL); err != nil {
 		return fmt.Errorf("sign: %w", err)
 	}
+	recordNIP05Name(r.Store, actor)
 
 	return r.Publisher.Publish(ctx, event)
 }
@@ -0,0 +1,152 @@
+package ap
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// QueuedDelivery is a failed delivery awaiting retry.
+type QueuedDelivery struct {
+	Inbox      string
+	ActivityID string
+	Activity   string // JSON-encoded activity
+	Attempts   int
+}
+
+// DeliveryQueue persists failed deliveries so they survive restarts.
+type DeliveryQueue interface {
+	EnqueueDelivery(inbox, activityID, activity string, attempts int, nextRetry time.Time, lastError string) error
+	GetDueDeliveries(now time.Time, limit int) ([]QueuedDelivery, error)
+	RescheduleDelivery(inbox, activityID string, attempts int, nextRetry time.Time, lastError string) error
+	DeleteDelivery(inbox, activityID string) error
+}
+
+// Retry schedule: the n-th retry waits deliveryRetryBase·2^(n-1), capped at
+// deliveryRetryMaxDelay. With 8 attempts a delivery is given up on after
+// roughly 10 hours — long enough to ride out a typical instance outage.
+const (
+	deliveryRetryBase        = time.Minute
+	deliveryRetryMaxDelay    = 4 * time.Hour
+	deliveryMaxAttempts      = 8
+	deliveryRetryInterval    = 30 * time.Second
+	deliveryRetryBatchSize   = 50
+	deliveryRetryHTTPTimeout = 30 * time.Second
+)
+
+// deliveryBackoff returns the delay before the next retry after attempts
+// failed deliveries.
+func deliveryBackoff(attempts int) time.Duration {
+	d := deliveryRetryBase
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= deliveryRetryMaxDelay {
+			return deliveryRetryMaxDelay
+		}
+	}
+	return d
+}
+
+// enqueueRetry records a failed first delivery in the retry queue.
+// No-op when the Federator has no queue or the activity has no ID.
+func (f *Federator) enqueueRetry(inbox string, activity map[string]interface{}, deliveryErr error) {
+	if f.Queue == nil {
+		return
+	}
+	id, _ := activity["id"].(string)
+	if id == "" {
+		return
+	}
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return
+	}
+	next := time.Now().Add(deliveryBackoff(1))
+	if err := f.Queue.EnqueueDelivery(inbox, id, string(body), 1, next, deliveryErr.Error()); err != nil {
+		slog.Warn("failed to enqueue delivery retry", "inbox", inbox, "id", id, "error", err)
+		return
+	}
+	slog.Debug("delivery queued for retry", "inbox", inbox, "id", id, "next", next)
+}
+
+// RunRetryQueue periodically re-attempts queued deliveries whose retry time
+// has passed. Blocks until ctx is cancelled. No-op when Queue is nil.
+func (f *Federator) RunRetryQueue(ctx context.Context) {
+	if f.Queue == nil {
+		return
+	}
+	ticker := time.NewTicker(deliveryRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.retryDue(ctx)
+		}
+	}
+}
+
+// retryDue processes one batch of due deliveries.
+func (f *Federator) retryDue(ctx context.Context) {
+	due, err := f.Queue.GetDueDeliveries(time.Now(), deliveryRetryBatchSize)
+	if err != nil {
+		slog.Warn("failed to load delivery queue", "error", err)
+		return
+	}
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		f.retryOne(ctx, d)
+	}
+}
+
+func (f *Federator) retryOne(ctx context.Context, d QueuedDelivery) {
+	var activity map[string]interface{}
+	if err := json.Unmarshal([]byte(d.Activity), &activity); err != nil {
+		slog.Warn("dropping undecodable queued delivery", "inbox", d.Inbox, "id", d.ActivityID, "error", err)
+		_ = f.Queue.DeleteDelivery(d.Inbox, d.ActivityID)
+		return
+	}
+	// A tripped destination is not retried until its cooldown ends; that
+	// doesn't count as an attempt.
+	if f.HostCircuitOpen(d.Inbox) {
+		next := f.hostCircuit(d.Inbox).reopensAt(f.hostCooldown())
+		if err := f.Queue.RescheduleDelivery(d.Inbox, d.ActivityID, d.Attempts, next, errHostCircuitOpen.Error()); err != nil {
+			slog.Warn("failed to reschedule delivery", "inbox", d.Inbox, "id", d.ActivityID, "error", err)
+		}
+		return
+	}
+	if err := f.hostLimiter(d.Inbox).Wait(ctx); err != nil {
+		return
+	}
+
+	// Retries go to hosts that were slow or down before, so they get the
+	// longer of the two timeouts.
+	timeout := f.deliveryTimeout()
+	if timeout < deliveryRetryHTTPTimeout {
+		timeout = deliveryRetryHTTPTimeout
+	}
+	err := f.deliver(ctx, d.Inbox, activity, timeout)
+
+	switch {
+	case err == nil:
+		slog.Info("queued delivery succeeded", "inbox", d.Inbox, "id", d.ActivityID, "attempts", d.Attempts+1)
+		_ = f.Queue.DeleteDelivery(d.Inbox, d.ActivityID)
+	case !IsRetryableDelivery(err):
+		slog.Warn("queued delivery failed permanently", "inbox", d.Inbox, "id", d.ActivityID, "error", err)
+		_ = f.Queue.DeleteDelivery(d.Inbox, d.ActivityID)
+	case d.Attempts+1 >= deliveryMaxAttempts:
+		slog.Warn("giving up on queued delivery", "inbox", d.Inbox, "id", d.ActivityID, "attempts", d.Attempts+1, "error", err)
+		_ = f.Queue.DeleteDelivery(d.Inbox, d.ActivityID)
+	default:
+		attempts := d.Attempts + 1
+		next := time.Now().Add(deliveryBackoff(attempts))
+		if qerr := f.Queue.RescheduleDelivery(d.Inbox, d.ActivityID, attempts, next, err.Error()); qerr != nil {
+			slog.Warn("failed to reschedule delivery", "inbox", d.Inbox, "id", d.ActivityID, "error", qerr)
+		}
+		slog.Debug("queued delivery failed, rescheduled", "inbox", d.Inbox, "id", d.ActivityID, "attempts", attempts, "next", next)
+	}
+}
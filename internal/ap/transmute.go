@@ -7,7 +7,9 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/klppl/klistr/internal/bridge"
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip19"
 )
@@ -15,9 +17,38 @@ import (
 // TransmuteContext provides dependencies for Nostr→AP conversion.
 type TransmuteContext struct {
 	LocalDomain      string
-	LocalActorURL    string // full URL of the local AP actor, e.g. "https://domain.com/users/alice"
-	PublicKeyPem     string
+	LocalActorURL    string   // full URL of the local AP actor, e.g. "https://domain.com/users/alice"
+	Keys             *KeyPair // RSA #main-key, plus the Ed25519, prepared and retired keys advertised in assertionMethod
 	GetAPIDForObject func(nostrID string) (string, bool)
+	// GetActorForKey maps a p-tagged pubkey to the remote AP actor it was
+	// derived for (actor_keys), so mentions address that actor. Optional.
+	GetActorForKey func(pubkey string) (string, bool)
+
+	// MaxNoteLength is the kind-1 content length (in characters) above which
+	// LongNoteMode applies. 0 disables the check.
+	MaxNoteLength int
+	// LongNoteMode selects how over-length notes are bridged:
+	// "article" (default) sends an Article with the full content and a
+	// generated title; "truncate" cuts the Note and links to the full post.
+	LongNoteMode string
+
+	// BridgeLocation emits NIP-52 style location/g tags as an AP Place.
+	BridgeLocation bool
+
+	// Discoverable and Indexable set the Mastodon discovery flags on the
+	// local actor (see Actor).
+	Discoverable bool
+	Indexable    bool
+
+	// UnlistedTag and FollowersOnlyTag are marker hashtags (without '#') that
+	// narrow a note's Fediverse visibility to unlisted or followers-only
+	// (see noteVisibility). Empty disables the marker.
+	UnlistedTag      string
+	FollowersOnlyTag string
+
+	// ExternalBaseURL is the Nostr web viewer that links to Nostr entities
+	// point at (EXTERNAL_BASE_URL). Empty means https://njump.me.
+	ExternalBaseURL string
 }
 
 // baseURL constructs an absolute URL from a path.
@@ -25,6 +56,16 @@ func (tc *TransmuteContext) baseURL(path string) string {
 	return strings.TrimRight(tc.LocalDomain, "/") + path
 }
 
+// externalURL returns the link to a NIP-19 entity (or event ID) on the
+// configured Nostr web viewer.
+func (tc *TransmuteContext) externalURL(entity string) string {
+	base := strings.TrimRight(tc.ExternalBaseURL, "/")
+	if base == "" {
+		base = "https://njump.me"
+	}
+	return base + "/" + entity
+}
+
 // actorURL returns the AP URL for the local actor.
 // In the single-user design, all Nostr events in the Nostr→AP direction
 // originate from the configured local user.
@@ -32,6 +73,17 @@ func (tc *TransmuteContext) actorURL(_ string) string {
 	return tc.LocalActorURL
 }
 
+// mentionURL returns the AP actor URL for a p-tagged pubkey: the remote actor
+// it belongs to when known, otherwise the local actor.
+func (tc *TransmuteContext) mentionURL(pubkey string) string {
+	if tc.GetActorForKey != nil {
+		if apURL, ok := tc.GetActorForKey(pubkey); ok && IsActorID(apURL) {
+			return apURL
+		}
+	}
+	return tc.actorURL(pubkey)
+}
+
 // objectURL returns the AP URL for a Nostr event ID.
 func (tc *TransmuteContext) objectURL(eventID string) string {
 	if apID, ok := tc.GetAPIDForObject(eventID); ok {
@@ -41,10 +93,10 @@ func (tc *TransmuteContext) objectURL(eventID string) string {
 }
 
 var (
-	tagRefRe   = regexp.MustCompile(`\n{0,2}#\[\d+\]$`)
+	tagRefRe   = bridge.TrailingTagRefRe
 	trailingRe = regexp.MustCompile(`\s+$`)
 	urlRe      = regexp.MustCompile(`https?://[^\s<>"{}|\\^` + "`" + `\[\]]+`)
-	mentionRe  = regexp.MustCompile(`nostr:(npub|nprofile|note|nevent|naddr)[a-z0-9]+`)
+	mentionRe  = bridge.NostrURIRe
 
 	// Markdown inline patterns used by markdownToHTML.
 	mdBoldRe   = regexp.MustCompile(`\*\*(.+?)\*\*`)
@@ -82,15 +134,15 @@ func ToActor(event *nostr.Event, tc *TransmuteContext) *Actor {
 		Outbox:            actorURL + "/outbox",
 		Followers:         actorURL + "/followers",
 		Following:         actorURL + "/following",
-		PublicKey: &PublicKey{
-			ID:           actorURL + "#main-key",
-			Owner:        actorURL,
-			PublicKeyPem: tc.PublicKeyPem,
-		},
+		Featured:          actorURL + "/collections/featured",
+		PublicKey:         tc.Keys.ActorPublicKey(actorURL),
+		AssertionMethod:   tc.Keys.AssertionMethod(actorURL),
 		Endpoints: &Endpoints{
 			SharedInbox: tc.baseURL("/inbox"),
 		},
-		ProxyOf: []Proxy{toNpubProxy(event)},
+		ProxyOf:      []Proxy{toNpubProxy(event)},
+		Discoverable: &tc.Discoverable,
+		Indexable:    &tc.Indexable,
 	}
 
 	if meta.Picture != "" {
@@ -127,7 +179,24 @@ func ToActor(event *nostr.Event, tc *TransmuteContext) *Actor {
 
 // ToNote converts a Nostr kind-1 text note to an AP Note.
 func ToNote(event *nostr.Event, tc *TransmuteContext) *Note {
-	content := renderContent(event.Content, event.Tags, tc)
+	visibility, marker := noteVisibility(event, tc)
+	text := event.Content
+	if marker != "" {
+		text = stripMarker(text, marker)
+	}
+	overLength := tc.MaxNoteLength > 0 && utf8.RuneCountInString(text) > tc.MaxNoteLength
+
+	var content string
+	if overLength && tc.LongNoteMode == "truncate" {
+		// Cut at a word boundary and link to the full post on Nostr.
+		link := tc.externalURL(event.ID)
+		if nevent, err := nip19.EncodeEvent(event.ID, nil, event.PubKey); err == nil {
+			link = tc.externalURL(nevent)
+		}
+		content = renderContent(bridge.TruncateAtWord(text, tc.MaxNoteLength)+"…\n\n"+link, event.Tags, tc)
+	} else {
+		content = renderContent(text, event.Tags, tc)
+	}
 
 	note := &Note{
 		ID:           tc.objectURL(event.ID),
@@ -144,6 +213,12 @@ func ToNote(event *nostr.Event, tc *TransmuteContext) *Note {
 		},
 		ProxyOf: []Proxy{toNoteProxy(event)},
 	}
+	switch visibility {
+	case visibilityUnlisted:
+		note.To, note.CC = note.CC, note.To
+	case visibilityFollowers:
+		note.To, note.CC = note.CC, []string{}
+	}
 
 	// inReplyTo: find root or reply 'e' tag.
 	if replyTag := findReplyTag(event); replyTag != "" {
@@ -161,11 +236,11 @@ func ToNote(event *nostr.Event, tc *TransmuteContext) *Note {
 		case len(tag) >= 2 && tag[0] == "p":
 			note.Tag = append(note.Tag, Mention{
 				Type: "Mention",
-				Href: tc.actorURL(tag[1]),
+				Href: tc.mentionURL(tag[1]),
 				Name: "@" + tag[1][:8],
 			})
-			note.To = append(note.To, tc.actorURL(tag[1]))
-		case len(tag) >= 2 && tag[0] == "t":
+			note.To = append(note.To, tc.mentionURL(tag[1]))
+		case len(tag) >= 2 && tag[0] == "t" && (marker == "" || !strings.EqualFold(tag[1], marker)):
 			note.Tag = append(note.Tag, Hashtag{
 				Type: "Hashtag",
 				Href: tc.baseURL("/tags/" + tag[1]),
@@ -191,6 +266,10 @@ func ToNote(event *nostr.Event, tc *TransmuteContext) *Note {
 		}
 	}
 
+	if tc.BridgeLocation {
+		note.Location = eventPlace(event)
+	}
+
 	// Media attachments from imeta tags.
 	for _, tag := range event.Tags {
 		if tag[0] == "imeta" {
@@ -201,9 +280,59 @@ func ToNote(event *nostr.Event, tc *TransmuteContext) *Note {
 		}
 	}
 
+	// Over-length notes in article mode are sent as long-form Articles so
+	// Fediverse servers with short note limits don't cut the content.
+	if overLength && tc.LongNoteMode != "truncate" {
+		note.Type = "Article"
+		note.Name = longNoteTitle(event.Content)
+		note.URL = note.ID
+	}
+
 	return note
 }
 
+// eventPlace builds an AP Place from an event's location and g (geohash) tags.
+// The geohash is decoded to the centre of its cell. Returns nil when the
+// event carries neither.
+func eventPlace(event *nostr.Event) *Place {
+	var place Place
+	var geohash string
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "location":
+			if place.Name == "" {
+				place.Name = strings.TrimSpace(tag[1])
+			}
+		case "g":
+			// Events may carry several g tags at decreasing precision;
+			// keep the most precise.
+			if lat, lon, ok := bridge.DecodeGeohash(tag[1]); ok && len(tag[1]) > len(geohash) {
+				place.Latitude, place.Longitude = lat, lon
+				geohash = tag[1]
+			}
+		}
+	}
+	if place.Name == "" && geohash == "" {
+		return nil
+	}
+	place.Type = "Place"
+	return &place
+}
+
+// longNoteTitle derives an Article title from the first line of a note,
+// stripped of markdown heading markers and capped at 80 characters.
+func longNoteTitle(content string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	line = strings.TrimSpace(strings.TrimLeft(line, "# "))
+	if utf8.RuneCountInString(line) > 80 {
+		line = bridge.TruncateAtWord(line, 80) + "…"
+	}
+	return line
+}
+
 // ToAnnounce converts a kind-1 quote post or kind-6 repost to an AP Announce.
 // Returns nil if no quote/repost target is found.
 func ToAnnounce(event *nostr.Event, tc *TransmuteContext) *Activity {
@@ -244,13 +373,15 @@ func ToLike(event *nostr.Event, tc *TransmuteContext) *Activity {
 	// Add p-tag targets.
 	for _, tag := range event.Tags {
 		if len(tag) >= 2 && tag[0] == "p" {
-			act.To = append(act.To, tc.actorURL(tag[1]))
+			act.To = append(act.To, tc.mentionURL(tag[1]))
 		}
 	}
 	return act
 }
 
 // ToEmojiReact converts a kind-7 emoji reaction to an AP EmojiReact.
+// NIP-30 custom emoji reactions (content ":shortcode:" with a matching emoji
+// tag) carry an Emoji tag so receiving servers can render the image.
 func ToEmojiReact(event *nostr.Event, tc *TransmuteContext) map[string]interface{} {
 	reactedID := findLastEventTag(event)
 	if reactedID == "" {
@@ -268,21 +399,47 @@ func ToEmojiReact(event *nostr.Event, tc *TransmuteContext) map[string]interface
 		"proxyOf": []Proxy{toNoteProxy(event)},
 	}
 
+	shortcode := customEmojiShortcode(event.Content)
 	for _, tag := range event.Tags {
-		if len(tag) >= 2 && tag[0] == "p" {
+		switch {
+		case len(tag) >= 2 && tag[0] == "p":
 			if to, ok := obj["to"].([]string); ok {
-				obj["to"] = append(to, tc.actorURL(tag[1]))
+				obj["to"] = append(to, tc.mentionURL(tag[1]))
+			}
+		case shortcode != "" && len(tag) >= 3 && tag[0] == "emoji" && tag[1] == shortcode:
+			if _, done := obj["tag"]; !done {
+				obj["tag"] = []interface{}{Emoji{
+					Type: "Emoji",
+					Name: ":" + shortcode + ":",
+					Icon: &Image{Type: "Image", URL: tag[2]},
+				}}
 			}
 		}
 	}
 	return obj
 }
 
+// customEmojiShortcode returns the shortcode of a NIP-30 ":shortcode:"
+// reaction, or "" when content is anything else (e.g. a unicode emoji).
+func customEmojiShortcode(content string) string {
+	if len(content) < 3 || content[0] != ':' || content[len(content)-1] != ':' {
+		return ""
+	}
+	code := content[1 : len(content)-1]
+	for _, r := range code {
+		if !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return ""
+		}
+	}
+	return code
+}
+
 // ToZap converts a kind-9735 zap receipt to an AP Zap activity.
 // The Zap type is present in DefaultContext via the mostr.pub namespace.
 // AP servers that do not recognise the type will silently discard the activity.
 func ToZap(event *nostr.Event, tc *TransmuteContext) map[string]interface{} {
-	// Zap receipts target a specific note via 'e' tag; skip profile-only zaps.
+	// Zap receipts target a specific note via 'e' tag; profile zaps go
+	// through ToProfileZap.
 	var reactedID string
 	for _, tag := range event.Tags {
 		if len(tag) >= 2 && tag[0] == "e" {
@@ -293,28 +450,48 @@ func ToZap(event *nostr.Event, tc *TransmuteContext) map[string]interface{} {
 	if reactedID == "" {
 		return nil
 	}
+	return zapActivity(event, tc, tc.objectURL(reactedID))
+}
 
-	// Parse the embedded zap request (kind-9734) from the description tag to
-	// extract the sats amount and optional comment left by the zap sender.
-	var amountMsats int64
-	var comment string
-	for _, tag := range event.Tags {
-		if len(tag) >= 2 && tag[0] == "description" {
-			var zapReq struct {
-				Content string     `json:"content"`
-				Tags    [][]string `json:"tags"`
-			}
-			if err := json.Unmarshal([]byte(tag[1]), &zapReq); err == nil {
-				comment = zapReq.Content
-				for _, t := range zapReq.Tags {
-					if len(t) >= 2 && t[0] == "amount" {
-						fmt.Sscanf(t[1], "%d", &amountMsats)
-					}
-				}
-			}
-			break
+// ToProfileZap converts a kind-9735 receipt for a zap of the local user's
+// profile (no 'e' tag) to an AP Zap activity whose object is the local actor.
+func ToProfileZap(event *nostr.Event, tc *TransmuteContext) map[string]interface{} {
+	return zapActivity(event, tc, tc.LocalActorURL)
+}
+
+// ToProfileZapNote converts a kind-9735 receipt for a zap of the local user's
+// profile to a public Note ("⚡ Zapped 21 sats by npub1…: comment"), for
+// servers that ignore Zap activities.
+func ToProfileZapNote(event *nostr.Event, tc *TransmuteContext) *Note {
+	sender, amountMsats, comment := parseZapReceipt(event)
+	text := "⚡ Zapped"
+	if amountMsats > 0 {
+		text += fmt.Sprintf(" %d sats", amountMsats/1000)
+	}
+	if sender != "" {
+		if npub, err := nip19.EncodePublicKey(sender); err == nil {
+			text += " by " + npub
 		}
 	}
+	if comment != "" {
+		text += ": " + comment
+	}
+	return &Note{
+		ID:           tc.objectURL(event.ID),
+		Type:         "Note",
+		AttributedTo: tc.LocalActorURL,
+		Content:      "<p>" + html.EscapeString(text) + "</p>",
+		Published:    NostrDate(event.CreatedAt),
+		To:           []string{PublicURI},
+		CC:           []string{tc.LocalActorURL + "/followers"},
+		ProxyOf:      []Proxy{toNoteProxy(event)},
+	}
+}
+
+// zapActivity builds the Zap activity for a receipt, with the sats amount and
+// the zap sender's comment as its content.
+func zapActivity(event *nostr.Event, tc *TransmuteContext, object string) map[string]interface{} {
+	_, amountMsats, comment := parseZapReceipt(event)
 
 	// Build human-readable content (sats + optional comment).
 	content := comment
@@ -332,7 +509,7 @@ func ToZap(event *nostr.Event, tc *TransmuteContext) map[string]interface{} {
 		"id":       tc.objectURL(event.ID),
 		"type":     "Zap",
 		"actor":    tc.LocalActorURL,
-		"object":   tc.objectURL(reactedID),
+		"object":   object,
 		"to":       []string{PublicURI},
 		"cc":       []string{tc.LocalActorURL + "/followers"},
 		"proxyOf":  []Proxy{toNoteProxy(event)},
@@ -343,6 +520,32 @@ func ToZap(event *nostr.Event, tc *TransmuteContext) map[string]interface{} {
 	return act
 }
 
+// parseZapReceipt returns the zap sender and the sender's comment from the
+// zap request (kind-9734) embedded in a receipt's description tag, and the
+// amount in millisats from the paid bolt11 invoice — the request's amount
+// tag is only what the sender asked for.
+func parseZapReceipt(event *nostr.Event) (sender string, amountMsats int64, comment string) {
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "description":
+			var zapReq struct {
+				PubKey  string `json:"pubkey"`
+				Content string `json:"content"`
+			}
+			if err := json.Unmarshal([]byte(tag[1]), &zapReq); err == nil {
+				sender = zapReq.PubKey
+				comment = zapReq.Content
+			}
+		case "bolt11":
+			amountMsats, _ = bridge.Bolt11AmountMsats(tag[1])
+		}
+	}
+	return sender, amountMsats, comment
+}
+
 // ToQuestion converts a Nostr kind-1068 poll event (NIP-69) to an AP Question object.
 // Returns nil if the event has no poll options.
 func ToQuestion(event *nostr.Event, tc *TransmuteContext) *Note {
@@ -471,6 +674,58 @@ func ToArticle(event *nostr.Event, tc *TransmuteContext) *Note {
 	return note
 }
 
+// ToFileNote converts a Nostr kind-1063 file metadata event (NIP-94) to an AP
+// Note carrying the file as a media attachment, with the event content (the
+// file description) as its text. Returns nil if the event has no url tag.
+func ToFileNote(event *nostr.Event, tc *TransmuteContext) *Note {
+	// NIP-94 uses the imeta keys, but as separate tags.
+	var entries []string
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "url", "m", "dim", "blurhash", "alt", "fallback":
+			entries = append(entries, tag[0]+" "+tag[1])
+		}
+	}
+	att := parseImeta(entries)
+	if att == nil {
+		return nil
+	}
+
+	note := &Note{
+		ID:           tc.objectURL(event.ID),
+		Type:         "Note",
+		AttributedTo: tc.actorURL(event.PubKey),
+		Content:      renderContent(event.Content, event.Tags, tc),
+		Published:    NostrDate(event.CreatedAt),
+		To:           []string{PublicURI},
+		CC:           []string{tc.actorURL(event.PubKey) + "/followers"},
+		Attachment:   []Attachment{*att},
+		Generator: &Generator{
+			Type: "Application",
+			Name: "klistr",
+			URL:  "https://github.com/klppl/klistr",
+		},
+		ProxyOf: []Proxy{toNoteProxy(event)},
+	}
+	for _, tag := range event.Tags {
+		switch {
+		case len(tag) >= 2 && tag[0] == "t":
+			note.Tag = append(note.Tag, Hashtag{
+				Type: "Hashtag",
+				Href: tc.baseURL("/tags/" + tag[1]),
+				Name: "#" + tag[1],
+			})
+		case len(tag) >= 2 && tag[0] == "content-warning":
+			note.Sensitive = true
+			note.Summary = tag[1]
+		}
+	}
+	return note
+}
+
 // markdownToHTML converts a Markdown string to HTML. It handles the most common
 // constructs: fenced code blocks, headings (h1–h3), paragraphs, and inline
 // elements (bold, italic, inline code, links). Edge cases like nested emphasis
@@ -695,16 +950,10 @@ func renderContent(content string, tags nostr.Tags, tc *TransmuteContext) string
 	content = tagRefRe.ReplaceAllString(content, "")
 	content = trailingRe.ReplaceAllString(content, "")
 
-	// Replace inline nostr: URIs with links to njump.me so AP readers can
-	// follow them. This covers all NIP-19 types: npub, nprofile, note, nevent, naddr.
-	content = mentionRe.ReplaceAllStringFunc(content, func(s string) string {
-		bech32 := s[6:] // strip "nostr:" prefix
-		label := bech32
-		if len(label) > 16 {
-			label = label[:16] + "…"
-		}
-		return fmt.Sprintf(`<a href="https://njump.me/%s">%s</a>`, bech32, label)
-	})
+	// Replace inline nostr: URIs with URLs on the Nostr web viewer, which
+	// linkifyText turns into links AP readers can follow. This covers all
+	// NIP-19 types: npub, nprofile, note, nevent, naddr.
+	content = bridge.ReplaceNostrURIs(content, tc.externalURL)
 
 	// Escape and linkify.
 	return linkifyText(content)
@@ -0,0 +1,97 @@
+package ap
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// TestPlaceRoundTrip bridges a Note's location to Nostr and back: the Place
+// name and coordinates survive the location and g (geohash) tags.
+func TestPlaceRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		want     *Place
+	}{
+		{"name and coordinates", `{"type":"Place","name":"Stockholm","latitude":59.3293,"longitude":18.0686}`,
+			&Place{Type: "Place", Name: "Stockholm", Latitude: 59.3293, Longitude: 18.0686}},
+		{"coordinates as strings", `{"type":"Place","latitude":"-33.8688","longitude":"151.2093"}`,
+			&Place{Type: "Place", Latitude: -33.8688, Longitude: 151.2093}},
+		{"name only", `{"type":"Place","name":"Somewhere"}`,
+			&Place{Type: "Place", Name: "Somewhere"}},
+		{"first usable of an array", `[{"type":"Place"},{"type":"Place","name":"Reykjavík","latitude":64.1466,"longitude":-21.9426}]`,
+			&Place{Type: "Place", Name: "Reykjavík", Latitude: 64.1466, Longitude: -21.9426}},
+		{"out of range coordinates", `{"type":"Place","name":"Nowhere","latitude":91,"longitude":0}`,
+			&Place{Type: "Place", Name: "Nowhere"}},
+		{"empty", `{"type":"Place"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m map[string]interface{}
+			raw := `{"id":"https://a.example/notes/1","type":"Note","attributedTo":"https://a.example/users/bob","content":"<p>here</p>","location":` + tt.location + `}`
+			if err := json.Unmarshal([]byte(raw), &m); err != nil {
+				t.Fatal(err)
+			}
+			h := newTestAPHandler()
+			h.BridgeLocation = true
+			event, err := h.noteToEvent(context.Background(), mapToNote(m))
+			if err != nil || event == nil {
+				t.Fatalf("noteToEvent = %v, %v", event, err)
+			}
+
+			tc := &TransmuteContext{
+				LocalDomain:      "https://bridge.example",
+				LocalActorURL:    "https://bridge.example/users/alice",
+				GetAPIDForObject: func(string) (string, bool) { return "", false },
+				BridgeLocation:   true,
+			}
+			got := ToNote(event, tc).Location
+			switch {
+			case tt.want == nil && got == nil:
+			case tt.want == nil || got == nil:
+				t.Fatalf("Location = %+v, want %+v (tags %v)", got, tt.want, event.Tags)
+			case got.Type != tt.want.Type || got.Name != tt.want.Name ||
+				math.Abs(got.Latitude-tt.want.Latitude) > 1e-4 ||
+				math.Abs(got.Longitude-tt.want.Longitude) > 1e-4:
+				t.Errorf("Location = %+v, want %+v (tags %v)", got, tt.want, event.Tags)
+			}
+		})
+	}
+}
+
+func TestToNoteExternalLinks(t *testing.T) {
+	const npub = "npub1sg6plzptd64u62a878hep2kev88swjh3tw00gjsfl8f237lmu63q0uf63m"
+	event := &nostr.Event{
+		ID:      strings.Repeat("ab", 32),
+		PubKey:  strings.Repeat("cd", 32),
+		Kind:    1,
+		Content: "hello nostr:" + npub + " " + strings.Repeat("word ", 30),
+	}
+	for _, tt := range []struct {
+		base, want string
+	}{
+		{"", "https://njump.me/"},
+		{"https://viewer.example/", "https://viewer.example/"},
+	} {
+		tc := &TransmuteContext{
+			LocalDomain:      "https://bridge.example",
+			LocalActorURL:    "https://bridge.example/users/alice",
+			GetAPIDForObject: func(string) (string, bool) { return "", false },
+			MaxNoteLength:    120,
+			LongNoteMode:     "truncate",
+			ExternalBaseURL:  tt.base,
+		}
+		content := ToNote(event, tc).Content
+		if !strings.Contains(content, `<a href="`+tt.want+npub+`"`) {
+			t.Errorf("base %q: nostr: URI not linked to %s: %s", tt.base, tt.want, content)
+		}
+		if !strings.Contains(content, tt.want+"nevent1") {
+			t.Errorf("base %q: truncated note does not link to %snevent1…: %s", tt.base, tt.want, content)
+		}
+	}
+}
@@ -36,19 +36,26 @@ var DefaultContext = []interface{}{
 	ActivityStreamsNS,
 	SecurityNS,
 	map[string]interface{}{
-		"Hashtag":       "as:Hashtag",
-		"sensitive":     "as:sensitive",
-		"schema":        "http://schema.org#",
-		"PropertyValue": "schema:PropertyValue",
-		"value":         "schema:value",
-		"EmojiReact":    "http://joinmastodon.org/ns#EmojiReact",
-		"Emoji":         "http://joinmastodon.org/ns#Emoji",
-		"Zap":           "https://mostr.pub/ns#Zap",
-		"proxyOf":       "https://mostr.pub/ns#proxyOf",
-		"proxied":       "https://mostr.pub/ns#proxied",
-		"protocol":      "https://mostr.pub/ns#protocol",
-		"authoritative": "https://mostr.pub/ns#authoritative",
-		"quoteUrl":      "as:quoteUrl",
+		"Hashtag":            "as:Hashtag",
+		"sensitive":          "as:sensitive",
+		"schema":             "http://schema.org#",
+		"PropertyValue":      "schema:PropertyValue",
+		"value":              "schema:value",
+		"EmojiReact":         "http://joinmastodon.org/ns#EmojiReact",
+		"Emoji":              "http://joinmastodon.org/ns#Emoji",
+		"Zap":                "https://mostr.pub/ns#Zap",
+		"proxyOf":            "https://mostr.pub/ns#proxyOf",
+		"proxied":            "https://mostr.pub/ns#proxied",
+		"protocol":           "https://mostr.pub/ns#protocol",
+		"authoritative":      "https://mostr.pub/ns#authoritative",
+		"quoteUrl":           "as:quoteUrl",
+		"discoverable":       "http://joinmastodon.org/ns#discoverable",
+		"indexable":          "http://joinmastodon.org/ns#indexable",
+		"featured":           map[string]interface{}{"@id": "http://joinmastodon.org/ns#featured", "@type": "@id"},
+		"Multikey":           "https://w3id.org/security#Multikey",
+		"publicKeyMultibase": "https://w3id.org/security#publicKeyMultibase",
+		"controller":         map[string]interface{}{"@id": "https://w3id.org/security#controller", "@type": "@id"},
+		"assertionMethod":    map[string]interface{}{"@id": "https://w3id.org/security#assertionMethod", "@type": "@id", "@container": "@set"},
 	},
 }
 
@@ -64,14 +71,22 @@ type Actor struct {
 	Outbox            string          `json:"outbox,omitempty"`
 	Followers         string          `json:"followers,omitempty"`
 	Following         string          `json:"following,omitempty"`
+	Featured          string          `json:"featured,omitempty"` // pinned posts collection
 	PublicKey         *PublicKey      `json:"publicKey,omitempty"`
+	AssertionMethod   []Multikey      `json:"assertionMethod,omitempty"`
 	Icon              *Image          `json:"icon,omitempty"`
 	Image             *Image          `json:"image,omitempty"`
 	Attachment        []PropertyValue `json:"attachment,omitempty"`
+	AlsoKnownAs       []string        `json:"alsoKnownAs,omitempty"` // account aliases (migration)
 	Tag               []interface{}   `json:"tag,omitempty"`
 	URL               string          `json:"url,omitempty"`
 	Endpoints         *Endpoints      `json:"endpoints,omitempty"`
 	ProxyOf           []Proxy         `json:"proxyOf,omitempty"`
+	// Mastodon discovery flags: discoverable lists the actor in profile
+	// directories and suggestions; indexable opts its posts into full-text
+	// search.
+	Discoverable *bool `json:"discoverable,omitempty"`
+	Indexable    *bool `json:"indexable,omitempty"`
 }
 
 // PublicKey represents an RSA public key attached to an actor.
@@ -81,6 +96,15 @@ type PublicKey struct {
 	PublicKeyPem string `json:"publicKeyPem"`
 }
 
+// Multikey is a FEP-521a public key listed in an actor's assertionMethod.
+// klistr publishes and verifies Ed25519 keys (multibase "z6Mk…").
+type Multikey struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller"`
+	PublicKeyMultibase string `json:"publicKeyMultibase"`
+}
+
 // Image represents an ActivityPub Image object.
 type Image struct {
 	Type string `json:"type"`
@@ -118,6 +142,7 @@ type Note struct {
 	Sensitive    bool          `json:"sensitive,omitempty"`
 	Summary      string        `json:"summary,omitempty"`
 	Generator    *Generator    `json:"generator,omitempty"`
+	Location     *Place        `json:"location,omitempty"`
 	ProxyOf      []Proxy       `json:"proxyOf,omitempty"`
 	// Poll fields (type=Question only).
 	OneOf       []QuestionOption `json:"oneOf,omitempty"`
@@ -125,6 +150,13 @@ type Note struct {
 	EndTime     string           `json:"endTime,omitempty"`
 	Closed      string           `json:"closed,omitempty"`
 	VotersCount int              `json:"votersCount,omitempty"`
+
+	// partialThread marks a reply bridged without its ancestors — the
+	// topmost ancestor bridged by ensureAncestorsBridged when the walk
+	// stopped at its depth cap or a reply loop, or the target of an
+	// interaction (withBridgedTarget): noteToEvent bridges it without thread
+	// context instead of dropping it as an unresolvable reply.
+	partialThread bool
 }
 
 // QuestionOption represents a single poll choice in an AP Question object.
@@ -151,6 +183,22 @@ type Attachment struct {
 	Height    int    `json:"height,omitempty"`
 }
 
+// Place is an ActivityPub Place, used as the location of a Note.
+// Coordinates of (0, 0) are treated as absent.
+type Place struct {
+	Type      string  `json:"type"`
+	Name      string  `json:"name,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+}
+
+// HasCoordinates reports whether the place carries a usable position.
+func (p *Place) HasCoordinates() bool {
+	return (p.Latitude != 0 || p.Longitude != 0) &&
+		p.Latitude >= -90 && p.Latitude <= 90 &&
+		p.Longitude >= -180 && p.Longitude <= 180
+}
+
 // Mention is a tag pointing to another actor.
 type Mention struct {
 	Type string `json:"type"`
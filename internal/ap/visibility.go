@@ -0,0 +1,53 @@
+package ap
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/bridge"
+)
+
+// Note visibilities selectable with a marker hashtag (see noteVisibility).
+const (
+	visibilityPublic    = "public"
+	visibilityUnlisted  = "unlisted"
+	visibilityFollowers = "followers"
+)
+
+// noteVisibility returns the Fediverse visibility an outbound note asks for,
+// and the marker hashtag that selected it. Nostr has no visibility of its
+// own, so a note carrying tc.FollowersOnlyTag or tc.UnlistedTag — as a "t"
+// tag or a #hashtag in the content, case-insensitively — is narrowed
+// accordingly. Followers-only wins when both are present. Everything else is
+// public.
+func noteVisibility(event *nostr.Event, tc *TransmuteContext) (visibility, marker string) {
+	for _, c := range []struct{ tag, visibility string }{
+		{tc.FollowersOnlyTag, visibilityFollowers},
+		{tc.UnlistedTag, visibilityUnlisted},
+	} {
+		tag := normalizeMarker(c.tag)
+		if tag != "" && bridge.HasHashtag(event, tag) {
+			return c.visibility, tag
+		}
+	}
+	return visibilityPublic, ""
+}
+
+// normalizeMarker lowercases a configured marker hashtag and drops its '#'.
+func normalizeMarker(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// stripMarker removes the marker hashtag from content, so followers do not
+// see the bridge's addressing convention.
+func stripMarker(content, tag string) string {
+	return bridge.StripHashtag(content, tag)
+}
+
+// IsFollowersOnly reports whether note is addressed to neither the public
+// collection in to nor cc. Such notes are left out of the public outbox.
+func IsFollowersOnly(note *Note) bool {
+	return !slices.Contains(note.To, PublicURI) && !slices.Contains(note.CC, PublicURI)
+}
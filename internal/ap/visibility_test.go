@@ -0,0 +1,60 @@
+package ap
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestNoteVisibility(t *testing.T) {
+	tc := &TransmuteContext{UnlistedTag: "quiet", FollowersOnlyTag: "#Private"}
+	tests := []struct {
+		name       string
+		content    string
+		tags       nostr.Tags
+		visibility string
+		marker     string
+	}{
+		{"no marker", "hello world", nil, visibilityPublic, ""},
+		{"followers-only hashtag", "hello #private", nil, visibilityFollowers, "private"},
+		{"case-insensitive", "#PRIVATE hello", nil, visibilityFollowers, "private"},
+		{"t tag", "hello", nostr.Tags{{"t", "Private"}}, visibilityFollowers, "private"},
+		{"unlisted", "hello #quiet", nil, visibilityUnlisted, "quiet"},
+		{"followers-only wins", "#quiet hello #private", nil, visibilityFollowers, "private"},
+		{"longer hashtag", "hello #privateparty", nil, visibilityPublic, ""},
+		{"inside a word", "hello foo#private", nil, visibilityPublic, ""},
+		{"inside a URL", "https://example.com/#private", nil, visibilityPublic, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := &nostr.Event{Kind: 1, Content: tt.content, Tags: tt.tags}
+			visibility, marker := noteVisibility(ev, tc)
+			if visibility != tt.visibility || marker != tt.marker {
+				t.Errorf("noteVisibility = %q, %q, want %q, %q", visibility, marker, tt.visibility, tt.marker)
+			}
+		})
+	}
+
+	if v, _ := noteVisibility(&nostr.Event{Content: "#private"}, &TransmuteContext{}); v != visibilityPublic {
+		t.Errorf("markers disabled: visibility = %q, want public", v)
+	}
+}
+
+func TestStripMarker(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"hello #private", "hello"},
+		{"#Private hello", "hello"},
+		{"hello #private world", "hello world"},
+		{"line one\n#private\nline two", "line one\nline two"},
+		{"hello #privateparty", "hello #privateparty"},
+		{"see https://example.com/#private", "see https://example.com/#private"},
+	}
+	for _, tt := range tests {
+		if got := stripMarker(tt.content, "private"); got != tt.want {
+			t.Errorf("stripMarker(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package bridge
+
+import (
+	"strconv"
+	"strings"
+)
+
+// bolt11Multipliers maps a BOLT-11 amount multiplier to millisatoshis per
+// unit, times ten so the pico multiplier stays integral.
+var bolt11Multipliers = map[byte]int64{
+	'm': 1e8 * 10,
+	'u': 1e5 * 10,
+	'n': 1e2 * 10,
+	'p': 1,
+}
+
+// bolt11MinDataLen is the length of the shortest BOLT-11 data part: a 7-char
+// timestamp, a 104-char signature and a 6-char checksum. A shorter tail after
+// the last '1' means the '1' belongs to the amount of a truncated invoice.
+const bolt11MinDataLen = 7 + 104 + 6
+
+// Bolt11AmountMsats returns the amount of a BOLT-11 invoice in millisatoshis,
+// read from its human-readable part (e.g. lnbc210n1… is 21 sats). ok is false
+// for invoices without an amount or that cannot be parsed. The invoice's
+// signature is not checked.
+func Bolt11AmountMsats(invoice string) (msats int64, ok bool) {
+	invoice = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(invoice)), "lightning:")
+	sep := strings.LastIndexByte(invoice, '1')
+	if !strings.HasPrefix(invoice, "ln") || sep < 0 || len(invoice)-sep-1 < bolt11MinDataLen {
+		return 0, false
+	}
+	hrp := invoice[2:sep]
+	// Skip the currency prefix (bc, tb, tbs, bcrt, …).
+	i := 0
+	for i < len(hrp) && hrp[i] >= 'a' && hrp[i] <= 'z' {
+		i++
+	}
+	amount := hrp[i:]
+	if amount == "" {
+		return 0, false
+	}
+	perUnit := int64(1e11 * 10) // whole bitcoin
+	if m, isMultiplier := bolt11Multipliers[amount[len(amount)-1]]; isMultiplier {
+		perUnit = m
+		amount = amount[:len(amount)-1]
+	}
+	n, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil || n <= 0 || n > (1<<63-1)/perUnit {
+		return 0, false
+	}
+	tenths := n * perUnit
+	if tenths%10 != 0 {
+		return 0, false // sub-millisatoshi pico amount
+	}
+	return tenths / 10, true
+}
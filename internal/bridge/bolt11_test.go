@@ -0,0 +1,47 @@
+package bridge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBolt11AmountMsats(t *testing.T) {
+	// Only the length of the data part after the last '1' is checked, so a
+	// stand-in of the minimum length keeps the cases readable.
+	data := "1" + strings.Repeat("q", bolt11MinDataLen)
+	tests := []struct {
+		name    string
+		invoice string
+		want    int64
+		ok      bool
+	}{
+		{"BOLT-11 example", "lnbc2500u1pvjluezpp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqdq5xysxxatsyp3k7enxv4jsxqzpuaztrnwngzn3kdzw5hydlzf03qdgm2hdq27cqv3agm2awhz5se903vruatfhq77w3ls4evs3ch9zw97j25emudupq63nyw24cg27h2rspfj9srp", 250_000_000, true},
+		{"milli", "lnbc2m" + data, 200_000_000, true},
+		{"micro", "lnbc2500u" + data, 250_000_000, true},
+		{"nano", "lnbc210n" + data, 21_000, true},
+		{"pico", "lnbc10p" + data, 1, true},
+		{"pico sub-millisatoshi", "lnbc15p" + data, 0, false},
+		{"whole bitcoin", "lnbc2" + data, 200_000_000_000, true},
+		{"no amount", "lnbc" + data, 0, false},
+		{"regtest", "lnbcrt500u" + data, 50_000_000, true},
+		{"testnet", "lntb20m" + data, 2_000_000_000, true},
+		{"signet", "lntbs1u" + data, 100_000, true},
+		{"upper case with scheme", "LIGHTNING:LNBC210N" + data, 21_000, true},
+		{"surrounding whitespace", "  lnbc210n" + data + "\n", 21_000, true},
+		{"overflow", "lnbc99999999999m" + data, 0, false},
+		{"overflow whole bitcoin", "lnbc9999999999" + data, 0, false},
+		{"zero", "lnbc0n" + data, 0, false},
+		{"not an invoice", "lnurl1dp68gurn8ghj7", 0, false},
+		{"truncated", "lnbc210n", 0, false},
+		{"short data part", "lnbc210n1" + strings.Repeat("q", bolt11MinDataLen-1), 0, false},
+		{"not lightning", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Bolt11AmountMsats(tt.invoice)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Bolt11AmountMsats(%q) = %d, %v, want %d, %v", tt.invoice, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
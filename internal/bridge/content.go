@@ -0,0 +1,84 @@
+package bridge
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// NostrURIRe matches inline NIP-21 nostr: URIs for all NIP-19 entity types.
+	NostrURIRe = regexp.MustCompile(`nostr:(npub|nprofile|note|nevent|naddr)1[a-z0-9]+`)
+
+	// TrailingTagRefRe matches a legacy NIP-08 #[n] reference at the end of the
+	// content, together with the blank lines before it.
+	TrailingTagRefRe = regexp.MustCompile(`\n{0,2}#\[\d+\]$`)
+
+	tagRefRe = regexp.MustCompile(`#\[\d+\]`)
+
+	// lightningRe matches LNURLs and BOLT-11 invoices, optionally prefixed by
+	// the lightning: URI scheme. Both are bech32 and may be upper-case.
+	lightningRe = regexp.MustCompile(`(?i)(lightning:)?\b(lnurl1|lnbc|lntb|lntbs|lnbcrt)[0-9a-z]{20,}`)
+
+	spaceRunRe = regexp.MustCompile(`[ \t]{2,}`)
+	blankRunRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// ReplaceNostrURIs calls fn for every inline nostr: URI in content, passing the
+// bech32 entity without the "nostr:" prefix, and substitutes the result.
+func ReplaceNostrURIs(content string, fn func(bech32 string) string) string {
+	return NostrURIRe.ReplaceAllStringFunc(content, func(s string) string {
+		return fn(strings.TrimPrefix(s, "nostr:"))
+	})
+}
+
+// CleanForBluesky rewrites Nostr-specific syntax in a note so it reads
+// naturally as plain text on Bluesky:
+//   - nostr: URIs become links under linkBase (e.g. https://njump.me/npub1…),
+//     which Bluesky's link facets make clickable
+//   - legacy #[n] tag references are removed
+//   - LNURLs and lightning invoices are removed
+//
+// #[n] references are dropped rather than resolved because the tagged pubkey
+// has no Bluesky identity to point at.
+func CleanForBluesky(content, linkBase string) string {
+	if content == "" {
+		return ""
+	}
+	base := strings.TrimRight(linkBase, "/")
+	if base == "" {
+		base = "https://njump.me"
+	}
+
+	content = tagRefRe.ReplaceAllString(content, "")
+	content = lightningRe.ReplaceAllString(content, "")
+	content = ReplaceNostrURIs(content, func(bech32 string) string {
+		return base + "/" + bech32
+	})
+
+	// Tidy the gaps left by removed tokens.
+	content = spaceRunRe.ReplaceAllString(content, " ")
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	content = blankRunRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(content)
+}
+
+// TruncateAtWord shortens s to at most n characters, backing up to the last
+// whitespace so words are not split. Trailing whitespace is trimmed.
+func TruncateAtWord(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	cut := n
+	for i := n; i > n/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace)
+}
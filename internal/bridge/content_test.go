@@ -0,0 +1,31 @@
+package bridge
+
+import "testing"
+
+func TestCleanForBluesky(t *testing.T) {
+	const npub = "npub180cvv07tjdrrgpa0j7j7tmnyl2yr6yr7l8j4s3evf6u64th6gkwsyjh6w6"
+	const note = "note1fntxtkcy9pjwucqwa9mddn7v03wwwsu9j330jj350nvhpky2tuaspk6nqc"
+	tests := []struct {
+		name     string
+		content  string
+		linkBase string
+		want     string
+	}{
+		{"empty", "", "", ""},
+		{"plain text unchanged", "gm from nostr", "", "gm from nostr"},
+		{"nostr URI to default base", "hello nostr:" + npub + "!", "", "hello https://njump.me/" + npub + "!"},
+		{"nostr URI to custom base", "see nostr:" + note, "https://example.com/", "see https://example.com/" + note},
+		{"tag reference removed", "thanks #[0] for this", "", "thanks for this"},
+		{"trailing tag reference removed", "quoting this\n\n#[1]", "", "quoting this"},
+		{"invoice removed", "zap me lnbc10u1pjqxyzzpp5qqqsyqcyq5rqwzqfqypqsyqcyq5rqwz please", "", "zap me please"},
+		{"lightning URI removed", "tips: lightning:LNURL1DP68GURN8GHJ7MRWW4EXCTNXD9SHG6NPVCHXXMMD9AKXUATJDSKHQCTE8AEK2UMND9HKU0", "", "tips:"},
+		{"blank runs collapsed", "one\n\n\n\n#[0]\n\ntwo", "", "one\n\ntwo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanForBluesky(tt.content, tt.linkBase); got != tt.want {
+				t.Errorf("CleanForBluesky(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package bridge
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+)
+
+// Content filter actions.
+const (
+	FilterSkip = "skip" // the post is not bridged
+	FilterWarn = "cw"   // the post is bridged behind a content warning
+)
+
+const (
+	// MaxFilterPatternLength caps the length of a filter pattern.
+	MaxFilterPatternLength = 256
+	// maxFilterProgramSize caps the compiled size of a regex pattern. Go's
+	// RE2 engine runs in linear time, so there is no catastrophic
+	// backtracking, but large counted repetitions (e.g. (a|b){900}) still
+	// blow up the program every post is matched against.
+	maxFilterProgramSize = 2000
+)
+
+// ContentFilterRule is one keyword or regular expression filter on the text
+// of inbound posts.
+type ContentFilterRule struct {
+	Pattern string `json:"pattern"`
+	// Regex marks Pattern as a regular expression; otherwise it is a keyword,
+	// matched case-insensitively anywhere in the text.
+	Regex bool `json:"regex"`
+	// Action is FilterSkip or FilterWarn.
+	Action string `json:"action"`
+}
+
+// Compile validates the rule and returns its matcher.
+func (r ContentFilterRule) Compile() (*regexp.Regexp, error) {
+	if r.Pattern == "" {
+		return nil, fmt.Errorf("pattern required")
+	}
+	if len(r.Pattern) > MaxFilterPatternLength {
+		return nil, fmt.Errorf("pattern longer than %d characters", MaxFilterPatternLength)
+	}
+	if r.Action != FilterSkip && r.Action != FilterWarn {
+		return nil, fmt.Errorf("action must be %q or %q", FilterSkip, FilterWarn)
+	}
+	if !r.Regex {
+		return regexp.MustCompile("(?i)" + regexp.QuoteMeta(r.Pattern)), nil
+	}
+	parsed, err := syntax.Parse(r.Pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	if len(prog.Inst) > maxFilterProgramSize {
+		return nil, fmt.Errorf("regex too complex")
+	}
+	return regexp.Compile(r.Pattern)
+}
+
+// ContentFilter holds the compiled content filter rules. Like
+// AuthorExclusions it is shared between the server, which loads the rules and
+// updates them from the admin API, and the AP handler and Bluesky poller,
+// which check each post against them. A nil *ContentFilter matches nothing.
+type ContentFilter struct {
+	mu    sync.RWMutex
+	rules []compiledFilter
+}
+
+type compiledFilter struct {
+	rule ContentFilterRule
+	re   *regexp.Regexp
+}
+
+// Set replaces the rules. Rules that fail to compile are left out and
+// returned as an error; the others still apply.
+func (f *ContentFilter) Set(rules []ContentFilterRule) error {
+	compiled := make([]compiledFilter, 0, len(rules))
+	var bad []string
+	for _, r := range rules {
+		re, err := r.Compile()
+		if err != nil {
+			bad = append(bad, fmt.Sprintf("%q: %v", r.Pattern, err))
+			continue
+		}
+		compiled = append(compiled, compiledFilter{rule: r, re: re})
+	}
+	f.mu.Lock()
+	f.rules = compiled
+	f.mu.Unlock()
+	if len(bad) > 0 {
+		return fmt.Errorf("invalid content filters: %s", strings.Join(bad, "; "))
+	}
+	return nil
+}
+
+// Check matches text against the rules and returns the action to take and
+// the pattern that decided it, or "" when nothing matches. A skip rule wins
+// over a content-warning rule.
+func (f *ContentFilter) Check(text string) (action, pattern string) {
+	if f == nil || text == "" {
+		return "", ""
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, c := range f.rules {
+		if !c.re.MatchString(text) {
+			continue
+		}
+		if c.rule.Action == FilterSkip {
+			return FilterSkip, c.rule.Pattern
+		}
+		if action == "" {
+			action, pattern = c.rule.Action, c.rule.Pattern
+		}
+	}
+	return action, pattern
+}
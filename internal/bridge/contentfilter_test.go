@@ -0,0 +1,76 @@
+package bridge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContentFilterRuleCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    ContentFilterRule
+		wantErr string // "" for a valid rule
+	}{
+		{"keyword", ContentFilterRule{Pattern: "crypto", Action: FilterSkip}, ""},
+		{"keyword with regex syntax", ContentFilterRule{Pattern: "a.b(c", Action: FilterWarn}, ""},
+		{"regex", ContentFilterRule{Pattern: `\bnft\b`, Regex: true, Action: FilterSkip}, ""},
+		{"empty pattern", ContentFilterRule{Action: FilterSkip}, "pattern required"},
+		{"bad action", ContentFilterRule{Pattern: "x", Action: "drop"}, "action must be"},
+		{"too long", ContentFilterRule{Pattern: strings.Repeat("a", MaxFilterPatternLength+1), Action: FilterSkip}, "longer than"},
+		{"invalid regex", ContentFilterRule{Pattern: "a(b", Regex: true, Action: FilterSkip}, "invalid regex"},
+		{"program too large", ContentFilterRule{Pattern: "(a|b){900}", Regex: true, Action: FilterSkip}, "too complex"},
+		{"large but within cap", ContentFilterRule{Pattern: "a{1000}", Regex: true, Action: FilterSkip}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := tt.rule.Compile()
+			if tt.wantErr == "" {
+				if err != nil || re == nil {
+					t.Fatalf("Compile = %v, %v, want a matcher", re, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestContentFilterCheck(t *testing.T) {
+	var f ContentFilter
+	err := f.Set([]ContentFilterRule{
+		{Pattern: "Spoiler", Action: FilterWarn},
+		{Pattern: `\bnft\b`, Regex: true, Action: FilterSkip},
+		{Pattern: "a(b", Regex: true, Action: FilterSkip}, // invalid, left out
+	})
+	if err == nil {
+		t.Error("Set accepted an invalid rule")
+	}
+
+	tests := []struct {
+		text        string
+		wantAction  string
+		wantPattern string
+	}{
+		{"nothing to see", "", ""},
+		{"major SPOILER ahead", FilterWarn, "Spoiler"},
+		{"mint an NFT today", "", ""}, // regexes are case-sensitive unless (?i)
+		{"mint an nft today", FilterSkip, `\bnft\b`},
+		{"spoiler: nft drop", FilterSkip, `\bnft\b`}, // skip beats cw
+		{"nfts", "", ""},
+		{"a(b", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		action, pattern := f.Check(tt.text)
+		if action != tt.wantAction || pattern != tt.wantPattern {
+			t.Errorf("Check(%q) = %q, %q, want %q, %q", tt.text, action, pattern, tt.wantAction, tt.wantPattern)
+		}
+	}
+
+	var nilFilter *ContentFilter
+	if action, _ := nilFilter.Check("nft"); action != "" {
+		t.Errorf("nil filter matched: %q", action)
+	}
+}
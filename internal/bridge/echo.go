@@ -0,0 +1,141 @@
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Echo suppression: the bridge remembers a hash of every note the local user
+// sends out, for a short window. Inbound content whose normalised hash is
+// already known is treated as an echo — e.g. the user's own Nostr note coming
+// back via a third-party Fediverse mirror — and is not bridged again. This
+// catches loops that proxy-tag and derived-key checks cannot see. Inbound
+// posts are never recorded, so two people writing the same text do not
+// suppress each other.
+
+// echoMinLength is the minimum normalised length (in runes) for content to
+// take part in echo detection. Short posts ("gm", "+1") are too likely to be
+// written independently by different people.
+const echoMinLength = 20
+
+// echoMaxEntries bounds the hash set regardless of TTL.
+const echoMaxEntries = 10000
+
+// echoTTL is a var (not const) so it can be overridden at startup via
+// SetEchoTTL. Zero disables echo suppression entirely.
+var echoTTL = 10 * time.Minute
+
+var (
+	echoMu     sync.Mutex
+	echoHashes = make(map[string]time.Time) // content hash → expiry
+	echoCount  atomic.Int64
+)
+
+// SetEchoTTL overrides how long bridged content hashes are remembered.
+// A zero or negative duration disables echo suppression. Call once at
+// startup, before any concurrent use.
+func SetEchoTTL(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	echoTTL = d
+}
+
+func init() {
+	// Background sweeper: evicts expired hashes so the set stays small.
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			echoMu.Lock()
+			sweepEchoes(time.Now())
+			echoMu.Unlock()
+		}
+	}()
+}
+
+// sweepEchoes removes expired hashes. Caller must hold echoMu.
+func sweepEchoes(now time.Time) {
+	for h, exp := range echoHashes {
+		if now.After(exp) {
+			delete(echoHashes, h)
+		}
+	}
+}
+
+// RecordContent remembers content the local user has just published, so a
+// copy coming back in is recognised by IsEcho.
+func RecordContent(content string) {
+	h, ok := contentHash(content)
+	if !ok || echoTTL <= 0 {
+		return
+	}
+	now := time.Now()
+	echoMu.Lock()
+	defer echoMu.Unlock()
+	if _, exists := echoHashes[h]; !exists && len(echoHashes) >= echoMaxEntries {
+		sweepEchoes(now)
+		// Still full: drop an arbitrary entry rather than grow unbounded.
+		for old := range echoHashes {
+			if len(echoHashes) < echoMaxEntries {
+				break
+			}
+			delete(echoHashes, old)
+		}
+	}
+	echoHashes[h] = now.Add(echoTTL)
+}
+
+// IsEcho reports whether content matches something bridged within the echo
+// TTL. Each positive result increments the SuppressedEchoes counter, so call
+// it only when the content will be dropped on a match.
+func IsEcho(content string) bool {
+	h, ok := contentHash(content)
+	if !ok || echoTTL <= 0 {
+		return false
+	}
+	echoMu.Lock()
+	exp, found := echoHashes[h]
+	echoMu.Unlock()
+	if !found || time.Now().After(exp) {
+		return false
+	}
+	echoCount.Add(1)
+	return true
+}
+
+// SuppressedEchoes returns the number of inbound notes dropped as echoes
+// since startup.
+func SuppressedEchoes() int64 {
+	return echoCount.Load()
+}
+
+// contentHash returns the hex SHA-256 of the normalised content. ok is false
+// when the normalised text is too short to compare meaningfully.
+func contentHash(content string) (hash string, ok bool) {
+	norm := normalizeContent(content)
+	if utf8.RuneCountInString(norm) < echoMinLength {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(norm))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// echoLinkRe matches the parts of a note each bridge rewrites on the way out:
+// URLs (media, source links, njump links for nostr: URIs), nostr: URIs and
+// #[n] references.
+var echoLinkRe = regexp.MustCompile(`(?i)(?:https?://|nostr:)\S+|#\[\d+\]|🔗`)
+
+// normalizeContent is the one normalisation used for every echo comparison,
+// whichever protocol the text came from: links and references are removed,
+// runs of whitespace collapse to a single space, and the result is
+// lower-cased.
+func normalizeContent(content string) string {
+	return strings.ToLower(strings.Join(strings.Fields(echoLinkRe.ReplaceAllString(content, " ")), " "))
+}
@@ -0,0 +1,38 @@
+package bridge
+
+import "testing"
+
+func TestNormalizeContent(t *testing.T) {
+	const npub = "npub180cvv07tjdrrgpa0j7j7tmnyl2yr6yr7l8j4s3evf6u64th6gkwsyjh6w6"
+	note := "Hello  Fediverse, see nostr:" + npub + "\n\nhttps://example.com/cat.jpg"
+	// The same note as it comes back from a Fediverse mirror and from
+	// Bluesky, with the bridges' rewrites.
+	copies := []string{
+		"hello fediverse, see https://njump.me/" + npub + "\nhttps://example.com/cat.jpg\n\n🔗 https://mirror.example/@bob/1",
+		"Hello Fediverse, see https://njump.me/" + npub,
+		"HELLO FEDIVERSE, SEE #[0]",
+	}
+	want := normalizeContent(note)
+	if want != "hello fediverse, see" {
+		t.Fatalf("normalizeContent(%q) = %q", note, want)
+	}
+	for _, c := range copies {
+		if got := normalizeContent(c); got != want {
+			t.Errorf("normalizeContent(%q) = %q, want %q", c, got, want)
+		}
+	}
+}
+
+func TestIsEchoOnlyAfterRecord(t *testing.T) {
+	const text = "an independently written sentence about echoes"
+	if IsEcho(text) {
+		t.Fatal("IsEcho before RecordContent")
+	}
+	RecordContent(text + " https://example.com/link")
+	if !IsEcho(" An independently  written sentence about ECHOES ") {
+		t.Error("IsEcho = false after RecordContent")
+	}
+	if IsEcho("gm") {
+		t.Error("short content must never count as an echo")
+	}
+}
@@ -6,6 +6,7 @@ package bridge
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/nbd-wtf/go-nostr"
 )
@@ -48,6 +49,10 @@ type NormalizedPost struct {
 	Hashtags       []string // → t-tags
 	ContentWarning string   // → content-warning tag
 
+	// Location (NIP-52 style tags).
+	LocationName string // → location tag
+	Geohash      string // → g tag
+
 	// Source attribution (SHOW_SOURCE_LINK).
 	// Full URL goes into an r-tag; only the bare hostname goes into content
 	// so it does not trigger an embed card that overshadows shared links.
@@ -62,12 +67,39 @@ type NormalizedPost struct {
 	ProxyProtocol string // "activitypub" or "atproto"
 }
 
+// maxNoteLength is the post text length (in characters) above which
+// BuildKind1Event truncates. Zero disables truncation. Set via
+// SetMaxNoteLength.
+var maxNoteLength int
+
+// SetMaxNoteLength sets the length above which bridged post text is cut at a
+// word boundary and followed by a link to the source post, so relays with a
+// NIP-11 max_content_length accept it. Zero or negative disables truncation.
+// Call once at startup, before any concurrent use.
+func SetMaxNoteLength(n int) {
+	if n < 0 {
+		n = 0
+	}
+	maxNoteLength = n
+}
+
 // BuildKind1Event converts a NormalizedPost into an unsigned Nostr kind-1
 // event. The caller is responsible for signing before publishing.
+//
+// Text longer than SetMaxNoteLength is truncated before media URLs and the
+// source link are appended; the source URL then follows as a "read more"
+// link. Long-form articles are built elsewhere and never truncated.
 func BuildKind1Event(post NormalizedPost) *nostr.Event {
 	content := post.Content
 	tags := nostr.Tags{}
 
+	if maxNoteLength > 0 && utf8.RuneCountInString(content) > maxNoteLength {
+		content = TruncateAtWord(content, maxNoteLength) + "…"
+		if post.SourceURL != "" {
+			content += "\n\n" + post.SourceURL
+		}
+	}
+
 	// Proxy tag first so loop-prevention checks on downstream relays fire early.
 	if post.ProxyID != "" {
 		tags = append(tags, nostr.Tag{"proxy", post.ProxyID, post.ProxyProtocol})
@@ -118,6 +150,14 @@ func BuildKind1Event(post NormalizedPost) *nostr.Event {
 		tags = append(tags, nostr.Tag{"content-warning", post.ContentWarning})
 	}
 
+	// Location.
+	if post.LocationName != "" {
+		tags = append(tags, nostr.Tag{"location", post.LocationName})
+	}
+	if post.Geohash != "" {
+		tags = append(tags, nostr.Tag{"g", post.Geohash})
+	}
+
 	// Image imeta tags + append CDN/media URLs to content.
 	for _, img := range post.Images {
 		tags = append(tags, buildImeta(img))
@@ -143,6 +183,63 @@ func BuildKind1Event(post NormalizedPost) *nostr.Event {
 	}
 }
 
+// CommentScope locates a NIP-22 comment within a thread whose root is an
+// addressable event (e.g. a kind-30023 article).
+type CommentScope struct {
+	RootAddress   string // "kind:pubkey:d" of the root event
+	RootEventID   string
+	ParentEventID string // empty or RootEventID for a top-level comment
+	ParentPubkey  string // author of a parent comment; optional
+}
+
+// BuildCommentEvent converts a NormalizedPost into an unsigned NIP-22 kind-1111
+// comment scoped by scope. post's reply fields are ignored: threading is
+// expressed with the uppercase root (A/K/P) and lowercase parent tags instead.
+func BuildCommentEvent(post NormalizedPost, scope CommentScope) *nostr.Event {
+	post.ReplyToEventID, post.RootEventID = "", ""
+	event := BuildKind1Event(post)
+	event.Kind = 1111
+
+	withRelay := func(tag nostr.Tag) nostr.Tag {
+		if post.RelayHint != "" {
+			return append(tag, post.RelayHint)
+		}
+		return tag
+	}
+	rootKind, rest, _ := strings.Cut(scope.RootAddress, ":")
+	rootPubkey, _, _ := strings.Cut(rest, ":")
+
+	scopeTags := nostr.Tags{
+		withRelay(nostr.Tag{"A", scope.RootAddress}),
+		{"K", rootKind},
+		withRelay(nostr.Tag{"P", rootPubkey}),
+	}
+	if scope.ParentEventID == "" || scope.ParentEventID == scope.RootEventID {
+		scopeTags = append(scopeTags,
+			withRelay(nostr.Tag{"a", scope.RootAddress}),
+			withRelay(nostr.Tag{"e", scope.RootEventID}),
+			nostr.Tag{"k", rootKind},
+			withRelay(nostr.Tag{"p", rootPubkey}),
+		)
+	} else {
+		scopeTags = append(scopeTags,
+			withRelay(nostr.Tag{"e", scope.ParentEventID}),
+			nostr.Tag{"k", "1111"},
+		)
+		if scope.ParentPubkey != "" {
+			scopeTags = append(scopeTags, withRelay(nostr.Tag{"p", scope.ParentPubkey}))
+		}
+	}
+
+	// Keep the proxy tag first (see BuildKind1Event).
+	n := 0
+	if len(event.Tags) > 0 && event.Tags[0][0] == "proxy" {
+		n = 1
+	}
+	event.Tags = append(event.Tags[:n:n], append(scopeTags, event.Tags[n:]...)...)
+	return event
+}
+
 // ExtractHost returns the hostname from a URL string
 // (e.g. "https://bsky.app/profile/…" → "bsky.app").
 // Returns an empty string when the input does not look like a URL.
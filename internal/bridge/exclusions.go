@@ -0,0 +1,36 @@
+package bridge
+
+import "sync"
+
+// AuthorExclusions is the set of source-network authors — Bluesky DIDs and
+// AP actor URLs — whose posts are not bridged to Nostr, although they can
+// still be followed on their own network. It is shared between the server,
+// which loads it from the database and updates it from the admin API, and
+// the AP handler and Bluesky poller, which consult it. A nil
+// *AuthorExclusions excludes nobody.
+type AuthorExclusions struct {
+	mu      sync.RWMutex
+	authors map[string]struct{}
+}
+
+// Set replaces the excluded authors.
+func (e *AuthorExclusions) Set(authors []string) {
+	m := make(map[string]struct{}, len(authors))
+	for _, a := range authors {
+		m[a] = struct{}{}
+	}
+	e.mu.Lock()
+	e.authors = m
+	e.mu.Unlock()
+}
+
+// Excluded reports whether author's posts should not be bridged.
+func (e *AuthorExclusions) Excluded(author string) bool {
+	if e == nil || author == "" {
+		return false
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.authors[author]
+	return ok
+}
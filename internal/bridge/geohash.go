@@ -0,0 +1,71 @@
+package bridge
+
+import "strings"
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashPrecision is the geohash length used for bridged locations
+// (9 characters ≈ 5 m cells).
+const GeohashPrecision = 9
+
+// EncodeGeohash encodes a latitude/longitude pair as a geohash of the given
+// length, as used in NIP-52 "g" tags.
+func EncodeGeohash(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	var sb strings.Builder
+	even := true // geohash interleaves bits starting with longitude
+	bit, ch := 0, 0
+	for sb.Len() < precision {
+		rng, v := &latRange, lat
+		if even {
+			rng, v = &lonRange, lon
+		}
+		mid := (rng[0] + rng[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			rng[0] = mid
+		} else {
+			rng[1] = mid
+		}
+		even = !even
+		if bit++; bit == 5 {
+			sb.WriteByte(geohashBase32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// DecodeGeohash returns the centre of the cell described by a geohash.
+// ok is false when hash is empty or contains characters outside the geohash
+// alphabet.
+func DecodeGeohash(hash string) (lat, lon float64, ok bool) {
+	if hash == "" {
+		return 0, 0, false
+	}
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		idx := strings.IndexRune(geohashBase32, c)
+		if idx < 0 {
+			return 0, 0, false
+		}
+		for mask := 16; mask > 0; mask >>= 1 {
+			rng := &latRange
+			if even {
+				rng = &lonRange
+			}
+			mid := (rng[0] + rng[1]) / 2
+			if idx&mask != 0 {
+				rng[0] = mid
+			} else {
+				rng[1] = mid
+			}
+			even = !even
+		}
+	}
+	return (latRange[0] + latRange[1]) / 2, (lonRange[0] + lonRange[1]) / 2, true
+}
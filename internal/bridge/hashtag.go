@@ -0,0 +1,39 @@
+package bridge
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// hashtagRes caches the compiled pattern of each hashtag passed to HasHashtag
+// and StripHashtag. The tags come from configuration, so the set stays small.
+var hashtagRes sync.Map // tag → *regexp.Regexp
+
+// hashtagRe matches #tag as a whole hashtag, case-insensitively, with the
+// whitespace before it.
+func hashtagRe(tag string) *regexp.Regexp {
+	if re, ok := hashtagRes.Load(tag); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, _ := hashtagRes.LoadOrStore(tag, regexp.MustCompile(`(?i)(^|\s)#`+regexp.QuoteMeta(tag)+`\b`))
+	return re.(*regexp.Regexp)
+}
+
+// HasHashtag reports whether event carries tag (without '#') as a "t" tag or
+// as a #hashtag in its content, case-insensitively.
+func HasHashtag(event *nostr.Event, tag string) bool {
+	for _, t := range event.Tags {
+		if len(t) >= 2 && t[0] == "t" && strings.EqualFold(t[1], tag) {
+			return true
+		}
+	}
+	return hashtagRe(tag).MatchString(event.Content)
+}
+
+// StripHashtag removes every #tag hashtag from content.
+func StripHashtag(content, tag string) string {
+	return strings.TrimSpace(hashtagRe(tag).ReplaceAllString(content, ""))
+}
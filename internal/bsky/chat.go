@@ -0,0 +1,203 @@
+package bsky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// chatProxy is the atproto-proxy target for chat.bsky.* calls: the PDS
+// forwards them to the Bluesky chat service, which rate-limits them on its own.
+const chatProxy = "did:web:api.bsky.chat#bsky_chat"
+
+// kvChatSinceKey stores the RFC3339 time chat bridging was first enabled.
+// Messages of a conversation without a cursor are only delivered when sent
+// after it, so enabling the bridge does not replay the whole DM history.
+const kvChatSinceKey = "bsky_chat_since"
+
+const (
+	// chatRevRetention is how long the cursor of a quiet conversation is
+	// kept. A conversation whose cursor was pruned had no messages since,
+	// so only messages newer than the retention window are delivered.
+	chatRevRetention = 90 * 24 * time.Hour
+	// maxChatConvoPages caps the conversation pages (of 50) checked per poll.
+	maxChatConvoPages = 2
+	// maxChatMessagePages caps the message pages (of 50) fetched per
+	// conversation and poll; older messages are skipped.
+	maxChatMessagePages = 4
+	// chatErrorBackoff is how long chat polling pauses after a failure that
+	// is not a rate limit, e.g. an app password without DM access.
+	chatErrorBackoff = 5 * time.Minute
+	// minChatRateLimitBackoff is the shortest pause after a rate limit, for
+	// responses without a usable RetryAfter.
+	minChatRateLimitBackoff = 30 * time.Second
+)
+
+// isChatMethod reports whether an XRPC method belongs to the chat service.
+func isChatMethod(method string) bool {
+	return strings.HasPrefix(method, "chat.bsky.")
+}
+
+// ListConvos lists the account's chat conversations, most recently active
+// first, via chat.bsky.convo.listConvos. The app password must have been
+// created with direct message access.
+func (c *Client) ListConvos(ctx context.Context, cursor string) (*ListConvosResponse, error) {
+	params := url.Values{}
+	params.Set("limit", "50")
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	var resp ListConvosResponse
+	if err := c.authedGet(ctx, "chat.bsky.convo.listConvos", params, &resp); err != nil {
+		return nil, fmt.Errorf("bsky listConvos: %w", err)
+	}
+	return &resp, nil
+}
+
+// GetMessages fetches a page of a conversation's messages, newest first, via
+// chat.bsky.convo.getMessages.
+func (c *Client) GetMessages(ctx context.Context, convoID, cursor string) (*GetMessagesResponse, error) {
+	params := url.Values{}
+	params.Set("convoId", convoID)
+	params.Set("limit", "50")
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	var resp GetMessagesResponse
+	if err := c.authedGet(ctx, "chat.bsky.convo.getMessages", params, &resp); err != nil {
+		return nil, fmt.Errorf("bsky getMessages: %w", err)
+	}
+	return &resp, nil
+}
+
+// pollChat delivers new Bluesky direct messages as notification DMs, returning
+// how many were delivered. Conversations whose rev has not moved since the
+// stored cursor are skipped without fetching their messages. Failures pause
+// chat polling (until the chat service's RetryAfter when rate limited) without
+// slowing down the rest of the poll cycle.
+func (p *Poller) pollChat(ctx context.Context) int {
+	if time.Now().Before(p.chatRetryAt) {
+		return 0
+	}
+	sinceRaw, ok := p.Store.GetKV(kvChatSinceKey)
+	if !ok {
+		sinceRaw = time.Now().UTC().Format(time.RFC3339)
+		_ = p.Store.SetKV(kvChatSinceKey, sinceRaw)
+	}
+	since, _ := time.Parse(time.RFC3339, sinceRaw)
+	if cutoff := time.Now().Add(-chatRevRetention); since.Before(cutoff) {
+		since = cutoff
+	}
+	if time.Since(p.chatPrunedAt) > 24*time.Hour {
+		p.chatPrunedAt = time.Now()
+		if n, err := p.Store.PruneChatRevs(time.Now().Add(-chatRevRetention)); err != nil {
+			slog.Warn("bsky poller: failed to prune chat cursors", "error", err)
+		} else if n > 0 {
+			slog.Debug("bsky poller: pruned chat cursors", "count", n)
+		}
+	}
+
+	delivered := 0
+	cursor := ""
+	for page := 0; page < maxChatConvoPages; page++ {
+		resp, err := p.Client.ListConvos(ctx, cursor)
+		if err != nil {
+			p.noteChatError(err)
+			return delivered
+		}
+		for _, convo := range resp.Convos {
+			lastRev, _ := p.Store.GetChatRev(convo.ID)
+			if lastRev != "" && convo.Rev <= lastRev {
+				continue
+			}
+			n, err := p.bridgeConvo(ctx, convo, lastRev, since)
+			delivered += n
+			if err != nil {
+				p.noteChatError(err)
+				return delivered
+			}
+			_ = p.Store.SetChatRev(convo.ID, convo.Rev)
+		}
+		if resp.Cursor == "" {
+			break
+		}
+		cursor = resp.Cursor
+	}
+	return delivered
+}
+
+// bridgeConvo delivers the messages of convo newer than lastRev — or, without
+// a cursor, sent after since — oldest first. Messages sent by the account
+// itself and deleted messages are skipped, and so is every message of a muted
+// conversation. The cursor advances after each message, so a failed delivery
+// is retried on the next poll without repeating the ones before it.
+func (p *Poller) bridgeConvo(ctx context.Context, convo ChatConvo, lastRev string, since time.Time) (int, error) {
+	var pending []ChatMessage
+	cursor := ""
+collect:
+	for page := 0; page < maxChatMessagePages; page++ {
+		resp, err := p.Client.GetMessages(ctx, convo.ID, cursor)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range resp.Messages {
+			if lastRev != "" && m.Rev <= lastRev {
+				break collect
+			}
+			if lastRev == "" {
+				if sent, err := time.Parse(time.RFC3339, m.SentAt); err == nil && !sent.After(since) {
+					break collect
+				}
+			}
+			pending = append(pending, m)
+		}
+		if resp.Cursor == "" {
+			break
+		}
+		cursor = resp.Cursor
+	}
+
+	own := p.Client.DID()
+	delivered := 0
+	for i := len(pending) - 1; i >= 0; i-- {
+		m := pending[i]
+		if !convo.Muted && m.Sender.DID != own && m.Text != "" {
+			handle := m.Sender.DID
+			for _, member := range convo.Members {
+				if member.DID == m.Sender.DID {
+					handle = member.Handle
+				}
+			}
+			msg := fmt.Sprintf("✉️ New Bluesky DM from @%s: %s\nhttps://bsky.app/messages/%s",
+				handle, m.Text, convo.ID)
+			dm, err := p.Signer.CreateNotificationDM(msg)
+			if err != nil {
+				return delivered, fmt.Errorf("create DM: %w", err)
+			}
+			if err := p.Publisher.Publish(ctx, dm); err != nil {
+				return delivered, fmt.Errorf("publish DM: %w", err)
+			}
+			delivered++
+			slog.Info("bsky poller: bridged chat message", "from", handle, "convo", convo.ID)
+		}
+		_ = p.Store.SetChatRev(convo.ID, m.Rev)
+	}
+	return delivered, nil
+}
+
+// noteChatError pauses chat polling after a failure: until the RetryAfter of
+// the chat service when rate limited (at least minChatRateLimitBackoff), else
+// for chatErrorBackoff.
+func (p *Poller) noteChatError(err error) {
+	wait := chatErrorBackoff
+	var rl *ErrRateLimited
+	if errors.As(err, &rl) {
+		wait = max(rl.RetryAfter, minChatRateLimitBackoff)
+	}
+	p.chatRetryAt = time.Now().Add(wait)
+	slog.Warn("bsky poller: chat poll failed, pausing chat", "error", err, "retry_in", wait.Round(time.Second))
+}
@@ -18,6 +18,17 @@ import (
 
 const defaultPDSURL = "https://bsky.social"
 
+// userAgent is sent on every XRPC request.
+var userAgent = "klistr/1.0 (https://github.com/klppl/klistr)"
+
+// SetUserAgent sets the User-Agent of XRPC requests; empty keeps the default.
+// Call once at startup, before any concurrent use.
+func SetUserAgent(ua string) {
+	if ua != "" {
+		userAgent = ua
+	}
+}
+
 // Client is a thin XRPC HTTP client for the Bluesky PDS.
 // It handles authentication and re-authenticates automatically on 401.
 type Client struct {
@@ -25,11 +36,14 @@ type Client struct {
 	Identifier  string
 	AppPassword string
 
-	mu                 sync.Mutex
-	session            *Session
-	http               *http.Client
-	rateLimitRemaining int
-	rateLimitReset     time.Time
+	mu      sync.Mutex
+	session *Session
+	http    *http.Client
+	// rateLimit tracks the PDS's rate-limit window; chatRateLimit the chat
+	// service's, which limits chat.bsky.* calls proxied through the PDS
+	// separately (see rateWindow).
+	rateLimit     rateWindow
+	chatRateLimit rateWindow
 
 	// reauth serialises re-authentication attempts so that concurrent goroutines
 	// (e.g. poller + poster) that both receive a 401 don't each independently call
@@ -38,19 +52,39 @@ type Client struct {
 	reauth sync.Mutex
 }
 
+// rateWindow is the rate-limit headroom last reported by a service.
+type rateWindow struct {
+	remaining int
+	reset     time.Time
+}
+
+// rateWindow returns the window method is counted against. The caller must
+// hold c.mu.
+func (c *Client) rateWindow(method string) *rateWindow {
+	if isChatMethod(method) {
+		return &c.chatRateLimit
+	}
+	return &c.rateLimit
+}
+
 // rateLimitWarnThreshold is the RateLimit-Remaining value below which we emit
 // a warning so operators notice before requests start failing.
 const rateLimitWarnThreshold = 10
 
-// rateLimitRetryMax caps how long we'll sleep after a 429 before retrying.
+// rateLimitRetryMax caps how long we'll block waiting for a rate-limit window
+// to reset (after a 429, or before a request once RateLimit-Remaining hits 0).
+// Longer waits fail fast with *ErrRateLimited instead.
 const rateLimitRetryMax = 5 * time.Minute
 
-// errRateLimited is returned by doRequest when the PDS responds with HTTP 429.
-type errRateLimited struct {
+// ErrRateLimited is returned when the PDS rate limit is exhausted and the
+// reset is further away than rateLimitRetryMax, or when a request is still
+// rejected with HTTP 429 after one backoff. RetryAfter is how long the caller
+// should wait before trying again.
+type ErrRateLimited struct {
 	RetryAfter time.Duration
 }
 
-func (e *errRateLimited) Error() string {
+func (e *ErrRateLimited) Error() string {
 	return fmt.Sprintf("rate limited by Bluesky PDS; retry after %s", e.RetryAfter.Round(time.Second))
 }
 
@@ -210,14 +244,42 @@ func (c *Client) FollowActor(ctx context.Context, did string) (string, error) {
 	return parts[len(parts)-1], nil
 }
 
-// GetPostThread fetches the thread view for a post, including up to 10 levels
-// of ancestor posts and no replies (depth=0). Used to bridge missing parent
-// posts when a followed account replies inside a thread.
-func (c *Client) GetPostThread(ctx context.Context, uri string) (*GetPostThreadResponse, error) {
+// CreateThreadgate restricts who can reply to the post at postURI. An empty
+// rules slice means nobody can reply. The threadgate shares the post's rkey,
+// as required by the lexicon.
+func (c *Client) CreateThreadgate(ctx context.Context, postURI string, rules []ThreadgateRule) error {
+	rkey := RKeyFromURI(postURI)
+	if rkey == "" {
+		return fmt.Errorf("bsky createThreadgate: unexpected URI format: %s", postURI)
+	}
+	if rules == nil {
+		rules = []ThreadgateRule{}
+	}
+	_, err := c.CreateRecord(ctx, CreateRecordRequest{
+		Repo:       c.DID(),
+		Collection: "app.bsky.feed.threadgate",
+		RKey:       rkey,
+		Record: Threadgate{
+			Type:      "app.bsky.feed.threadgate",
+			Post:      postURI,
+			Allow:     rules,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("bsky createThreadgate: %w", err)
+	}
+	return nil
+}
+
+// GetPostThread fetches the thread view for a post, including up to
+// parentHeight levels of ancestor posts and no replies (depth=0). Used to
+// bridge missing parent posts when a followed account replies inside a thread.
+func (c *Client) GetPostThread(ctx context.Context, uri string, parentHeight int) (*GetPostThreadResponse, error) {
 	params := url.Values{}
 	params.Set("uri", uri)
 	params.Set("depth", "0")
-	params.Set("parentHeight", "10")
+	params.Set("parentHeight", strconv.Itoa(parentHeight))
 	var resp GetPostThreadResponse
 	if err := c.authedGet(ctx, "app.bsky.feed.getPostThread", params, &resp); err != nil {
 		return nil, fmt.Errorf("bsky getPostThread: %w", err)
@@ -225,6 +287,20 @@ func (c *Client) GetPostThread(ctx context.Context, uri string) (*GetPostThreadR
 	return &resp, nil
 }
 
+// GetPosts fetches hydrated post views for up to 25 AT URIs via
+// app.bsky.feed.getPosts. Posts that are deleted or blocked are omitted.
+func (c *Client) GetPosts(ctx context.Context, uris []string) (*GetPostsResponse, error) {
+	params := url.Values{}
+	for _, u := range uris {
+		params.Add("uris", u)
+	}
+	var resp GetPostsResponse
+	if err := c.authedGet(ctx, "app.bsky.feed.getPosts", params, &resp); err != nil {
+		return nil, fmt.Errorf("bsky getPosts: %w", err)
+	}
+	return &resp, nil
+}
+
 // GetProfile fetches a profile via app.bsky.actor.getProfile.
 func (c *Client) GetProfile(ctx context.Context, actor string) (*Profile, error) {
 	params := url.Values{}
@@ -254,6 +330,9 @@ func (c *Client) authedPost(ctx context.Context, method string, body, out interf
 	// detect whether another goroutine has already refreshed it.
 	staleToken := c.currentToken()
 
+	if err := c.waitForRateLimit(ctx, method); err != nil {
+		return err
+	}
 	err := c.xrpcPostWithAuth(ctx, method, body, out)
 	if isAuthError(err) {
 		if authErr := c.singleAuthenticate(ctx, staleToken); authErr != nil {
@@ -261,12 +340,12 @@ func (c *Client) authedPost(ctx context.Context, method string, body, out interf
 		}
 		err = c.xrpcPostWithAuth(ctx, method, body, out)
 	}
-	var rl *errRateLimited
+	var rl *ErrRateLimited
 	if errors.As(err, &rl) {
-		wait := rl.RetryAfter
-		if wait > rateLimitRetryMax {
-			wait = rateLimitRetryMax
+		if rl.RetryAfter > rateLimitRetryMax {
+			return err
 		}
+		wait := rl.RetryAfter
 		slog.Warn("bsky rate limited on POST, backing off", "method", method, "retry_after", wait.Round(time.Second))
 		select {
 		case <-ctx.Done():
@@ -285,6 +364,9 @@ func (c *Client) authedGet(ctx context.Context, method string, params url.Values
 	// detect whether another goroutine has already refreshed it.
 	staleToken := c.currentToken()
 
+	if err := c.waitForRateLimit(ctx, method); err != nil {
+		return err
+	}
 	err := c.xrpcGetWithAuth(ctx, method, params, out)
 	if isAuthError(err) {
 		if authErr := c.singleAuthenticate(ctx, staleToken); authErr != nil {
@@ -292,12 +374,12 @@ func (c *Client) authedGet(ctx context.Context, method string, params url.Values
 		}
 		err = c.xrpcGetWithAuth(ctx, method, params, out)
 	}
-	var rl *errRateLimited
+	var rl *ErrRateLimited
 	if errors.As(err, &rl) {
-		wait := rl.RetryAfter
-		if wait > rateLimitRetryMax {
-			wait = rateLimitRetryMax
+		if rl.RetryAfter > rateLimitRetryMax {
+			return err
 		}
+		wait := rl.RetryAfter
 		slog.Warn("bsky rate limited on GET, backing off", "method", method, "retry_after", wait.Round(time.Second))
 		select {
 		case <-ctx.Done():
@@ -332,10 +414,13 @@ func (c *Client) xrpcGetWithAuth(ctx context.Context, method string, params url.
 		return fmt.Errorf("create GET request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "klistr/1.0 (https://github.com/klppl/klistr)")
+	req.Header.Set("User-Agent", userAgent)
 	if auth := c.authHeader(); auth != "" {
 		req.Header.Set("Authorization", auth)
 	}
+	if isChatMethod(method) {
+		req.Header.Set("atproto-proxy", chatProxy)
+	}
 
 	return c.doRequest(req, out)
 }
@@ -353,10 +438,13 @@ func (c *Client) doPost(ctx context.Context, method string, body interface{}, ou
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "klistr/1.0 (https://github.com/klppl/klistr)")
+	req.Header.Set("User-Agent", userAgent)
 	if authHeader != "" {
 		req.Header.Set("Authorization", authHeader)
 	}
+	if isChatMethod(method) {
+		req.Header.Set("atproto-proxy", chatProxy)
+	}
 
 	return c.doRequest(req, out)
 }
@@ -381,8 +469,7 @@ func (c *Client) updateRateLimit(resp *http.Response, method string) {
 		}
 	}
 	c.mu.Lock()
-	c.rateLimitRemaining = n
-	c.rateLimitReset = reset
+	*c.rateWindow(method) = rateWindow{remaining: n, reset: reset}
 	c.mu.Unlock()
 	if n <= rateLimitWarnThreshold {
 		slog.Warn("bsky rate limit headroom low",
@@ -393,6 +480,34 @@ func (c *Client) updateRateLimit(resp *http.Response, method string) {
 	}
 }
 
+// waitForRateLimit blocks until the current rate-limit window resets when the
+// last response reported no remaining headroom, so we don't spend a request
+// just to be told 429. Returns *ErrRateLimited without waiting when the reset
+// is more than rateLimitRetryMax away.
+func (c *Client) waitForRateLimit(ctx context.Context, method string) error {
+	c.mu.Lock()
+	w := *c.rateWindow(method)
+	c.mu.Unlock()
+	remaining, reset := w.remaining, w.reset
+	if reset.IsZero() || remaining > 0 {
+		return nil
+	}
+	wait := time.Until(reset)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > rateLimitRetryMax {
+		return &ErrRateLimited{RetryAfter: wait}
+	}
+	slog.Warn("bsky rate limit exhausted, waiting for reset", "method", method, "reset_in", wait.Round(time.Second))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+	}
+	return nil
+}
+
 func (c *Client) doRequest(req *http.Request, out interface{}) error {
 	resp, err := c.http.Do(req)
 	if err != nil {
@@ -417,7 +532,7 @@ func (c *Client) doRequest(req *http.Request, out interface{}) error {
 		return errAuthExpired
 	}
 	if resp.StatusCode == 429 {
-		return &errRateLimited{RetryAfter: parseRetryAfter(resp)}
+		return &ErrRateLimited{RetryAfter: parseRetryAfter(resp)}
 	}
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
@@ -0,0 +1,139 @@
+package bsky
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	listType        = "app.bsky.graph.list"
+	starterPackType = "app.bsky.graph.starterpack"
+
+	// listPageSize is the page size requested from app.bsky.graph.getList
+	// (the lexicon maximum).
+	listPageSize = 100
+)
+
+// FollowSet is the resolved membership of a Bluesky list or starter pack.
+type FollowSet struct {
+	URI       string    // AT URI of the list or starter pack
+	Name      string    // display name of the list or pack
+	Total     int       // member count reported by the AppView
+	Members   []Profile // at most the requested limit, in list order
+	Truncated bool      // true when members beyond the limit were dropped
+}
+
+// parseFollowSetURL splits a list or starter-pack reference into its repo,
+// collection and rkey. Accepted forms:
+//
+//	https://bsky.app/profile/<did-or-handle>/lists/<rkey>
+//	https://bsky.app/starter-pack/<did-or-handle>/<rkey>
+//	at://<did-or-handle>/app.bsky.graph.list/<rkey>
+//	at://<did-or-handle>/app.bsky.graph.starterpack/<rkey>
+func parseFollowSetURL(raw string) (repo, collection, rkey string, err error) {
+	raw = strings.TrimSpace(raw)
+	if rest, ok := strings.CutPrefix(raw, "at://"); ok {
+		parts := strings.Split(rest, "/")
+		if len(parts) == 3 && (parts[1] == listType || parts[1] == starterPackType) && parts[0] != "" && parts[2] != "" {
+			return parts[0], parts[1], parts[2], nil
+		}
+		return "", "", "", fmt.Errorf("not a list or starter pack AT URI: %s", raw)
+	}
+	for _, host := range []string{"https://bsky.app/", "http://bsky.app/"} {
+		rest, ok := strings.CutPrefix(raw, host)
+		if !ok {
+			continue
+		}
+		if i := strings.IndexAny(rest, "?#"); i >= 0 {
+			rest = rest[:i]
+		}
+		parts := strings.Split(strings.TrimRight(rest, "/"), "/")
+		switch {
+		case len(parts) == 4 && parts[0] == "profile" && parts[2] == "lists" && parts[1] != "" && parts[3] != "":
+			return parts[1], listType, parts[3], nil
+		case len(parts) == 3 && parts[0] == "starter-pack" && parts[1] != "" && parts[2] != "":
+			return parts[1], starterPackType, parts[2], nil
+		}
+	}
+	return "", "", "", fmt.Errorf("not a Bluesky list or starter pack URL: %s", raw)
+}
+
+// IsFollowSetURL reports whether raw looks like a Bluesky list or starter
+// pack reference that Client.GetFollowSet accepts.
+func IsFollowSetURL(raw string) bool {
+	_, _, _, err := parseFollowSetURL(raw)
+	return err == nil
+}
+
+// GetFollowSet resolves a list or starter-pack URL (see parseFollowSetURL)
+// and returns up to limit of its members. Handles in the URL are resolved to
+// DIDs first, since the graph endpoints only accept DID-based AT URIs.
+func (c *Client) GetFollowSet(ctx context.Context, rawURL string, limit int) (*FollowSet, error) {
+	repo, collection, rkey, err := parseFollowSetURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(repo, "did:") {
+		profile, err := c.GetProfile(ctx, repo)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", repo, err)
+		}
+		repo = profile.DID
+	}
+	uri := "at://" + repo + "/" + collection + "/" + rkey
+
+	set := &FollowSet{URI: uri}
+	listURI := uri
+	if collection == starterPackType {
+		params := url.Values{}
+		params.Set("starterPack", uri)
+		var resp GetStarterPackResponse
+		if err := c.authedGet(ctx, "app.bsky.graph.getStarterPack", params, &resp); err != nil {
+			return nil, fmt.Errorf("bsky getStarterPack: %w", err)
+		}
+		if resp.StarterPack.List == nil || resp.StarterPack.List.URI == "" {
+			return nil, fmt.Errorf("starter pack %s has no member list", uri)
+		}
+		set.Name = resp.StarterPack.Record.Name
+		listURI = resp.StarterPack.List.URI
+	}
+
+	cursor := ""
+	for {
+		params := url.Values{}
+		params.Set("list", listURI)
+		params.Set("limit", strconv.Itoa(listPageSize))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		var resp GetListResponse
+		if err := c.authedGet(ctx, "app.bsky.graph.getList", params, &resp); err != nil {
+			return nil, fmt.Errorf("bsky getList: %w", err)
+		}
+		if set.Name == "" {
+			set.Name = resp.List.Name
+		}
+		set.Total = resp.List.ListItemCount
+		for _, item := range resp.Items {
+			if len(set.Members) >= limit {
+				set.Truncated = true
+				break
+			}
+			set.Members = append(set.Members, item.Subject)
+		}
+		if set.Truncated || resp.Cursor == "" || len(resp.Items) == 0 {
+			break
+		}
+		cursor = resp.Cursor
+	}
+	if set.Total < len(set.Members) {
+		set.Total = len(set.Members)
+	}
+	if set.Total > len(set.Members) {
+		set.Truncated = true
+	}
+	return set, nil
+}
@@ -3,16 +3,20 @@ package bsky
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/nbd-wtf/go-nostr"
 
 	"github.com/klppl/klistr/internal/bridge"
+	"github.com/klppl/klistr/internal/notify"
 )
 
 // kvLastSeenKey stores the indexedAt timestamp of the most-recently processed
@@ -39,7 +43,7 @@ type Signer interface {
 	// Sign derives a deterministic key for id and signs the event.
 	// Used to give each Bluesky author a consistent pseudonymous Nostr identity.
 	Sign(event *nostr.Event, id string) error
-	CreateDMToSelf(message string) (*nostr.Event, error)
+	CreateNotificationDM(message string) (*nostr.Event, error)
 }
 
 // PollerStore is the subset of db.Store used by the Poller.
@@ -49,6 +53,10 @@ type PollerStore interface {
 	AddFollow(followerID, followedID string) error
 	SetKV(key, value string) error
 	GetKV(key string) (string, bool)
+	// Used by the chat bridge for per-conversation cursors.
+	GetChatRev(convoID string) (string, bool)
+	SetChatRev(convoID, rev string) error
+	PruneChatRevs(before time.Time) (int64, error)
 }
 
 // Poller polls Bluesky notifications and publishes them as Nostr events.
@@ -61,19 +69,97 @@ type Poller struct {
 	LocalActorURL  string // used to record inbound Bluesky followers
 	LocalDomain    string // used to build NIP-05 identifiers for bridged Bluesky authors
 	Interval       time.Duration
+	// MaxInterval is how far Interval widens while polls keep coming back
+	// empty; at or below Interval the poller polls at a fixed rate.
+	MaxInterval time.Duration
+	// Jitter randomises each wait by up to ±Jitter (a fraction, e.g. 0.1).
+	Jitter float64
 	ShowSourceLink *atomic.Bool // append bsky.app post URL at the bottom of bridged notes
 	// BridgeTimeline, when true, enables bridging posts from followed accounts'
 	// home timeline to Nostr kind-1 events. On by default — set
 	// BSKY_BRIDGE_TIMELINE=false to disable. When disabled, only notifications
 	// (likes, reposts, replies, mentions, new followers) are bridged.
 	BridgeTimeline bool
+	// TimelineReplies, TimelineReposts and TimelineQuotes independently filter
+	// what timeline bridging carries. Replies are only bridged when the parent
+	// author is followed (or is the local user) unless TimelineReplies is set;
+	// reposts are bridged as kind-6 events only when TimelineReposts is set;
+	// quote posts are skipped unless TimelineQuotes is set.
+	TimelineReplies bool
+	TimelineReposts bool
+	TimelineQuotes  bool
+	// BridgeChat, when true, delivers Bluesky direct messages (chat.bsky
+	// conversations) as notification DMs (see pollChat). Only the primary
+	// account's chats are bridged.
+	BridgeChat bool
 	// TriggerCh, if non-nil, triggers an immediate poll when sent to.
 	TriggerCh <-chan struct{}
+	// Notifier, if non-nil, receives new-follower webhook events.
+	Notifier *notify.Webhook
+	// Exclusions, if non-nil, lists DIDs whose timeline posts and reposts
+	// are not bridged.
+	Exclusions *bridge.AuthorExclusions
+	// ContentFilter, if non-nil, skips posts matching a keyword/regex filter
+	// or bridges them behind a content warning.
+	ContentFilter *bridge.ContentFilter
+	// MaxAncestorDepth caps how many unbridged ancestors of a reply are
+	// fetched and bridged. Defaults to defaultMaxAncestorDepth if zero.
+	MaxAncestorDepth int
+	// MaxAncestorWalks caps how many replies per poll cycle may fetch their
+	// unbridged ancestors; later ones are left for the next cycle.
+	// Defaults to defaultMaxAncestorWalks if zero.
+	MaxAncestorWalks int
+	// PrimaryDID, when set, marks this as the poller of an additional account
+	// (BSKY_EXTRA_ACCOUNTS) and names the primary account, which the Poster
+	// cross-posts to: its posts originate from Nostr and are never bridged
+	// back. An additional account only bridges its home timeline — its own
+	// posts included — and keeps its poll state under kv keys suffixed with
+	// its DID. Notifications stay with the primary account.
+	PrimaryDID string
+
+	// mu serializes poll cycles with on-demand bridging (BridgeURL), which
+	// share the per-cycle state below.
+	mu sync.Mutex
 
 	// pollSeenDIDs tracks DIDs whose profiles have already been published in
 	// the current poll cycle. Reset at the start of each poll() call.
-	// Not goroutine-safe — only accessed from the single poll goroutine.
+	// Not goroutine-safe — only accessed while holding mu.
 	pollSeenDIDs map[string]struct{}
+
+	// pollRateLimit is the longest RetryAfter reported by the PDS during the
+	// current poll cycle, or zero when the cycle was not rate limited.
+	// Only accessed while holding mu.
+	pollRateLimit time.Duration
+	// pollFailed is set when an API call of the current cycle failed.
+	// Only accessed while holding mu.
+	pollFailed bool
+	// pollAncestorWalks counts the ancestor walks of the current cycle (see
+	// MaxAncestorWalks). Only accessed while holding mu.
+	pollAncestorWalks int
+	// pollDeferred is set when a reply was left unbridged because the cycle
+	// used up its ancestor walks. Only accessed while holding mu.
+	pollDeferred bool
+	// quoteDepth is how many quoted posts resolveQuote is bridging above the
+	// current one. Only accessed while holding mu.
+	quoteDepth int
+	// chatRetryAt pauses chat polling after a failure; the chat service is
+	// rate limited separately, so it does not back off the whole poller.
+	// Only accessed while holding mu.
+	chatRetryAt time.Time
+	// chatPrunedAt is when stale chat cursors were last pruned.
+	// Only accessed while holding mu.
+	chatPrunedAt time.Time
+}
+
+// maxPollBackoff caps how far the poll interval is stretched while the PDS
+// keeps rate limiting us or failing.
+const maxPollBackoff = 15 * time.Minute
+
+// pollResult summarises one poll cycle for the adaptive interval in Start.
+type pollResult struct {
+	retryAfter time.Duration // longest RetryAfter from the PDS; zero when not rate limited
+	failed     bool          // an API call failed, rate limited or not
+	items      int           // new notifications and timeline posts seen
 }
 
 // Start begins the notification polling loop. Blocks until ctx is cancelled.
@@ -82,14 +168,42 @@ func (p *Poller) Start(ctx context.Context) {
 	if interval <= 0 {
 		interval = 30 * time.Second
 	}
-
-	slog.Info("bsky poller started", "interval", interval)
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	maxInterval := max(p.MaxInterval, interval)
+
+	slog.Info("bsky poller started", "interval", interval, "max_interval", maxInterval)
+
+	// current is the effective interval. It snaps back to interval after a
+	// cycle that saw new activity, widens by half after each empty cycle (up
+	// to maxInterval), and doubles after a failed cycle (up to maxPollBackoff,
+	// and at least the PDS's RetryAfter when rate limited).
+	current := interval
+	failing := false
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	adjust := func(res pollResult) {
+		next := interval
+		switch {
+		case res.retryAfter > 0:
+			next = min(max(current*2, res.retryAfter), max(maxPollBackoff, interval))
+		case res.failed:
+			next = min(current*2, max(maxPollBackoff, interval))
+		case res.items == 0:
+			next = min(current+current/2, maxInterval)
+		}
+		switch {
+		case res.failed && next > current:
+			slog.Warn("bsky poller failing, backing off", "interval", next, "rate_limited", res.retryAfter > 0)
+		case !res.failed && failing:
+			slog.Info("bsky poller recovered, restoring interval", "interval", next)
+		case next != current:
+			slog.Debug("bsky poller interval adjusted", "interval", next, "items", res.items)
+		}
+		current, failing = next, res.failed
+		timer.Reset(p.jitter(current))
+	}
 
 	// Poll once immediately on start.
-	p.poll(ctx)
+	adjust(p.poll(ctx))
 
 	// A nil channel blocks forever — safe to select on when TriggerCh is unset.
 	trigCh := p.TriggerCh
@@ -99,25 +213,103 @@ func (p *Poller) Start(ctx context.Context) {
 		case <-ctx.Done():
 			slog.Info("bsky poller stopped")
 			return
-		case <-ticker.C:
-			p.poll(ctx)
+		case <-timer.C:
+			adjust(p.poll(ctx))
 		case <-trigCh:
 			slog.Info("bsky poll triggered manually")
-			p.poll(ctx)
+			adjust(p.poll(ctx))
 		}
 	}
 }
 
+// jitter spreads d by up to ±Jitter (a fraction of d) so pollers restarted
+// together do not stay aligned.
+func (p *Poller) jitter(d time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return d
+	}
+	spread := time.Duration(float64(d) * min(p.Jitter, 1))
+	if spread <= 0 {
+		return d
+	}
+	return d - spread + rand.N(2*spread+1)
+}
+
 // poll runs one full polling cycle: notifications, then (optionally) timeline.
-func (p *Poller) poll(ctx context.Context) {
+func (p *Poller) poll(ctx context.Context) pollResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	// Reset per-cycle profile dedup map so each DID gets at most one
 	// GetProfile API call per poll, regardless of how many posts they authored.
 	p.pollSeenDIDs = make(map[string]struct{})
-	p.pollNotifications(ctx)
-	if p.BridgeTimeline {
-		p.pollTimeline(ctx)
+	p.pollRateLimit = 0
+	p.pollFailed = false
+	p.pollAncestorWalks = 0
+	var items int
+	if p.PrimaryDID == "" {
+		items = p.pollNotifications(ctx)
+		if p.BridgeChat {
+			items += p.pollChat(ctx)
+		}
+	}
+	if p.BridgeTimeline && p.pollRateLimit == 0 {
+		items += p.pollTimeline(ctx)
 	}
 	p.pollSeenDIDs = nil // release for GC between polls
+	return pollResult{retryAfter: p.pollRateLimit, failed: p.pollFailed, items: items}
+}
+
+// kvKey returns the kv key holding this poller's copy of the state in base.
+// The primary account uses base itself, so existing state carries over.
+func (p *Poller) kvKey(base string) string {
+	if p.PrimaryDID == "" {
+		return base
+	}
+	return base + "_" + p.Client.DID()
+}
+
+// fromNostr reports whether did is the primary account, whose posts are
+// cross-posts of Nostr notes.
+func (p *Poller) fromNostr(did string) bool {
+	if p.PrimaryDID != "" {
+		return did == p.PrimaryDID
+	}
+	return did == p.Client.DID()
+}
+
+// PollState is a poller's account and progress, shown per account in the
+// admin dashboard.
+type PollState struct {
+	Handle   string `json:"handle"`
+	DID      string `json:"did"`
+	Primary  bool   `json:"primary"`
+	LastPoll string `json:"last_poll"` // RFC3339; empty if never polled
+	LastSeen string `json:"last_seen"` // indexedAt of the newest processed item
+}
+
+// PollState returns the account's poll state as recorded in the kv store.
+func (p *Poller) PollState() PollState {
+	st := PollState{Handle: p.Client.Handle(), DID: p.Client.DID(), Primary: p.PrimaryDID == ""}
+	if st.Handle == "" {
+		st.Handle = p.Client.Identifier
+	}
+	st.LastPoll, _ = p.Store.GetKV(p.kvKey(kvLastPollKey))
+	if st.Primary {
+		st.LastSeen, _ = p.Store.GetKV(kvLastSeenKey)
+	} else {
+		st.LastSeen, _ = p.Store.GetKV(p.kvKey(kvTimelineLastSeenKey))
+	}
+	return st
+}
+
+// notePollError records a failed API call on the current cycle, and its
+// RetryAfter if it is a rate-limit error.
+func (p *Poller) notePollError(err error) {
+	p.pollFailed = true
+	var rl *ErrRateLimited
+	if errors.As(err, &rl) && rl.RetryAfter > p.pollRateLimit {
+		p.pollRateLimit = rl.RetryAfter
+	}
 }
 
 // maxPollPages caps how many pages (of 50) are fetched per poll cycle.
@@ -126,10 +318,10 @@ func (p *Poller) poll(ctx context.Context) {
 const maxPollPages = 10
 
 // pollNotifications fetches new Bluesky notifications (likes, reposts, replies,
-// mentions, follows) and converts them to Nostr events.
-// It paginates until all new notifications since lastSeen are collected,
-// so no items are dropped when more than 50 arrive between polls.
-func (p *Poller) pollNotifications(ctx context.Context) {
+// mentions, follows) and converts them to Nostr events, returning how many
+// were new. It paginates until all new notifications since lastSeen are
+// collected, so no items are dropped when more than 50 arrive between polls.
+func (p *Poller) pollNotifications(ctx context.Context) int {
 	lastSeen, _ := p.Store.GetKV(kvLastSeenKey)
 
 	// Collect all new notifications across pages (API returns newest-first).
@@ -139,7 +331,8 @@ func (p *Poller) pollNotifications(ctx context.Context) {
 		resp, err := p.Client.ListNotifications(ctx, cursor)
 		if err != nil {
 			slog.Warn("bsky poller: list notifications failed", "error", err)
-			return
+			p.notePollError(err)
+			return 0
 		}
 		if page == 0 {
 			// Record a successful poll on the first page, regardless of results.
@@ -169,7 +362,7 @@ func (p *Poller) pollNotifications(ctx context.Context) {
 	}
 
 	if len(allNew) == 0 {
-		return
+		return 0
 	}
 
 	// Process oldest-first (collected newest-first above, so reverse).
@@ -189,13 +382,15 @@ func (p *Poller) pollNotifications(ctx context.Context) {
 			slog.Warn("bsky poller: failed to save last-seen timestamp", "error", err)
 		}
 	}
+	return len(allNew)
 }
 
 // pollTimeline fetches posts from followed Bluesky accounts and bridges them
-// to Nostr kind-1 events, mirroring how Fediverse follows work via AP inbox.
-// It paginates until all new posts since lastSeen are collected.
-func (p *Poller) pollTimeline(ctx context.Context) {
-	lastSeen, _ := p.Store.GetKV(kvTimelineLastSeenKey)
+// to Nostr kind-1 events, mirroring how Fediverse follows work via AP inbox,
+// and returns how many posts were new. It paginates until all new posts
+// since lastSeen are collected.
+func (p *Poller) pollTimeline(ctx context.Context) int {
+	lastSeen, _ := p.Store.GetKV(p.kvKey(kvTimelineLastSeenKey))
 
 	var allNew []TimelineFeedPost
 	cursor := ""
@@ -203,7 +398,13 @@ func (p *Poller) pollTimeline(ctx context.Context) {
 		resp, err := p.Client.GetTimeline(ctx, cursor)
 		if err != nil {
 			slog.Warn("bsky poller: get timeline failed", "error", err)
-			return
+			p.notePollError(err)
+			return 0
+		}
+		if page == 0 && p.PrimaryDID != "" {
+			// Additional accounts don't poll notifications, which record
+			// the primary's successful polls.
+			_ = p.Store.SetKV(p.kvKey(kvLastPollKey), time.Now().UTC().Format(time.RFC3339))
 		}
 		if len(resp.Feed) == 0 {
 			break
@@ -228,49 +429,151 @@ func (p *Poller) pollTimeline(ctx context.Context) {
 	}
 
 	if len(allNew) == 0 {
-		return
+		return 0
 	}
 
 	// Process oldest-first.
 	slices.Reverse(allNew)
 
-	var newest string
+	// Replies deferred for lack of ancestor walks must come back next cycle,
+	// so lastSeen stops short of the first one; the posts bridged after it
+	// are skipped next time as already bridged.
+	var newest, deferredAt string
 	for i := range allNew {
 		item := &allNew[i]
+		p.pollDeferred = false
 		p.bridgeTimelinePost(ctx, item)
-		if item.Post.IndexedAt > newest {
+		if p.pollDeferred && deferredAt == "" {
+			deferredAt = item.Post.IndexedAt
+		}
+		if deferredAt == "" && item.Post.IndexedAt > newest {
 			newest = item.Post.IndexedAt
 		}
 	}
+	if deferredAt != "" && newest >= deferredAt {
+		newest = ""
+	}
 
 	if newest != "" {
-		_ = p.Store.SetKV(kvTimelineLastSeenKey, newest)
+		_ = p.Store.SetKV(p.kvKey(kvTimelineLastSeenKey), newest)
 	}
+	return len(allNew)
 }
 
 // bridgeTimelinePost converts a single timeline feed item into a Nostr kind-1
 // event signed with a derived key for the Bluesky author's DID.
 func (p *Poller) bridgeTimelinePost(ctx context.Context, item *TimelineFeedPost) {
 	// Skip the bridge account's own posts — they originate from Nostr.
-	if item.Post.Author.DID == p.Client.DID() {
+	if p.fromNostr(item.Post.Author.DID) {
+		return
+	}
+	if p.Exclusions.Excluded(item.Post.Author.DID) {
+		slog.Debug("bsky poller: skipping timeline post from excluded author", "author", item.Post.Author.Handle)
 		return
 	}
 
-	// Reposts (boosts) in the timeline are already bridged as kind-6 events
-	// via the notification poller when the local user's content is reposted,
-	// or are irrelevant third-party reposts. Skip them here.
+	// Reposts of the local user's content are bridged as kind-6 events via
+	// the notification poller. Third-party reposts are skipped unless
+	// TimelineReposts is set.
 	if item.Reason != nil && strings.HasSuffix(item.Reason.Type, "#reasonRepost") {
+		if p.TimelineReposts {
+			p.bridgeTimelineRepost(ctx, item)
+		}
+		return
+	}
+
+	record, _ := item.Post.Record.(map[string]interface{})
+	if _, isReply := record["reply"].(map[string]interface{}); isReply && !p.TimelineReplies && !p.followsReplyParent(item) {
+		slog.Debug("bsky poller: skipping timeline reply to unfollowed author", "uri", item.Post.URI)
+		return
+	}
+	if !p.TimelineQuotes {
+		if uri, _ := extractQuoteURI(record, item.Post.Embed); uri != "" {
+			slog.Debug("bsky poller: skipping timeline quote post", "uri", item.Post.URI)
+			return
+		}
+	}
+
+	// Skip near-identical copies of a note the local user just published,
+	// e.g. a mirror account re-posting it.
+	if bridge.IsEcho(extractContentFromRecord(record)) {
+		slog.Info("bsky poller: suppressed echoed post", "author", item.Post.Author.Handle, "uri", item.Post.URI)
+		return
+	}
+
+	p.bridgePost(ctx, &item.Post)
+}
+
+// followsReplyParent reports whether the parent of a timeline reply was
+// written by the local user, by the replying author themselves (a self
+// thread), or by an account the local user follows.
+func (p *Poller) followsReplyParent(item *TimelineFeedPost) bool {
+	if item.Reply == nil || item.Reply.Parent.Author == nil {
+		return false
+	}
+	parent := item.Reply.Parent.Author
+	return parent.DID == p.Client.DID() || p.fromNostr(parent.DID) || parent.DID == item.Post.Author.DID || parent.Viewer.Following != ""
+}
+
+// bridgeTimelineRepost bridges a repost from the timeline: the reposted post
+// is bridged first (if needed), then a kind-6 signed with the reposter's
+// derived key is published referencing it.
+func (p *Poller) bridgeTimelineRepost(ctx context.Context, item *TimelineFeedPost) {
+	by := item.Reason.By
+	if p.fromNostr(by.DID) || p.Exclusions.Excluded(by.DID) {
+		return
+	}
+	repostID := item.Reason.URI
+	if repostID == "" {
+		repostID = "repost:" + by.DID + ":" + item.Post.URI
+	}
+	if _, ok := p.Store.GetNostrIDForObject(repostID); ok {
 		return
 	}
 
 	p.bridgePost(ctx, &item.Post)
+	subjectID, ok := p.Store.GetNostrIDForObject(item.Post.URI)
+	if !ok {
+		return
+	}
+
+	event := &nostr.Event{
+		Kind:      6,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{{"e", subjectID, "", "mention"}, {"proxy", repostID, "atproto"}},
+	}
+	p.publishAuthorProfile(ctx, by.DID, by.Handle, by.DisplayName)
+	if err := p.Signer.Sign(event, by.DID); err != nil {
+		slog.Warn("bsky poller: sign repost failed", "author", by.Handle, "error", err)
+		return
+	}
+	if err := p.Publisher.Publish(ctx, event); err != nil {
+		slog.Warn("bsky poller: publish repost failed", "author", by.Handle, "error", err)
+		return
+	}
+	if err := p.Store.AddObject(repostID, event.ID); err != nil {
+		slog.Warn("bsky poller: store mapping failed", "uri", repostID, "error", err)
+	}
+	slog.Info("bsky poller: bridged repost", "author", by.Handle, "uri", item.Post.URI)
 }
 
 // bridgePost bridges a single Bluesky post to a Nostr kind-1 event.
 // If the post is a reply and its parent is not yet in the DB, it fetches the
-// full ancestor chain and bridges any missing posts first so the thread is
+// ancestor chain and bridges any missing posts first so the thread is
 // preserved in Nostr.
 func (p *Poller) bridgePost(ctx context.Context, post *TimelinePost) {
+	p.bridgeSinglePost(ctx, post, true, false)
+}
+
+// bridgeSinglePost bridges post, first bridging its missing ancestors when
+// fetchAncestors is set. A reply whose ancestors would need a walk after the
+// cycle used up MaxAncestorWalks is not bridged: pollDeferred is set so the
+// timeline poll retries it next cycle. partialThread marks the top of an
+// ancestor chain cut short by a cap or a loop: it is bridged without thread
+// context and links to its source, so the full thread stays reachable. A
+// reply left without a parent for other reasons (a deleted or blocked
+// parent, a failed fetch) is bridged without thread context as before.
+func (p *Poller) bridgeSinglePost(ctx context.Context, post *TimelinePost, fetchAncestors, partialThread bool) {
 	// Idempotency: skip if this AT URI is already in the DB.
 	if _, ok := p.Store.GetNostrIDForObject(post.URI); ok {
 		return
@@ -279,6 +582,19 @@ func (p *Poller) bridgePost(ctx context.Context, post *TimelinePost) {
 	record, _ := post.Record.(map[string]interface{})
 	content := extractContentFromRecord(record)
 
+	quoteURI, quoteView := extractQuoteURI(record, post.Embed)
+
+	contentWarning := contentWarningFromLabels(record, post.Labels, post.Author.Labels)
+	switch action, pattern := p.ContentFilter.Check(contentFilterText(content, record, quoteView)); action {
+	case bridge.FilterSkip:
+		slog.Info("bsky poller: skipped post matching a content filter", "uri", post.URI, "pattern", pattern)
+		return
+	case bridge.FilterWarn:
+		if contentWarning == "" {
+			contentWarning = "Filtered: " + pattern
+		}
+	}
+
 	// Parse the post's own createdAt; fall back to indexedAt.
 	var createdAt nostr.Timestamp
 	if ts, _ := record["createdAt"].(string); ts != "" {
@@ -299,21 +615,31 @@ func (p *Poller) bridgePost(ctx context.Context, post *TimelinePost) {
 	var replyToID, rootID string
 	if replyBlock, ok := record["reply"].(map[string]interface{}); ok {
 		replyToID, rootID = p.resolveReplyRefs(replyBlock)
-		if replyToID == "" {
-			// Parent not in DB — fetch the thread and bridge missing ancestors.
-			if parentMap, ok2 := replyBlock["parent"].(map[string]interface{}); ok2 {
-				if parentURI, _ := parentMap["uri"].(string); parentURI != "" {
-					p.ensureAncestorsBridged(ctx, parentURI)
-					replyToID, rootID = p.resolveReplyRefs(replyBlock)
-				}
+		parentURI := replyParentURI(replyBlock)
+		if replyToID == "" && fetchAncestors && parentURI != "" {
+			// Parent not in DB — fetch the thread and bridge missing ancestors,
+			// unless this cycle has used up its walks.
+			if p.pollAncestorWalks >= p.maxAncestorWalks() {
+				slog.Info("bsky poller: ancestor walks used up for this cycle, deferring reply",
+					"uri", post.URI, "walks", p.pollAncestorWalks)
+				p.pollDeferred = true
+				return
 			}
+			p.pollAncestorWalks++
+			p.ensureAncestorsBridged(ctx, parentURI)
+			replyToID, rootID = p.resolveReplyRefs(replyBlock)
 		}
 	}
 
-	// Quote post: resolve the quoted AT URI to a Nostr event ID.
+	// Quote post: resolve the quoted AT URI to a Nostr event ID, bridging the
+	// quoted post first if needed. If that fails, link to it instead.
 	var quoteEventID string
-	if uri := extractQuoteURI(record); uri != "" {
-		quoteEventID, _ = p.Store.GetNostrIDForObject(uri)
+	if quoteURI != "" {
+		var fallbackURL string
+		quoteEventID, fallbackURL = p.resolveQuote(ctx, quoteURI, quoteView)
+		if fallbackURL != "" {
+			content = strings.TrimRight(content, "\n") + "\n\n" + fallbackURL
+		}
 	}
 
 	np := bridge.NormalizedPost{
@@ -324,8 +650,9 @@ func (p *Poller) bridgePost(ctx context.Context, post *TimelinePost) {
 		RootEventID:    rootID,
 		QuoteEventID:   quoteEventID,
 		Hashtags:       extractHashtagsFromRecord(record),
+		ContentWarning: contentWarning,
 		SourceURL:      atURIToHTTPS(post.URI),
-		ShowSourceLink: p.ShowSourceLink.Load(),
+		ShowSourceLink: p.ShowSourceLink.Load() || partialThread,
 		ProxyID:        post.URI,
 		ProxyProtocol:  "atproto",
 	}
@@ -348,34 +675,169 @@ func (p *Poller) bridgePost(ctx context.Context, post *TimelinePost) {
 	slog.Info("bsky poller: bridged post", "author", post.Author.Handle, "uri", post.URI)
 }
 
-// ensureAncestorsBridged fetches the full ancestor chain for the given AT URI
-// via app.bsky.feed.getPostThread and bridges any posts that are not yet in the
-// DB, oldest-first, so each post can reference its parent's Nostr event ID.
-func (p *Poller) ensureAncestorsBridged(ctx context.Context, parentURI string) {
-	thread, err := p.Client.GetPostThread(ctx, parentURI)
+// BridgeURL bridges the Bluesky post at rawURL — an AT URI or a bsky.app post
+// URL — on demand, together with any unbridged ancestors, and returns its
+// Nostr event ID and the author's DID. Posts that are already bridged return
+// their existing event ID. Waits for a running poll cycle to finish.
+func (p *Poller) BridgeURL(ctx context.Context, rawURL string) (eventID, authorDID string, err error) {
+	repo, rkey, err := parsePostURL(rawURL)
 	if err != nil {
-		slog.Debug("bsky poller: could not fetch thread for ancestor bridging",
-			"uri", parentURI, "error", err)
-		return
+		return "", "", err
+	}
+	if !strings.HasPrefix(repo, "did:") {
+		profile, err := p.Client.GetProfile(ctx, repo)
+		if err != nil {
+			return "", "", fmt.Errorf("resolve handle %s: %w", repo, err)
+		}
+		repo = profile.DID
+	}
+	uri := "at://" + repo + "/" + feedPostType + "/" + rkey
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pollAncestorWalks = 0
+	if id, ok := p.Store.GetNostrIDForObject(uri); ok {
+		return id, repo, nil
+	}
+	resp, err := p.Client.GetPosts(ctx, []string{uri})
+	if err != nil {
+		return "", "", fmt.Errorf("fetch post: %w", err)
+	}
+	if len(resp.Posts) == 0 {
+		return "", "", fmt.Errorf("post not found: %s", uri)
+	}
+	p.bridgePost(ctx, &resp.Posts[0])
+	if id, ok := p.Store.GetNostrIDForObject(uri); ok {
+		return id, repo, nil
+	}
+	return "", "", fmt.Errorf("%s could not be bridged (see the log)", uri)
+}
+
+// defaultMaxAncestorDepth is the default Poller.MaxAncestorDepth.
+const defaultMaxAncestorDepth = 20
+
+// defaultMaxAncestorWalks is the default Poller.MaxAncestorWalks.
+const defaultMaxAncestorWalks = 10
+
+// maxAncestorWalks returns MaxAncestorWalks or its default.
+func (p *Poller) maxAncestorWalks() int {
+	if p.MaxAncestorWalks > 0 {
+		return p.MaxAncestorWalks
 	}
+	return defaultMaxAncestorWalks
+}
 
-	// Collect the ancestor chain: current node first, root last.
+// ensureAncestorsBridged bridges parentURI and its unbridged ancestors,
+// oldest-first, so each can thread to its own parent. The walk stops at the
+// first ancestor already bridged, at the thread root, at a deleted or blocked
+// post, on a loop, or after MaxAncestorDepth posts; in the last two cases the
+// topmost post bridged carries a link to its source (see bridgeSinglePost).
+// Ancestors are bridged without walking further themselves, so a deep thread
+// costs a bounded number of fetches instead of recursing.
+func (p *Poller) ensureAncestorsBridged(ctx context.Context, parentURI string) {
+	maxDepth := p.MaxAncestorDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxAncestorDepth
+	}
+
+	// Collect the ancestor chain: parent first, oldest last. Each thread
+	// fetch returns up to the remaining depth of ancestors; when the chain
+	// continues above the returned view, the walk resumes from its top.
 	const threadViewPost = "app.bsky.feed.defs#threadViewPost"
 	var chain []TimelinePost
-	for node := &thread.Thread; node != nil; node = node.Parent {
-		if node.Type == threadViewPost {
+	visited := make(map[string]bool)
+	cut := false
+	for uri := parentURI; uri != "" && len(chain) < maxDepth; {
+		thread, err := p.Client.GetPostThread(ctx, uri, maxDepth-len(chain))
+		if err != nil {
+			slog.Debug("bsky poller: could not fetch thread for ancestor bridging",
+				"uri", uri, "error", err)
+			break
+		}
+		uri = ""
+		for node := &thread.Thread; node != nil && node.Type == threadViewPost && len(chain) < maxDepth; node = node.Parent {
+			if visited[node.Post.URI] {
+				slog.Warn("bsky poller: reply loop in thread, stopping ancestor walk", "uri", node.Post.URI)
+				cut = true
+				break
+			}
+			if _, ok := p.Store.GetNostrIDForObject(node.Post.URI); ok {
+				break
+			}
+			visited[node.Post.URI] = true
 			chain = append(chain, node.Post)
+			if node.Parent == nil {
+				record, _ := node.Post.Record.(map[string]interface{})
+				replyBlock, _ := record["reply"].(map[string]interface{})
+				uri = replyParentURI(replyBlock)
+			}
 		}
 	}
+	if len(chain) == maxDepth {
+		cut = true
+		slog.Info("bsky poller: ancestor walk reached its depth cap, bridging a partial thread",
+			"uri", parentURI, "depth", maxDepth)
+	}
 
-	// Reverse to process oldest-first so each post can thread to its parent.
+	// Process oldest-first so each post can thread to its parent.
 	slices.Reverse(chain)
-
 	for i := range chain {
-		p.bridgePost(ctx, &chain[i])
+		p.bridgeSinglePost(ctx, &chain[i], false, cut && i == 0)
 	}
 }
 
+// replyParentURI returns the parent AT URI of a post record's reply block.
+func replyParentURI(replyBlock map[string]interface{}) string {
+	parent, _ := replyBlock["parent"].(map[string]interface{})
+	uri, _ := parent["uri"].(string)
+	return uri
+}
+
+// maxQuoteDepth caps how many nested quoted posts resolveQuote bridges.
+const maxQuoteDepth = 3
+
+// resolveQuote returns the Nostr event ID of the quoted post at uri, bridging
+// it first when it is not yet in the DB. view is the quoted post's hydrated
+// record from the embed, if available; otherwise the post is fetched via
+// app.bsky.feed.getPosts. When the quoted post cannot be bridged, the returned
+// fallbackURL is its bsky.app URL so the caller can link to it instead. Quote
+// chains (and quote loops) are bridged at most maxQuoteDepth posts deep.
+func (p *Poller) resolveQuote(ctx context.Context, uri string, view *TimelinePost) (eventID, fallbackURL string) {
+	if id, ok := p.Store.GetNostrIDForObject(uri); ok {
+		return id, ""
+	}
+	// Only posts can be bridged; quoted lists and feed generators are left as-is.
+	if CollectionFromURI(uri) != "app.bsky.feed.post" {
+		return "", ""
+	}
+	if p.quoteDepth >= maxQuoteDepth {
+		slog.Info("bsky poller: quote chain too deep, linking the quoted post", "uri", uri)
+		return "", atURIToHTTPS(uri)
+	}
+	if view == nil {
+		resp, err := p.Client.GetPosts(ctx, []string{uri})
+		if err != nil {
+			slog.Debug("bsky poller: could not fetch quoted post", "uri", uri, "error", err)
+		} else if len(resp.Posts) > 0 {
+			view = &resp.Posts[0]
+		}
+	}
+	// The bridge account's own posts originate from Nostr and are never
+	// re-bridged under a derived key.
+	if view != nil && !p.fromNostr(view.Author.DID) {
+		// A quoted reply deferred for lack of ancestor walks is linked
+		// instead; the quoting post itself is done and need not come back.
+		deferred := p.pollDeferred
+		p.quoteDepth++
+		p.bridgePost(ctx, view)
+		p.quoteDepth--
+		p.pollDeferred = deferred
+		if id, ok := p.Store.GetNostrIDForObject(uri); ok {
+			return id, ""
+		}
+	}
+	return "", atURIToHTTPS(uri)
+}
 
 // resolveReplyRefs looks up Nostr event IDs for the parent and root of a
 // Bluesky reply record. Returns empty strings if either is not bridged.
@@ -414,9 +876,10 @@ func (p *Poller) handleNotification(ctx context.Context, n *Notification) {
 				}
 			}
 		}
-		// Send a NIP-04 self-DM notification.
+		p.Notifier.Notify(notify.EventBskyFollower, map[string]string{"did": n.Author.DID, "handle": n.Author.Handle})
+		// Send a NIP-04 notification DM.
 		msg := "🔔 New Bluesky follower: @" + n.Author.Handle
-		dm, err := p.Signer.CreateDMToSelf(msg)
+		dm, err := p.Signer.CreateNotificationDM(msg)
 		if err != nil {
 			slog.Warn("bsky poller: create DM failed", "error", err)
 			return
@@ -433,7 +896,16 @@ func (p *Poller) handleNotification(ctx context.Context, n *Notification) {
 			return
 		}
 
-		event, err := NotificationToNostrEvent(n, p.LocalPubKey)
+		// The subject is one of our own posts, cross-posted by the Poster,
+		// which records the AT URI → Nostr event ID mapping when it posts.
+		subjectURI := notificationSubject(n)
+		subjectID, ok := p.Store.GetNostrIDForObject(subjectURI)
+		if !ok {
+			slog.Debug("bsky poller: subject not bridged, skipping", "reason", n.Reason, "subject", subjectURI)
+			return
+		}
+
+		event, err := NotificationToNostrEvent(n, subjectID, p.LocalPubKey)
 		if err != nil {
 			slog.Warn("bsky poller: transmute failed", "reason", n.Reason, "error", err)
 			return
@@ -510,10 +982,15 @@ func (p *Poller) bridgeReply(ctx context.Context, n *Notification) bool {
 	record, _ := n.Record.(map[string]interface{})
 	content := extractContentFromRecord(record)
 
-	// Quote post: resolve the quoted AT URI to a Nostr event ID.
+	// Quote post: resolve the quoted AT URI to a Nostr event ID, bridging the
+	// quoted post first if needed. If that fails, link to it instead.
 	var quoteEventID string
-	if uri := extractQuoteURI(record); uri != "" {
-		quoteEventID, _ = p.Store.GetNostrIDForObject(uri)
+	if uri, view := extractQuoteURI(record, nil); uri != "" {
+		var fallbackURL string
+		quoteEventID, fallbackURL = p.resolveQuote(ctx, uri, view)
+		if fallbackURL != "" {
+			content = strings.TrimRight(content, "\n") + "\n\n" + fallbackURL
+		}
 	}
 
 	np := bridge.NormalizedPost{
@@ -524,6 +1001,7 @@ func (p *Poller) bridgeReply(ctx context.Context, n *Notification) bool {
 		RootEventID:    rootNostrID,
 		QuoteEventID:   quoteEventID,
 		Hashtags:       extractHashtagsFromRecord(record),
+		ContentWarning: contentWarningFromLabels(record, n.Labels, n.Author.Labels),
 		SourceURL:      atURIToHTTPS(n.URI),
 		ShowSourceLink: p.ShowSourceLink.Load(),
 		ProxyID:        n.URI,
@@ -651,12 +1129,12 @@ func (p *Poller) publishBskyAuthorProfile(ctx context.Context, n *Notification)
 	p.publishAuthorProfile(ctx, n.Author.DID, n.Author.Handle, n.Author.DisplayName)
 }
 
-// sendDMNotification delivers a Bluesky interaction as a NIP-04 self-DM.
+// sendDMNotification delivers a Bluesky interaction as a NIP-04 notification DM.
 func (p *Poller) sendDMNotification(ctx context.Context, n *Notification) {
 	content := extractNotifText(n)
 	msg := fmt.Sprintf("💬 New Bluesky %s from @%s: %s\n%s",
 		n.Reason, n.Author.Handle, content, atURIToHTTPS(n.URI))
-	dm, err := p.Signer.CreateDMToSelf(msg)
+	dm, err := p.Signer.CreateNotificationDM(msg)
 	if err != nil {
 		slog.Warn("bsky poller: create DM failed", "reason", n.Reason, "error", err)
 		return
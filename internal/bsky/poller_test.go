@@ -0,0 +1,244 @@
+package bsky
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const testAuthorDID = "did:plc:author"
+
+// testPostURI returns the AT URI of test post n.
+func testPostURI(n int) string {
+	return "at://" + testAuthorDID + "/app.bsky.feed.post/p" + strconv.Itoa(n)
+}
+
+// testThreadPDS serves app.bsky.feed.getPostThread from parents, a map of
+// post URI to parent URI ("" for a thread root), and counts the calls.
+type testThreadPDS struct {
+	parents map[string]string
+	calls   atomic.Int32
+}
+
+func (s *testThreadPDS) post(uri string) TimelinePost {
+	record := map[string]interface{}{
+		"text":      "post " + uri,
+		"createdAt": "2026-01-01T00:00:00Z",
+	}
+	if parent := s.parents[uri]; parent != "" {
+		record["reply"] = map[string]interface{}{
+			"parent": map[string]interface{}{"uri": parent},
+			"root":   map[string]interface{}{"uri": parent},
+		}
+	}
+	return TimelinePost{
+		URI:       uri,
+		Author:    NotifAuthor{DID: testAuthorDID, Handle: "author.test"},
+		Record:    record,
+		IndexedAt: "2026-01-01T00:00:00Z",
+	}
+}
+
+func (s *testThreadPDS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/app.bsky.feed.getPostThread") {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"InvalidRequest"}`)
+		return
+	}
+	s.calls.Add(1)
+	height, _ := strconv.Atoi(r.URL.Query().Get("parentHeight"))
+	uri := r.URL.Query().Get("uri")
+	root := &ThreadViewPost{Type: "app.bsky.feed.defs#threadViewPost", Post: s.post(uri)}
+	node := root
+	for i := 0; i < height && s.parents[node.Post.URI] != ""; i++ {
+		node.Parent = &ThreadViewPost{Type: "app.bsky.feed.defs#threadViewPost", Post: s.post(s.parents[node.Post.URI])}
+		node = node.Parent
+	}
+	json.NewEncoder(w).Encode(GetPostThreadResponse{Thread: *root})
+}
+
+type testPollerStore struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (s *testPollerStore) AddObject(apID, nostrID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[apID] = nostrID
+	return nil
+}
+
+func (s *testPollerStore) GetNostrIDForObject(apID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.objects[apID]
+	return id, ok
+}
+
+func (s *testPollerStore) AddFollow(followerID, followedID string) error { return nil }
+func (s *testPollerStore) SetKV(key, value string) error                 { return nil }
+func (s *testPollerStore) GetKV(key string) (string, bool)               { return "", false }
+func (s *testPollerStore) GetChatRev(convoID string) (string, bool)      { return "", false }
+func (s *testPollerStore) SetChatRev(convoID, rev string) error          { return nil }
+func (s *testPollerStore) PruneChatRevs(before time.Time) (int64, error) { return 0, nil }
+
+type testPollerSigner struct{ n atomic.Int32 }
+
+func (s *testPollerSigner) SignAsUser(event *nostr.Event) error { return s.Sign(event, "") }
+func (s *testPollerSigner) Sign(event *nostr.Event, id string) error {
+	event.ID = fmt.Sprintf("%064d", s.n.Add(1))
+	return nil
+}
+func (s *testPollerSigner) CreateNotificationDM(message string) (*nostr.Event, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+type testPollerPublisher struct {
+	mu     sync.Mutex
+	events []*nostr.Event
+}
+
+func (p *testPollerPublisher) Publish(ctx context.Context, event *nostr.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// content returns the content of the published kind-1 whose proxy tag is uri.
+func (p *testPollerPublisher) content(uri string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ev := range p.events {
+		if tag := ev.Tags.GetFirst([]string{"proxy", uri}); ev.Kind == 1 && tag != nil {
+			return ev.Content, true
+		}
+	}
+	return "", false
+}
+
+func newTestThreadPoller(t *testing.T, parents map[string]string) (*Poller, *testThreadPDS, *testPollerPublisher) {
+	t.Helper()
+	pds := &testThreadPDS{parents: parents}
+	srv := httptest.NewServer(pds)
+	t.Cleanup(srv.Close)
+
+	client := NewClient("bridge.test", "")
+	client.PDSURL = srv.URL
+	client.session = &Session{DID: "did:plc:bridge", AccessJwt: "token"}
+	pub := &testPollerPublisher{}
+	p := &Poller{
+		Client:         client,
+		Publisher:      pub,
+		Signer:         &testPollerSigner{},
+		Store:          &testPollerStore{objects: make(map[string]string)},
+		ShowSourceLink: &atomic.Bool{},
+	}
+	return p, pds, pub
+}
+
+func TestEnsureAncestorsBridgedDepthCap(t *testing.T) {
+	// p0 ← p1 ← … ← p6, replying to the one before.
+	parents := map[string]string{testPostURI(0): ""}
+	for i := 1; i <= 6; i++ {
+		parents[testPostURI(i)] = testPostURI(i - 1)
+	}
+	p, pds, pub := newTestThreadPoller(t, parents)
+	p.MaxAncestorDepth = 3
+
+	post := pds.post(testPostURI(6))
+	p.bridgePost(context.Background(), &post)
+
+	for i := 0; i <= 6; i++ {
+		_, bridged := pub.content(testPostURI(i))
+		if want := i >= 3; bridged != want {
+			t.Errorf("p%d bridged = %v, want %v", i, bridged, want)
+		}
+	}
+	top, _ := pub.content(testPostURI(3))
+	if !strings.Contains(top, atURIToHTTPS(testPostURI(3))) {
+		t.Errorf("top of the cut chain has no source link: %q", top)
+	}
+	if mid, _ := pub.content(testPostURI(4)); strings.Contains(mid, atURIToHTTPS(testPostURI(4))) {
+		t.Errorf("post below the cut has a forced source link: %q", mid)
+	}
+}
+
+func TestEnsureAncestorsBridgedLoop(t *testing.T) {
+	// p1 and p2 reply to each other; p3 replies to p2.
+	parents := map[string]string{
+		testPostURI(1): testPostURI(2),
+		testPostURI(2): testPostURI(1),
+		testPostURI(3): testPostURI(2),
+	}
+	p, pds, pub := newTestThreadPoller(t, parents)
+
+	post := pds.post(testPostURI(3))
+	done := make(chan struct{})
+	go func() {
+		p.bridgePost(context.Background(), &post)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ancestor walk did not stop on a reply loop")
+	}
+
+	for i := 1; i <= 3; i++ {
+		if _, ok := pub.content(testPostURI(i)); !ok {
+			t.Errorf("p%d not bridged", i)
+		}
+	}
+	if top, _ := pub.content(testPostURI(1)); !strings.Contains(top, atURIToHTTPS(testPostURI(1))) {
+		t.Errorf("top of the looped chain has no source link: %q", top)
+	}
+	if n := pds.calls.Load(); n != 1 {
+		t.Errorf("getPostThread called %d times, want 1", n)
+	}
+}
+
+func TestBridgeSinglePostDefersWithoutWalks(t *testing.T) {
+	parents := map[string]string{
+		testPostURI(0): "",
+		testPostURI(1): testPostURI(0),
+		testPostURI(2): "",
+		testPostURI(3): testPostURI(2),
+	}
+	p, pds, pub := newTestThreadPoller(t, parents)
+	p.MaxAncestorWalks = 1
+
+	first, second := pds.post(testPostURI(1)), pds.post(testPostURI(3))
+	p.bridgePost(context.Background(), &first)
+	if p.pollDeferred {
+		t.Fatal("first reply deferred while a walk was left")
+	}
+	p.bridgePost(context.Background(), &second)
+	if !p.pollDeferred {
+		t.Error("second reply not flagged as deferred")
+	}
+	if _, ok := pub.content(testPostURI(3)); ok {
+		t.Error("reply bridged after the cycle used up its walks")
+	}
+
+	// The next cycle has its walks again and bridges it with its thread.
+	p.pollAncestorWalks, p.pollDeferred = 0, false
+	p.bridgePost(context.Background(), &second)
+	if _, ok := pub.content(testPostURI(2)); !ok {
+		t.Error("deferred reply's parent not bridged on the next cycle")
+	}
+	if _, ok := pub.content(testPostURI(3)); !ok {
+		t.Error("deferred reply not bridged on the next cycle")
+	}
+}
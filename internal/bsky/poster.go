@@ -2,11 +2,14 @@ package bsky
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/bridge"
 )
 
 // PosterStore is the subset of db.Store used by the Poster.
@@ -23,6 +26,16 @@ type Poster struct {
 	Store           PosterStore
 	LocalDomain     string
 	ExternalBaseURL string
+
+	// ReplyGate restricts who can reply to cross-posted top-level posts:
+	// "nobody" or a comma list of "mentioned", "following" and "followers".
+	// Empty (the default) leaves replies open.
+	ReplyGate string
+
+	// FollowersOnlyTag is the FOLLOWERS_ONLY_HASHTAG marker (without '#').
+	// Bluesky has no followers-only posts, so notes carrying it are not
+	// cross-posted. Empty disables the check.
+	FollowersOnlyTag string
 }
 
 // Handle processes a Nostr event and mirrors it to Bluesky when appropriate.
@@ -48,6 +61,10 @@ func (p *Poster) handleKind1(ctx context.Context, event *nostr.Event) {
 		slog.Debug("bsky: skipping already-bridged note", "id", event.ID)
 		return
 	}
+	if tag := strings.TrimPrefix(strings.TrimSpace(p.FollowersOnlyTag), "#"); tag != "" && bridge.HasHashtag(event, tag) {
+		slog.Debug("bsky: skipping followers-only note", "id", event.ID)
+		return
+	}
 
 	if err := p.postNote(ctx, event); err != nil {
 		slog.Warn("bsky: failed to post note", "id", event.ID, "error", err)
@@ -204,5 +221,34 @@ func (p *Poster) postNote(ctx context.Context, event *nostr.Event) error {
 	}
 
 	slog.Info("bsky: posted note", "nostrID", event.ID, "atURI", resp.URI)
-	return p.Store.AddObject(resp.URI, event.ID)
+	// The AT URI → Nostr ID mapping is what lets the Poller turn Bluesky likes
+	// and reposts of this post back into Nostr reactions and reposts.
+	if err := p.Store.AddObject(resp.URI, event.ID); err != nil {
+		return fmt.Errorf("store AT URI mapping for %s: %w", resp.URI, err)
+	}
+
+	// Threadgates only apply to thread roots.
+	if p.ReplyGate != "" && post.Reply == nil {
+		if err := p.Client.CreateThreadgate(ctx, resp.URI, threadgateRules(p.ReplyGate)); err != nil {
+			slog.Warn("bsky: failed to create threadgate", "atURI", resp.URI, "error", err)
+		}
+	}
+	return nil
+}
+
+// threadgateRules converts a ReplyGate setting into threadgate allow rules.
+// "nobody" yields an empty (non-nil) slice.
+func threadgateRules(gate string) []ThreadgateRule {
+	rules := []ThreadgateRule{}
+	for _, r := range strings.Split(gate, ",") {
+		switch strings.TrimSpace(r) {
+		case "mentioned":
+			rules = append(rules, ThreadgateRule{Type: "app.bsky.feed.threadgate#mentionRule"})
+		case "following":
+			rules = append(rules, ThreadgateRule{Type: "app.bsky.feed.threadgate#followingRule"})
+		case "followers":
+			rules = append(rules, ThreadgateRule{Type: "app.bsky.feed.threadgate#followerRule"})
+		}
+	}
+	return rules
 }
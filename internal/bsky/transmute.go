@@ -29,7 +29,10 @@ var (
 // NostrNoteToFeedPost converts a Nostr kind-1 event to a Bluesky FeedPost.
 // getATURI resolves a Nostr event ID to an AT URI for threading (may be nil).
 func NostrNoteToFeedPost(event *nostr.Event, externalBaseURL string, getATURI func(nostrID string) (string, bool)) (*FeedPost, error) {
-	text := event.Content
+	// Rewrite Nostr-only syntax (nostr: URIs, #[n] refs, LNURLs) first so the
+	// length check below sees the text that will actually be posted.
+	content := bridge.CleanForBluesky(event.Content, externalBaseURL)
+	text := content
 
 	// Truncate to 300 graphemes, appending an njump link if truncated.
 	var truncated bool
@@ -41,7 +44,7 @@ func NostrNoteToFeedPost(event *nostr.Event, externalBaseURL string, getATURI fu
 		if available < 0 {
 			available = 0
 		}
-		text, _ = truncateGraphemes(event.Content, available)
+		text, _ = truncateGraphemes(content, available)
 		text += suffix
 	}
 
@@ -197,31 +200,32 @@ func graphemeCount(s string) int {
 // ─── Bluesky → Nostr ─────────────────────────────────────────────────────────
 
 // NotificationToNostrEvent converts a Bluesky notification to a Nostr event.
+// subjectID is the Nostr event ID of the liked or reposted post (see
+// notificationSubject); the caller resolves it from the stored AT URI mapping.
 // Returns nil for notification types that don't map to Nostr events (e.g. "follow").
 // The returned event is unsigned; call SignAsUser before publishing.
-func NotificationToNostrEvent(n *Notification, localPubKey string) (*nostr.Event, error) {
+func NotificationToNostrEvent(n *Notification, subjectID, localPubKey string) (*nostr.Event, error) {
 	proxyTag := nostr.Tag{"proxy", n.URI, "atproto"}
 
 	switch n.Reason {
 	case "like":
 		// Bluesky like → Nostr kind-7 "+" reaction.
-		// We point the e-tag at the URI (used as a reference; nostr ID lookup is done by caller).
 		event := &nostr.Event{
 			Kind:      7,
 			Content:   "+",
 			CreatedAt: nostr.Now(),
-			Tags:      nostr.Tags{{"e", n.URI}, proxyTag},
+			Tags:      nostr.Tags{{"e", subjectID}, {"p", localPubKey}, proxyTag},
 			PubKey:    localPubKey,
 		}
 		return event, nil
 
 	case "repost":
-		// Bluesky repost → Nostr kind-6.
+		// Bluesky repost → Nostr kind-6 (NIP-18).
 		event := &nostr.Event{
 			Kind:      6,
 			Content:   "",
 			CreatedAt: nostr.Now(),
-			Tags:      nostr.Tags{{"e", n.URI, "", "mention"}, proxyTag},
+			Tags:      nostr.Tags{{"e", subjectID, "", "mention"}, {"p", localPubKey}, proxyTag},
 			PubKey:    localPubKey,
 		}
 		return event, nil
@@ -235,6 +239,25 @@ func NotificationToNostrEvent(n *Notification, localPubKey string) (*nostr.Event
 	}
 }
 
+// notificationSubject returns the AT URI of the post a like or repost
+// notification targets: the reasonSubject field, falling back to the record's
+// subject.uri.
+func notificationSubject(n *Notification) string {
+	if n.ReasonSubject != "" {
+		return n.ReasonSubject
+	}
+	m, ok := n.Record.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	subject, ok := m["subject"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	uri, _ := subject["uri"].(string)
+	return uri
+}
+
 // atURIToHTTPS converts an AT URI (at://did/collection/rkey) to a bsky.app URL.
 func atURIToHTTPS(uri string) string {
 	// at://did.plc.xxx/app.bsky.feed.post/rkey
@@ -252,6 +275,36 @@ func atURIToHTTPS(uri string) string {
 	return fmt.Sprintf("https://bsky.app/profile/%s/post/%s", did, rkey)
 }
 
+// parsePostURL splits a post reference — an AT URI or a bsky.app post URL —
+// into its repo (a DID or handle) and record key.
+func parsePostURL(raw string) (repo, rkey string, err error) {
+	raw = strings.TrimSpace(raw)
+	if rest, ok := strings.CutPrefix(raw, "at://"); ok {
+		parts := strings.Split(rest, "/")
+		if len(parts) == 3 && parts[1] == feedPostType && parts[0] != "" && parts[2] != "" {
+			return parts[0], parts[2], nil
+		}
+		return "", "", fmt.Errorf("not a post AT URI: %s", raw)
+	}
+	// https://bsky.app/profile/<did-or-handle>/post/<rkey>
+	for _, prefix := range []string{"https://bsky.app/profile/", "http://bsky.app/profile/"} {
+		if rest, ok := strings.CutPrefix(raw, prefix); ok {
+			parts := strings.Split(strings.TrimRight(rest, "/"), "/")
+			if len(parts) == 3 && parts[1] == "post" && parts[0] != "" && parts[2] != "" {
+				return parts[0], parts[2], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("not a Bluesky post URL: %s", raw)
+}
+
+// IsPostURL reports whether raw looks like a Bluesky post reference that
+// Poller.BridgeURL accepts.
+func IsPostURL(raw string) bool {
+	_, _, err := parsePostURL(raw)
+	return err == nil
+}
+
 // extractReplyRefs returns the parent and root AT URIs from a Bluesky reply
 // record's reply reference block (reply.parent.uri / reply.root.uri).
 // Returns empty strings if the record is not a reply or the fields are absent.
@@ -508,31 +561,178 @@ func extractHashtagsFromRecord(record map[string]interface{}) []string {
 	return tags
 }
 
+// ─── Labels → content warning (Bluesky → Nostr) ───────────────────────────────
+
+// labelWarnings maps the global Bluesky content labels to content-warning
+// text. Other label values (moderation actions, "!no-unauthenticated") are
+// not content warnings and are ignored.
+var labelWarnings = map[string]string{
+	"porn":          "Adult content",
+	"sexual":        "Sexually suggestive",
+	"nudity":        "Nudity",
+	"graphic-media": "Graphic media",
+	"gore":          "Graphic media",
+}
+
+// contentWarningFromLabels builds a content warning from the post record's
+// self-labels (com.atproto.label.defs#selfLabels) and the given view labels —
+// those on the post and the author's account-level labels. A negated view
+// label (Neg) cancels the label of the same value its labeler applied to the
+// same subject. Returns "" when none apply.
+func contentWarningFromLabels(record map[string]interface{}, viewLabels ...[]Label) string {
+	var vals []string
+	if labels, ok := record["labels"].(map[string]interface{}); ok {
+		values, _ := labels["values"].([]interface{})
+		for _, v := range values {
+			if m, ok := v.(map[string]interface{}); ok {
+				val, _ := m["val"].(string)
+				vals = append(vals, val)
+			}
+		}
+	}
+	type labelKey struct{ src, uri, val string }
+	negated := make(map[labelKey]bool)
+	for _, labels := range viewLabels {
+		for _, l := range labels {
+			if l.Neg {
+				negated[labelKey{l.Src, l.URI, l.Val}] = true
+			}
+		}
+	}
+	for _, labels := range viewLabels {
+		for _, l := range labels {
+			if !l.Neg && !negated[labelKey{l.Src, l.URI, l.Val}] {
+				vals = append(vals, l.Val)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var warnings []string
+	for _, val := range vals {
+		w, ok := labelWarnings[val]
+		if ok && !seen[w] {
+			seen[w] = true
+			warnings = append(warnings, w)
+		}
+	}
+	return strings.Join(warnings, ", ")
+}
+
+// contentFilterText returns the text a post is checked against the content
+// filter with: its content, the alt text of its images or video, and the
+// text and alt text of the quoted post when quote (its hydrated view, see
+// extractQuoteURI) is available.
+func contentFilterText(content string, record map[string]interface{}, quote *TimelinePost) string {
+	parts := []string{content}
+	parts = append(parts, embedAltTexts(record)...)
+	if quote != nil {
+		quoted, _ := quote.Record.(map[string]interface{})
+		parts = append(parts, extractContentFromRecord(quoted))
+		parts = append(parts, embedAltTexts(quoted)...)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// embedAltTexts returns the non-empty alt texts of a post record's images or
+// video, including the media of a recordWithMedia embed.
+func embedAltTexts(record map[string]interface{}) []string {
+	embed, _ := record["embed"].(map[string]interface{})
+	if t, _ := embed["$type"].(string); t == "app.bsky.embed.recordWithMedia" {
+		embed, _ = embed["media"].(map[string]interface{})
+	}
+	var alts []string
+	switch t, _ := embed["$type"].(string); t {
+	case "app.bsky.embed.images":
+		images, _ := embed["images"].([]interface{})
+		for _, img := range images {
+			image, _ := img.(map[string]interface{})
+			if alt, _ := image["alt"].(string); alt != "" {
+				alts = append(alts, alt)
+			}
+		}
+	case "app.bsky.embed.video":
+		if alt, _ := embed["alt"].(string); alt != "" {
+			alts = append(alts, alt)
+		}
+	}
+	return alts
+}
+
 // extractQuoteURI returns the AT URI of a quoted post from embed.record or
-// embed.recordWithMedia. Returns empty string if no quote embed is present.
-func extractQuoteURI(record map[string]interface{}) string {
+// embed.recordWithMedia, together with the quoted post's hydrated view taken
+// from embedView (a post's Embed field) when one is available. view is nil
+// when embedView is nil (e.g. notification records), or the quoted post is
+// missing, blocked or not a post. Returns an empty URI if no quote embed is
+// present.
+func extractQuoteURI(record map[string]interface{}, embedView interface{}) (uri string, view *TimelinePost) {
 	if record == nil {
-		return ""
+		return "", nil
 	}
 	embed, ok := record["embed"].(map[string]interface{})
 	if !ok {
-		return ""
+		return "", nil
 	}
 	switch embedType, _ := embed["$type"].(string); embedType {
 	case "app.bsky.embed.record":
 		if rec, ok := embed["record"].(map[string]interface{}); ok {
-			uri, _ := rec["uri"].(string)
-			return uri
+			uri, _ = rec["uri"].(string)
 		}
 	case "app.bsky.embed.recordWithMedia":
 		if rec, ok := embed["record"].(map[string]interface{}); ok {
 			if inner, ok := rec["record"].(map[string]interface{}); ok {
-				uri, _ := inner["uri"].(string)
-				return uri
+				uri, _ = inner["uri"].(string)
 			}
 		}
 	}
-	return ""
+	if uri == "" {
+		return "", nil
+	}
+	return uri, quotedViewRecord(embedView, uri)
+}
+
+// quotedViewRecord extracts the app.bsky.embed.record#viewRecord for uri from
+// a hydrated embed view (#view or recordWithMedia#view) and converts it to a
+// TimelinePost so it can be bridged like any other post.
+func quotedViewRecord(embedView interface{}, uri string) *TimelinePost {
+	ev, ok := embedView.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	rec, _ := ev["record"].(map[string]interface{})
+	if t, _ := ev["$type"].(string); t == "app.bsky.embed.recordWithMedia#view" {
+		rec, _ = rec["record"].(map[string]interface{})
+	}
+	if rec == nil {
+		return nil
+	}
+	if t, _ := rec["$type"].(string); t != "app.bsky.embed.record#viewRecord" {
+		return nil
+	}
+	if u, _ := rec["uri"].(string); u != uri {
+		return nil
+	}
+	value, ok := rec["value"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	post := &TimelinePost{URI: uri, Record: value}
+	post.CID, _ = rec["cid"].(string)
+	post.IndexedAt, _ = rec["indexedAt"].(string)
+	if author, ok := rec["author"].(map[string]interface{}); ok {
+		post.Author.DID, _ = author["did"].(string)
+		post.Author.Handle, _ = author["handle"].(string)
+		post.Author.DisplayName, _ = author["displayName"].(string)
+	}
+	if post.Author.DID == "" {
+		return nil
+	}
+	// viewRecord carries hydrated embeds as a list; keep the first so nested
+	// quotes can still be resolved without an extra fetch.
+	if embeds, ok := rec["embeds"].([]interface{}); ok && len(embeds) > 0 {
+		post.Embed = embeds[0]
+	}
+	return post
 }
 
 // RKeyFromURI extracts the rkey from an AT URI (at://did/collection/rkey).
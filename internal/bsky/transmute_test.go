@@ -0,0 +1,58 @@
+package bsky
+
+import "testing"
+
+func TestContentWarningFromLabels(t *testing.T) {
+	const (
+		post    = "at://did:plc:author/app.bsky.feed.post/p1"
+		labeler = "did:plc:labeler"
+	)
+	selfLabeled := map[string]interface{}{
+		"labels": map[string]interface{}{
+			"$type":  "com.atproto.label.defs#selfLabels",
+			"values": []interface{}{map[string]interface{}{"val": "nudity"}},
+		},
+	}
+	tests := []struct {
+		name   string
+		record map[string]interface{}
+		labels []Label
+		want   string
+	}{
+		{"none", nil, nil, ""},
+		{"self-label", selfLabeled, nil, "Nudity"},
+		{"view label", nil, []Label{{Src: labeler, URI: post, Val: "porn"}}, "Adult content"},
+		{"not a warning", nil, []Label{{Src: labeler, URI: post, Val: "!hide"}}, ""},
+		{"duplicate warnings", nil, []Label{
+			{Src: labeler, URI: post, Val: "gore"},
+			{Src: labeler, URI: post, Val: "graphic-media"},
+		}, "Graphic media"},
+		{"negated", nil, []Label{
+			{Src: labeler, URI: post, Val: "porn"},
+			{Src: labeler, URI: post, Val: "porn", Neg: true},
+		}, ""},
+		{"negation before the label", nil, []Label{
+			{Src: labeler, URI: post, Val: "porn", Neg: true},
+			{Src: labeler, URI: post, Val: "porn"},
+		}, ""},
+		{"negated by another labeler", nil, []Label{
+			{Src: labeler, URI: post, Val: "porn"},
+			{Src: "did:plc:other", URI: post, Val: "porn", Neg: true},
+		}, "Adult content"},
+		{"other value negated", nil, []Label{
+			{Src: labeler, URI: post, Val: "porn"},
+			{Src: labeler, URI: post, Val: "sexual", Neg: true},
+		}, "Adult content"},
+		{"self-label kept", selfLabeled, []Label{
+			{Src: labeler, URI: post, Val: "sexual"},
+			{Src: labeler, URI: post, Val: "sexual", Neg: true},
+		}, "Nudity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentWarningFromLabels(tt.record, tt.labels); got != tt.want {
+				t.Errorf("contentWarningFromLabels = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ type CreateSessionInput struct {
 type CreateRecordRequest struct {
 	Repo       string      `json:"repo"`
 	Collection string      `json:"collection"`
+	RKey       string      `json:"rkey,omitempty"`
 	Record     interface{} `json:"record"`
 }
 
@@ -104,6 +105,26 @@ type RepostRecord struct {
 	CreatedAt string `json:"createdAt"`
 }
 
+// ─── Threadgate record (app.bsky.feed.threadgate) ─────────────────────────────
+
+// Threadgate is the lexicon record for app.bsky.feed.threadgate. It must be
+// created with the same rkey as the post it gates. An empty Allow list means
+// nobody can reply.
+type Threadgate struct {
+	Type      string           `json:"$type"`
+	Post      string           `json:"post"`
+	Allow     []ThreadgateRule `json:"allow"`
+	CreatedAt string           `json:"createdAt"`
+}
+
+// ThreadgateRule is one allow rule of a threadgate. The $type field selects the variant:
+//   - app.bsky.feed.threadgate#mentionRule   → users mentioned in the post
+//   - app.bsky.feed.threadgate#followingRule → users the author follows
+//   - app.bsky.feed.threadgate#followerRule  → users following the author
+type ThreadgateRule struct {
+	Type string `json:"$type"`
+}
+
 // ─── Notifications ────────────────────────────────────────────────────────────
 
 // Notification is a single entry from app.bsky.notification.listNotifications.
@@ -116,13 +137,27 @@ type Notification struct {
 	Record    interface{} `json:"record"`
 	IsRead    bool        `json:"isRead"`
 	IndexedAt string      `json:"indexedAt"`
+	// ReasonSubject is the AT URI of the post a like or repost targets.
+	ReasonSubject string  `json:"reasonSubject,omitempty"`
+	Labels        []Label `json:"labels,omitempty"`
 }
 
 // NotifAuthor holds basic author info for a notification.
+// Labels are account-level labels (self-applied or from a labeler).
 type NotifAuthor struct {
-	DID         string `json:"did"`
-	Handle      string `json:"handle"`
-	DisplayName string `json:"displayName"`
+	DID         string  `json:"did"`
+	Handle      string  `json:"handle"`
+	DisplayName string  `json:"displayName"`
+	Labels      []Label `json:"labels,omitempty"`
+}
+
+// Label is a com.atproto.label.defs#label attached to a post or account
+// view. Neg marks a label that negates an earlier one.
+type Label struct {
+	Src string `json:"src"`
+	URI string `json:"uri"`
+	Val string `json:"val"`
+	Neg bool   `json:"neg,omitempty"`
 }
 
 // ListNotificationsResponse is returned by app.bsky.notification.listNotifications.
@@ -135,18 +170,42 @@ type ListNotificationsResponse struct {
 
 // TimelineFeedPost is one entry in the timeline feed response.
 // Reason is non-nil when the post appears because someone the user follows
-// reposted it (app.bsky.feed.defs#reasonRepost).
+// reposted it (app.bsky.feed.defs#reasonRepost). Reply is non-nil for replies
+// and carries the hydrated parent post.
 type TimelineFeedPost struct {
-	Post   TimelinePost `json:"post"`
-	Reason *FeedReason  `json:"reason,omitempty"`
+	Post   TimelinePost  `json:"post"`
+	Reason *FeedReason   `json:"reason,omitempty"`
+	Reply  *FeedReplyRef `json:"reply,omitempty"`
+}
+
+// FeedReplyRef is the reply context of a timeline entry. Parent.Author is nil
+// when the parent is not a regular post view (notFoundPost, blockedPost).
+type FeedReplyRef struct {
+	Parent struct {
+		Author *ViewerAuthor `json:"author,omitempty"`
+	} `json:"parent"`
+}
+
+// ViewerAuthor is an author view including the authenticated user's
+// relationship to them. Viewer.Following is the AT URI of the user's follow
+// record, empty when the user does not follow the author.
+type ViewerAuthor struct {
+	DID    string `json:"did"`
+	Viewer struct {
+		Following string `json:"following,omitempty"`
+	} `json:"viewer"`
 }
 
 // TimelinePost holds the core post data within a timeline feed item.
+// Embed is the hydrated embed view (e.g. app.bsky.embed.record#view), which
+// carries the quoted post's content alongside the raw record's strong ref.
 type TimelinePost struct {
 	URI       string      `json:"uri"`
 	CID       string      `json:"cid"`
 	Author    NotifAuthor `json:"author"`
 	Record    interface{} `json:"record"`
+	Embed     interface{} `json:"embed,omitempty"`
+	Labels    []Label     `json:"labels,omitempty"`
 	IndexedAt string      `json:"indexedAt"`
 }
 
@@ -155,6 +214,7 @@ type TimelinePost struct {
 type FeedReason struct {
 	Type string      `json:"$type"`
 	By   NotifAuthor `json:"by"`
+	URI  string      `json:"uri,omitempty"` // AT URI of the repost record, when provided
 }
 
 // GetTimelineResponse is returned by app.bsky.feed.getTimeline.
@@ -180,6 +240,11 @@ type GetPostThreadResponse struct {
 	Thread ThreadViewPost `json:"thread"`
 }
 
+// GetPostsResponse is returned by app.bsky.feed.getPosts.
+type GetPostsResponse struct {
+	Posts []TimelinePost `json:"posts"`
+}
+
 // ─── Profile ──────────────────────────────────────────────────────────────────
 
 // Profile is returned by app.bsky.actor.getProfile.
@@ -191,3 +256,75 @@ type Profile struct {
 	Avatar      string `json:"avatar"`
 	Banner      string `json:"banner"`
 }
+
+// ─── Lists and starter packs ──────────────────────────────────────────────────
+
+// ListView is the list summary embedded in list and starter-pack responses.
+type ListView struct {
+	URI           string `json:"uri"`
+	Name          string `json:"name"`
+	ListItemCount int    `json:"listItemCount"`
+}
+
+// ListItem is one member of a list returned by app.bsky.graph.getList.
+type ListItem struct {
+	URI     string  `json:"uri"`
+	Subject Profile `json:"subject"`
+}
+
+// GetListResponse is returned by app.bsky.graph.getList.
+type GetListResponse struct {
+	Cursor string     `json:"cursor"`
+	List   ListView   `json:"list"`
+	Items  []ListItem `json:"items"`
+}
+
+// GetStarterPackResponse is returned by app.bsky.graph.getStarterPack. List
+// is the curate list holding the pack's members.
+type GetStarterPackResponse struct {
+	StarterPack struct {
+		URI    string `json:"uri"`
+		Record struct {
+			Name string `json:"name"`
+		} `json:"record"`
+		List *ListView `json:"list"`
+	} `json:"starterPack"`
+}
+
+// ─── Chat ─────────────────────────────────────────────────────────────────────
+
+// ChatMessage is a chat.bsky.convo.defs#messageView, or a #deletedMessageView
+// (Type set, Text empty).
+type ChatMessage struct {
+	Type   string `json:"$type"`
+	ID     string `json:"id"`
+	Rev    string `json:"rev"`
+	Text   string `json:"text"`
+	Sender struct {
+		DID string `json:"did"`
+	} `json:"sender"`
+	SentAt string `json:"sentAt"`
+}
+
+// ChatConvo is a chat.bsky.convo.defs#convoView. Rev advances with every
+// message in the conversation.
+type ChatConvo struct {
+	ID      string    `json:"id"`
+	Rev     string    `json:"rev"`
+	Members []Profile `json:"members"`
+	Muted   bool      `json:"muted"`
+}
+
+// ListConvosResponse is returned by chat.bsky.convo.listConvos, most
+// recently active first.
+type ListConvosResponse struct {
+	Cursor string      `json:"cursor"`
+	Convos []ChatConvo `json:"convos"`
+}
+
+// GetMessagesResponse is returned by chat.bsky.convo.getMessages, newest
+// first.
+type GetMessagesResponse struct {
+	Cursor   string        `json:"cursor"`
+	Messages []ChatMessage `json:"messages"`
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -27,24 +29,115 @@ type Config struct {
 	DatabaseURL       string
 	RSAPrivateKeyPath string
 	RSAPublicKeyPath  string
+	Ed25519PrivateKeyPath string // ED25519_PRIVATE_KEY_PATH — optional Ed25519 key (generated if missing) advertised in assertionMethod and used for servers that reject RSA signatures (default: none = RSA only)
 	SignFetch         bool
+	SignedFetch       bool // SIGNED_FETCH — sign every outbound AP GET, not only retries after a 401 (default false)
 	ExternalBaseURL   string
-	ZapPubkey         string
-	ZapSplit          float64
+	ZapPubkey         string  // ZAP_PUBKEY — reserved; stored but not applied to LNURL-pay invoices
+	ZapSplit          float64 // ZAP_SPLIT — reserved; stored but not applied to LNURL-pay invoices
+	LightningAddress  string // LIGHTNING_ADDRESS env var — Lightning address (name@host) served via /.well-known/lnurlp (default: none = disabled)
 	Port              string
 	BskyIdentifier    string // BSKY_IDENTIFIER env var (handle or DID)
 	BskyAppPassword   string // BSKY_APP_PASSWORD env var
 	BskyPDSURL        string // BSKY_PDS_URL env var — PDS endpoint (default: https://bsky.social); set for third-party PDS / did:web accounts
+	BskyExtraAccounts []BskyAccount // BSKY_EXTRA_ACCOUNTS env var — further accounts whose home timelines are bridged (comma-separated identifier:app-password)
 	BskyBridgeTimeline bool  // BSKY_BRIDGE_TIMELINE env var — bridge followed accounts' timeline posts to Nostr (default: true)
+	BskyTimelineReplies bool // BSKY_TIMELINE_REPLIES env var — bridge timeline replies to accounts you don't follow (default: true)
+	BskyTimelineReposts bool // BSKY_TIMELINE_REPOSTS env var — bridge timeline reposts as kind-6 events (default: false)
+	BskyTimelineQuotes  bool // BSKY_TIMELINE_QUOTES env var — bridge timeline quote posts (default: true)
+	BskyBridgeChat      bool // BSKY_BRIDGE_CHAT env var — deliver Bluesky direct messages as notification DMs; needs an app password with DM access (default: false)
+	BskyReplyGate     string // BSKY_REPLY_GATE env var — who may reply to cross-posted Bluesky posts: "nobody" or a comma list of mentioned,following,followers (default: "" = everybody)
 	WebAdminPassword  string // WEB_ADMIN env var — enables /web admin UI when set
 	ShowSourceLink    bool   // SHOW_SOURCE_LINK env var — append original post URL to bridged notes
+	LongNoteThreshold int    // LONG_NOTE_THRESHOLD env var — kind-1 length above which LONG_NOTE_MODE applies (default: 0 = disabled)
+	LongNoteMode      string // LONG_NOTE_MODE env var — "article" (send as AP Article) or "truncate" (default: article)
+	ContentFormat     string // CONTENT_FORMAT env var — "plain" or "markdown" rendering of inbound AP post HTML (default: plain)
+	ReplyReactions    bool   // REPLY_REACTIONS env var — bridge emoji-only AP replies (Misskey-style reactions) as Nostr kind-7 reactions (default: false)
+	ThreadMaxDepth    int    // THREAD_MAX_DEPTH env var — max unbridged ancestors fetched when bridging a Fediverse or Bluesky reply (default: 20)
+	ThreadMaxBreadth  int    // THREAD_MAX_BREADTH env var — max Bluesky replies per poll cycle whose unbridged ancestors are fetched; later ones wait for the next cycle (default: 10)
+	NoteEditMode      string // NOTE_EDIT_MODE env var — "replace", "reply" or "off": how edits of bridged AP notes reach Nostr (default: replace)
+	NostrStatus       string // NOSTR_STATUS env var — "summary", "field" or "off": where the user's NIP-38 status (kind 30315) appears on the AP actor (default: off)
+	ProfileZaps       string // PROFILE_ZAPS env var — "zap", "note" or "off": how zaps of the user's profile (not a note) are federated (default: off)
+	NIP89Handler      bool   // NIP89_HANDLER env var — publish NIP-89 handler information (kind 31990) for the bridge at startup (default: false)
+	FollowMinFollowers  int           // FOLLOW_MIN_FOLLOWERS env var — hold/reject inbound follows from actors with fewer followers (default: 0 = disabled)
+	FollowMaxRatio      float64       // FOLLOW_MAX_RATIO env var — hold/reject inbound follows from actors whose following/followers ratio exceeds this (default: 0 = disabled)
+	FollowMinAccountAge time.Duration // FOLLOW_MIN_ACCOUNT_AGE env var — hold/reject inbound follows from accounts younger than this (default: 0 = disabled)
+	FollowFilterAction  string        // FOLLOW_FILTER_ACTION env var — "hold" (manual approval in /web) or "reject" for follows failing the filter (default: hold)
+	ActorDiscoverable bool   // ACTOR_DISCOVERABLE env var — list the bridged actor in Fediverse profile directories and suggestions (default: true)
+	ActorIndexable    bool   // ACTOR_INDEXABLE env var — allow Fediverse full-text search of bridged posts (default: true)
+	BridgeLocation    bool   // BRIDGE_LOCATION env var — round-trip post locations between AP Place objects and Nostr location/g tags (default: false)
+	UnlistedTag       string // UNLISTED_HASHTAG env var — hashtag marking outbound notes as unlisted on the Fediverse (default: "" = disabled)
+	FollowersOnlyTag  string // FOLLOWERS_ONLY_HASHTAG env var — hashtag marking outbound notes as followers-only on the Fediverse (default: "" = disabled)
+	SensitiveCW       string // SENSITIVE_CW_TEXT env var — content warning for inbound AP posts marked sensitive without a summary (default: "Sensitive content")
+	WebhookURL        string // WEBHOOK_URL env var — POST JSON bridge events (followers, follow outcomes, moves, publish failures) here (default: none = disabled)
+	WebhookSecret     string // WEBHOOK_SECRET env var — HMAC-SHA256 key for the X-Klistr-Signature header (default: none = unsigned)
+	NotificationPubkey string   // NOTIFICATION_PUBKEY env var — hex or npub that receives bridge notification DMs (default: own pubkey)
+	DMFormat           string   // DM_FORMAT env var — "nip04" (kind-4) or "nip17" (gift-wrapped kind-14) notification DMs (default: nip04)
+	Delegation         bool     // DELEGATION env var — bridged remote-author events of regular kinds, kind-1 included, carry a NIP-26 delegation tag from the local user; NIP-26 clients then show every bridged post as the local user's (default: false = raw derived keys)
+	FeaturedPosts      []string // FEATURED_POSTS env var — comma-separated event IDs (hex, note1 or nevent1) pinned to the AP profile (default: none = use the kind-10001 pin list)
 
 	// Tunable performance constants (all have sensible defaults; rarely need changing).
 	ResyncInterval          time.Duration // RESYNC_INTERVAL — how often AP actor profiles are re-fetched (default 24h)
+	ResyncConcurrency       int           // RESYNC_CONCURRENCY — AP actors re-fetched at once by the profile resync (default 4)
+	FollowReconcileInterval time.Duration // FOLLOW_RECONCILE_INTERVAL — how often kind-3 is reconciled with bridged follows (default 0 = disabled)
 	APCacheTTL              time.Duration // AP_CACHE_TTL — TTL for the AP object / WebFinger caches (default 1h)
+	APCacheMaxEntries       int           // AP_CACHE_MAX_ENTRIES — max entries in each of the AP object / WebFinger caches, least recently used evicted first (default 10000)
 	BskyPollInterval        time.Duration // BSKY_POLL_INTERVAL — how often the Bluesky notification poller runs (default 30s)
+	BskyPollMaxInterval     time.Duration // BSKY_POLL_MAX_INTERVAL — how far the poll interval widens while polls come back empty (default 5m)
+	BskyPollJitter          float64       // BSKY_POLL_JITTER — random spread of each poll wait, as a fraction of the interval (default 0.1)
 	APFederationConcurrency int           // AP_FEDERATION_CONCURRENCY — max concurrent outbound AP HTTP requests (default 10)
+	FederationTimeout       time.Duration // FEDERATION_TIMEOUT — per-delivery timeout for outbound AP activities (default 10s)
+	FederationCBThreshold   int           // FEDERATION_CB_THRESHOLD — consecutive delivery failures before a destination host is skipped (default 5)
+	FederationCBCooldown    time.Duration // FEDERATION_CB_COOLDOWN — how long a failing destination host is skipped (default 10m)
 	RelayCBThreshold        int           // RELAY_CB_THRESHOLD — consecutive publish failures before circuit opens (default 3)
+	RelayCBMaxCooldown      time.Duration // RELAY_CB_MAX_COOLDOWN — cap on the doubling cooldown of a repeatedly failing relay (default 1h)
+	RelayMaxConnections     int           // RELAY_MAX_CONNECTIONS — max read relays subscribed at once; 0 = unlimited (default 0)
+	RelayIdleTimeout        time.Duration // RELAY_IDLE_TIMEOUT — recycle a read relay connection after this long without events; 0 = never (default 1h)
+	RelaySubscriptionKinds  []int         // RELAY_SUBSCRIPTION_KINDS — kinds subscribed from the local user; nil = the RelayPool default
+	RelayMentionKinds       []int         // RELAY_MENTION_KINDS — kinds by other authors that p-tag a local user to subscribe to; reported to the webhook (default none)
+	RelayHintDynamic        bool          // RELAY_HINT_DYNAMIC — use the healthiest write relay as the relay hint in e/q tags instead of the first configured one (default true)
+	PublishTimeout          time.Duration // PUBLISH_TIMEOUT — per-relay timeout for publishing an event (default 15s)
+	PublishConcurrency      int           // PUBLISH_CONCURRENCY — max relays published to at once; 0 = all (default 10)
+	PublishQuorum           int           // PUBLISH_QUORUM — relay acknowledgements to wait for before Publish returns (default 1)
+	EchoTTL                 time.Duration // ECHO_TTL — how long hashes of the user's outgoing notes are kept for echo suppression; 0 = disabled (default 10m)
+	MaxNoteLength           int           // MAX_NOTE_LENGTH — inbound post text length above which bridged kind-1s are truncated with a source link; 0 = disabled (default 0)
+	BulkBatchSize           int           // BULK_BATCH_SIZE — follows read from the DB per page by bulk follow operations (default 500)
+	BulkConcurrency         int           // BULK_CONCURRENCY — follows processed at once by bulk follow operations (default 8)
+	ShutdownGracePeriod     time.Duration // SHUTDOWN_GRACE_PERIOD — how long shutdown waits for in-flight inbox activities (default 20s)
+	KeyRotationGrace        time.Duration // KEY_ROTATION_GRACE — how long a rotated-out RSA key stays advertised in assertionMethod (default 168h)
+	Kind3MaxShrink          float64       // KIND3_MAX_SHRINK — largest fraction of the contact list a kind-3 merge may drop without being forced; 1 = no limit (default 0.1)
+
+	// OutboundHeaders are extra HTTP headers added to every outbound AP request.
+	// OUTBOUND_HEADERS — "|"-separated "Name: value" pairs (default: none).
+	OutboundHeaders map[string]string
+
+	// UserAgent overrides the User-Agent of outbound AP, Bluesky and webhook
+	// requests. USER_AGENT
+	// (default: klistr/1.0 naming LOCAL_DOMAIN and OPERATOR_EMAIL).
+	UserAgent string
+	// OperatorEmail is a contact address sent as the From header on outbound
+	// AP requests so remote admins can reach the operator. OPERATOR_EMAIL
+	// (default: none).
+	OperatorEmail string
+	// TrustedProxies are the networks whose X-Forwarded-For and X-Real-IP
+	// headers are believed when determining a request's client address.
+	// TRUSTED_PROXIES — comma-separated CIDRs or IPs (default: loopback only).
+	TrustedProxies []netip.Prefix
+	// HashtagFollows are the Fediverse hashtags (lowercase, without '#') whose
+	// public posts are bridged. HASHTAG_FOLLOWS — comma-separated (default:
+	// none = disabled).
+	HashtagFollows []string
+	// HashtagServers are the hosts whose public tag timelines are polled for
+	// HashtagFollows. HASHTAG_SERVERS — comma-separated hosts or URLs
+	// (default: mastodon.social).
+	HashtagServers []string
+	// HashtagPollInterval is how often the tag timelines are polled.
+	// HASHTAG_POLL_INTERVAL (default: 10m).
+	HashtagPollInterval time.Duration
+	// AdminCORSOrigins are the origins (lowercase scheme://host[:port]) allowed
+	// to call the /web admin API cross-origin. ADMIN_CORS_ORIGINS —
+	// comma-separated (default: none = same-origin only).
+	AdminCORSOrigins []string
 }
 
 // BskyEnabled returns true if Bluesky bridge credentials are configured.
@@ -91,6 +184,50 @@ func Load() *Config {
 		displayName = username
 	}
 
+	notifyPubKey := pubKey
+	if v := strings.TrimSpace(os.Getenv("NOTIFICATION_PUBKEY")); v != "" {
+		notifyPubKey, err = parsePubkey(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: invalid NOTIFICATION_PUBKEY: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	featuredPosts, err := parseEventIDs(os.Getenv("FEATURED_POSTS"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid FEATURED_POSTS: %v\n", err)
+		os.Exit(1)
+	}
+
+	bskyExtraAccounts, err := parseBskyAccounts(os.Getenv("BSKY_EXTRA_ACCOUNTS"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid BSKY_EXTRA_ACCOUNTS: %v\n", err)
+		os.Exit(1)
+	}
+
+	subscriptionKinds, err := parseKinds(os.Getenv("RELAY_SUBSCRIPTION_KINDS"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid RELAY_SUBSCRIPTION_KINDS: %v\n", err)
+		os.Exit(1)
+	}
+	mentionKinds, err := parseKinds(os.Getenv("RELAY_MENTION_KINDS"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid RELAY_MENTION_KINDS: %v\n", err)
+		os.Exit(1)
+	}
+
+	trustedProxies, err := parseTrustedProxies(getEnv("TRUSTED_PROXIES", "127.0.0.0/8,::1/128"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid TRUSTED_PROXIES: %v\n", err)
+		os.Exit(1)
+	}
+
+	replyGate, err := parseReplyGate(os.Getenv("BSKY_REPLY_GATE"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid BSKY_REPLY_GATE: %v\n", err)
+		os.Exit(1)
+	}
+
 	nostrRelays := parseRelays(os.Getenv("NOSTR_RELAY"))
 	if len(nostrRelays) == 0 {
 		nostrRelays = []string{"wss://relay.mostr.pub"}
@@ -110,24 +247,220 @@ func Load() *Config {
 		DatabaseURL:       getEnv("DATABASE_URL", "klistr.db"),
 		RSAPrivateKeyPath: getEnv("RSA_PRIVATE_KEY_PATH", "private.pem"),
 		RSAPublicKeyPath:  getEnv("RSA_PUBLIC_KEY_PATH", "public.pem"),
+		Ed25519PrivateKeyPath: os.Getenv("ED25519_PRIVATE_KEY_PATH"),
 		SignFetch:         getEnv("SIGN_FETCH", "true") != "false",
+		SignedFetch:       getEnvBool("SIGNED_FETCH"),
 		ExternalBaseURL:   getEnv("EXTERNAL_BASE_URL", "https://njump.me"),
 		ZapPubkey:         os.Getenv("ZAP_PUBKEY"),
 		ZapSplit:          parseFloat(os.Getenv("ZAP_SPLIT"), 0.1),
+		LightningAddress:  os.Getenv("LIGHTNING_ADDRESS"),
 		Port:              getEnv("PORT", "8000"),
 		BskyIdentifier:     os.Getenv("BSKY_IDENTIFIER"),
 		BskyAppPassword:    os.Getenv("BSKY_APP_PASSWORD"),
 		BskyPDSURL:         getEnv("BSKY_PDS_URL", "https://bsky.social"),
+		BskyExtraAccounts:  bskyExtraAccounts,
 		BskyBridgeTimeline: getEnv("BSKY_BRIDGE_TIMELINE", "true") != "false",
+		BskyTimelineReplies: getEnv("BSKY_TIMELINE_REPLIES", "true") != "false",
+		BskyTimelineReposts: getEnvBool("BSKY_TIMELINE_REPOSTS"),
+		BskyTimelineQuotes:  getEnv("BSKY_TIMELINE_QUOTES", "true") != "false",
+		BskyBridgeChat:      getEnvBool("BSKY_BRIDGE_CHAT"),
+		BskyReplyGate:      replyGate,
 		WebAdminPassword:   os.Getenv("WEB_ADMIN"),
 		ShowSourceLink:    getEnvBool("SHOW_SOURCE_LINK"),
+		LongNoteThreshold: parseInt(os.Getenv("LONG_NOTE_THRESHOLD"), 0),
+		LongNoteMode:      getEnv("LONG_NOTE_MODE", "article"),
+		ContentFormat:     getEnv("CONTENT_FORMAT", "plain"),
+		ReplyReactions:    getEnvBool("REPLY_REACTIONS"),
+		ThreadMaxDepth:    parseInt(os.Getenv("THREAD_MAX_DEPTH"), 20),
+		ThreadMaxBreadth:  parseInt(os.Getenv("THREAD_MAX_BREADTH"), 10),
+		NoteEditMode:      getEnv("NOTE_EDIT_MODE", "replace"),
+		NostrStatus:       strings.ToLower(getEnv("NOSTR_STATUS", "off")),
+		ProfileZaps:       strings.ToLower(getEnv("PROFILE_ZAPS", "off")),
+		NIP89Handler:      getEnvBool("NIP89_HANDLER"),
+		FollowMinFollowers:  parseInt(os.Getenv("FOLLOW_MIN_FOLLOWERS"), 0),
+		FollowMaxRatio:      parseFloat(os.Getenv("FOLLOW_MAX_RATIO"), 0),
+		FollowMinAccountAge: parseDuration(os.Getenv("FOLLOW_MIN_ACCOUNT_AGE"), 0),
+		FollowFilterAction:  getEnv("FOLLOW_FILTER_ACTION", "hold"),
+		ActorDiscoverable: getEnv("ACTOR_DISCOVERABLE", "true") != "false",
+		ActorIndexable:    getEnv("ACTOR_INDEXABLE", "true") != "false",
+		BridgeLocation:    getEnvBool("BRIDGE_LOCATION"),
+		UnlistedTag:       os.Getenv("UNLISTED_HASHTAG"),
+		FollowersOnlyTag:  os.Getenv("FOLLOWERS_ONLY_HASHTAG"),
+		SensitiveCW:       getEnv("SENSITIVE_CW_TEXT", "Sensitive content"),
+		WebhookURL:        os.Getenv("WEBHOOK_URL"),
+		WebhookSecret:     os.Getenv("WEBHOOK_SECRET"),
+		NotificationPubkey: notifyPubKey,
+		DMFormat:           getEnv("DM_FORMAT", "nip04"),
+		Delegation:         getEnvBool("DELEGATION"),
+		FeaturedPosts:      featuredPosts,
 
 		ResyncInterval:          parseDuration(os.Getenv("RESYNC_INTERVAL"), 24*time.Hour),
+		ResyncConcurrency:       parseInt(os.Getenv("RESYNC_CONCURRENCY"), 4),
+		FollowReconcileInterval: parseDuration(os.Getenv("FOLLOW_RECONCILE_INTERVAL"), 0),
 		APCacheTTL:              parseDuration(os.Getenv("AP_CACHE_TTL"), time.Hour),
+		APCacheMaxEntries:       parseInt(os.Getenv("AP_CACHE_MAX_ENTRIES"), 10000),
 		BskyPollInterval:        parseDuration(os.Getenv("BSKY_POLL_INTERVAL"), 30*time.Second),
+		BskyPollMaxInterval:     parseDuration(os.Getenv("BSKY_POLL_MAX_INTERVAL"), 5*time.Minute),
+		BskyPollJitter:          parseFloat(os.Getenv("BSKY_POLL_JITTER"), 0.1),
 		APFederationConcurrency: parseInt(os.Getenv("AP_FEDERATION_CONCURRENCY"), 10),
+		FederationTimeout:       parseDuration(os.Getenv("FEDERATION_TIMEOUT"), 10*time.Second),
+		FederationCBThreshold:   parseInt(os.Getenv("FEDERATION_CB_THRESHOLD"), 5),
+		FederationCBCooldown:    parseDuration(os.Getenv("FEDERATION_CB_COOLDOWN"), 10*time.Minute),
 		RelayCBThreshold:        parseInt(os.Getenv("RELAY_CB_THRESHOLD"), 3),
+		RelayCBMaxCooldown:      parseDuration(os.Getenv("RELAY_CB_MAX_COOLDOWN"), time.Hour),
+		RelayMaxConnections:     parseInt(os.Getenv("RELAY_MAX_CONNECTIONS"), 0),
+		RelayIdleTimeout:        parseDuration(os.Getenv("RELAY_IDLE_TIMEOUT"), time.Hour),
+		RelaySubscriptionKinds:  subscriptionKinds,
+		RelayMentionKinds:       mentionKinds,
+		PublishTimeout:          parseDuration(os.Getenv("PUBLISH_TIMEOUT"), 15*time.Second),
+		PublishConcurrency:      parseInt(os.Getenv("PUBLISH_CONCURRENCY"), 10),
+		PublishQuorum:           parseInt(os.Getenv("PUBLISH_QUORUM"), 1),
+		RelayHintDynamic:        getEnv("RELAY_HINT_DYNAMIC", "true") != "false",
+		EchoTTL:                 parseDuration(os.Getenv("ECHO_TTL"), 10*time.Minute),
+		MaxNoteLength:           parseInt(os.Getenv("MAX_NOTE_LENGTH"), 0),
+		BulkBatchSize:           parseInt(os.Getenv("BULK_BATCH_SIZE"), 500),
+		BulkConcurrency:         parseInt(os.Getenv("BULK_CONCURRENCY"), 8),
+		ShutdownGracePeriod:     parseDuration(os.Getenv("SHUTDOWN_GRACE_PERIOD"), 20*time.Second),
+		KeyRotationGrace:        parseDuration(os.Getenv("KEY_ROTATION_GRACE"), 7*24*time.Hour),
+		Kind3MaxShrink:          parseFloat(os.Getenv("KIND3_MAX_SHRINK"), 0.1),
+
+		OutboundHeaders: parseHeaders(os.Getenv("OUTBOUND_HEADERS")),
+		UserAgent:       os.Getenv("USER_AGENT"),
+		OperatorEmail:   strings.TrimSpace(os.Getenv("OPERATOR_EMAIL")),
+		TrustedProxies:  trustedProxies,
+
+		HashtagFollows:      parseHashtags(os.Getenv("HASHTAG_FOLLOWS")),
+		HashtagServers:      parseHosts(getEnv("HASHTAG_SERVERS", "mastodon.social")),
+		HashtagPollInterval: parseDuration(os.Getenv("HASHTAG_POLL_INTERVAL"), 10*time.Minute),
+
+		AdminCORSOrigins: parseOrigins(os.Getenv("ADMIN_CORS_ORIGINS")),
+	}
+}
+
+// parsePubkey accepts a Nostr public key as 64-char hex or npub and returns hex.
+func parsePubkey(v string) (string, error) {
+	if strings.HasPrefix(v, "npub1") {
+		prefix, data, err := nip19.Decode(v)
+		if err != nil {
+			return "", err
+		}
+		if pk, ok := data.(string); ok && prefix == "npub" {
+			return pk, nil
+		}
+		return "", fmt.Errorf("not an npub")
+	}
+	if !nostr.IsValid32ByteHex(v) {
+		return "", fmt.Errorf("expected 64-char hex or npub")
+	}
+	return strings.ToLower(v), nil
+}
+
+// parseEventIDs parses a comma-separated list of event IDs given as hex,
+// note1 or nevent1 and returns them as lower-case hex.
+func parseEventIDs(v string) ([]string, error) {
+	var ids []string
+	for _, part := range parseRelays(v) {
+		if strings.HasPrefix(part, "note1") || strings.HasPrefix(part, "nevent1") {
+			_, data, err := nip19.Decode(part)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", part, err)
+			}
+			switch d := data.(type) {
+			case string:
+				ids = append(ids, d)
+			case nostr.EventPointer:
+				ids = append(ids, d.ID)
+			}
+			continue
+		}
+		if !nostr.IsValid32ByteHex(part) {
+			return nil, fmt.Errorf("%s: expected 64-char hex, note1 or nevent1", part)
+		}
+		ids = append(ids, strings.ToLower(part))
+	}
+	return ids, nil
+}
+
+// parseKinds parses a comma-separated list of Nostr event kinds. An empty
+// value yields nil.
+func parseKinds(v string) ([]int, error) {
+	var kinds []int
+	for _, part := range parseRelays(v) {
+		k, err := strconv.Atoi(part)
+		if err != nil || k < 0 || k > 65535 {
+			return nil, fmt.Errorf("%s: expected an event kind (0-65535)", part)
+		}
+		kinds = append(kinds, k)
 	}
+	return kinds, nil
+}
+
+// parseTrustedProxies parses a comma-separated list of CIDRs; a bare IP is
+// taken as a single-address network.
+func parseTrustedProxies(v string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, part := range parseRelays(v) {
+		if addr, err := netip.ParseAddr(part); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, fmt.Errorf("%s: expected a CIDR or IP address", part)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// parseReplyGate validates a BSKY_REPLY_GATE value and returns it normalised
+// to a lower-case comma list. "" and "everybody" mean no gate.
+func parseReplyGate(v string) (string, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" || v == "everybody" {
+		return "", nil
+	}
+	if v == "nobody" {
+		return v, nil
+	}
+	var rules []string
+	for _, r := range strings.Split(v, ",") {
+		r = strings.TrimSpace(r)
+		switch r {
+		case "mentioned", "following", "followers":
+			rules = append(rules, r)
+		case "":
+		default:
+			return "", fmt.Errorf("unknown rule %q (want nobody, mentioned, following or followers)", r)
+		}
+	}
+	return strings.Join(rules, ","), nil
+}
+
+// BskyAccount is the login of an additional Bluesky account (BSKY_EXTRA_ACCOUNTS).
+type BskyAccount struct {
+	Identifier  string // handle or DID
+	AppPassword string
+}
+
+// parseBskyAccounts parses BSKY_EXTRA_ACCOUNTS: comma-separated
+// "identifier:app-password" pairs. The password is split off at the last
+// colon, so DIDs (which contain colons) work as identifiers.
+func parseBskyAccounts(v string) ([]BskyAccount, error) {
+	var accounts []BskyAccount
+	for n, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 || i == len(entry)-1 {
+			// Don't echo the entry: it holds a password.
+			return nil, fmt.Errorf("entry %d is not identifier:app-password", n+1)
+		}
+		accounts = append(accounts, BskyAccount{Identifier: entry[:i], AppPassword: entry[i+1:]})
+	}
+	return accounts, nil
 }
 
 // getEnvBool returns true if the env var is "true" or "1" (case-insensitive).
@@ -169,6 +502,57 @@ func parseRelays(s string) []string {
 	return result
 }
 
+// parseHashtags parses a comma-separated hashtag list, lowercased and with
+// any leading '#' removed.
+func parseHashtags(s string) []string {
+	tags := parseRelays(s)
+	for i, t := range tags {
+		tags[i] = strings.ToLower(strings.TrimPrefix(t, "#"))
+	}
+	return slices.DeleteFunc(tags, func(t string) bool { return t == "" })
+}
+
+// parseHosts parses a comma-separated list of hosts, accepting full URLs too.
+func parseHosts(s string) []string {
+	hosts := parseRelays(s)
+	for i, h := range hosts {
+		if u, err := url.Parse(h); err == nil && u.Host != "" {
+			h = u.Host
+		}
+		hosts[i] = strings.ToLower(strings.TrimSuffix(h, "/"))
+	}
+	return hosts
+}
+
+// parseOrigins parses a comma-separated list of web origins, lowercased and
+// without a trailing slash, as browsers send them in the Origin header.
+func parseOrigins(s string) []string {
+	origins := parseRelays(s)
+	for i, o := range origins {
+		origins[i] = strings.ToLower(strings.TrimSuffix(o, "/"))
+	}
+	return origins
+}
+
+// parseHeaders parses a list of "Name: value" pairs separated by "|" or
+// newlines; commas are left alone, since header values often contain them.
+// Malformed entries (no colon or empty name) are skipped.
+func parseHeaders(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	result := make(map[string]string)
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '|' || r == '\n' }) {
+		name, value, ok := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		result[name] = strings.TrimSpace(value)
+	}
+	return result
+}
+
 func parseFloat(s string, fallback float64) float64 {
 	if s == "" {
 		return fallback
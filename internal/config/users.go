@@ -0,0 +1,41 @@
+package config
+
+// LocalUser is the Nostr account bridged by this instance, configured with
+// the NOSTR_* env vars.
+type LocalUser struct {
+	Username    string
+	PrivateKey  string
+	PublicKey   string
+	Npub        string
+	DisplayName string
+	Summary     string
+	Picture     string
+	Banner      string
+	// LightningAddress (name@host) backs the user's LNURL-pay endpoint.
+	LightningAddress string
+}
+
+// Primary returns the local user. Profile fields reflect live changes made
+// through the admin settings API.
+func (c *Config) Primary() LocalUser {
+	return LocalUser{
+		Username:    c.NostrUsername,
+		PrivateKey:  c.NostrPrivateKey,
+		PublicKey:   c.NostrPublicKey,
+		Npub:        c.NostrNpub,
+		DisplayName: c.NostrDisplayName,
+		Summary:     c.NostrSummary,
+		Picture:     c.NostrPicture,
+		Banner:      c.NostrBanner,
+
+		LightningAddress: c.LightningAddress,
+	}
+}
+
+// LocalUser looks up the local user by username.
+func (c *Config) LocalUser(username string) (LocalUser, bool) {
+	if username == c.NostrUsername {
+		return c.Primary(), true
+	}
+	return LocalUser{}, false
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"database/sql"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Retry policy for SQLite writes that fail with SQLITE_BUSY even after
+// busy_timeout: a burst of concurrent inbox activities can keep the single
+// writer lock contended for longer than the pragma waits.
+const (
+	busyMaxAttempts = 4
+	busyBaseDelay   = 50 * time.Millisecond
+)
+
+// isBusy reports whether err is SQLite lock contention worth retrying.
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") || strings.Contains(msg, "database is locked")
+}
+
+// execWrite runs a write statement, retrying SQLITE_BUSY failures up to
+// busyMaxAttempts times with jittered exponential backoff. Each retry is
+// counted (BusyRetries) so operators can tell when they are hitting SQLite's
+// single-writer ceiling. PostgreSQL writes run once.
+func (s *Store) execWrite(query string, args ...any) (sql.Result, error) {
+	res, err := s.db.Exec(query, args...)
+	if s.driver != "sqlite" {
+		return res, err
+	}
+	delay := busyBaseDelay
+	for attempt := 1; attempt < busyMaxAttempts && isBusy(err); attempt++ {
+		s.busyRetries.Add(1)
+		slog.Debug("sqlite busy, retrying write", "attempt", attempt, "error", err)
+		time.Sleep(delay/2 + rand.N(delay))
+		delay *= 2
+		res, err = s.db.Exec(query, args...)
+	}
+	if isBusy(err) {
+		slog.Warn("sqlite write failed: database busy; consider PostgreSQL for this load",
+			"attempts", busyMaxAttempts, "error", err)
+	}
+	return res, err
+}
+
+// BusyRetries returns the number of SQLite writes retried after SQLITE_BUSY
+// since startup. Always 0 on PostgreSQL.
+func (s *Store) BusyRetries() int64 { return s.busyRetries.Load() }
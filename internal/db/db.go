@@ -4,14 +4,16 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	_ "modernc.org/sqlite"
 )
 
@@ -23,6 +25,12 @@ type Store struct {
 	// In-memory caches to reduce DB round-trips.
 	objectsByAP    sync.Map // ap_id → nostr_id
 	objectsByNostr sync.Map // nostr_id → ap_id
+
+	// PostgreSQL only: cross-process cache invalidation (see invalidate.go).
+	listener *pq.Listener
+	origin   string
+
+	busyRetries atomic.Int64 // SQLite writes retried after SQLITE_BUSY (busy.go)
 }
 
 // Open opens a database connection. The URL can be:
@@ -74,7 +82,13 @@ func Open(databaseURL string) (*Store, error) {
 		)
 	}
 
-	return &Store{db: db, driver: driver}, nil
+	s := &Store{db: db, driver: driver}
+	if err := s.startInvalidationListener(dsn); err != nil {
+		// Single-instance deployments work fine without it; only a shared
+		// database needs cross-process invalidation.
+		slog.Warn("object cache invalidation listener unavailable", "error", err)
+	}
+	return s, nil
 }
 
 // Migrate runs all pending database migrations.
@@ -123,6 +137,131 @@ var commonMigrations = []string{
 		detail TEXT NOT NULL DEFAULT ''
 	)`,
 	`CREATE INDEX IF NOT EXISTS audit_log_ts ON audit_log(ts)`,
+	// Hashtags carried by locally-originated objects, indexed at bridge time so
+	// /tags/{tag} can list them. ts is RFC3339Nano (see audit_log).
+	`CREATE TABLE IF NOT EXISTS object_tags (
+		ap_id TEXT NOT NULL,
+		tag   TEXT NOT NULL,
+		ts    TEXT NOT NULL DEFAULT '',
+		UNIQUE(ap_id, tag)
+	)`,
+	`CREATE INDEX IF NOT EXISTS object_tags_tag ON object_tags(tag, ts)`,
+	// Outbound AP deliveries that failed with a retryable error. next_retry_at
+	// is a Unix timestamp (seconds) so due rows can be selected numerically.
+	`CREATE TABLE IF NOT EXISTS delivery_queue (
+		inbox         TEXT    NOT NULL,
+		activity_id   TEXT    NOT NULL,
+		activity      TEXT    NOT NULL,
+		attempts      INTEGER NOT NULL DEFAULT 0,
+		next_retry_at BIGINT  NOT NULL,
+		last_error    TEXT    NOT NULL DEFAULT '',
+		UNIQUE(inbox, activity_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS delivery_queue_next ON delivery_queue(next_retry_at)`,
+	// Instance-level delivery rules for the inbox. list is "block" or "allow";
+	// domain may be a wildcard ("*.spam.example").
+	`CREATE TABLE IF NOT EXISTS instance_rules (
+		domain TEXT NOT NULL,
+		list   TEXT NOT NULL DEFAULT 'block',
+		ts     TEXT NOT NULL DEFAULT '',
+		UNIQUE(domain, list)
+	)`,
+	// Additional AP IDs for an object already in objects (a second fetch URL,
+	// the human-readable url vs the canonical id, ...). objects keeps exactly
+	// one canonical ap_id per nostr_id; aliases only resolve inbound.
+	`CREATE TABLE IF NOT EXISTS object_aliases (
+		alias_id TEXT NOT NULL PRIMARY KEY,
+		nostr_id TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS object_aliases_nostr_id ON object_aliases(nostr_id)`,
+	// Inbound AP activities that failed to bridge, kept for inspection and
+	// retry from the admin UI. activity_id is the activity's id (or a hash of
+	// the body); raw is the possibly truncated body. ts is RFC3339Nano.
+	`CREATE TABLE IF NOT EXISTS failed_activities (
+		activity_id TEXT NOT NULL PRIMARY KEY,
+		ts          TEXT NOT NULL,
+		type        TEXT NOT NULL DEFAULT '',
+		actor       TEXT NOT NULL DEFAULT '',
+		error       TEXT NOT NULL DEFAULT '',
+		raw         TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS failed_activities_ts ON failed_activities(ts)`,
+	// Inbound follows held by the follow spam filter until the admin approves
+	// or rejects them. activity_id is the original Follow's id, needed for
+	// the Accept/Reject. ts is RFC3339Nano.
+	`CREATE TABLE IF NOT EXISTS pending_follows (
+		actor_id    TEXT NOT NULL,
+		followed_id TEXT NOT NULL,
+		activity_id TEXT NOT NULL,
+		reason      TEXT NOT NULL DEFAULT '',
+		ts          TEXT NOT NULL,
+		UNIQUE(actor_id, followed_id)
+	)`,
+	// Source-network authors (Bluesky DIDs, AP actor URLs) whose posts are
+	// not bridged to Nostr. ts is RFC3339Nano.
+	`CREATE TABLE IF NOT EXISTS bridge_exclusions (
+		author TEXT NOT NULL PRIMARY KEY,
+		label  TEXT NOT NULL DEFAULT '',
+		ts     TEXT NOT NULL
+	)`,
+	// Outbound follows whose Follow was sent but not yet answered with an
+	// Accept. The follow itself stays in follows; a row here marks it
+	// pending. ts is RFC3339Nano, the time the Follow was sent.
+	`CREATE TABLE IF NOT EXISTS follow_requests (
+		follower_id TEXT NOT NULL,
+		followed_id TEXT NOT NULL,
+		ts          TEXT NOT NULL,
+		UNIQUE(follower_id, followed_id)
+	)`,
+	// NIP-01 addresses ("kind:pubkey:d") of bridged and local addressable
+	// events, so replies to an article can be scoped as NIP-22 comments.
+	// Rows go when the event is deleted. Earlier versions kept these in kv
+	// under event_addr_<event id>; the two statements after the index move
+	// them over.
+	`CREATE TABLE IF NOT EXISTS event_addresses (
+		event_id TEXT NOT NULL PRIMARY KEY,
+		address  TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS event_addresses_address ON event_addresses(address)`,
+	`INSERT INTO event_addresses (event_id, address)
+		SELECT substr(key, 12), value FROM kv WHERE key LIKE 'event\_addr\_%' ESCAPE '\'
+		ON CONFLICT DO NOTHING`,
+	`DELETE FROM kv WHERE key LIKE 'event\_addr\_%' ESCAPE '\'`,
+	// The NIP-05 name ("user_at_host", lower-cased) advertised in each
+	// bridged actor's kind-0, so nostr.json resolves it without WebFinger.
+	// One row per actor: a renamed actor replaces its old name, and the row
+	// goes with the actor's key. Moved from kv nip05_name_<name> rows.
+	`CREATE TABLE IF NOT EXISTS nip05_names (
+		name      TEXT NOT NULL PRIMARY KEY,
+		actor_url TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS nip05_names_actor ON nip05_names(actor_url)`,
+	`INSERT INTO nip05_names (name, actor_url)
+		SELECT substr(key, 12), value FROM kv WHERE key LIKE 'nip05\_name\_%' ESCAPE '\'
+		ON CONFLICT DO NOTHING`,
+	`DELETE FROM kv WHERE key LIKE 'nip05\_name\_%' ESCAPE '\'`,
+	// Per-conversation cursor of the Bluesky chat bridge: the rev of the
+	// newest message delivered. ts is RFC3339Nano, the last update; rows
+	// moved from kv bsky_chat_rev_<convo> keys have none until updated and
+	// are never pruned before then.
+	`CREATE TABLE IF NOT EXISTS bsky_chat_revs (
+		convo_id TEXT NOT NULL PRIMARY KEY,
+		rev      TEXT NOT NULL,
+		ts       TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS bsky_chat_revs_ts ON bsky_chat_revs(ts)`,
+	`INSERT INTO bsky_chat_revs (convo_id, rev)
+		SELECT substr(key, 15), value FROM kv WHERE key LIKE 'bsky\_chat\_rev\_%' ESCAPE '\'
+		ON CONFLICT DO NOTHING`,
+	`DELETE FROM kv WHERE key LIKE 'bsky\_chat\_rev\_%' ESCAPE '\'`,
+	// Earlier Nostr events of an object republished after an edit (see
+	// ReplaceObject). Like object_aliases in the other direction: they only
+	// resolve Nostr → AP, so replies to the old event still thread.
+	`CREATE TABLE IF NOT EXISTS event_aliases (
+		nostr_id TEXT NOT NULL PRIMARY KEY,
+		ap_id    TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS event_aliases_ap_id ON event_aliases(ap_id)`,
 }
 
 func (s *Store) migrateSQLite() error {
@@ -151,9 +290,17 @@ func (s *Store) migratePostgres() error {
 
 // Close closes the database connection.
 func (s *Store) Close() error {
+	if s.listener != nil {
+		s.listener.Close()
+	}
 	return s.db.Close()
 }
 
+// Ping verifies the database connection is alive.
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 // ─── Objects ──────────────────────────────────────────────────────────────────
 
 // GetAPIDForObject returns the ActivityPub ID for a Nostr event ID, if known.
@@ -163,11 +310,18 @@ func (s *Store) GetAPIDForObject(nostrID string) (string, bool) {
 	}
 	var apID string
 	err := s.db.QueryRow(`SELECT ap_id FROM objects WHERE nostr_id = `+s.ph(), nostrID).Scan(&apID)
+	if err == nil {
+		s.objectsByNostr.Store(nostrID, apID)
+		s.objectsByAP.Store(apID, nostrID)
+		return apID, true
+	}
+	// Fall back to the events an edit replaced. Only the nostr_id → ap_id
+	// direction is cached: the AP ID must keep resolving to the current event.
+	err = s.db.QueryRow(`SELECT ap_id FROM event_aliases WHERE nostr_id = `+s.ph(), nostrID).Scan(&apID)
 	if err != nil {
 		return "", false
 	}
 	s.objectsByNostr.Store(nostrID, apID)
-	s.objectsByAP.Store(apID, nostrID)
 	return apID, true
 }
 
@@ -178,10 +332,17 @@ func (s *Store) GetNostrIDForObject(apID string) (string, bool) {
 	}
 	var nostrID string
 	err := s.db.QueryRow(`SELECT nostr_id FROM objects WHERE ap_id = `+s.ph(), apID).Scan(&nostrID)
+	if err == nil {
+		s.objectsByNostr.Store(nostrID, apID)
+		s.objectsByAP.Store(apID, nostrID)
+		return nostrID, true
+	}
+	// Fall back to aliases. Only the ap_id → nostr_id direction is cached:
+	// the reverse lookup must keep returning the canonical AP ID.
+	err = s.db.QueryRow(`SELECT nostr_id FROM object_aliases WHERE alias_id = `+s.ph(), apID).Scan(&nostrID)
 	if err != nil {
 		return "", false
 	}
-	s.objectsByNostr.Store(nostrID, apID)
 	s.objectsByAP.Store(apID, nostrID)
 	return nostrID, true
 }
@@ -189,22 +350,87 @@ func (s *Store) GetNostrIDForObject(apID string) (string, bool) {
 // DeleteObject removes an ActivityPub ↔ Nostr object ID mapping from the
 // database and evicts both cache entries. Called when a Delete activity or a
 // kind-5 deletion event is processed so that stale mappings cannot cause ghost
-// re-deliveries or false-positive idempotency hits.
+// re-deliveries or false-positive idempotency hits. The events earlier edits
+// replaced (see ReplaceObject) go too.
 func (s *Store) DeleteObject(apID, nostrID string) error {
+	if err := s.deleteObject(apID, nostrID); err != nil {
+		return err
+	}
+	replaced, err := s.eventAliases(apID)
+	if err != nil || len(replaced) == 0 {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM event_aliases WHERE ap_id = `+s.ph(), apID); err != nil {
+		return err
+	}
+	for _, id := range replaced {
+		s.evictObjects(nil, id)
+		s.notifyInvalidate(nil, id)
+	}
+	return nil
+}
+
+// ReplaceObject moves the mapping of apID from oldNostrID to newNostrID, as
+// when an edited note is republished as a new event. oldNostrID is kept as an
+// event alias: GetAPIDForObject still resolves it to apID, so Nostr replies
+// and reactions to the old event still reach the note.
+func (s *Store) ReplaceObject(apID, oldNostrID, newNostrID string) error {
+	if err := s.deleteObject(apID, oldNostrID); err != nil {
+		return err
+	}
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT OR IGNORE INTO event_aliases (nostr_id, ap_id) VALUES (?, ?)`
+	} else {
+		q = `INSERT INTO event_aliases (nostr_id, ap_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	}
+	if _, err := s.execWrite(q, oldNostrID, apID); err != nil {
+		return err
+	}
+	return s.AddObject(apID, newNostrID)
+}
+
+// eventAliases returns the replaced Nostr event IDs recorded for apID.
+func (s *Store) eventAliases(apID string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT nostr_id FROM event_aliases WHERE ap_id = `+s.ph(), apID)
+	if err != nil {
+		return nil, err
+	}
+	return scanStringRows(rows)
+}
+
+// deleteObject removes the mapping of apID to nostrID with its object aliases
+// and event address.
+func (s *Store) deleteObject(apID, nostrID string) error {
 	var q string
 	if s.driver == "sqlite" {
 		q = `DELETE FROM objects WHERE ap_id = ? AND nostr_id = ?`
 	} else {
 		q = `DELETE FROM objects WHERE ap_id = $1 AND nostr_id = $2`
 	}
-	_, err := s.db.Exec(q, apID, nostrID)
+	if _, err := s.db.Exec(q, apID, nostrID); err != nil {
+		return err
+	}
+	// Drop the aliases too so they cannot resolve to a deleted event, and
+	// the event's address if it was an article.
+	aliases, err := s.objectAliases(nostrID)
+	if err == nil && len(aliases) > 0 {
+		_, err = s.db.Exec(`DELETE FROM object_aliases WHERE nostr_id = `+s.ph(), nostrID)
+	}
+	if err == nil {
+		err = s.DeleteEventAddress(nostrID)
+	}
 	// Evict from both caches regardless of whether a DB row was found.
-	s.objectsByAP.Delete(apID)
-	s.objectsByNostr.Delete(nostrID)
+	evicted := append([]string{apID}, aliases...)
+	s.evictObjects(evicted, nostrID)
+	s.notifyInvalidate(evicted, nostrID)
 	return err
 }
 
-// AddObject stores an ActivityPub ↔ Nostr object ID mapping.
+// AddObject stores an ActivityPub ↔ Nostr object ID mapping. The first AP ID
+// stored for a Nostr event stays canonical; when nostrID is already mapped to
+// a different AP ID, apID is recorded as an alias (see AddObjectAlias) instead
+// of being dropped.
 func (s *Store) AddObject(apID, nostrID string) error {
 	var q string
 	if s.driver == "sqlite" {
@@ -212,14 +438,105 @@ func (s *Store) AddObject(apID, nostrID string) error {
 	} else {
 		q = `INSERT INTO objects (ap_id, nostr_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
 	}
-	_, err := s.db.Exec(q, apID, nostrID)
-	if err == nil {
-		s.objectsByNostr.Store(nostrID, apID)
-		s.objectsByAP.Store(apID, nostrID)
+	res, err := s.execWrite(q, apID, nostrID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		// Either this exact mapping exists already, or one of the two IDs is
+		// taken by another mapping. Never let the cache disagree with the DB.
+		if canonical, ok := s.GetAPIDForObject(nostrID); ok && canonical != apID {
+			if _, taken := s.GetNostrIDForObject(apID); !taken {
+				return s.AddObjectAlias(apID, nostrID)
+			}
+		}
+		return nil
+	}
+	s.objectsByNostr.Store(nostrID, apID)
+	s.objectsByAP.Store(apID, nostrID)
+	s.notifyInvalidate([]string{apID}, nostrID)
+	return nil
+}
+
+// AddObjectAlias records aliasID as an additional AP ID for the Nostr event
+// nostrID, so GetNostrIDForObject resolves it while GetAPIDForObject keeps
+// returning the canonical AP ID. A no-op when aliasID is already known.
+func (s *Store) AddObjectAlias(aliasID, nostrID string) error {
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT OR IGNORE INTO object_aliases (alias_id, nostr_id) VALUES (?, ?)`
+	} else {
+		q = `INSERT INTO object_aliases (alias_id, nostr_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	}
+	if _, err := s.execWrite(q, aliasID, nostrID); err != nil {
+		return err
+	}
+	s.objectsByAP.Delete(aliasID) // re-read on next lookup; an earlier alias wins
+	s.notifyInvalidate([]string{aliasID}, "")
+	return nil
+}
+
+// objectAliases returns the alias AP IDs recorded for nostrID.
+func (s *Store) objectAliases(nostrID string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT alias_id FROM object_aliases WHERE nostr_id = `+s.ph(), nostrID)
+	if err != nil {
+		return nil, err
+	}
+	return scanStringRows(rows)
+}
+
+// ─── Object tags ──────────────────────────────────────────────────────────────
+
+// AddObjectTags records the hashtags carried by an AP object. Tags are
+// lowercased so lookups are case-insensitive; duplicates are ignored.
+func (s *Store) AddObjectTags(apID string, tags []string) error {
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT OR IGNORE INTO object_tags (ap_id, tag, ts) VALUES (?, ?, ?)`
+	} else {
+		q = `INSERT INTO object_tags (ap_id, tag, ts) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
 	}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+		if tag == "" {
+			continue
+		}
+		if _, err := s.db.Exec(q, apID, tag, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteObjectTags removes every hashtag recorded for an AP object.
+func (s *Store) DeleteObjectTags(apID string) error {
+	_, err := s.db.Exec(`DELETE FROM object_tags WHERE ap_id = `+s.ph(), apID)
 	return err
 }
 
+// GetObjectsByTag returns up to limit AP object IDs tagged with tag, newest first.
+func (s *Store) GetObjectsByTag(tag string, limit int) ([]string, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT ap_id FROM object_tags WHERE tag = ? ORDER BY ts DESC LIMIT ?`
+	} else {
+		q = `SELECT ap_id FROM object_tags WHERE tag = $1 ORDER BY ts DESC LIMIT $2`
+	}
+	rows, err := s.db.Query(q, strings.ToLower(tag), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanStringRows(rows)
+}
+
+// CountObjectsByTag returns the number of AP objects tagged with tag.
+func (s *Store) CountObjectsByTag(tag string) (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM object_tags WHERE tag = `+s.ph(), strings.ToLower(tag)).Scan(&n)
+	return n, err
+}
+
 // ─── Follows ──────────────────────────────────────────────────────────────────
 
 // AddFollow records that followerID follows followedID.
@@ -230,11 +547,12 @@ func (s *Store) AddFollow(followerID, followedID string) error {
 	} else {
 		q = `INSERT INTO follows (follower_id, followed_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
 	}
-	_, err := s.db.Exec(q, followerID, followedID)
+	_, err := s.execWrite(q, followerID, followedID)
 	return err
 }
 
-// RemoveFollow removes a follow relationship.
+// RemoveFollow removes a follow relationship, and its follow request if one
+// is still pending.
 func (s *Store) RemoveFollow(followerID, followedID string) error {
 	var q string
 	if s.driver == "sqlite" {
@@ -242,8 +560,38 @@ func (s *Store) RemoveFollow(followerID, followedID string) error {
 	} else {
 		q = `DELETE FROM follows WHERE follower_id = $1 AND followed_id = $2`
 	}
-	_, err := s.db.Exec(q, followerID, followedID)
-	return err
+	if _, err := s.db.Exec(q, followerID, followedID); err != nil {
+		return err
+	}
+	return s.MarkFollowAccepted(followerID, followedID)
+}
+
+// RemoveFollower removes every follow relationship in which followerID is the
+// follower. Returns the number of rows removed.
+func (s *Store) RemoveFollower(followerID string) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM follows WHERE follower_id = `+s.ph(), followerID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RemoveAllFollowsFor removes every follow relationship — pending follow
+// requests included — in which actorID is either the follower or the
+// followed party.
+func (s *Store) RemoveAllFollowsFor(actorID string) error {
+	for _, table := range []string{"follows", "follow_requests"} {
+		var q string
+		if s.driver == "sqlite" {
+			q = `DELETE FROM ` + table + ` WHERE follower_id = ? OR followed_id = ?`
+		} else {
+			q = `DELETE FROM ` + table + ` WHERE follower_id = $1 OR followed_id = $2`
+		}
+		if _, err := s.db.Exec(q, actorID, actorID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetFollowers returns all follower IDs for a given followed ID.
@@ -271,6 +619,35 @@ func (s *Store) GetAPFollowers(followedID string) ([]string, error) {
 	return scanStringRows(rows)
 }
 
+// GetAPFollowersPage returns up to limit AP follower IDs for followedID,
+// ordered by ID and skipping the first offset, for paginated collections.
+func (s *Store) GetAPFollowersPage(followedID string, limit, offset int) ([]string, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT follower_id FROM follows WHERE followed_id = ? AND follower_id LIKE 'http%' ORDER BY follower_id LIMIT ? OFFSET ?`
+	} else {
+		q = `SELECT follower_id FROM follows WHERE followed_id = $1 AND follower_id LIKE 'http%' ORDER BY follower_id LIMIT $2 OFFSET $3`
+	}
+	rows, err := s.db.Query(q, followedID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanStringRows(rows)
+}
+
+// CountAPFollowers returns the number of AP followers of followedID.
+func (s *Store) CountAPFollowers(followedID string) (int, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT COUNT(*) FROM follows WHERE followed_id = ? AND follower_id LIKE 'http%'`
+	} else {
+		q = `SELECT COUNT(*) FROM follows WHERE followed_id = $1 AND follower_id LIKE 'http%'`
+	}
+	var n int
+	err := s.db.QueryRow(q, followedID).Scan(&n)
+	return n, err
+}
+
 // GetBskyFollowers returns only Bluesky follower IDs (those starting with "bsky:")
 // for a given followed ID.
 func (s *Store) GetBskyFollowers(followedID string) ([]string, error) {
@@ -296,6 +673,29 @@ func (s *Store) GetFollowing(followerID string) ([]string, error) {
 	return scanStringRows(rows)
 }
 
+// GetFollowingPage returns up to limit followed IDs for followerID, ordered
+// by ID and skipping the first offset, for paginated collections.
+func (s *Store) GetFollowingPage(followerID string, limit, offset int) ([]string, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT followed_id FROM follows WHERE follower_id = ? ORDER BY followed_id LIMIT ? OFFSET ?`
+	} else {
+		q = `SELECT followed_id FROM follows WHERE follower_id = $1 ORDER BY followed_id LIMIT $2 OFFSET $3`
+	}
+	rows, err := s.db.Query(q, followerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanStringRows(rows)
+}
+
+// CountFollowing returns the number of follows for followerID.
+func (s *Store) CountFollowing(followerID string) (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM follows WHERE follower_id = `+s.ph(), followerID).Scan(&n)
+	return n, err
+}
+
 // GetAPFollowing returns only ActivityPub followed IDs (those starting with "http")
 // for a given follower ID. Bluesky entries (prefixed "bsky:") are excluded.
 func (s *Store) GetAPFollowing(followerID string) ([]string, error) {
@@ -312,6 +712,37 @@ func (s *Store) GetAPFollowing(followerID string) ([]string, error) {
 	return scanStringRows(rows)
 }
 
+// GetAPFollowingPage returns up to limit AP followed IDs for followerID that
+// sort after the cursor, in ascending order. Pass the last ID of the previous
+// page as after ("" for the first page) to stream large follow lists without
+// loading them into memory at once.
+func (s *Store) GetAPFollowingPage(followerID, after string, limit int) ([]string, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT followed_id FROM follows WHERE follower_id = ? AND followed_id LIKE 'http%' AND followed_id > ? ORDER BY followed_id LIMIT ?`
+	} else {
+		q = `SELECT followed_id FROM follows WHERE follower_id = $1 AND followed_id LIKE 'http%' AND followed_id > $2 ORDER BY followed_id LIMIT $3`
+	}
+	rows, err := s.db.Query(q, followerID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanStringRows(rows)
+}
+
+// CountAPFollowing returns the number of AP follows for followerID.
+func (s *Store) CountAPFollowing(followerID string) (int, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT COUNT(*) FROM follows WHERE follower_id = ? AND followed_id LIKE 'http%'`
+	} else {
+		q = `SELECT COUNT(*) FROM follows WHERE follower_id = $1 AND followed_id LIKE 'http%'`
+	}
+	var n int
+	err := s.db.QueryRow(q, followerID).Scan(&n)
+	return n, err
+}
+
 // GetBskyFollowing returns only Bluesky followed IDs (those starting with "bsky:")
 // for a given follower ID.
 func (s *Store) GetBskyFollowing(followerID string) ([]string, error) {
@@ -352,6 +783,45 @@ func (s *Store) GetAllActorURLs() ([]string, error) {
 	return scanStringRows(rows)
 }
 
+// DeleteActorKey removes the derived-key mapping for an AP actor URL.
+// Called when the remote account is deleted.
+func (s *Store) DeleteActorKey(apActorURL string) error {
+	if _, err := s.db.Exec(`DELETE FROM actor_keys WHERE ap_actor_url = `+s.ph(), apActorURL); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`DELETE FROM nip05_names WHERE actor_url = `+s.ph(), apActorURL)
+	return err
+}
+
+// SetNIP05Name records name as the NIP-05 name advertised for actorURL,
+// replacing any earlier name of that actor. Names are case-insensitive.
+func (s *Store) SetNIP05Name(name, actorURL string) error {
+	name = strings.ToLower(name)
+	var del, ins string
+	if s.driver == "sqlite" {
+		del = `DELETE FROM nip05_names WHERE actor_url = ? AND name <> ?`
+		ins = `INSERT INTO nip05_names (name, actor_url) VALUES (?, ?) ON CONFLICT(name) DO UPDATE SET actor_url=excluded.actor_url`
+	} else {
+		del = `DELETE FROM nip05_names WHERE actor_url = $1 AND name <> $2`
+		ins = `INSERT INTO nip05_names (name, actor_url) VALUES ($1, $2) ON CONFLICT(name) DO UPDATE SET actor_url=EXCLUDED.actor_url`
+	}
+	if _, err := s.execWrite(del, actorURL, name); err != nil {
+		return err
+	}
+	_, err := s.execWrite(ins, name, actorURL)
+	return err
+}
+
+// GetNIP05Name returns the actor recorded for a NIP-05 name by SetNIP05Name.
+func (s *Store) GetNIP05Name(name string) (string, bool) {
+	var actorURL string
+	err := s.db.QueryRow(`SELECT actor_url FROM nip05_names WHERE name = `+s.ph(), strings.ToLower(name)).Scan(&actorURL)
+	if err != nil {
+		return "", false
+	}
+	return actorURL, true
+}
+
 // GetActorForKey returns the AP actor URL for a derived Nostr pubkey, if known.
 func (s *Store) GetActorForKey(pubkey string) (string, bool) {
 	var apActorURL string
@@ -372,7 +842,7 @@ func (s *Store) SetKV(key, value string) error {
 	} else {
 		q = `INSERT INTO kv (key, value) VALUES ($1, $2) ON CONFLICT(key) DO UPDATE SET value=EXCLUDED.value`
 	}
-	_, err := s.db.Exec(q, key, value)
+	_, err := s.execWrite(q, key, value)
 	return err
 }
 
@@ -386,6 +856,42 @@ func (s *Store) GetKV(key string) (string, bool) {
 	return value, true
 }
 
+// SetEventAddress records the NIP-01 address ("kind:pubkey:d") of an
+// addressable Nostr event, such as a bridged or local kind-30023 article.
+func (s *Store) SetEventAddress(eventID, address string) error {
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT INTO event_addresses (event_id, address) VALUES (?, ?) ON CONFLICT(event_id) DO UPDATE SET address=excluded.address`
+	} else {
+		q = `INSERT INTO event_addresses (event_id, address) VALUES ($1, $2) ON CONFLICT(event_id) DO UPDATE SET address=EXCLUDED.address`
+	}
+	_, err := s.execWrite(q, eventID, address)
+	return err
+}
+
+// GetEventAddress returns the address recorded by SetEventAddress, if any.
+func (s *Store) GetEventAddress(eventID string) (string, bool) {
+	var address string
+	err := s.db.QueryRow(`SELECT address FROM event_addresses WHERE event_id = `+s.ph(), eventID).Scan(&address)
+	if err != nil {
+		return "", false
+	}
+	return address, true
+}
+
+// DeleteEventAddress forgets the address of a deleted event.
+func (s *Store) DeleteEventAddress(eventID string) error {
+	_, err := s.db.Exec(`DELETE FROM event_addresses WHERE event_id = `+s.ph(), eventID)
+	return err
+}
+
+// DeleteAddress forgets every event recorded under address, for a NIP-09
+// deletion of the whole addressable event (an "a" tag).
+func (s *Store) DeleteAddress(address string) error {
+	_, err := s.db.Exec(`DELETE FROM event_addresses WHERE address = `+s.ph(), address)
+	return err
+}
+
 // ─── Audit log ────────────────────────────────────────────────────────────────
 
 // AuditLogEntry is one record in the admin audit log.
@@ -433,6 +939,476 @@ func (s *Store) GetAuditLog(limit int) ([]AuditLogEntry, error) {
 	return entries, rows.Err()
 }
 
+// ─── Delivery Queue ───────────────────────────────────────────────────────────
+
+// QueuedDelivery is one pending retry of an outbound AP delivery.
+type QueuedDelivery struct {
+	Inbox      string
+	ActivityID string
+	Activity   string // JSON-encoded activity
+	Attempts   int
+}
+
+// EnqueueDelivery schedules a retry of activity to inbox at nextRetry.
+// attempts is the number of deliveries already tried. Re-enqueuing the same
+// (inbox, activityID) pair is a no-op.
+func (s *Store) EnqueueDelivery(inbox, activityID, activity string, attempts int, nextRetry time.Time, lastError string) error {
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT OR IGNORE INTO delivery_queue (inbox, activity_id, activity, attempts, next_retry_at, last_error) VALUES (?, ?, ?, ?, ?, ?)`
+	} else {
+		q = `INSERT INTO delivery_queue (inbox, activity_id, activity, attempts, next_retry_at, last_error) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`
+	}
+	_, err := s.db.Exec(q, inbox, activityID, activity, attempts, nextRetry.Unix(), lastError)
+	return err
+}
+
+// GetDueDeliveries returns up to limit queued deliveries whose retry time has
+// passed, oldest first.
+func (s *Store) GetDueDeliveries(now time.Time, limit int) ([]QueuedDelivery, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT inbox, activity_id, activity, attempts FROM delivery_queue WHERE next_retry_at <= ? ORDER BY next_retry_at LIMIT ?`
+	} else {
+		q = `SELECT inbox, activity_id, activity, attempts FROM delivery_queue WHERE next_retry_at <= $1 ORDER BY next_retry_at LIMIT $2`
+	}
+	rows, err := s.db.Query(q, now.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []QueuedDelivery
+	for rows.Next() {
+		var d QueuedDelivery
+		if err := rows.Scan(&d.Inbox, &d.ActivityID, &d.Activity, &d.Attempts); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// RescheduleDelivery records a further failed attempt and the next retry time.
+func (s *Store) RescheduleDelivery(inbox, activityID string, attempts int, nextRetry time.Time, lastError string) error {
+	var q string
+	if s.driver == "sqlite" {
+		q = `UPDATE delivery_queue SET attempts = ?, next_retry_at = ?, last_error = ? WHERE inbox = ? AND activity_id = ?`
+	} else {
+		q = `UPDATE delivery_queue SET attempts = $1, next_retry_at = $2, last_error = $3 WHERE inbox = $4 AND activity_id = $5`
+	}
+	_, err := s.db.Exec(q, attempts, nextRetry.Unix(), lastError, inbox, activityID)
+	return err
+}
+
+// DeleteDelivery removes a queued delivery (delivered or given up on).
+func (s *Store) DeleteDelivery(inbox, activityID string) error {
+	var q string
+	if s.driver == "sqlite" {
+		q = `DELETE FROM delivery_queue WHERE inbox = ? AND activity_id = ?`
+	} else {
+		q = `DELETE FROM delivery_queue WHERE inbox = $1 AND activity_id = $2`
+	}
+	_, err := s.db.Exec(q, inbox, activityID)
+	return err
+}
+
+// ─── Failed activities ────────────────────────────────────────────────────────
+
+// FailedActivity is an inbound AP activity that failed to bridge.
+type FailedActivity struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"ts"`
+	Type      string `json:"type"`
+	Actor     string `json:"actor"`
+	Error     string `json:"error"`
+	Raw       string `json:"raw,omitempty"`
+}
+
+// RecordFailedActivity stores (or refreshes) a failed activity, then deletes
+// all but the keep most recent entries.
+func (s *Store) RecordFailedActivity(f FailedActivity, keep int) error {
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	var q, prune string
+	if s.driver == "sqlite" {
+		q = `INSERT INTO failed_activities (activity_id, ts, type, actor, error, raw) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(activity_id) DO UPDATE SET ts=excluded.ts, error=excluded.error`
+		prune = `DELETE FROM failed_activities WHERE ts < (SELECT ts FROM failed_activities ORDER BY ts DESC LIMIT 1 OFFSET ?)`
+	} else {
+		q = `INSERT INTO failed_activities (activity_id, ts, type, actor, error, raw) VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT(activity_id) DO UPDATE SET ts=EXCLUDED.ts, error=EXCLUDED.error`
+		prune = `DELETE FROM failed_activities WHERE ts < (SELECT ts FROM failed_activities ORDER BY ts DESC LIMIT 1 OFFSET $1)`
+	}
+	if _, err := s.db.Exec(q, f.ID, ts, f.Type, f.Actor, f.Error, f.Raw); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(prune, keep-1)
+	return err
+}
+
+// GetFailedActivities returns up to limit failed activities, newest first,
+// without their raw bodies.
+func (s *Store) GetFailedActivities(limit int) ([]FailedActivity, error) {
+	rows, err := s.db.Query(`SELECT activity_id, ts, type, actor, error FROM failed_activities ORDER BY ts DESC LIMIT `+s.ph(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []FailedActivity
+	for rows.Next() {
+		var f FailedActivity
+		if err := rows.Scan(&f.ID, &f.Timestamp, &f.Type, &f.Actor, &f.Error); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// GetFailedActivity returns one failed activity including its raw body.
+func (s *Store) GetFailedActivity(id string) (FailedActivity, bool) {
+	f := FailedActivity{ID: id}
+	err := s.db.QueryRow(`SELECT ts, type, actor, error, raw FROM failed_activities WHERE activity_id = `+s.ph(), id).
+		Scan(&f.Timestamp, &f.Type, &f.Actor, &f.Error, &f.Raw)
+	if err != nil {
+		return FailedActivity{}, false
+	}
+	return f, true
+}
+
+// DeleteFailedActivity removes a failed activity (retried successfully).
+func (s *Store) DeleteFailedActivity(id string) error {
+	_, err := s.db.Exec(`DELETE FROM failed_activities WHERE activity_id = `+s.ph(), id)
+	return err
+}
+
+// ─── Pending follows ──────────────────────────────────────────────────────────
+
+// PendingFollow is an inbound follow held for manual approval.
+type PendingFollow struct {
+	ActorID    string `json:"actor"`
+	FollowedID string `json:"followed"`
+	ActivityID string `json:"activity_id"`
+	Reason     string `json:"reason"`
+	Timestamp  string `json:"ts"`
+}
+
+// AddPendingFollow holds a follow for approval. A repeated Follow from the
+// same actor replaces the held one.
+func (s *Store) AddPendingFollow(activityID, actorID, followedID, reason string) error {
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT INTO pending_follows (actor_id, followed_id, activity_id, reason, ts) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(actor_id, followed_id) DO UPDATE SET activity_id=excluded.activity_id, reason=excluded.reason, ts=excluded.ts`
+	} else {
+		q = `INSERT INTO pending_follows (actor_id, followed_id, activity_id, reason, ts) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT(actor_id, followed_id) DO UPDATE SET activity_id=EXCLUDED.activity_id, reason=EXCLUDED.reason, ts=EXCLUDED.ts`
+	}
+	_, err := s.db.Exec(q, actorID, followedID, activityID, reason, ts)
+	return err
+}
+
+// GetPendingFollows returns all held follows, newest first.
+func (s *Store) GetPendingFollows() ([]PendingFollow, error) {
+	rows, err := s.db.Query(`SELECT actor_id, followed_id, activity_id, reason, ts FROM pending_follows ORDER BY ts DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PendingFollow
+	for rows.Next() {
+		var p PendingFollow
+		if err := rows.Scan(&p.ActorID, &p.FollowedID, &p.ActivityID, &p.Reason, &p.Timestamp); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// GetPendingFollow returns the held follow of followedID by actorID.
+func (s *Store) GetPendingFollow(actorID, followedID string) (PendingFollow, bool) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT activity_id, reason, ts FROM pending_follows WHERE actor_id = ? AND followed_id = ?`
+	} else {
+		q = `SELECT activity_id, reason, ts FROM pending_follows WHERE actor_id = $1 AND followed_id = $2`
+	}
+	p := PendingFollow{ActorID: actorID, FollowedID: followedID}
+	if err := s.db.QueryRow(q, actorID, followedID).Scan(&p.ActivityID, &p.Reason, &p.Timestamp); err != nil {
+		return PendingFollow{}, false
+	}
+	return p, true
+}
+
+// DeletePendingFollow removes a held follow (approved, rejected or undone).
+func (s *Store) DeletePendingFollow(actorID, followedID string) error {
+	var q string
+	if s.driver == "sqlite" {
+		q = `DELETE FROM pending_follows WHERE actor_id = ? AND followed_id = ?`
+	} else {
+		q = `DELETE FROM pending_follows WHERE actor_id = $1 AND followed_id = $2`
+	}
+	_, err := s.db.Exec(q, actorID, followedID)
+	return err
+}
+
+// CountPendingFollows returns how many follows are held for approval.
+func (s *Store) CountPendingFollows() (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM pending_follows`).Scan(&n)
+	return n, err
+}
+
+// PrunePendingFollows deletes the follows held since before and returns how
+// many were removed. No Reject is sent: to the follower they stay pending, as
+// if never decided.
+func (s *Store) PrunePendingFollows(before time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM pending_follows WHERE ts < `+s.ph(),
+		before.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ─── Follow Requests ──────────────────────────────────────────────────────────
+
+// MarkFollowRequested records that a Follow of followedID was sent on behalf
+// of followerID and awaits an Accept. Re-sending refreshes the timestamp.
+func (s *Store) MarkFollowRequested(followerID, followedID string) error {
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT INTO follow_requests (follower_id, followed_id, ts) VALUES (?, ?, ?)
+			ON CONFLICT(follower_id, followed_id) DO UPDATE SET ts=excluded.ts`
+	} else {
+		q = `INSERT INTO follow_requests (follower_id, followed_id, ts) VALUES ($1, $2, $3)
+			ON CONFLICT(follower_id, followed_id) DO UPDATE SET ts=EXCLUDED.ts`
+	}
+	_, err := s.db.Exec(q, followerID, followedID, ts)
+	return err
+}
+
+// MarkFollowAccepted clears the pending follow request of followedID by
+// followerID, if any.
+func (s *Store) MarkFollowAccepted(followerID, followedID string) error {
+	var q string
+	if s.driver == "sqlite" {
+		q = `DELETE FROM follow_requests WHERE follower_id = ? AND followed_id = ?`
+	} else {
+		q = `DELETE FROM follow_requests WHERE follower_id = $1 AND followed_id = $2`
+	}
+	_, err := s.db.Exec(q, followerID, followedID)
+	return err
+}
+
+// GetFollowRequests returns the follows of followerID still awaiting an
+// Accept, mapped to the RFC3339Nano time the Follow was sent.
+func (s *Store) GetFollowRequests(followerID string) (map[string]string, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `SELECT followed_id, ts FROM follow_requests WHERE follower_id = ?`
+	} else {
+		q = `SELECT followed_id, ts FROM follow_requests WHERE follower_id = $1`
+	}
+	rows, err := s.db.Query(q, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var followedID, ts string
+		if err := rows.Scan(&followedID, &ts); err != nil {
+			return nil, err
+		}
+		out[followedID] = ts
+	}
+	return out, rows.Err()
+}
+
+// PruneFollowRequests removes follow requests sent before the given time
+// whose follow no longer exists — rejected follows that were never approved
+// after all — and returns how many were removed. Requests of follows still
+// awaiting their first answer are kept however old they are.
+func (s *Store) PruneFollowRequests(before time.Time) (int64, error) {
+	const notFollowing = `NOT EXISTS (SELECT 1 FROM follows f
+			WHERE f.follower_id = follow_requests.follower_id AND f.followed_id = follow_requests.followed_id)`
+	var q string
+	if s.driver == "sqlite" {
+		q = `DELETE FROM follow_requests WHERE ts < ? AND ` + notFollowing
+	} else {
+		q = `DELETE FROM follow_requests WHERE ts < $1 AND ` + notFollowing
+	}
+	res, err := s.db.Exec(q, before.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ─── Bluesky chat cursors ─────────────────────────────────────────────────────
+
+// GetChatRev returns the stored cursor of a Bluesky chat conversation.
+func (s *Store) GetChatRev(convoID string) (string, bool) {
+	var rev string
+	err := s.db.QueryRow(`SELECT rev FROM bsky_chat_revs WHERE convo_id = `+s.ph(), convoID).Scan(&rev)
+	if err != nil {
+		return "", false
+	}
+	return rev, true
+}
+
+// SetChatRev stores the cursor of a Bluesky chat conversation.
+func (s *Store) SetChatRev(convoID, rev string) error {
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT INTO bsky_chat_revs (convo_id, rev, ts) VALUES (?, ?, ?)
+			ON CONFLICT(convo_id) DO UPDATE SET rev=excluded.rev, ts=excluded.ts`
+	} else {
+		q = `INSERT INTO bsky_chat_revs (convo_id, rev, ts) VALUES ($1, $2, $3)
+			ON CONFLICT(convo_id) DO UPDATE SET rev=EXCLUDED.rev, ts=EXCLUDED.ts`
+	}
+	_, err := s.execWrite(q, convoID, rev, time.Now().UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// PruneChatRevs deletes the cursors of conversations not updated since
+// before and returns how many were removed.
+func (s *Store) PruneChatRevs(before time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM bsky_chat_revs WHERE ts <> '' AND ts < `+s.ph(),
+		before.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ─── Instance Rules ───────────────────────────────────────────────────────────
+
+// InstanceRule is one inbox block/allow list entry.
+type InstanceRule struct {
+	Domain    string `json:"domain"`
+	List      string `json:"list"` // "block" | "allow"
+	Timestamp string `json:"ts"`
+}
+
+// AddInstanceRule adds domain to the given list ("block" or "allow").
+// Returns false if the entry already exists.
+func (s *Store) AddInstanceRule(domain, list string) (bool, error) {
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT OR IGNORE INTO instance_rules (domain, list, ts) VALUES (?, ?, ?)`
+	} else {
+		q = `INSERT INTO instance_rules (domain, list, ts) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	}
+	res, err := s.db.Exec(q, domain, list, ts)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RemoveInstanceRule removes domain from the given list.
+// Returns false if the entry did not exist.
+func (s *Store) RemoveInstanceRule(domain, list string) (bool, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `DELETE FROM instance_rules WHERE domain = ? AND list = ?`
+	} else {
+		q = `DELETE FROM instance_rules WHERE domain = $1 AND list = $2`
+	}
+	res, err := s.db.Exec(q, domain, list)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetInstanceRules returns all block and allow list entries, ordered by domain.
+func (s *Store) GetInstanceRules() ([]InstanceRule, error) {
+	rows, err := s.db.Query(`SELECT domain, list, ts FROM instance_rules ORDER BY list, domain`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rules []InstanceRule
+	for rows.Next() {
+		var r InstanceRule
+		if err := rows.Scan(&r.Domain, &r.List, &r.Timestamp); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// ─── Bridge Exclusions ────────────────────────────────────────────────────────
+
+// BridgeExclusion is one author whose posts are not bridged.
+type BridgeExclusion struct {
+	Author    string `json:"author"` // Bluesky DID or AP actor URL
+	Label     string `json:"label"`  // handle as entered, for display
+	Timestamp string `json:"ts"`
+}
+
+// AddBridgeExclusion excludes author from bridging. Returns false if the
+// author is already excluded.
+func (s *Store) AddBridgeExclusion(author, label string) (bool, error) {
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	var q string
+	if s.driver == "sqlite" {
+		q = `INSERT OR IGNORE INTO bridge_exclusions (author, label, ts) VALUES (?, ?, ?)`
+	} else {
+		q = `INSERT INTO bridge_exclusions (author, label, ts) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	}
+	res, err := s.db.Exec(q, author, label, ts)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RemoveBridgeExclusion bridges author again. Returns false if the author
+// was not excluded.
+func (s *Store) RemoveBridgeExclusion(author string) (bool, error) {
+	var q string
+	if s.driver == "sqlite" {
+		q = `DELETE FROM bridge_exclusions WHERE author = ?`
+	} else {
+		q = `DELETE FROM bridge_exclusions WHERE author = $1`
+	}
+	res, err := s.db.Exec(q, author)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetBridgeExclusions returns all excluded authors, ordered by author.
+func (s *Store) GetBridgeExclusions() ([]BridgeExclusion, error) {
+	rows, err := s.db.Query(`SELECT author, label, ts FROM bridge_exclusions ORDER BY author`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var exclusions []BridgeExclusion
+	for rows.Next() {
+		var e BridgeExclusion
+		if err := rows.Scan(&e.Author, &e.Label, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, e)
+	}
+	return exclusions, rows.Err()
+}
+
 // ─── Stats ────────────────────────────────────────────────────────────────────
 
 // StoreStats holds aggregate counts returned by Stats.
@@ -451,11 +1427,16 @@ type StoreStats struct {
 	// Account resync
 	LastResyncAt    string // ISO 8601 timestamp of last profile resync; empty if never run
 	LastResyncCount string // e.g. "42/43" (ok/total) from last resync
+	// Follow reconciliation
+	LastReconcileAt     string // ISO 8601 timestamp of last kind-3 reconciliation; empty if never run
+	LastReconcileResult string // e.g. "in sync" or "followed 2, unfollowed 1"
+	// Outbound delivery
+	DeliveryQueueDepth int // failed AP deliveries awaiting retry
 }
 
 // Stats returns aggregate counts for the given followed actor URL.
 // The 9 original single-column queries are reduced to 2 batched SQL statements
-// plus 3 KV lookups, using FILTER (ANSI SQL, supported by SQLite ≥ 3.30 and PostgreSQL).
+// plus 6 KV lookups, using FILTER (ANSI SQL, supported by SQLite ≥ 3.30 and PostgreSQL).
 func (s *Store) Stats(followedID string) (StoreStats, error) {
 	var st StoreStats
 
@@ -480,8 +1461,8 @@ func (s *Store) Stats(followedID string) (StoreStats, error) {
 		return st, err
 	}
 
-	// ── Query 2: object and actor-key counts ──────────────────────────────────
-	// CTE combines counts across two tables in a single roundtrip.
+	// ── Query 2: object, actor-key and delivery-queue counts ──────────────────
+	// CTE combines counts across three tables in a single roundtrip.
 	const objectsQ = `
 		WITH obj AS (
 			SELECT
@@ -491,12 +1472,15 @@ func (s *Store) Stats(followedID string) (StoreStats, error) {
 			FROM objects
 		), ak AS (
 			SELECT COUNT(*) AS actor_key_count FROM actor_keys
+		), dq AS (
+			SELECT COUNT(*) AS delivery_queue_depth FROM delivery_queue
 		)
-		SELECT fediverse_objects, bsky_objects, total_objects, actor_key_count
-		FROM obj, ak`
+		SELECT fediverse_objects, bsky_objects, total_objects, actor_key_count, delivery_queue_depth
+		FROM obj, ak, dq`
 
 	if err := s.db.QueryRow(objectsQ).Scan(
 		&st.FediverseObjects, &st.BskyObjects, &st.TotalObjects, &st.ActorKeyCount,
+		&st.DeliveryQueueDepth,
 	); err != nil {
 		return st, err
 	}
@@ -506,6 +1490,8 @@ func (s *Store) Stats(followedID string) (StoreStats, error) {
 	st.BskyLastPoll, _ = s.GetKV("bsky_last_poll_at")
 	st.LastResyncAt, _ = s.GetKV("last_resync_at")
 	st.LastResyncCount, _ = s.GetKV("last_resync_count")
+	st.LastReconcileAt, _ = s.GetKV("last_reconcile_at")
+	st.LastReconcileResult, _ = s.GetKV("last_reconcile_result")
 	return st, nil
 }
 
@@ -0,0 +1,253 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestStore opens a migrated SQLite store in a temporary directory.
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "klistr.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestEventAddresses(t *testing.T) {
+	s := openTestStore(t)
+
+	// Addresses written to kv by earlier versions move to their table.
+	if err := s.SetKV("event_addr_e1", "30023:pk:post"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if addr, ok := s.GetEventAddress("e1"); !ok || addr != "30023:pk:post" {
+		t.Fatalf("migrated address = %q, %v", addr, ok)
+	}
+	if _, ok := s.GetKV("event_addr_e1"); ok {
+		t.Error("kv row left behind after migration")
+	}
+
+	if err := s.SetEventAddress("e2", "30023:pk:post"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetEventAddress("e3", "30023:pk:other"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddObject("https://a.example/articles/3", "e3"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting the bridged object drops its address.
+	if err := s.DeleteObject("https://a.example/articles/3", "e3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.GetEventAddress("e3"); ok {
+		t.Error("address kept after DeleteObject")
+	}
+
+	// Deleting the address drops every version recorded under it.
+	if err := s.DeleteAddress("30023:pk:post"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"e1", "e2"} {
+		if _, ok := s.GetEventAddress(id); ok {
+			t.Errorf("address of %s kept after DeleteAddress", id)
+		}
+	}
+}
+
+func TestNIP05Names(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.SetKV("nip05_name_alice_at_a.example", "https://a.example/users/alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if actor, ok := s.GetNIP05Name("Alice_at_a.example"); !ok || actor != "https://a.example/users/alice" {
+		t.Fatalf("migrated name = %q, %v", actor, ok)
+	}
+	if _, ok := s.GetKV("nip05_name_alice_at_a.example"); ok {
+		t.Error("kv row left behind after migration")
+	}
+
+	// A renamed actor keeps only its new name.
+	if err := s.SetNIP05Name("alice2_at_a.example", "https://a.example/users/alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.GetNIP05Name("alice_at_a.example"); ok {
+		t.Error("old name kept after rename")
+	}
+	if _, ok := s.GetNIP05Name("alice2_at_a.example"); !ok {
+		t.Error("new name not recorded")
+	}
+
+	// Names go with the actor.
+	if err := s.DeleteActorKey("https://a.example/users/alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.GetNIP05Name("alice2_at_a.example"); ok {
+		t.Error("name kept after DeleteActorKey")
+	}
+}
+
+func TestChatRevs(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.SetKV("bsky_chat_rev_legacy", "rev1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if rev, ok := s.GetChatRev("legacy"); !ok || rev != "rev1" {
+		t.Fatalf("migrated rev = %q, %v", rev, ok)
+	}
+	if err := s.SetChatRev("active", "rev2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is older than an hour ago; migrated rows have no timestamp.
+	if n, err := s.PruneChatRevs(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("PruneChatRevs(-1h) = %d, %v", n, err)
+	}
+	// Everything updated so far is older than an hour from now, except the
+	// migrated row, which is kept until it is updated.
+	if n, err := s.PruneChatRevs(time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Fatalf("PruneChatRevs(+1h) = %d, %v", n, err)
+	}
+	if _, ok := s.GetChatRev("active"); ok {
+		t.Error("stale cursor kept")
+	}
+	if _, ok := s.GetChatRev("legacy"); !ok {
+		t.Error("migrated cursor pruned before its first update")
+	}
+}
+
+func TestFollowRequests(t *testing.T) {
+	s := openTestStore(t)
+	const me = "https://bridge.example/users/alice"
+
+	for _, followed := range []string{"https://a.example/users/bob", "https://a.example/users/carol"} {
+		if err := s.MarkFollowRequested(me, followed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.MarkFollowRequested("https://bridge.example/users/other", "https://a.example/users/bob"); err != nil {
+		t.Fatal(err)
+	}
+	requests, err := s.GetFollowRequests(me)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("GetFollowRequests = %v, want bob and carol", requests)
+	}
+
+	if err := s.MarkFollowAccepted(me, "https://a.example/users/bob"); err != nil {
+		t.Fatal(err)
+	}
+	if requests, _ := s.GetFollowRequests(me); len(requests) != 1 || requests["https://a.example/users/carol"] == "" {
+		t.Fatalf("after accept: %v, want only carol", requests)
+	}
+
+	// Old requests are pruned only when the follow itself is gone.
+	if err := s.MarkFollowRequested(me, "https://a.example/users/bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddFollow(me, "https://a.example/users/bob"); err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.PruneFollowRequests(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 { // carol and the other follower's request
+		t.Errorf("pruned %d requests, want 2", n)
+	}
+	if requests, _ := s.GetFollowRequests(me); len(requests) != 1 || requests["https://a.example/users/bob"] == "" {
+		t.Errorf("after prune: %v, want only bob", requests)
+	}
+}
+
+func TestReplaceObject(t *testing.T) {
+	s := openTestStore(t)
+	const note = "https://a.example/notes/1"
+
+	if err := s.AddObject(note, "e1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddObjectAlias("https://a.example/@bob/1", "e1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReplaceObject(note, "e1", "e2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReplaceObject(note, "e2", "e3"); err != nil {
+		t.Fatal(err)
+	}
+
+	if id, ok := s.GetNostrIDForObject(note); !ok || id != "e3" {
+		t.Errorf("GetNostrIDForObject = %q, %v, want e3", id, ok)
+	}
+	for _, old := range []string{"e1", "e2", "e3"} {
+		if apID, ok := s.GetAPIDForObject(old); !ok || apID != note {
+			t.Errorf("GetAPIDForObject(%s) = %q, %v, want the note", old, apID, ok)
+		}
+	}
+	if _, ok := s.GetNostrIDForObject("https://a.example/@bob/1"); ok {
+		t.Error("alias of the replaced event still resolves")
+	}
+
+	// Deleting the note drops the replaced events too.
+	if err := s.DeleteObject(note, "e3"); err != nil {
+		t.Fatal(err)
+	}
+	for _, old := range []string{"e1", "e2", "e3"} {
+		if _, ok := s.GetAPIDForObject(old); ok {
+			t.Errorf("GetAPIDForObject(%s) resolves after the note was deleted", old)
+		}
+	}
+}
+
+func TestPendingFollows(t *testing.T) {
+	s := openTestStore(t)
+	const me = "https://bridge.example/users/alice"
+
+	for _, actor := range []string{"https://a.example/users/bob", "https://a.example/users/carol"} {
+		if err := s.AddPendingFollow(actor+"#follow", actor, me, "0 followers (minimum 5)"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A repeated Follow replaces the held one.
+	if err := s.AddPendingFollow("https://a.example/users/bob#follow2", "https://a.example/users/bob", me, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.CountPendingFollows(); err != nil || n != 2 {
+		t.Fatalf("CountPendingFollows = %d, %v; want 2", n, err)
+	}
+	if f, ok := s.GetPendingFollow("https://a.example/users/bob", me); !ok || f.ActivityID != "https://a.example/users/bob#follow2" {
+		t.Errorf("GetPendingFollow = %+v, %v; want the repeated Follow", f, ok)
+	}
+
+	if n, err := s.PrunePendingFollows(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PrunePendingFollows(an hour ago) = %d, %v; want 0", n, err)
+	}
+	if n, err := s.PrunePendingFollows(time.Now().Add(time.Minute)); err != nil || n != 2 {
+		t.Errorf("PrunePendingFollows(now) = %d, %v; want 2", n, err)
+	}
+	if n, _ := s.CountPendingFollows(); n != 0 {
+		t.Errorf("%d follows still held after prune, want 0", n)
+	}
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// invalidateChannel is the PostgreSQL NOTIFY channel on which object cache
+// invalidations are broadcast between klistr processes sharing one database.
+const invalidateChannel = "klistr_object_invalidate"
+
+// maxNotifyPayload keeps NOTIFY payloads under PostgreSQL's 8000-byte limit.
+// Larger invalidations are sent as a full flush instead.
+const maxNotifyPayload = 7000
+
+// invalidation is the NOTIFY payload for one object cache invalidation.
+type invalidation struct {
+	Origin  string   `json:"origin"`          // sending process; its own notifications are ignored
+	APIDs   []string `json:"ap,omitempty"`    // evicted from objectsByAP
+	NostrID string   `json:"nostr,omitempty"` // evicted from objectsByNostr
+	All     bool     `json:"all,omitempty"`   // flush both caches
+}
+
+// startInvalidationListener subscribes to object cache invalidations from
+// other processes on the same PostgreSQL database. Each process only evicts
+// its own in-memory caches, so without this a mapping deleted by one instance
+// keeps resolving from another's cache. A no-op on SQLite.
+func (s *Store) startInvalidationListener(dsn string) error {
+	if s.driver != "postgres" {
+		return nil
+	}
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return err
+	}
+	s.origin = hex.EncodeToString(origin)
+
+	l := pq.NewListener(dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("object cache listener: connection event", "event", ev, "error", err)
+		}
+	})
+	if err := l.Listen(invalidateChannel); err != nil {
+		l.Close()
+		return err
+	}
+	s.listener = l
+	go s.listenInvalidations(l)
+	return nil
+}
+
+// listenInvalidations applies notifications until the listener is closed.
+func (s *Store) listenInvalidations(l *pq.Listener) {
+	for {
+		select {
+		case n, ok := <-l.Notify:
+			if !ok {
+				return
+			}
+			if n == nil {
+				// Reconnected: notifications sent while disconnected are lost.
+				slog.Info("object cache listener: reconnected, flushing object caches")
+				s.evictAllObjects()
+				continue
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(n.Extra), &inv); err != nil {
+				slog.Warn("object cache listener: bad payload", "error", err)
+				continue
+			}
+			if inv.Origin == s.origin {
+				continue
+			}
+			if inv.All {
+				s.evictAllObjects()
+				continue
+			}
+			s.evictObjects(inv.APIDs, inv.NostrID)
+		case <-time.After(90 * time.Second):
+			go l.Ping() // detect a dead connection while idle
+		}
+	}
+}
+
+// notifyInvalidate tells other processes to evict apIDs and nostrID from their
+// object caches. Best-effort: failures are logged, never returned, since the
+// database write it follows has already succeeded. A no-op on SQLite.
+func (s *Store) notifyInvalidate(apIDs []string, nostrID string) {
+	if s.listener == nil {
+		return
+	}
+	payload, _ := json.Marshal(invalidation{Origin: s.origin, APIDs: apIDs, NostrID: nostrID})
+	if len(payload) > maxNotifyPayload {
+		payload, _ = json.Marshal(invalidation{Origin: s.origin, All: true})
+	}
+	if _, err := s.db.Exec(`SELECT pg_notify($1, $2)`, invalidateChannel, string(payload)); err != nil {
+		slog.Warn("failed to broadcast object cache invalidation", "nostr_id", nostrID, "error", err)
+	}
+}
+
+// evictObjects drops apIDs and nostrID from the local object caches.
+func (s *Store) evictObjects(apIDs []string, nostrID string) {
+	for _, apID := range apIDs {
+		s.objectsByAP.Delete(apID)
+	}
+	if nostrID != "" {
+		s.objectsByNostr.Delete(nostrID)
+	}
+}
+
+// evictAllObjects empties the local object caches.
+func (s *Store) evictAllObjects() {
+	s.objectsByAP.Clear()
+	s.objectsByNostr.Clear()
+}
@@ -0,0 +1,76 @@
+package nostr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// delegationExpiry is the created_at bound of every delegation token. Bridged
+// events keep the original post's timestamp, so there is no lower bound.
+const delegationExpiry = "created_at<4102444800" // 2100-01-01
+
+// delegationConditions returns the NIP-26 conditions of the token for events
+// of kind: that one kind only (verifiers AND the conditions together), so a
+// token can never vouch for a kind it was not issued for.
+func delegationConditions(kind int) string {
+	return "kind=" + strconv.Itoa(kind) + "&" + delegationExpiry
+}
+
+// delegable reports whether events of kind may carry a delegation tag. NIP-26
+// clients attribute delegated events to the delegator, so a bridged author's
+// replaceable (kind-0 profile, kind-3, kind-10002 relay list …) or addressable
+// event would compete with the local user's own; only regular kinds qualify.
+func delegable(kind int) bool {
+	return nostr.IsRegularKind(kind)
+}
+
+// SetDelegation enables NIP-26 delegation: events of regular kinds signed for
+// remote actors (Sign) carry a "delegation" tag from the local user, so anyone can verify
+// that the derived key was issued by this bridge. NIP-26 clients attribute a
+// delegated event to its delegator, so with delegation on every bridged
+// kind-1 note and reaction appears as the local user's own. Call once at
+// startup, before any concurrent use.
+func (s *Signer) SetDelegation(enabled bool) {
+	s.delegation = enabled
+}
+
+// delegationTag returns the NIP-26 delegation tag from the local user to the
+// derived key of apID for events of kind. Tokens are deterministic per key
+// and kind, and cached.
+func (s *Signer) delegationTag(apID string, kind int) (nostr.Tag, error) {
+	cacheKey := strconv.Itoa(kind) + ":" + apID
+	s.mu.RLock()
+	tag, ok := s.delegationCache[cacheKey]
+	s.mu.RUnlock()
+	if ok {
+		return tag, nil
+	}
+
+	delegatee, err := s.PublicKey(apID)
+	if err != nil {
+		return nil, fmt.Errorf("derive delegatee key: %w", err)
+	}
+	sk, err := hex.DecodeString(s.localPrivKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode private key: %w", err)
+	}
+	priv, _ := btcec.PrivKeyFromBytes(sk)
+	conditions := delegationConditions(kind)
+	hash := sha256.Sum256([]byte("nostr:delegation:" + delegatee + ":" + conditions))
+	sig, err := schnorr.Sign(priv, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("sign delegation token: %w", err)
+	}
+	tag = nostr.Tag{"delegation", s.localPubKey, conditions, hex.EncodeToString(sig.Serialize())}
+
+	s.mu.Lock()
+	s.delegationCache[cacheKey] = tag
+	s.mu.Unlock()
+	return tag, nil
+}
@@ -0,0 +1,77 @@
+package nostr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// Keys of the NIP-26 reference example.
+const (
+	nip26DelegatorSK = "ee35e8bb71131c02c1d7e73231daa48e9953d329a4b701f7133c8f46dd21139c"
+	nip26DelegatorPK = "8e0d3d3eb2881ec137a11debe736a9086715a8c8beeeda615780064d68bc25dd"
+	nip26DelegateeSK = "777e4f60b4aa87937e13acc84f7abcc3c93cc035cb4c1e9f7a9086dd78fffce1"
+	nip26DelegateePK = "477318cfb5427b9cfc66a9fa376150c1ddbc62115ae27cef72417eb959691396"
+)
+
+// TestDelegationTagNIP26Vector builds a delegation tag between the NIP-26
+// reference keys and verifies it the way NIP-26 clients do: the token is the
+// delegator's BIP-340 signature over
+// sha256("nostr:delegation:<delegatee pubkey>:<conditions>"). The reference
+// token itself cannot be compared byte for byte, since BIP-340 nonces differ
+// between implementations.
+func TestDelegationTagNIP26Vector(t *testing.T) {
+	const apID = "https://remote.example/users/bob"
+	s := NewSigner(nip26DelegatorSK, nip26DelegatorPK)
+	// Pin the derived key of apID to the reference delegatee.
+	s.cache[apID] = nip26DelegateeSK
+
+	tag, err := s.delegationTag(apID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tag) != 4 || tag[0] != "delegation" || tag[1] != nip26DelegatorPK {
+		t.Fatalf("tag = %v, want [delegation %s <conditions> <token>]", tag, nip26DelegatorPK)
+	}
+	if want := "kind=1&created_at<4102444800"; tag[2] != want {
+		t.Errorf("conditions = %q, want %q", tag[2], want)
+	}
+
+	sigBytes, err := hex.DecodeString(tag[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := schnorr.ParseSignature(sigBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkBytes, _ := hex.DecodeString(nip26DelegatorPK)
+	pk, err := schnorr.ParsePubKey(pkBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256([]byte("nostr:delegation:" + nip26DelegateePK + ":" + tag[2]))
+	if !sig.Verify(hash[:], pk) {
+		t.Fatal("delegation token does not verify against the NIP-26 delegation string")
+	}
+
+	// Sign attaches the tag to regular kinds only.
+	s.SetDelegation(true)
+	note := &nostr.Event{Kind: 1, CreatedAt: 1677426298, Content: "hello"}
+	if err := s.Sign(note, apID); err != nil {
+		t.Fatal(err)
+	}
+	if note.PubKey != nip26DelegateePK || note.Tags.GetFirst([]string{"delegation"}) == nil {
+		t.Errorf("kind-1 event: pubkey %s, tags %v; want the delegatee and a delegation tag", note.PubKey, note.Tags)
+	}
+	profile := &nostr.Event{Kind: 0, CreatedAt: 1677426298, Content: "{}"}
+	if err := s.Sign(profile, apID); err != nil {
+		t.Fatal(err)
+	}
+	if profile.Tags.GetFirst([]string{"delegation"}) != nil {
+		t.Errorf("kind-0 event carries a delegation tag: %v", profile.Tags)
+	}
+}
@@ -2,11 +2,17 @@ package nostr
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/bridge"
+	"github.com/klppl/klistr/internal/notify"
 )
 
 // FollowStore is the subset of db.Store used by the kind-3 handler.
@@ -19,6 +25,22 @@ type FollowStore interface {
 	GetActorForKey(pubkey string) (string, bool)
 }
 
+// TagStore indexes the hashtags of locally-originated notes so the AP
+// /tags/{tag} collection can list them.
+type TagStore interface {
+	AddObjectTags(apID string, tags []string) error
+	DeleteObjectTags(apID string) error
+}
+
+// AddressStore records the NIP-01 addresses of addressable events (articles)
+// published by the local user, so inbound AP replies to them can be bridged as
+// NIP-22 comments, and forgets them when the events are deleted.
+type AddressStore interface {
+	SetEventAddress(eventID, address string) error
+	DeleteEventAddress(eventID string) error
+	DeleteAddress(address string) error
+}
+
 // BskyPoster is the interface for the optional Bluesky outbound bridge.
 type BskyPoster interface {
 	Handle(ctx context.Context, event *nostr.Event)
@@ -44,8 +66,47 @@ type Handler struct {
 	BskyPoster BskyPoster
 	// RelayUpdater syncs the relay list when a kind-10002 event is received (optional).
 	RelayUpdater RelayUpdater
+	// Tags indexes hashtags of outbound notes (optional).
+	Tags TagStore
+	// Addresses records the addresses of outbound articles (optional).
+	Addresses AddressStore
+	// Decrypt decrypts the private items of the local user's NIP-51 mute
+	// list (optional; without it only public entries are honoured).
+	Decrypt func(content string) (string, error)
+	// BridgedKinds limits which ToggleableKinds are federated (optional; nil
+	// federates every kind). Updated live by the admin settings API.
+	BridgedKinds *BridgedKinds
+	// LocalPubKey is the local user's pubkey. When set, events by other
+	// authors — delivered by the RelayPool's mention
+	// subscription — are reported via Notifier instead of bridged.
+	LocalPubKey string
+	// Notifier receives mention and zap webhook events (nil-safe).
+	Notifier *notify.Webhook
+	// ProfileZaps selects how zaps of a local user's profile, seen through
+	// the mention subscription, are federated: "zap", "note" or "off".
+	ProfileZaps string
+	// ZapReceiptSigner returns the nostrPubkey of a local user's LNURL
+	// provider, the only key trusted to sign their zap receipts (optional;
+	// without it zaps of the profile are never federated).
+	ZapReceiptSigner func(ctx context.Context, recipient string) (string, error)
+
+	// mutes holds the pubkeys muted by the local user's kind-10000; events
+	// involving them are not bridged (mutes.go).
+	mutes muteList
+
+	mediaMu     sync.Mutex
+	recentMedia map[string]time.Time // attachment URL → when a kind-1 federated it
 }
 
+const (
+	// fileShareGrace delays federating a kind-1063 so that a kind-1 embedding
+	// the same file (imeta) — many clients publish both — can arrive first.
+	// The file then already reached the Fediverse as that note's attachment.
+	fileShareGrace = 30 * time.Second
+	// recentMediaTTL is how long a kind-1 attachment URL is remembered.
+	recentMediaTTL = 10 * time.Minute
+)
+
 // Handle processes a single Nostr event.
 func (h *Handler) Handle(ctx context.Context, event *nostr.Event) {
 	if !nostr.IsValidPublicKey(event.PubKey) {
@@ -61,30 +122,47 @@ func (h *Handler) Handle(ctx context.Context, event *nostr.Event) {
 	if !h.isEligible(event) {
 		return
 	}
+	if h.isMuted(event) {
+		slog.Debug("skipping event involving a muted pubkey", "id", event.ID, "kind", event.Kind)
+		return
+	}
+
+	if !h.isLocal(event.PubKey) {
+		h.handleMention(ctx, event)
+		return
+	}
 
 	slog.Debug("handling nostr event", "id", event.ID, "kind", event.Kind, "pubkey", event.PubKey[:8])
 
-	switch event.Kind {
-	case 0:
-		h.handleKind0(ctx, event)
-	case 1:
-		h.handleKind1(ctx, event)
-	case 3:
-		h.handleKind3(ctx, event)
-	case 5:
-		h.handleKind5(ctx, event)
-	case 6:
-		h.handleKind6(ctx, event)
-	case 7:
-		h.handleKind7(ctx, event)
-	case 9735:
-		h.handleKind9735(ctx, event)
-	case 10002:
-		h.handleKind10002(event)
-	case 1068:
-		h.handleKind1068(ctx, event)
-	case 30023:
-		h.handleKind30023(ctx, event)
+	if !h.BridgedKinds.Enabled(event.Kind) {
+		slog.Debug("outbound bridging disabled for kind", "id", event.ID, "kind", event.Kind)
+	} else {
+		switch event.Kind {
+		case 0:
+			h.handleKind0(ctx, event)
+		case 1:
+			h.handleKind1(ctx, event)
+		case 3:
+			h.handleKind3(ctx, event)
+		case 5:
+			h.handleKind5(ctx, event)
+		case 6:
+			h.handleKind6(ctx, event)
+		case 7:
+			h.handleKind7(ctx, event)
+		case 9735:
+			h.handleKind9735(ctx, event)
+		case 10002:
+			h.handleKind10002(event)
+		case kindMuteList:
+			h.handleKind10000(event)
+		case 1063:
+			h.handleKind1063(ctx, event)
+		case 1068:
+			h.handleKind1068(ctx, event)
+		case 30023:
+			h.handleKind30023(ctx, event)
+		}
 	}
 
 	// Mirror to Bluesky if bridge is configured.
@@ -106,18 +184,97 @@ func (h *Handler) handleKind0(ctx context.Context, event *nostr.Event) {
 
 func (h *Handler) handleKind1(ctx context.Context, event *nostr.Event) {
 	if ap.IsRepost(event) {
-		activity := ap.ToAnnounce(event, h.TC)
-		if activity != nil {
-			h.Federator.Federate(ctx, ap.ActivityToMap(activity))
-		}
+		h.federateAnnounce(ctx, h.TC, event)
 	} else {
+		// Remember the text so a mirror echoing it back is not re-bridged.
+		bridge.RecordContent(event.Content)
 		note := ap.ToNote(event, h.TC)
+		h.addressReplyAuthor(ctx, note, h.TC)
+		h.indexTags(note.ID, event)
+		h.recordMedia(note.Attachment)
 		activity := ap.BuildCreate(note, h.TC.LocalDomain)
 		h.Federator.Federate(ctx, activity)
 	}
 }
 
+// addressReplyAuthor adds the author of the remote Fediverse post a note
+// replies to as a recipient and Mention, so the Create reaches their inbox
+// and notifies them even when their pubkey is not p-tagged or not in
+// actor_keys. Mastodon only notifies mentioned accounts.
+func (h *Handler) addressReplyAuthor(ctx context.Context, note *ap.Note, tc *ap.TransmuteContext) {
+	author := remoteObjectAuthor(ctx, note.InReplyTo, tc)
+	if author == "" || slices.Contains(note.To, author) || slices.Contains(note.CC, author) {
+		return
+	}
+	note.CC = append(note.CC, author)
+	mention := ap.Mention{Type: "Mention", Href: author}
+	if actor, err := ap.FetchActor(ctx, author); err == nil && actor.PreferredUsername != "" {
+		mention.Name = "@" + actor.PreferredUsername + "@" + bridge.ExtractHost(author)
+	}
+	note.Tag = append(note.Tag, mention)
+}
+
+// remoteObjectAuthor returns the author of a remote Fediverse object, or ""
+// when objectID is local (a native Nostr event) or its author cannot be
+// resolved.
+func remoteObjectAuthor(ctx context.Context, objectID string, tc *ap.TransmuteContext) string {
+	if !ap.IsActorID(objectID) || ap.IsLocalID(objectID, tc.LocalDomain) {
+		return ""
+	}
+	author, err := ap.FetchObjectAuthor(ctx, objectID)
+	if err != nil {
+		slog.Debug("could not resolve object author", "object", objectID, "error", err)
+		return ""
+	}
+	if author == tc.LocalActorURL {
+		return ""
+	}
+	return author
+}
+
+// indexTags records the event's "t" tags against the AP object ID.
+func (h *Handler) indexTags(apID string, event *nostr.Event) {
+	if h.Tags == nil {
+		return
+	}
+	var tags []string
+	for _, tag := range event.Tags {
+		if len(tag) >= 2 && tag[0] == "t" && tag[1] != "" {
+			tags = append(tags, tag[1])
+		}
+	}
+	if len(tags) == 0 {
+		return
+	}
+	if err := h.Tags.AddObjectTags(apID, tags); err != nil {
+		slog.Warn("failed to index note hashtags", "id", event.ID, "error", err)
+	}
+}
+
 func (h *Handler) handleKind5(ctx context.Context, event *nostr.Event) {
+	if h.Tags != nil {
+		for _, tag := range event.Tags {
+			if len(tag) >= 2 && tag[0] == "e" {
+				_ = h.Tags.DeleteObjectTags(strings.TrimRight(h.TC.LocalDomain, "/") + "/objects/" + tag[1])
+			}
+		}
+	}
+	if h.Addresses != nil {
+		for _, tag := range event.Tags {
+			if len(tag) < 2 {
+				continue
+			}
+			switch tag[0] {
+			case "e":
+				_ = h.Addresses.DeleteEventAddress(tag[1])
+			case "a":
+				// Only the author can delete an address (NIP-09).
+				if parts := strings.SplitN(tag[1], ":", 3); len(parts) == 3 && parts[1] == event.PubKey {
+					_ = h.Addresses.DeleteAddress(tag[1])
+				}
+			}
+		}
+	}
 	activity := ap.ToDelete(event, h.TC)
 	if activity != nil {
 		h.Federator.Federate(ctx, ap.ActivityToMap(activity))
@@ -125,10 +282,23 @@ func (h *Handler) handleKind5(ctx context.Context, event *nostr.Event) {
 }
 
 func (h *Handler) handleKind6(ctx context.Context, event *nostr.Event) {
-	activity := ap.ToAnnounce(event, h.TC)
-	if activity != nil {
-		h.Federator.Federate(ctx, ap.ActivityToMap(activity))
+	h.federateAnnounce(ctx, h.TC, event)
+}
+
+// federateAnnounce federates a repost or quote-only note as an Announce. When
+// the reposted event is a bridged Fediverse post, its author is cc'd so the
+// boost reaches their inbox and shows up as a reblog; followers alone would
+// never deliver it to them.
+func (h *Handler) federateAnnounce(ctx context.Context, tc *ap.TransmuteContext, event *nostr.Event) {
+	activity := ap.ToAnnounce(event, tc)
+	if activity == nil {
+		return
+	}
+	object, _ := activity.Object.(string)
+	if author := remoteObjectAuthor(ctx, object, tc); author != "" && !slices.Contains(activity.CC, author) {
+		activity.CC = append(activity.CC, author)
 	}
+	h.Federator.Federate(ctx, ap.ActivityToMap(activity))
 }
 
 func (h *Handler) handleKind7(ctx context.Context, event *nostr.Event) {
@@ -136,11 +306,23 @@ func (h *Handler) handleKind7(ctx context.Context, event *nostr.Event) {
 	if content == "+" || content == "" {
 		activity := ap.ToLike(event, h.TC)
 		if activity != nil {
+			// A like of a bridged Fediverse post must reach its author's
+			// inbox to show up as a favourite; the p tag alone only does
+			// when actor_keys knows the pubkey.
+			object, _ := activity.Object.(string)
+			if author := remoteObjectAuthor(ctx, object, h.TC); author != "" && !slices.Contains(activity.To, author) {
+				activity.To = append(activity.To, author)
+			}
 			h.Federator.Federate(ctx, ap.ActivityToMap(activity))
 		}
 	} else if isEmojiContent(content) {
 		activity := ap.ToEmojiReact(event, h.TC)
 		if activity != nil {
+			object, _ := activity["object"].(string)
+			to, _ := activity["to"].([]string)
+			if author := remoteObjectAuthor(ctx, object, h.TC); author != "" && !slices.Contains(to, author) {
+				activity["to"] = append(to, author)
+			}
 			h.Federator.Federate(ctx, activity)
 		}
 	}
@@ -206,6 +388,53 @@ func (h *Handler) handleKind10002(event *nostr.Event) {
 	}
 }
 
+// handleKind1063 federates a NIP-94 file metadata event as a Note with the
+// file attached, after fileShareGrace. It is skipped when a kind-1 already
+// carried the file as an attachment.
+func (h *Handler) handleKind1063(ctx context.Context, event *nostr.Event) {
+	note := ap.ToFileNote(event, h.TC)
+	if note == nil {
+		return
+	}
+	time.AfterFunc(fileShareGrace, func() {
+		if h.mediaFederated(note.Attachment[0].URL) {
+			slog.Debug("kind1063: file already federated as a note attachment", "id", event.ID)
+			return
+		}
+		h.indexTags(note.ID, event)
+		h.Federator.Federate(ctx, ap.BuildCreate(note, h.TC.LocalDomain))
+	})
+}
+
+// recordMedia remembers the URLs of attachments federated with a kind-1.
+func (h *Handler) recordMedia(atts []ap.Attachment) {
+	if len(atts) == 0 {
+		return
+	}
+	now := time.Now()
+	h.mediaMu.Lock()
+	defer h.mediaMu.Unlock()
+	if h.recentMedia == nil {
+		h.recentMedia = make(map[string]time.Time)
+	}
+	for url, at := range h.recentMedia {
+		if now.Sub(at) > recentMediaTTL {
+			delete(h.recentMedia, url)
+		}
+	}
+	for _, att := range atts {
+		h.recentMedia[att.URL] = now
+	}
+}
+
+// mediaFederated reports whether a kind-1 federated url within recentMediaTTL.
+func (h *Handler) mediaFederated(url string) bool {
+	h.mediaMu.Lock()
+	defer h.mediaMu.Unlock()
+	at, ok := h.recentMedia[url]
+	return ok && time.Since(at) <= recentMediaTTL
+}
+
 func (h *Handler) handleKind1068(ctx context.Context, event *nostr.Event) {
 	question := ap.ToQuestion(event, h.TC)
 	if question != nil {
@@ -214,6 +443,12 @@ func (h *Handler) handleKind1068(ctx context.Context, event *nostr.Event) {
 }
 
 func (h *Handler) handleKind30023(ctx context.Context, event *nostr.Event) {
+	if h.Addresses != nil {
+		addr := fmt.Sprintf("%d:%s:%s", event.Kind, event.PubKey, event.Tags.GetD())
+		if err := h.Addresses.SetEventAddress(event.ID, addr); err != nil {
+			slog.Warn("failed to store article address", "id", event.ID, "error", err)
+		}
+	}
 	article := ap.ToArticle(event, h.TC)
 	if article != nil {
 		h.Federator.Federate(ctx, ap.BuildCreate(article, h.TC.LocalDomain))
@@ -291,12 +526,47 @@ func (h *Handler) resolveAPActor(ctx context.Context, pubkey string) string {
 // ─── Eligibility check ────────────────────────────────────────────────────────
 
 // isEligible returns true if this event should be processed by the bridge.
-// Since the relay subscription is already filtered to the local user's pubkey,
-// the only check needed is to skip events bridged from AP (loop prevention).
+// The relay subscription is already filtered to the local user's pubkey (and
+// events mentioning them), so what is left to skip are drafts of any kind
+// (IsDraft), which must never produce a Create or Update, and events bridged
+// from AP (loop prevention).
 func (h *Handler) isEligible(event *nostr.Event) bool {
+	if IsDraft(event) {
+		slog.Debug("skipping draft event", "id", event.ID, "kind", event.Kind)
+		return false
+	}
 	return !ap.IsProxyEvent(event)
 }
 
+// Draft kinds that must never leave the relay: kind-30024 long-form drafts
+// (NIP-23) and NIP-37 draft wraps.
+const (
+	kindLongFormDraft = 30024
+	kindDraftWrap     = 31234
+)
+
+// IsDraft reports whether event is an unpublished draft: a draft kind, or any
+// event carrying a ["draft"] tag or a ["status","draft"] tag. Publishing the
+// draft (e.g. a kind-30023 with the same d tag and no draft tag) bridges
+// normally, since nothing is recorded for the draft itself.
+func IsDraft(event *nostr.Event) bool {
+	if event.Kind == kindLongFormDraft || event.Kind == kindDraftWrap {
+		return true
+	}
+	for _, tag := range event.Tags {
+		if len(tag) == 0 {
+			continue
+		}
+		switch {
+		case tag[0] == "draft" && (len(tag) < 2 || tag[1] != "false"):
+			return true
+		case tag[0] == "status" && len(tag) >= 2 && strings.EqualFold(tag[1], "draft"):
+			return true
+		}
+	}
+	return false
+}
+
 // ─── Helpers ─────────────────────────────────────────────────────────────────
 
 // isEmojiContent returns true if the string contains at least one rune that
@@ -0,0 +1,74 @@
+package nostr
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/ap"
+)
+
+// TestHandleDraftsNotFederated checks that drafts never produce a Create or
+// Update: the Federator is never asked for the followers to deliver to.
+func TestHandleDraftsNotFederated(t *testing.T) {
+	sk := nostr.GeneratePrivateKey()
+	pk, _ := nostr.GetPublicKey(sk)
+
+	tests := []struct {
+		name      string
+		kind      int
+		tags      nostr.Tags
+		federated bool
+	}{
+		{"note", 1, nil, true},
+		{"note with draft=false", 1, nostr.Tags{{"draft", "false"}}, true},
+		{"article", 30023, nostr.Tags{{"d", "post"}, {"title", "Post"}}, true},
+		{"note with draft tag", 1, nostr.Tags{{"draft"}}, false},
+		{"profile with draft tag", 0, nostr.Tags{{"draft"}}, false},
+		{"article with draft status", 30023, nostr.Tags{{"d", "post"}, {"status", "draft"}}, false},
+		{"long-form draft", kindLongFormDraft, nostr.Tags{{"d", "post"}}, false},
+		{"draft wrap", kindDraftWrap, nostr.Tags{{"d", "post"}, {"k", "1"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var federated atomic.Bool
+			h := &Handler{
+				TC: &ap.TransmuteContext{
+					LocalDomain:   "https://bridge.example",
+					LocalActorURL: "https://bridge.example/users/alice",
+					GetAPIDForObject: func(string) (string, bool) {
+						return "", false
+					},
+				},
+				Federator: &ap.Federator{
+					LocalDomain: "https://bridge.example",
+					GetFollowers: func(string) ([]string, error) {
+						federated.Store(true)
+						return nil, nil
+					},
+				},
+				LocalPubKey: pk,
+			}
+			event := &nostr.Event{
+				PubKey:    pk,
+				CreatedAt: nostr.Now(),
+				Kind:      tt.kind,
+				Tags:      tt.tags,
+				Content:   "hello",
+			}
+			if tt.kind == 0 {
+				event.Content = `{"name":"alice"}`
+			}
+			if err := event.Sign(sk); err != nil {
+				t.Fatal(err)
+			}
+
+			h.Handle(context.Background(), event)
+			if got := federated.Load(); got != tt.federated {
+				t.Errorf("federated = %v, want %v", got, tt.federated)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package nostr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// kindHandlerInformation is the NIP-89 application handler event.
+const kindHandlerInformation = 31990
+
+// handlerKinds are the event kinds klistr publishes for Fediverse and Bluesky
+// authors, advertised in its NIP-89 handler information.
+var handlerKinds = []int{0, 1, 6, 7, 1111, 30023}
+
+// handlerInfoEvents builds klistr's NIP-89 announcement, unsigned: a kind-0
+// profile for the bridge's service identity and a kind-31990 handler
+// information event listing handlerKinds, with web handler URLs of the form
+// webURL/<bech32> for the entities the bridge can send back to their source.
+// Both are proxied by serviceActorURL, the bridge's AP service actor.
+func handlerInfoEvents(serviceActorURL, webURL, host string) ([]*nostr.Event, error) {
+	metadata, err := json.Marshal(map[string]string{
+		"name":         "klistr",
+		"display_name": "klistr (" + host + ")",
+		"about":        "Nostr ↔ Fediverse and Bluesky bridge at " + host + ". Events from this bridge carry a proxy tag linking to the original post.",
+		"website":      "https://github.com/klppl/klistr",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal handler metadata: %w", err)
+	}
+	proxy := nostr.Tag{"proxy", serviceActorURL, "activitypub"}
+
+	tags := nostr.Tags{{"d", "klistr"}}
+	for _, k := range handlerKinds {
+		tags = append(tags, nostr.Tag{"k", strconv.Itoa(k)})
+	}
+	webURL = strings.TrimRight(webURL, "/")
+	for _, entity := range []string{"nevent", "note", "nprofile", "npub"} {
+		tags = append(tags, nostr.Tag{"web", webURL + "/<bech32>", entity})
+	}
+	tags = append(tags, proxy)
+
+	now := nostr.Now()
+	return []*nostr.Event{
+		{Kind: 0, Content: string(metadata), CreatedAt: now, Tags: nostr.Tags{proxy}},
+		{Kind: kindHandlerInformation, Content: string(metadata), CreatedAt: now, Tags: tags},
+	}, nil
+}
+
+// PublishHandlerInfo signs klistr's NIP-89 announcement (handlerInfoEvents)
+// with the service actor's derived key and publishes it, so NIP-89-aware
+// clients can attribute bridged events and offer to open them at their
+// source. Both events are replaceable; republishing on every start is cheap.
+func PublishHandlerInfo(ctx context.Context, signer *Signer, publisher *Publisher, serviceActorURL, webURL, host string) error {
+	events, err := handlerInfoEvents(serviceActorURL, webURL, host)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := signer.Sign(event, serviceActorURL); err != nil {
+			return fmt.Errorf("sign kind-%d: %w", event.Kind, err)
+		}
+		if err := publisher.Publish(ctx, event); err != nil {
+			return fmt.Errorf("publish kind-%d: %w", event.Kind, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package nostr
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ToggleableKinds are the event kinds whose outbound federation the admin can
+// switch off: notes, reposts, reactions, zaps, polls and articles. Other kinds
+// (profile, contacts, deletions, relay and mute lists, files) are always
+// handled, since the bridge needs them to stay consistent.
+var ToggleableKinds = []int{1, 6, 7, 9735, 1068, 30023}
+
+// BridgedKinds is the set of ToggleableKinds the Handler federates to the
+// Fediverse. It is shared between the server, which loads it from the kv
+// store and updates it from the settings API, and the Handler, which checks
+// it before transmuting an event. Until Set is called — and for a nil
+// *BridgedKinds — every kind is bridged.
+type BridgedKinds struct {
+	enabled atomic.Pointer[map[int]bool]
+}
+
+// Set replaces the enabled kinds. Kinds outside ToggleableKinds are ignored.
+func (b *BridgedKinds) Set(kinds []int) {
+	m := make(map[int]bool, len(kinds))
+	for _, k := range kinds {
+		if slices.Contains(ToggleableKinds, k) {
+			m[k] = true
+		}
+	}
+	b.enabled.Store(&m)
+}
+
+// Enabled reports whether events of kind should be federated.
+func (b *BridgedKinds) Enabled(kind int) bool {
+	if b == nil || !slices.Contains(ToggleableKinds, kind) {
+		return true
+	}
+	m := b.enabled.Load()
+	return m == nil || (*m)[kind]
+}
+
+// List returns the enabled ToggleableKinds in ToggleableKinds order.
+func (b *BridgedKinds) List() []int {
+	out := make([]int, 0, len(ToggleableKinds))
+	for _, k := range ToggleableKinds {
+		if b.Enabled(k) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// ParseKinds parses a comma-separated list of event kinds as persisted in the
+// kv store, skipping invalid entries. An empty string yields an empty,
+// non-nil list (no kinds enabled).
+func ParseKinds(s string) []int {
+	kinds := []int{}
+	for _, f := range strings.Split(s, ",") {
+		if k, err := strconv.Atoi(strings.TrimSpace(f)); err == nil {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
+
+// FormatKinds is the inverse of ParseKinds.
+func FormatKinds(kinds []int) string {
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = strconv.Itoa(k)
+	}
+	return strings.Join(parts, ",")
+}
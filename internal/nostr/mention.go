@@ -0,0 +1,116 @@
+package nostr
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+
+	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/notify"
+)
+
+// maxMentionExcerpt caps the content excerpt sent with a mention webhook.
+const maxMentionExcerpt = 280
+
+// isLocal reports whether pubkey is the local user's. Without LocalPubKey
+// every author counts as local, as the pool then only subscribes to the
+// local author.
+func (h *Handler) isLocal(pubkey string) bool {
+	return h.LocalPubKey == "" || pubkey == h.LocalPubKey
+}
+
+// handleMention reports an event by another author that p-tags a local user
+// (RelayPool.MentionKinds) to the webhook: a kind-9735 zap receipt that
+// passes verifyZapReceipt as nostr.zap, anything else as nostr.mention. Nothing is federated — the
+// event isn't the local user's to publish — except zaps of a local user's
+// profile when ProfileZaps is set (see federateProfileZap).
+func (h *Handler) handleMention(ctx context.Context, event *nostr.Event) {
+	var recipient string
+	for _, tag := range event.Tags {
+		if len(tag) >= 2 && tag[0] == "p" && tag[1] != event.PubKey && h.isLocal(tag[1]) {
+			recipient = tag[1]
+			break
+		}
+	}
+	if recipient == "" {
+		return
+	}
+
+	if event.Kind == 9735 {
+		zap, err := h.verifyZapReceipt(ctx, event, recipient)
+		if err != nil {
+			slog.Warn("ignoring unverified zap receipt", "id", event.ID, "error", err)
+			return
+		}
+		data := map[string]string{
+			"id":          event.ID,
+			"recipient":   npub(recipient),
+			"sender":      npub(zap.Sender),
+			"amount_sats": strconv.FormatInt(zap.AmountMsats/1000, 10),
+			"comment":     zap.Comment,
+		}
+		id := tagValue(event.Tags, "e")
+		if id != "" {
+			data["event"] = id
+		}
+		slog.Info("zap received", "id", event.ID, "sender", zap.Sender, "msats", zap.AmountMsats)
+		h.Notifier.Notify(notify.EventNostrZap, data)
+		if id == "" {
+			h.federateProfileZap(ctx, event, recipient)
+		}
+		return
+	}
+
+	excerpt := []rune(event.Content)
+	if len(excerpt) > maxMentionExcerpt {
+		excerpt = append(excerpt[:maxMentionExcerpt], '…')
+	}
+	slog.Info("mentioned on nostr", "id", event.ID, "kind", event.Kind, "author", event.PubKey[:8])
+	h.Notifier.Notify(notify.EventNostrMention, map[string]string{
+		"id":        event.ID,
+		"kind":      strconv.Itoa(event.Kind),
+		"author":    npub(event.PubKey),
+		"recipient": npub(recipient),
+		"content":   string(excerpt),
+	})
+}
+
+// federateProfileZap bridges a verified receipt for a zap of recipient's
+// profile as ProfileZaps selects: "zap" sends a Zap activity on recipient's actor,
+// "note" a public "⚡ Zapped …" Note. Zaps of notes are bridged by the note
+// author's own receipt (handleKind9735) instead.
+func (h *Handler) federateProfileZap(ctx context.Context, event *nostr.Event, recipient string) {
+	if !h.BridgedKinds.Enabled(9735) {
+		return
+	}
+	switch h.ProfileZaps {
+	case "zap":
+		h.Federator.Federate(ctx, ap.ToProfileZap(event, h.TC))
+	case "note":
+		h.Federator.Federate(ctx, ap.BuildCreate(ap.ToProfileZapNote(event, h.TC), h.TC.LocalDomain))
+	default:
+		return
+	}
+	slog.Info("bridged profile zap", "id", event.ID, "mode", h.ProfileZaps)
+}
+
+// tagValue returns the value of the first name tag, or "".
+func tagValue(tags nostr.Tags, name string) string {
+	for _, tag := range tags {
+		if len(tag) >= 2 && tag[0] == name {
+			return tag[1]
+		}
+	}
+	return ""
+}
+
+// npub encodes a hex pubkey as npub, falling back to the hex.
+func npub(pubkey string) string {
+	if n, err := nip19.EncodePublicKey(pubkey); err == nil {
+		return n
+	}
+	return pubkey
+}
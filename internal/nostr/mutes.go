@@ -0,0 +1,100 @@
+package nostr
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// kindMuteList is the NIP-51 mute list, a replaceable event.
+const kindMuteList = 10000
+
+// muteList is the set of pubkeys on the local user's latest kind-10000.
+type muteList struct {
+	mu        sync.RWMutex
+	pubkeys   map[string]struct{}
+	updatedAt nostr.Timestamp
+}
+
+// replace swaps in the pubkeys of a mute list published at createdAt.
+// Returns false when the same or a newer list is already loaded, as happens
+// each time the relay subscription restarts.
+func (m *muteList) replace(pubkeys map[string]struct{}, createdAt nostr.Timestamp) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pubkeys != nil && createdAt <= m.updatedAt {
+		return false
+	}
+	m.pubkeys = pubkeys
+	m.updatedAt = createdAt
+	return true
+}
+
+func (m *muteList) contains(pubkey string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.pubkeys[pubkey]
+	return ok
+}
+
+func (m *muteList) len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.pubkeys)
+}
+
+// handleKind10000 loads the muted pubkeys of the local user's NIP-51 mute
+// list: public "p" tags, plus the private ones in the encrypted content when
+// Decrypt is set. Older lists than the one loaded are ignored.
+func (h *Handler) handleKind10000(event *nostr.Event) {
+	pubkeys := make(map[string]struct{})
+	addTags := func(tags nostr.Tags) {
+		for _, tag := range tags {
+			if len(tag) >= 2 && tag[0] == "p" && nostr.IsValidPublicKey(tag[1]) {
+				pubkeys[tag[1]] = struct{}{}
+			}
+		}
+	}
+	addTags(event.Tags)
+	if event.Content != "" && h.Decrypt != nil {
+		plain, err := h.Decrypt(event.Content)
+		if err != nil {
+			slog.Warn("kind10000: failed to decrypt private mute entries", "id", event.ID, "error", err)
+		} else {
+			var private nostr.Tags
+			if err := json.Unmarshal([]byte(plain), &private); err != nil {
+				slog.Warn("kind10000: invalid private mute entries", "id", event.ID, "error", err)
+			} else {
+				addTags(private)
+			}
+		}
+	}
+	if h.mutes.replace(pubkeys, event.CreatedAt) {
+		slog.Info("kind10000: mute list loaded", "muted", len(pubkeys))
+	}
+}
+
+// isMuted reports whether event is an interaction that involves a muted
+// pubkey — authored by one or p-tagging one — and must not be bridged.
+// Only posts and interactions are checked: profile, contact, deletion and
+// list events always pass.
+func (h *Handler) isMuted(event *nostr.Event) bool {
+	switch event.Kind {
+	case 0, 3, 5, kindMuteList, 10002:
+		return false
+	}
+	if h.mutes.len() == 0 {
+		return false
+	}
+	if h.mutes.contains(event.PubKey) {
+		return true
+	}
+	for _, tag := range event.Tags {
+		if len(tag) >= 2 && tag[0] == "p" && h.mutes.contains(tag[1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,14 +2,20 @@ package nostr
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nbd-wtf/go-nostr"
 	"golang.org/x/time/rate"
+
+	"github.com/klppl/klistr/internal/notify"
 )
 
 // EventHandler is a function that processes a Nostr event.
@@ -18,6 +24,9 @@ type EventHandler func(ctx context.Context, event *nostr.Event)
 const (
 	cbCooldown            = 5 * time.Minute
 	relayEventConcurrency = 20
+
+	// kvRelayCircuits is the KV key holding the persisted circuit state.
+	kvRelayCircuits = "relay_circuits"
 )
 
 // cbThreshold is a var (not const) so it can be overridden at startup via
@@ -33,17 +42,61 @@ func SetCircuitBreakerThreshold(n int) {
 	}
 }
 
+// cbMaxCooldown caps the backoff of a repeatedly failing relay. A var for the
+// same reason as cbThreshold; see SetCircuitBreakerMaxCooldown.
+var cbMaxCooldown = time.Hour
+
+// SetCircuitBreakerMaxCooldown caps how long a relay's circuit stays open.
+// Call once at startup, before any Publisher is created, to override the
+// default of one hour.
+func SetCircuitBreakerMaxCooldown(d time.Duration) {
+	if d > 0 {
+		cbMaxCooldown = d
+	}
+}
+
 // relayCircuit is a per-relay circuit breaker.
 type relayCircuit struct {
 	mu            sync.Mutex
-	failCount     int
+	failCount     int // consecutive failures since the last success
+	trips         int // times opened since the last success; doubles the cooldown
 	openedAt      time.Time
 	open          bool
 	permanentOpen bool // true when relay requires PoW; stays open until manual reset
+
+	// errRate is an exponential moving average of publish failures (0 = every
+	// recent publish succeeded, 1 = every one failed). Unlike failCount it
+	// survives a success, so a flapping relay ranks below a steady one.
+	errRate float64
+
+	// lastSuccess is when the relay last accepted (or policy-rejected) a
+	// publish; avgLatency is an exponential moving average of the round-trip
+	// time of those publishes. Neither is persisted.
+	lastSuccess time.Time
+	avgLatency  time.Duration
+}
+
+// errRateDecay is the weight kept from the previous errRate on each publish.
+const errRateDecay = 0.8
+
+// latencyDecay is the weight kept from the previous avgLatency on each
+// answered publish.
+const latencyDecay = 0.8
+
+// cooldown returns how long the circuit stays open: cbCooldown, doubled for
+// each further trip without an intervening success, capped at cbMaxCooldown.
+// Caller must hold cb.mu.
+func (cb *relayCircuit) cooldown() time.Duration {
+	d := cbCooldown
+	for i := 1; i < cb.trips && d < cbMaxCooldown; i++ {
+		d *= 2
+	}
+	return min(d, cbMaxCooldown)
 }
 
 // isOpen returns true when the circuit is open (relay should be bypassed).
-// Resets to closed once cbCooldown has elapsed (half-open retry), unless permanentOpen is set.
+// Closes once the cooldown has elapsed (half-open retry), unless permanentOpen
+// is set. failCount is kept, so a single failed retry reopens the circuit.
 func (cb *relayCircuit) isOpen() bool {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
@@ -53,9 +106,8 @@ func (cb *relayCircuit) isOpen() bool {
 	if !cb.open {
 		return false
 	}
-	if time.Since(cb.openedAt) >= cbCooldown {
+	if time.Since(cb.openedAt) >= cb.cooldown() {
 		cb.open = false
-		cb.failCount = 0
 		return false
 	}
 	return true
@@ -78,21 +130,32 @@ func (cb *relayCircuit) recordFailure() bool {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 	cb.failCount++
+	cb.errRate = cb.errRate*errRateDecay + (1 - errRateDecay)
 	if !cb.open && cb.failCount >= cbThreshold {
 		cb.open = true
 		cb.openedAt = time.Now()
+		cb.trips++
 		return true
 	}
 	return false
 }
 
-// recordSuccess resets all failure state. Returns true if the circuit was open.
-func (cb *relayCircuit) recordSuccess() bool {
+// recordSuccess resets all failure state and folds the publish round-trip
+// time rtt into avgLatency. Returns true if the circuit was open.
+func (cb *relayCircuit) recordSuccess(rtt time.Duration) bool {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 	was := cb.open || cb.failCount > 0
 	cb.open = false
 	cb.failCount = 0
+	cb.trips = 0
+	cb.errRate *= errRateDecay
+	cb.lastSuccess = time.Now()
+	if cb.avgLatency == 0 {
+		cb.avgLatency = rtt
+	} else {
+		cb.avgLatency = time.Duration(float64(cb.avgLatency)*latencyDecay + float64(rtt)*(1-latencyDecay))
+	}
 	return was
 }
 
@@ -103,6 +166,19 @@ func (cb *relayCircuit) reset() {
 	cb.open = false
 	cb.permanentOpen = false
 	cb.failCount = 0
+	cb.trips = 0
+	cb.errRate = 0
+}
+
+// health returns whether the relay is currently usable and a rank (lower is
+// healthier) derived from its consecutive failures and failure history.
+func (cb *relayCircuit) health() (usable bool, rank int) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	open := cb.permanentOpen || (cb.open && time.Since(cb.openedAt) < cb.cooldown())
+	// Bucket errRate into tenths so near-equal relays tie and fall back to the
+	// configured preference order.
+	return !open, cb.failCount*10 + int(cb.errRate*10)
 }
 
 // RelayStatus describes a relay and its circuit-breaker state.
@@ -110,16 +186,18 @@ type RelayStatus struct {
 	URL               string
 	CircuitOpen       bool
 	FailCount         int
-	CooldownRemaining int // seconds remaining until circuit resets
+	CooldownRemaining int           // seconds remaining until circuit resets
+	LastSuccess       time.Time     // last accepted publish; zero if none since startup
+	AvgLatency        time.Duration // moving average publish round-trip time; zero if unknown
 }
 
 func (cb *relayCircuit) status(url string) RelayStatus {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	open := cb.permanentOpen || (cb.open && time.Since(cb.openedAt) < cbCooldown)
+	open := cb.permanentOpen || (cb.open && time.Since(cb.openedAt) < cb.cooldown())
 	var remaining int
 	if open && !cb.permanentOpen {
-		r := cbCooldown - time.Since(cb.openedAt)
+		r := cb.cooldown() - time.Since(cb.openedAt)
 		if r > 0 {
 			remaining = int(r.Seconds())
 		}
@@ -129,12 +207,55 @@ func (cb *relayCircuit) status(url string) RelayStatus {
 		CircuitOpen:       open,
 		FailCount:         cb.failCount,
 		CooldownRemaining: remaining,
+		LastSuccess:       cb.lastSuccess,
+		AvgLatency:        cb.avgLatency,
+	}
+}
+
+// circuitState is the persisted form of a relayCircuit.
+type circuitState struct {
+	FailCount int   `json:"fail_count"`
+	Trips     int   `json:"trips,omitempty"`
+	Open      bool  `json:"open,omitempty"`
+	OpenedAt  int64 `json:"opened_at,omitempty"` // unix ts
+	PoW       bool  `json:"pow,omitempty"`
+}
+
+// snapshot returns the circuit's persistable state; ok is false for a
+// healthy circuit, which needs no entry.
+func (cb *relayCircuit) snapshot() (st circuitState, ok bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.failCount == 0 && !cb.open && !cb.permanentOpen {
+		return circuitState{}, false
+	}
+	st = circuitState{FailCount: cb.failCount, Trips: cb.trips, Open: cb.open, PoW: cb.permanentOpen}
+	if cb.open {
+		st.OpenedAt = cb.openedAt.Unix()
+	}
+	return st, true
+}
+
+// restore loads persisted state into a fresh circuit.
+func (cb *relayCircuit) restore(st circuitState) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failCount = st.FailCount
+	cb.trips = st.Trips
+	cb.open = st.Open || st.PoW
+	cb.permanentOpen = st.PoW
+	if st.OpenedAt > 0 {
+		cb.openedAt = time.Unix(st.OpenedAt, 0)
 	}
 }
 
 // ─── RelayPool ────────────────────────────────────────────────────────────────
 
-// RelayPool manages read-relay subscriptions for a single Nostr author.
+// DefaultKinds are the event kinds the RelayPool subscribes to from the local
+// authors when Kinds is unset: everything Handler bridges.
+var DefaultKinds = []int{0, 1, 3, 5, 6, 7, 1063, 1068, 9735, 10002, 30023}
+
+// RelayPool manages read-relay subscriptions for the local Nostr author.
 type RelayPool struct {
 	mu           sync.RWMutex
 	readRelays   []string
@@ -142,6 +263,33 @@ type RelayPool struct {
 	handler      EventHandler
 	sem          chan struct{}
 	restartCh    chan struct{} // closed/sent when relay list changes
+
+	// MaxConnections caps how many read relays are subscribed at once; relays
+	// beyond the cap (in list order) are not connected. 0 means unlimited.
+	MaxConnections int
+	// IdleTimeout recycles a relay connection that has delivered no events for
+	// this long. The pool reconnects on demand, which clears half-open sockets
+	// that would otherwise silently stop delivering. 0 disables recycling.
+	IdleTimeout time.Duration
+	// Kinds are the event kinds subscribed from the local author; nil means
+	// DefaultKinds. The current mute list is always fetched as well.
+	Kinds []int
+	// MentionKinds are the event kinds subscribed from any author when they
+	// p-tag the local user, e.g. 1 for mentions and 9735 for zap receipts.
+	// Empty disables the subscription. Handler reports such events instead
+	// of bridging them (see Handler.handleMention).
+	MentionKinds []int
+
+	pool      *nostr.SimplePool
+	lastEvent map[string]time.Time // normalized relay URL → last event (or subscribe) time
+}
+
+// RelayConnState describes the firehose connection state of one read relay.
+type RelayConnState struct {
+	URL         string
+	Subscribed  bool // within MaxConnections and part of the active subscription
+	Connected   bool
+	LastEventAt time.Time
 }
 
 // NewRelayPool creates a relay pool that subscribes to events from authorPubKey.
@@ -153,6 +301,7 @@ func NewRelayPool(readRelays, _ []string, authorPubKey string, handler EventHand
 		handler:      handler,
 		sem:          make(chan struct{}, relayEventConcurrency),
 		restartCh:    make(chan struct{}, 1),
+		lastEvent:    make(map[string]time.Time),
 	}
 }
 
@@ -194,6 +343,21 @@ func (rp *RelayPool) RemoveRelay(url string) bool {
 	return false
 }
 
+// SetRelays replaces the read list with urls and triggers a single restart
+// when it changed.
+func (rp *RelayPool) SetRelays(urls []string) {
+	rp.mu.Lock()
+	changed := !slices.Equal(rp.readRelays, urls)
+	rp.readRelays = append([]string{}, urls...)
+	rp.mu.Unlock()
+	if changed {
+		select {
+		case rp.restartCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
 // Relays returns a copy of the current read relay list.
 func (rp *RelayPool) Relays() []string {
 	rp.mu.RLock()
@@ -201,6 +365,113 @@ func (rp *RelayPool) Relays() []string {
 	return append([]string{}, rp.readRelays...)
 }
 
+// subscribedRelays returns the read relays within the MaxConnections cap.
+func (rp *RelayPool) subscribedRelays() []string {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+	relays := append([]string{}, rp.readRelays...)
+	if rp.MaxConnections > 0 && len(relays) > rp.MaxConnections {
+		relays = relays[:rp.MaxConnections]
+	}
+	return relays
+}
+
+// ConnStates returns the connection state of every configured read relay.
+func (rp *RelayPool) ConnStates() []RelayConnState {
+	all := rp.Relays()
+	active := make(map[string]bool)
+	for _, url := range rp.subscribedRelays() {
+		active[url] = true
+	}
+
+	rp.mu.RLock()
+	pool := rp.pool
+	states := make([]RelayConnState, 0, len(all))
+	for _, url := range all {
+		nm := nostr.NormalizeURL(url)
+		st := RelayConnState{URL: url, Subscribed: active[url], LastEventAt: rp.lastEvent[nm]}
+		if pool != nil {
+			if relay, ok := pool.Relays.Load(nm); ok && relay != nil {
+				st.Connected = relay.IsConnected()
+			}
+		}
+		states = append(states, st)
+	}
+	rp.mu.RUnlock()
+	return states
+}
+
+// touch records activity for a relay, resetting its idle timer.
+func (rp *RelayPool) touch(url string) {
+	rp.mu.Lock()
+	rp.lastEvent[nostr.NormalizeURL(url)] = time.Now()
+	rp.mu.Unlock()
+}
+
+// reapIdle periodically closes connections to subscribed relays that have not
+// delivered an event within IdleTimeout. SimplePool's subscription loop then
+// reconnects and resubscribes from the current time.
+func (rp *RelayPool) reapIdle(ctx context.Context) {
+	interval := min(max(rp.IdleTimeout/2, time.Second), time.Minute)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, url := range rp.subscribedRelays() {
+			nm := nostr.NormalizeURL(url)
+			rp.mu.RLock()
+			last, seen := rp.lastEvent[nm]
+			rp.mu.RUnlock()
+			if !seen || time.Since(last) < rp.IdleTimeout {
+				continue
+			}
+			relay, ok := rp.pool.Relays.Load(nm)
+			if !ok || relay == nil || !relay.IsConnected() {
+				continue
+			}
+			slog.Info("recycling idle relay connection", "relay", url, "idle", time.Since(last).Round(time.Second))
+			rp.touch(url)
+			relay.Close()
+		}
+	}
+}
+
+// Filters returns the subscription filters for events created at or after
+// since: the local author's events of Kinds, its current mute list and,
+// with MentionKinds set, other authors' events p-tagging the local user.
+func (rp *RelayPool) Filters(since nostr.Timestamp) nostr.Filters {
+	authors := []string{rp.authorPubKey}
+	kinds := rp.Kinds
+	if kinds == nil {
+		kinds = DefaultKinds
+	}
+	filters := nostr.Filters{{
+		Kinds:   kinds,
+		Authors: authors,
+		Since:   &since,
+		Limit:   0,
+	}, {
+		// The current NIP-51 mute list, whenever it was published, so
+		// muted pubkeys are known before the first event is bridged.
+		Kinds:   []int{kindMuteList},
+		Authors: []string{rp.authorPubKey},
+		Limit:   1,
+	}}
+	if len(rp.MentionKinds) > 0 {
+		filters = append(filters, nostr.Filter{
+			Kinds: rp.MentionKinds,
+			Tags:  nostr.TagMap{"p": authors},
+			Since: &since,
+			Limit: 0,
+		})
+	}
+	return filters
+}
+
 // Start begins listening to the relay firehose. Blocks until ctx is cancelled.
 func (rp *RelayPool) Start(ctx context.Context) {
 	rp.mu.RLock()
@@ -213,12 +484,24 @@ func (rp *RelayPool) Start(ctx context.Context) {
 	}
 
 	pool := nostr.NewSimplePool(ctx)
+	rp.mu.Lock()
+	rp.pool = pool
+	rp.mu.Unlock()
 	since := nostr.Now()
 
+	if rp.IdleTimeout > 0 {
+		go rp.reapIdle(ctx)
+	}
+
 	for {
-		rp.mu.RLock()
-		relays := append([]string{}, rp.readRelays...)
-		rp.mu.RUnlock()
+		relays := rp.subscribedRelays()
+		if total := len(rp.Relays()); total > len(relays) {
+			slog.Warn("relay connection limit reached; some read relays are not subscribed",
+				"limit", rp.MaxConnections, "configured", total)
+		}
+		for _, url := range relays {
+			rp.touch(url)
+		}
 
 		select {
 		case <-ctx.Done():
@@ -226,14 +509,9 @@ func (rp *RelayPool) Start(ctx context.Context) {
 		default:
 		}
 
-		slog.Info("starting relay firehose", "relays", relays, "author", rp.authorPubKey[:8])
-
-		filters := nostr.Filters{{
-			Kinds:   []int{0, 1, 3, 5, 6, 7, 1068, 9735, 10002, 30023},
-			Authors: []string{rp.authorPubKey},
-			Since:   &since,
-			Limit:   0,
-		}}
+		filters := rp.Filters(since)
+		slog.Info("starting relay firehose", "relays", relays, "author", rp.authorPubKey[:8],
+			"authors", len(filters[0].Authors), "mention_kinds", rp.MentionKinds)
 
 		subCtx, subCancel := context.WithCancel(ctx)
 		immediateRestart := make(chan struct{}, 1)
@@ -255,6 +533,9 @@ func (rp *RelayPool) Start(ctx context.Context) {
 			if ev.Event == nil {
 				continue
 			}
+			if ev.Relay != nil {
+				rp.touch(ev.Relay.URL)
+			}
 			event := ev.Event
 			select {
 			case rp.sem <- struct{}{}:
@@ -303,7 +584,8 @@ func (rp *RelayPool) Start(ctx context.Context) {
 
 // Publisher publishes Nostr events to write relays with per-relay circuit breakers.
 // A circuit opens after cbThreshold consecutive failures and stays open for cbCooldown,
-// preventing repeated connection attempts to unreachable relays.
+// doubling each time a retry fails up to cbMaxCooldown, preventing repeated
+// connection attempts to unreachable relays.
 type Publisher struct {
 	mu       sync.RWMutex
 	relays   []string
@@ -311,11 +593,33 @@ type Publisher struct {
 	pool     *nostr.SimplePool
 	poolOnce sync.Once
 	limiter  *rate.Limiter
+
+	// state persists circuit state across restarts; nil keeps it in memory.
+	state  CircuitStore
+	saveMu sync.Mutex
+
+	// Timeout bounds each relay's publish attempt. 0 uses publishDefaultTimeout.
+	Timeout time.Duration
+	// Concurrency caps how many relays are contacted at once. 0 = all at once.
+	Concurrency int
+	// Quorum is the number of relay acknowledgements Publish waits for before
+	// returning; remaining relays complete in the background. 0 is treated as 1.
+	Quorum int
+	// Notifier, if non-nil, receives a webhook event when an event cannot be
+	// published to enough relays. At most one per publishFailNotifyInterval,
+	// so a relay outage doesn't flood the webhook.
+	Notifier *notify.Webhook
+
+	lastFailNotify atomic.Int64 // unix seconds
 }
 
 const (
 	publishRateLimit = rate.Limit(2) // 2 events per second per publisher
 	publishRateBurst = 5             // burst allowance to handle short threads
+
+	publishDefaultTimeout = 15 * time.Second
+
+	publishFailNotifyInterval = time.Minute
 )
 
 // NewPublisher creates a new Publisher.
@@ -331,6 +635,64 @@ func NewPublisher(writeRelays []string) *Publisher {
 	}
 }
 
+// CircuitStore persists relay circuit-breaker state. Satisfied by *db.Store.
+type CircuitStore interface {
+	GetKV(key string) (string, bool)
+	SetKV(key, value string) error
+}
+
+// SetCircuitStore attaches store and restores the circuit state saved by a
+// previous run, so a relay that was failing before a restart stays in cooldown
+// instead of being retried immediately. State for relays that are no longer
+// configured is dropped.
+func (p *Publisher) SetCircuitStore(store CircuitStore) {
+	p.mu.Lock()
+	p.state = store
+	restored := 0
+	if raw, ok := store.GetKV(kvRelayCircuits); ok && raw != "" {
+		var saved map[string]circuitState
+		if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+			slog.Warn("failed to parse saved relay circuit state", "error", err)
+		}
+		for url, st := range saved {
+			if cb, ok := p.circuits[url]; ok {
+				cb.restore(st)
+				restored++
+			}
+		}
+	}
+	p.mu.Unlock()
+	if restored > 0 {
+		slog.Info("restored relay circuit state", "relays", restored)
+	}
+}
+
+// saveCircuits writes the state of every unhealthy circuit to the store.
+// Writes are serialised so an older snapshot never overwrites a newer one.
+func (p *Publisher) saveCircuits() {
+	p.saveMu.Lock()
+	defer p.saveMu.Unlock()
+	p.mu.RLock()
+	store := p.state
+	saved := make(map[string]circuitState)
+	for url, cb := range p.circuits {
+		if st, ok := cb.snapshot(); ok {
+			saved[url] = st
+		}
+	}
+	p.mu.RUnlock()
+	if store == nil {
+		return
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return
+	}
+	if err := store.SetKV(kvRelayCircuits, string(data)); err != nil {
+		slog.Warn("failed to save relay circuit state", "error", err)
+	}
+}
+
 // AddRelay adds a relay to the write list. Returns false if already present.
 func (p *Publisher) AddRelay(url string) bool {
 	p.mu.Lock()
@@ -348,17 +710,47 @@ func (p *Publisher) AddRelay(url string) bool {
 // RemoveRelay removes a relay from the write list. Returns false if not found.
 func (p *Publisher) RemoveRelay(url string) bool {
 	p.mu.Lock()
-	defer p.mu.Unlock()
 	for i, r := range p.relays {
 		if r == url {
 			p.relays = append(p.relays[:i], p.relays[i+1:]...)
 			delete(p.circuits, url)
+			p.mu.Unlock()
+			p.saveCircuits()
 			return true
 		}
 	}
+	p.mu.Unlock()
 	return false
 }
 
+// SetRelays replaces the write list with urls in one step, returning the
+// relays it added and removed. Retained relays keep their circuit state.
+func (p *Publisher) SetRelays(urls []string) (added, removed []string) {
+	p.mu.Lock()
+	wanted := make(map[string]struct{}, len(urls))
+	for _, url := range urls {
+		wanted[url] = struct{}{}
+		if _, ok := p.circuits[url]; !ok {
+			p.circuits[url] = &relayCircuit{}
+		}
+		if !slices.Contains(p.relays, url) {
+			added = append(added, url)
+		}
+	}
+	for _, url := range p.relays {
+		if _, ok := wanted[url]; !ok {
+			removed = append(removed, url)
+			delete(p.circuits, url)
+		}
+	}
+	p.relays = append([]string{}, urls...)
+	p.mu.Unlock()
+	if len(removed) > 0 {
+		p.saveCircuits()
+	}
+	return added, removed
+}
+
 // Relays returns a copy of the current write relay list.
 func (p *Publisher) Relays() []string {
 	p.mu.RLock()
@@ -387,6 +779,32 @@ func (p *Publisher) RelayStatuses() []RelayStatus {
 	return statuses
 }
 
+// PreferredRelay returns the healthiest write relay for use as a relay hint in
+// event tags: relays with an open circuit are skipped, then the one with the
+// fewest consecutive failures and lowest failure history wins, with the
+// configured relay order as the tiebreaker. Health changes with every publish,
+// so the result is recomputed on each call. Falls back to the first configured
+// relay when none is usable, and returns "" when no relays are configured.
+func (p *Publisher) PreferredRelay() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	best, bestRank := "", 0
+	for _, url := range p.relays {
+		cb, ok := p.circuits[url]
+		if !ok {
+			cb = &relayCircuit{}
+		}
+		usable, rank := cb.health()
+		if usable && (best == "" || rank < bestRank) {
+			best, bestRank = url, rank
+		}
+	}
+	if best == "" && len(p.relays) > 0 {
+		return p.relays[0]
+	}
+	return best
+}
+
 // ResetCircuit clears the circuit-breaker state for a specific relay.
 func (p *Publisher) ResetCircuit(url string) {
 	p.mu.RLock()
@@ -394,6 +812,7 @@ func (p *Publisher) ResetCircuit(url string) {
 	p.mu.RUnlock()
 	if cb != nil {
 		cb.reset()
+		p.saveCircuits()
 		slog.Info("relay circuit breaker reset", "relay", url)
 	}
 }
@@ -419,9 +838,28 @@ func (p *Publisher) getPool() *nostr.SimplePool {
 }
 
 // Publish publishes an event to all configured write relays.
-// Relays with open circuits are skipped. If at least one relay succeeds, no error is returned.
-// An independent 15-second timeout is used so short-lived caller contexts don't abort delivery.
-func (p *Publisher) Publish(ctx context.Context, event *nostr.Event) error {
+// Relays with open circuits are skipped. Relays are contacted concurrently (at
+// most Concurrency at a time), each with its own Timeout, and Publish returns
+// as soon as Quorum of them have accepted the event; the rest finish in the
+// background and still feed their circuit breakers. An error is returned only
+// when the quorum can no longer be reached. Cancelling ctx stops the wait but
+// not the in-flight relay publishes, so short-lived callers don't abort delivery.
+func (p *Publisher) Publish(ctx context.Context, event *nostr.Event) (err error) {
+	defer func() {
+		if err == nil || ctx.Err() != nil || p.Notifier == nil {
+			return
+		}
+		now := time.Now().Unix()
+		last := p.lastFailNotify.Load()
+		if now-last >= int64(publishFailNotifyInterval/time.Second) && p.lastFailNotify.CompareAndSwap(last, now) {
+			p.Notifier.Notify(notify.EventPublishFailed, map[string]string{
+				"id":    event.ID,
+				"kind":  strconv.Itoa(event.Kind),
+				"error": err.Error(),
+			})
+		}
+	}()
+
 	p.mu.RLock()
 	allRelays := append([]string{}, p.relays...)
 	p.mu.RUnlock()
@@ -453,61 +891,115 @@ func (p *Publisher) Publish(ctx context.Context, event *nostr.Event) error {
 		return fmt.Errorf("outbound rate limit wait: %w", err)
 	}
 
-	// Honour explicit cancellation but otherwise use an independent deadline.
-	publishCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+	quorum := p.Quorum
+	if quorum <= 0 {
+		quorum = 1
+	}
+	if quorum > len(active) {
+		quorum = len(active)
+	}
+	workers := p.Concurrency
+	if workers <= 0 || workers > len(active) {
+		workers = len(active)
+	}
+
+	// Copy the event: publishes may outlive this call.
+	evt := *event
+	results := make(chan error, len(active)) // buffered: late results never block
 	go func() {
-		select {
-		case <-ctx.Done():
-			cancel()
-		case <-publishCtx.Done():
+		sem := make(chan struct{}, workers)
+		for _, url := range active {
+			sem <- struct{}{}
+			go func(url string) {
+				defer func() { <-sem }()
+				results <- p.publishOne(url, evt)
+			}(url)
 		}
 	}()
 
 	var published, failed int
-	for result := range p.getPool().PublishMany(publishCtx, active, *event) {
-		cb := p.getCircuit(result.RelayURL)
-		if result.Error != nil {
-			if isPowRequired(result.Error) {
-				// Relay requires NIP-13 proof-of-work which klistr doesn't mine.
-				// Permanently disable until the user removes it or resets the circuit.
-				cb.openForPoW()
-				slog.Warn("relay requires proof-of-work (NIP-13); disabling until manually reset — consider removing this relay",
-					"relay", result.RelayURL, "error", result.Error)
-			} else if isPolicyRejection(result.Error) {
-				// Relay is healthy but rejected the event content via NIP-01.
-				// Record success to keep circuit closed (preventing IP bans is not needed).
-				cb.recordSuccess()
-				slog.Debug("relay rejected event by policy", "relay", result.RelayURL, "id", event.ID, "error", result.Error)
-				failed++ // Count as publish failure for this specific event
+	for published+failed < len(active) {
+		select {
+		case err := <-results:
+			if err == nil {
+				published++
+				if published >= quorum {
+					return nil
+				}
 			} else {
-				justOpened := cb.recordFailure()
-				if justOpened {
-					slog.Warn("relay circuit opened; will retry in 5 minutes",
-						"relay", result.RelayURL, "error", result.Error)
-				} else if st := cb.status(result.RelayURL); !st.CircuitOpen {
-					// Below threshold: log the individual failure.
-					slog.Warn("failed to publish event",
-						"relay", result.RelayURL, "id", event.ID, "error", result.Error,
-						"fail_count", st.FailCount)
+				failed++
+				if len(active)-failed < quorum {
+					return fmt.Errorf("published to %d of %d active relays (quorum %d)", published, len(active), quorum)
 				}
-				// Circuit already open (not just opened): suppress log to avoid spam.
 			}
-			failed++
-		} else {
-			wasOpen := cb.recordSuccess()
-			if wasOpen {
-				slog.Info("relay recovered", "relay", result.RelayURL)
-			}
-			slog.Debug("published event", "relay", result.RelayURL, "id", event.ID, "kind", event.Kind)
-			published++
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
+	return nil
+}
+
+// publishOne sends evt to a single relay within the configured timeout and
+// records the outcome on the relay's circuit breaker. Returns nil on success.
+func (p *Publisher) publishOne(url string, evt nostr.Event) error {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = publishDefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	if published == 0 && failed > 0 {
-		return fmt.Errorf("failed to publish to all %d active relays", failed)
+	var rtt time.Duration
+	relay, err := p.getPool().EnsureRelay(url)
+	if err == nil {
+		start := time.Now()
+		err = relay.Publish(ctx, evt)
+		rtt = time.Since(start)
 	}
-	return nil
+
+	cb := p.getCircuit(url)
+	if err == nil {
+		if wasOpen := cb.recordSuccess(rtt); wasOpen {
+			p.saveCircuits()
+			slog.Info("relay recovered", "relay", url)
+		}
+		slog.Debug("published event", "relay", url, "id", evt.ID, "kind", evt.Kind)
+		return nil
+	}
+
+	switch {
+	case isPowRequired(err):
+		// Relay requires NIP-13 proof-of-work which klistr doesn't mine.
+		// Permanently disable until the user removes it or resets the circuit.
+		cb.openForPoW()
+		p.saveCircuits()
+		slog.Warn("relay requires proof-of-work (NIP-13); disabling until manually reset — consider removing this relay",
+			"relay", url, "error", err)
+	case isPolicyRejection(err):
+		// Relay is healthy but rejected the event content via NIP-01.
+		// Record success to keep circuit closed (preventing IP bans is not needed).
+		if cb.recordSuccess(rtt) {
+			p.saveCircuits()
+		}
+		slog.Debug("relay rejected event by policy", "relay", url, "id", evt.ID, "error", err)
+	default:
+		// Only a circuit opening is persisted; failures below the threshold
+		// are cheap to lose on restart and would otherwise cost a DB write
+		// per failed publish.
+		justOpened := cb.recordFailure()
+		if justOpened {
+			p.saveCircuits()
+			slog.Warn("relay circuit opened",
+				"relay", url, "retry_in", time.Duration(cb.status(url).CooldownRemaining)*time.Second, "error", err)
+		} else if st := cb.status(url); !st.CircuitOpen {
+			// Below threshold: log the individual failure.
+			slog.Warn("failed to publish event",
+				"relay", url, "id", evt.ID, "error", err,
+				"fail_count", st.FailCount)
+		}
+		// Circuit already open (not just opened): suppress log to avoid spam.
+	}
+	return err
 }
 
 // isPowRequired returns true if the relay rejected the event due to a
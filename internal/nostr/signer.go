@@ -6,10 +6,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/nbd-wtf/go-nostr/nip44"
+	"github.com/nbd-wtf/go-nostr/nip59"
 	"golang.org/x/crypto/hkdf"
 )
 
@@ -20,8 +24,13 @@ import (
 type Signer struct {
 	localPrivKey string
 	localPubKey  string
+	notifyPubKey string // recipient of notification DMs; defaults to localPubKey
+	dmFormat     string // "nip04" (default) or "nip17"
+	delegation   bool   // attach NIP-26 delegation tags in Sign; see SetDelegation
 	mu           sync.RWMutex
 	cache        map[string]string // apID → derived hex privkey
+
+	delegationCache map[string]nostr.Tag // apID → NIP-26 delegation tag
 }
 
 // NewSigner creates a new Signer with the user's Nostr private and public keys.
@@ -30,6 +39,8 @@ func NewSigner(privKey, pubKey string) *Signer {
 		localPrivKey: privKey,
 		localPubKey:  pubKey,
 		cache:        make(map[string]string),
+
+		delegationCache: make(map[string]nostr.Tag),
 	}
 }
 
@@ -84,16 +95,59 @@ func (s *Signer) PublicKey(apID string) (string, error) {
 	return nostr.GetPublicKey(s.derivedPrivKey(apID))
 }
 
-// Sign derives a deterministic key for an AP actor and signs the event.
+// Sign derives a deterministic key for an AP actor and signs the event. With
+// delegation enabled, events of regular kinds first get a NIP-26 delegation
+// tag from the local user (replacing any they already have, e.g. when
+// re-signed); replaceable and addressable kinds never carry one (delegable).
 func (s *Signer) Sign(event *nostr.Event, apID string) error {
+	event.Tags = slices.DeleteFunc(event.Tags, func(t nostr.Tag) bool {
+		return len(t) > 0 && t[0] == "delegation"
+	})
+	if s.delegation && delegable(event.Kind) {
+		tag, err := s.delegationTag(apID, event.Kind)
+		if err != nil {
+			return err
+		}
+		event.Tags = append(event.Tags, tag)
+	}
 	return event.Sign(s.derivedPrivKey(apID))
 }
 
-// CreateDMToSelf creates a NIP-04 encrypted direct message addressed to the
-// local user's own pubkey. This is used for self-notifications (e.g. new
-// Fediverse follower alerts). The returned event is already signed.
-func (s *Signer) CreateDMToSelf(message string) (*nostr.Event, error) {
-	sharedSecret, err := nip04.ComputeSharedSecret(s.localPubKey, s.localPrivKey)
+// SetNotificationRecipient routes notification DMs (see CreateNotificationDM)
+// to pubkey instead of the local user. An empty pubkey restores the default.
+// Call once at startup, before any concurrent use.
+func (s *Signer) SetNotificationRecipient(pubkey string) {
+	s.notifyPubKey = pubkey
+}
+
+// SetDMFormat selects how notification DMs are sent: "nip17" for NIP-17
+// gift wraps, anything else for legacy NIP-04 kind-4 DMs (the default).
+// Call once at startup, before any concurrent use.
+func (s *Signer) SetDMFormat(format string) {
+	s.dmFormat = strings.ToLower(strings.TrimSpace(format))
+}
+
+// CreateNotificationDM creates a bridge notification (e.g. new Fediverse
+// follower alerts) as a DM from the local user to the configured
+// notification recipient — the local user's own pubkey unless overridden via
+// SetNotificationRecipient. The DM is a NIP-04 kind-4 event, or a NIP-17
+// gift wrap when SetDMFormat("nip17") was called. The returned event is
+// already signed.
+func (s *Signer) CreateNotificationDM(message string) (*nostr.Event, error) {
+	recipient := s.notifyPubKey
+	if recipient == "" {
+		recipient = s.localPubKey
+	}
+	if s.dmFormat == "nip17" {
+		return s.CreateGiftWrapTo(recipient, message)
+	}
+	return s.CreateDMTo(recipient, message)
+}
+
+// CreateDMTo creates a NIP-04 encrypted direct message from the local user
+// to recipientPubkey. The returned event is already signed.
+func (s *Signer) CreateDMTo(recipientPubkey, message string) (*nostr.Event, error) {
+	sharedSecret, err := nip04.ComputeSharedSecret(recipientPubkey, s.localPrivKey)
 	if err != nil {
 		return nil, fmt.Errorf("compute shared secret: %w", err)
 	}
@@ -105,10 +159,64 @@ func (s *Signer) CreateDMToSelf(message string) (*nostr.Event, error) {
 		Kind:      4,
 		Content:   encrypted,
 		CreatedAt: nostr.Now(),
-		Tags:      nostr.Tags{{"p", s.localPubKey}},
+		Tags:      nostr.Tags{{"p", recipientPubkey}},
 	}
 	if err := event.Sign(s.localPrivKey); err != nil {
 		return nil, fmt.Errorf("sign DM: %w", err)
 	}
 	return event, nil
 }
+
+// CreateGiftWrapToSelf creates a NIP-17 private message from the local user
+// to themselves (see CreateGiftWrapTo).
+func (s *Signer) CreateGiftWrapToSelf(message string) (*nostr.Event, error) {
+	return s.CreateGiftWrapTo(s.localPubKey, message)
+}
+
+// CreateGiftWrapTo creates a NIP-17 private message from the local user to
+// recipientPubkey: an unsigned kind-14 rumor, sealed (kind 13, signed by the
+// local user) and gift-wrapped (kind 1059, signed by a one-off key), each
+// layer NIP-44 encrypted. Only the recipient's pubkey is visible on the
+// relays. The returned event is already signed.
+func (s *Signer) CreateGiftWrapTo(recipientPubkey, message string) (*nostr.Event, error) {
+	rumor := nostr.Event{
+		Kind:      nostr.KindDirectMessage,
+		PubKey:    s.localPubKey,
+		Content:   message,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{{"p", recipientPubkey}},
+	}
+	rumor.ID = rumor.GetID()
+
+	key, err := nip44.GenerateConversationKey(recipientPubkey, s.localPrivKey)
+	if err != nil {
+		return nil, fmt.Errorf("compute conversation key: %w", err)
+	}
+	wrap, err := nip59.GiftWrap(rumor, recipientPubkey,
+		func(plaintext string) (string, error) { return nip44.Encrypt(plaintext, key) },
+		func(seal *nostr.Event) error { return seal.Sign(s.localPrivKey) },
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gift wrap DM: %w", err)
+	}
+	return &wrap, nil
+}
+
+// DecryptFromSelf decrypts content the local user encrypted to themselves,
+// such as the private items of a NIP-51 list. NIP-04 ciphertexts (with an
+// "?iv=" suffix) and NIP-44 ciphertexts are both accepted.
+func (s *Signer) DecryptFromSelf(content string) (string, error) {
+	if strings.Contains(content, "?iv=") {
+		sharedSecret, err := nip04.ComputeSharedSecret(s.localPubKey, s.localPrivKey)
+		if err != nil {
+			return "", fmt.Errorf("compute shared secret: %w", err)
+		}
+		return nip04.Decrypt(content, sharedSecret)
+	}
+	key, err := nip44.GenerateConversationKey(s.localPubKey, s.localPrivKey)
+	if err != nil {
+		return "", fmt.Errorf("compute conversation key: %w", err)
+	}
+	return nip44.Decrypt(content, key)
+}
@@ -0,0 +1,58 @@
+package nostr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/bridge"
+)
+
+// zapReceipt is a kind-9735 zap receipt that passed verifyZapReceipt.
+type zapReceipt struct {
+	Sender      string // pubkey of the zap request's author
+	AmountMsats int64  // from the paid bolt11 invoice
+	Comment     string // the zap request's content
+}
+
+// verifyZapReceipt checks a kind-9735 receipt for a zap of recipient per
+// NIP-57 appendix F: it must be signed by the nostrPubkey of recipient's LNURL
+// provider (ZapReceiptSigner), embed a validly signed kind-9734 request that
+// tags recipient, and carry a bolt11 invoice whose amount matches the
+// request's amount tag, if any. Anyone can publish a kind-9735 that p-tags the
+// user, so nothing is reported or federated without this check.
+func (h *Handler) verifyZapReceipt(ctx context.Context, receipt *nostr.Event, recipient string) (*zapReceipt, error) {
+	if h.ZapReceiptSigner == nil {
+		return nil, fmt.Errorf("no Lightning address to verify zap receipts against")
+	}
+	provider, err := h.ZapReceiptSigner(ctx, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("look up zap provider: %w", err)
+	}
+	if receipt.PubKey != provider {
+		return nil, fmt.Errorf("receipt not signed by the zap provider")
+	}
+
+	var req nostr.Event
+	if err := json.Unmarshal([]byte(tagValue(receipt.Tags, "description")), &req); err != nil || req.Kind != 9734 {
+		return nil, fmt.Errorf("receipt has no zap request")
+	}
+	if ok, err := req.CheckSignature(); err != nil || !ok {
+		return nil, fmt.Errorf("invalid zap request signature")
+	}
+	if tagValue(req.Tags, "p") != recipient {
+		return nil, fmt.Errorf("zap request does not tag the recipient")
+	}
+
+	amountMsats, ok := bridge.Bolt11AmountMsats(tagValue(receipt.Tags, "bolt11"))
+	if !ok {
+		return nil, fmt.Errorf("receipt has no bolt11 amount")
+	}
+	if requested := tagValue(req.Tags, "amount"); requested != "" && requested != strconv.FormatInt(amountMsats, 10) {
+		return nil, fmt.Errorf("bolt11 amount does not match the zap request")
+	}
+	return &zapReceipt{Sender: req.PubKey, AmountMsats: amountMsats, Comment: req.Content}, nil
+}
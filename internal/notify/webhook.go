@@ -0,0 +1,154 @@
+// Package notify delivers bridge events (new followers, follow outcomes,
+// account moves, publish failures) to an operator-configured webhook, for
+// integration with external alerting such as ntfy, Discord or dashboards.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Event types sent in the "type" field of the payload and the
+// X-Klistr-Event header.
+const (
+	EventFediverseFollower = "fediverse.follower"
+	EventBskyFollower      = "bluesky.follower"
+	EventFollowAccepted    = "follow.accepted"
+	EventFollowRejected    = "follow.rejected"
+	EventActorMoved        = "actor.moved"
+	EventPublishFailed     = "publish.failed"
+	EventNostrMention      = "nostr.mention"
+	EventNostrZap          = "nostr.zap"
+)
+
+const (
+	// deliveryTimeout bounds each POST attempt.
+	deliveryTimeout = 10 * time.Second
+	// maxAttempts is the total number of delivery attempts per event.
+	maxAttempts = 3
+	// retryBackoff is the delay before the first retry; it doubles after each.
+	retryBackoff = 2 * time.Second
+)
+
+// userAgent is sent on every webhook request.
+var userAgent = "klistr/1.0 (https://github.com/klppl/klistr)"
+
+// SetUserAgent sets the User-Agent of webhook requests; empty keeps the
+// default. Call once at startup, before any concurrent use.
+func SetUserAgent(ua string) {
+	if ua != "" {
+		userAgent = ua
+	}
+}
+
+// Payload is the JSON body POSTed to the webhook.
+type Payload struct {
+	Type string            `json:"type"`
+	Time string            `json:"time"` // RFC 3339, UTC
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// Webhook posts event payloads to a URL. When Secret is set, each request
+// carries an X-Klistr-Signature header of the form "sha256=<hex>", the
+// HMAC-SHA256 of the raw body keyed with Secret.
+//
+// A nil *Webhook is valid and drops every event, so callers need not check
+// whether webhooks are configured.
+type Webhook struct {
+	URL    string
+	Secret string
+	client *http.Client
+}
+
+// New returns a Webhook for url, or nil when url is empty.
+func New(url, secret string) *Webhook {
+	if url == "" {
+		return nil
+	}
+	return &Webhook{
+		URL:    url,
+		Secret: secret,
+		client: &http.Client{Timeout: deliveryTimeout},
+	}
+}
+
+// Notify sends an event in the background. Delivery is retried on network
+// errors, 429 and 5xx responses; the final failure is logged.
+func (w *Webhook) Notify(eventType string, data map[string]string) {
+	if w == nil {
+		return
+	}
+	body, err := json.Marshal(Payload{
+		Type: eventType,
+		Time: time.Now().UTC().Format(time.RFC3339),
+		Data: data,
+	})
+	if err != nil {
+		slog.Warn("webhook: failed to marshal payload", "type", eventType, "error", err)
+		return
+	}
+	go w.deliver(eventType, body)
+}
+
+func (w *Webhook) deliver(eventType string, body []byte) {
+	backoff := retryBackoff
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var retry bool
+		if retry, err = w.post(eventType, body); err == nil {
+			slog.Debug("webhook delivered", "type", eventType)
+			return
+		}
+		if !retry || attempt == maxAttempts {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	slog.Warn("webhook delivery failed", "type", eventType, "error", err)
+}
+
+// post makes one delivery attempt. retry reports whether a failure is
+// transient.
+func (w *Webhook) post(eventType string, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Klistr-Event", eventType)
+	if w.Secret != "" {
+		req.Header.Set("X-Klistr-Signature", "sha256="+Sign(w.Secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret — the value a
+// receiver should compare (in constant time) against X-Klistr-Signature.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
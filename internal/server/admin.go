@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
+
+	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/bridge"
+	"github.com/klppl/klistr/internal/bsky"
 )
 
 // ─── Middleware ───────────────────────────────────────────────────────────────
@@ -24,6 +29,32 @@ func (s *Server) adminAuth(next http.Handler) http.Handler {
 	})
 }
 
+// adminCORS answers cross-origin requests to the admin routes, which the
+// global corsMiddleware leaves alone. Only origins in ADMIN_CORS_ORIGINS get
+// CORS headers — with credentials, so they can send Basic Auth and the CSRF
+// token. Without a match no headers are set and browsers keep the admin API
+// same-origin. Preflights are answered before adminAuth, as browsers send
+// them without credentials.
+func (s *Server) adminCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if origin == "" || !slices.Contains(s.cfg.AdminCORSOrigins, strings.ToLower(origin)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-CSRF-Token")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // csrfMiddleware validates the X-CSRF-Token header on all non-safe requests
 // (POST, PATCH, DELETE, PUT). The token is served via GET /web/api/status and
 // stored by the dashboard JS in _csrfToken, then sent with every mutating call.
@@ -91,20 +122,39 @@ func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	jsonResponse(w, map[string]interface{}{
-		"bsky_enabled":        s.cfg.BskyEnabled(),
-		"fediverse_followers": stats.FollowerCount,
-		"bsky_followers":      stats.BskyFollowerCount,
-		"fediverse_actors":    stats.ActorKeyCount,
-		"fediverse_objects":   stats.FediverseObjects,
-		"bsky_objects":        stats.BskyObjects,
-		"bsky_last_seen":      stats.BskyLastSeen,
-		"bsky_last_poll":      stats.BskyLastPoll,
-		"total_objects":       stats.TotalObjects,
-		"last_resync_at":      stats.LastResyncAt,
-		"last_resync_count":   stats.LastResyncCount,
+		"bsky_enabled":          s.cfg.BskyEnabled(),
+		"fediverse_followers":   stats.FollowerCount,
+		"bsky_followers":        stats.BskyFollowerCount,
+		"fediverse_actors":      stats.ActorKeyCount,
+		"fediverse_objects":     stats.FediverseObjects,
+		"bsky_objects":          stats.BskyObjects,
+		"bsky_last_seen":        stats.BskyLastSeen,
+		"bsky_last_poll":        stats.BskyLastPoll,
+		"total_objects":         stats.TotalObjects,
+		"last_resync_at":        stats.LastResyncAt,
+		"last_resync_count":     stats.LastResyncCount,
+		"last_reconcile_at":     stats.LastReconcileAt,
+		"last_reconcile_result": stats.LastReconcileResult,
+		"delivery_queue_depth":  stats.DeliveryQueueDepth,
+		"echoes_suppressed":     bridge.SuppressedEchoes(),
+		"activities_ignored":    ap.IgnoredActivities(),
+		"object_cache":          ap.ObjectCacheStats(),
+		"webfinger_cache":       ap.WebFingerCacheStats(),
+		"sqlite_busy_retries":   s.store.BusyRetries(),
+		"bsky_accounts":         s.bskyAccountStates(),
 	}, http.StatusOK)
 }
 
+// bskyAccountStates returns the poll state of each bridged Bluesky account,
+// primary first.
+func (s *Server) bskyAccountStates() []bsky.PollState {
+	states := make([]bsky.PollState, 0, len(s.bskyAccounts))
+	for _, a := range s.bskyAccounts {
+		states = append(states, a.PollState())
+	}
+	return states
+}
+
 func (s *Server) handleAdminResyncAccounts(w http.ResponseWriter, r *http.Request) {
 	if s.resyncTrigger == nil {
 		jsonResponse(w, map[string]string{"message": "Account resync is not available."}, http.StatusOK)
@@ -112,6 +162,7 @@ func (s *Server) handleAdminResyncAccounts(w http.ResponseWriter, r *http.Reques
 	}
 	select {
 	case s.resyncTrigger <- struct{}{}:
+		s.auditLog("accounts_resync", "")
 		jsonResponse(w, map[string]string{"message": "Account resync triggered — profiles will refresh in the background."}, http.StatusOK)
 	default:
 		jsonResponse(w, map[string]string{"message": "Resync already queued."}, http.StatusOK)
@@ -174,6 +225,7 @@ func (s *Server) handleAdminSyncBsky(w http.ResponseWriter, r *http.Request) {
 	}
 	select {
 	case s.bskyTrigger <- struct{}{}:
+		s.auditLog("bsky_sync", "")
 		jsonResponse(w, map[string]string{"message": "Bluesky sync triggered."}, http.StatusOK)
 	default:
 		// Channel full — a sync is already queued.
@@ -242,6 +294,7 @@ body{background:var(--bg);color:var(--text);font-family:-apple-system,BlinkMacSy
 .badge::before{content:'';display:inline-block;width:6px;height:6px;border-radius:50%;background:currentColor}
 .badge-green{background:rgba(63,185,80,.15);color:var(--green)}
 .badge-muted{background:rgba(139,148,158,.12);color:var(--muted)}
+.badge-yellow{background:rgba(210,153,34,.12);color:var(--yellow)}
 
 /* Copy button */
 .copy-btn{background:none;border:none;cursor:pointer;color:var(--muted);padding:0 2px;display:inline-flex;align-items:center;opacity:.6;transition:opacity .15s}
@@ -379,6 +432,12 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
         onkeydown="if(event.key==='Enter')addRelay()">
       <button class="btn btn-surface" style="padding:5px 12px;font-size:11px" onclick="addRelay()">+ Add</button>
     </div>
+    <details style="margin-top:8px">
+      <summary style="font-size:11px;color:var(--muted);cursor:pointer" onclick="fillRelayBulk()">Paste relay list</summary>
+      <textarea id="relay-bulk-textarea" placeholder="wss://relay.damus.io&#10;wss://nos.lol"
+        style="width:100%;height:90px;margin-top:6px;background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:6px 9px;color:var(--text);font-size:11px;font-family:monospace;resize:vertical"></textarea>
+      <button class="btn btn-surface" style="padding:5px 12px;font-size:11px;margin-top:5px" onclick="replaceRelays()">Replace relay list</button>
+    </details>
     <div id="relay-msg" style="font-size:11px;color:var(--muted);margin-top:5px;min-height:15px"></div>
   </div>
 </div>
@@ -406,6 +465,10 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
         <div class="bp-row"><span class="bpl">Objects</span><span class="bpv" id="bp-ap-objects">—</span></div>
         <div class="bp-row"><span class="bpl" title="Fediverse uses a push inbox — no polling required">Last poll</span><span class="bpv sm">—</span></div>
         <div class="bp-row"><span class="bpl">Last resync</span><span class="bpv sm" id="bp-last-resync">—</span></div>
+        <div class="bp-row"><span class="bpl" title="Kind-3 ↔ follow database reconciliation">Last reconcile</span><span class="bpv sm" id="bp-last-reconcile">—</span></div>
+        <div class="bp-row"><span class="bpl" title="Failed deliveries awaiting retry">Retry queue</span><span class="bpv" id="bp-ap-queue">—</span></div>
+        <div class="bp-row"><span class="bpl" title="Fetched AP objects cached in memory (entries · hit rate)">Object cache</span><span class="bpv sm" id="bp-ap-objcache">—</span></div>
+        <div class="bp-row"><span class="bpl" title="WebFinger lookups cached in memory (entries · hit rate)">WebFinger cache</span><span class="bpv sm" id="bp-ap-wfcache">—</span></div>
       </div>
     </div>
 
@@ -424,6 +487,9 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
         <div class="bp-row"><span class="bpl">Objects</span><span class="bpv big" id="bp-total-obj">—</span></div>
         <div class="bp-row"><span class="bpl">Followers</span><span class="bpv" id="bp-total-fol">—</span></div>
         <div class="bp-row"><span class="bpl">Actors</span><span class="bpv" id="bp-total-act">—</span></div>
+        <div class="bp-row"><span class="bpl" title="Inbound notes dropped as echoes of recently bridged content">Echoes</span><span class="bpv" id="bp-total-echo">—</span></div>
+        <div class="bp-row"><span class="bpl" title="Inbound View/Listen/Read receipts dropped (no Nostr equivalent)">Receipts</span><span class="bpv" id="bp-total-ignored">—</span></div>
+        <div class="bp-row"><span class="bpl" title="SQLite writes retried because the database was locked. A steadily growing count means the load is outgrowing SQLite; consider PostgreSQL.">DB busy retries</span><span class="bpv" id="bp-total-busy">—</span></div>
       </div>
     </div>
 
@@ -500,6 +566,7 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
       <svg width="13" height="13" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2.5"><path d="M16 21v-2a4 4 0 0 0-4-4H6a4 4 0 0 0-4 4v2"/><circle cx="9" cy="7" r="4"/><line x1="19" y1="8" x2="19" y2="14"/><line x1="22" y1="11" x2="16" y2="11"/></svg>
       Import &amp; Publish Kind-3
     </button>
+    <button class="btn btn-surface" id="btn-import-preview" onclick="importFollowing(true)">Preview</button>
     <span style="font-size:12px;color:var(--muted)" id="import-status"></span>
   </div>
   <div id="import-results" style="margin-top:14px"></div>
@@ -509,10 +576,10 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
 <div class="card-full" id="import-bsky-card" style="display:none">
   <h2>Import Bluesky Following</h2>
   <p style="color:var(--muted);font-size:12px;margin-bottom:12px">
-    Paste Bluesky handles or DIDs (one per line). klistr will follow each account on Bluesky, derive their Nostr pubkey, and publish an updated kind-3 contact list — merged with your existing follows.
+    Paste Bluesky handles or DIDs (one per line). klistr will follow each account on Bluesky, derive their Nostr pubkey, and publish an updated kind-3 contact list — merged with your existing follows. List and starter-pack URLs are expanded into their members (up to 150 per set); use Preview to see how many accounts they resolve to.
   </p>
   <textarea id="import-bsky-textarea"
-    placeholder="alice.bsky.social&#10;bob.bsky.social&#10;did:plc:xxxx"
+    placeholder="alice.bsky.social&#10;did:plc:xxxx&#10;https://bsky.app/starter-pack/bob.bsky.social/3kxxxx"
     style="width:100%;height:110px;background:var(--surface2);border:1px solid var(--border);border-radius:6px;padding:10px 12px;color:var(--text);font-family:'SF Mono',Consolas,monospace;font-size:12px;resize:vertical;line-height:1.6"
   ></textarea>
   <div style="display:flex;align-items:center;gap:10px;margin-top:10px">
@@ -520,12 +587,45 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
       <svg width="13" height="13" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2.5"><path d="M16 21v-2a4 4 0 0 0-4-4H6a4 4 0 0 0-4 4v2"/><circle cx="9" cy="7" r="4"/><line x1="19" y1="8" x2="19" y2="14"/><line x1="22" y1="11" x2="16" y2="11"/></svg>
       Import &amp; Publish Kind-3
     </button>
+    <button class="btn btn-surface" id="btn-import-bsky-preview" onclick="importBskyFollowing(true)">Preview</button>
     <span style="font-size:12px;color:var(--muted)" id="import-bsky-status"></span>
   </div>
   <div id="import-bsky-results" style="margin-top:14px"></div>
 </div>
 
-<!-- Row 5c: Actions -->
+<!-- Row 5c: Bridge a Post -->
+<div class="card-full">
+  <h2>Bridge a Post</h2>
+  <p style="color:var(--muted);font-size:12px;margin-bottom:12px">
+    Paste a Fediverse post URL, a bsky.app post URL or an AT URI to bridge it to Nostr now — useful for posts that failed to bridge on arrival. Already-bridged posts return their existing event.
+  </p>
+  <div style="display:flex;gap:8px">
+    <input type="text" id="bridge-url-input"
+      placeholder="https://mastodon.social/@alice/1234"
+      style="flex:1;background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:6px 10px;color:var(--text);font-size:12px;font-family:monospace"
+      onkeydown="if(event.key==='Enter')bridgeURL()">
+    <button class="btn btn-blue" id="btn-bridge-url" style="padding:6px 14px;font-size:12px" onclick="bridgeURL()">Bridge</button>
+  </div>
+  <div class="action-msg" id="bridge-url-msg"></div>
+</div>
+
+<!-- Row 5c2: Test Post -->
+<div class="card-full">
+  <h2>Test Post</h2>
+  <p style="color:var(--muted);font-size:12px;margin-bottom:12px">
+    Publishes a <strong style="color:var(--red)">real, public note</strong> as you to your relays, federates it to your Fediverse followers and cross-posts it to Bluesky (when enabled) — the same path as a post from your Nostr client. Use it to confirm a new relay or config change works end to end; delete the note from your client afterwards.
+  </p>
+  <div style="display:flex;gap:8px">
+    <input type="text" id="test-post-input" maxlength="500"
+      placeholder="Testing my bridge"
+      style="flex:1;background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:6px 10px;color:var(--text);font-size:12px"
+      onkeydown="if(event.key==='Enter')testPost()">
+    <button class="btn btn-blue" id="btn-test-post" style="padding:6px 14px;font-size:12px" onclick="testPost()">Publish</button>
+  </div>
+  <div class="action-msg" id="test-post-msg"></div>
+</div>
+
+<!-- Row 5d: Actions -->
 <div class="card-full">
   <h2>Actions</h2>
   <div style="display:flex;flex-direction:column;gap:10px">
@@ -546,6 +646,23 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
       <span style="font-size:12px;color:var(--muted)">Re-publishes your contact list (kind-3) to all configured relays. Useful after adding a new relay.</span>
     </div>
 
+    <div style="display:flex;align-items:center;gap:14px;flex-wrap:wrap">
+      <button class="btn btn-surface" id="btn-reconcile-follows" onclick="reconcileFollows()" style="min-width:178px">
+        <svg width="13" height="13" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2.5"><polyline points="17 1 21 5 17 9"/><path d="M3 11V9a4 4 0 0 1 4-4h14"/><polyline points="7 23 3 19 7 15"/><path d="M21 13v2a4 4 0 0 1-4 4H3"/></svg>
+        Reconcile Following
+      </button>
+      <span style="font-size:12px;color:var(--muted)">Compares your kind-3 contact list with bridged follows and repairs drift (missing Follows/Undos, or re-publishes the list). Runs automatically when FOLLOW_RECONCILE_INTERVAL is set.</span>
+    </div>
+
+    <div style="display:flex;align-items:center;gap:14px;flex-wrap:wrap">
+      <button class="btn btn-surface" id="btn-backfill" onclick="backfillHistory()" style="min-width:178px">
+        <svg width="13" height="13" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2.5"><polyline points="1 4 1 10 7 10"/><path d="M3.51 15a9 9 0 1 0 2.13-9.36L1 10"/></svg>
+        Backfill History
+      </button>
+      <input type="number" id="backfill-limit" value="200" min="1" max="2000" style="width:80px;background:var(--surface2);border:1px solid var(--border);border-radius:6px;padding:6px 8px;color:var(--text);font-size:12px">
+      <span style="font-size:12px;color:var(--muted)">Maps your most recent Nostr posts to Fediverse objects so they show in your outbox and can be replied to. Already mapped posts are skipped; safe to re-run.</span>
+    </div>
+
     <div style="display:flex;align-items:center;gap:14px;flex-wrap:wrap">
       <button class="btn btn-surface" id="btn-refresh-profiles" onclick="refreshProfiles()" style="min-width:178px">
         <svg width="13" height="13" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2.5"><path d="M20 11A8.1 8.1 0 0 0 4.5 9M4 5v4h4M4 13a8.1 8.1 0 0 0 15.5 2M20 19v-4h-4"/></svg>
@@ -553,6 +670,7 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
       </button>
       <span style="font-size:12px;color:var(--muted)">Re-fetches profiles for all bridged accounts and republishes their Nostr kind-0 metadata. Also runs automatically every 24 hours.</span>
     </div>
+    <div id="resync-report" style="display:none;font-size:12px;color:var(--muted)"></div>
 
     <div id="bsky-sync-row" style="display:flex;align-items:center;gap:14px;flex-wrap:wrap">
       <button class="btn btn-surface" id="btn-bsky-sync" onclick="syncBsky()" style="min-width:178px">
@@ -591,6 +709,12 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
       Append source link (🔗) at the bottom of bridged notes
     </label>
 
+    <!-- Outbound kinds -->
+    <div>
+      <div style="font-size:11px;font-weight:600;color:var(--muted);text-transform:uppercase;letter-spacing:.06em;margin-bottom:10px">Bridged to the Fediverse</div>
+      <div id="set-bridged-kinds" style="display:flex;flex-wrap:wrap;gap:8px 18px"></div>
+    </div>
+
     <!-- Profile fields -->
     <div>
       <div style="font-size:11px;font-weight:600;color:var(--muted);text-transform:uppercase;letter-spacing:.06em;margin-bottom:10px">Profile (saves kind-0 to relays)</div>
@@ -613,9 +737,11 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
         <span style="font-size:12px;color:var(--muted);text-align:right">External base URL</span>
         <input type="text" id="set-external-base-url" placeholder="https://njump.me" style="background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:6px 9px;color:var(--text);font-size:12px;font-family:monospace">
         <span style="font-size:12px;color:var(--muted);text-align:right">Zap pubkey</span>
-        <input type="text" id="set-zap-pubkey" placeholder="hex pubkey (optional)" style="background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:6px 9px;color:var(--text);font-size:12px;font-family:monospace">
+        <input type="text" id="set-zap-pubkey" placeholder="hex pubkey (reserved, not applied)" style="background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:6px 9px;color:var(--text);font-size:12px;font-family:monospace">
         <span style="font-size:12px;color:var(--muted);text-align:right">Zap split</span>
         <input type="number" id="set-zap-split" placeholder="0.1" step="0.01" min="0" max="1" style="background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:6px 9px;color:var(--text);font-size:12px;font-family:inherit;width:120px">
+        <span style="font-size:12px;color:var(--muted);text-align:right">Lightning address</span>
+        <input type="text" id="set-lightning-address" placeholder="you@walletofsatoshi.com (optional)" style="background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:6px 9px;color:var(--text);font-size:12px;font-family:monospace">
       </div>
     </div>
 
@@ -627,6 +753,93 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
   </div>
 </div>
 
+<!-- Row 6b: Instance blocks -->
+<div class="card-full">
+  <h2>Instance Blocks</h2>
+  <label style="display:flex;align-items:center;gap:10px;cursor:pointer;font-size:13px;user-select:none;margin-bottom:12px">
+    <input type="checkbox" id="ib-allowlist-mode" onchange="setInstanceAllowlistMode(this.checked)" style="width:15px;height:15px;accent-color:var(--blue);cursor:pointer">
+    Allowlist mode — only accept inbox deliveries from allowed instances
+  </label>
+  <div id="instance-blocks-list"><span class="empty">loading…</span></div>
+  <div style="display:flex;gap:7px;margin-top:10px;max-width:560px">
+    <input type="text" id="ib-add-input" placeholder="spam.example or *.spam.example"
+      style="flex:1;background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:5px 9px;color:var(--text);font-size:11px;font-family:monospace"
+      onkeydown="if(event.key==='Enter')addInstanceBlock('block')">
+    <button class="btn btn-surface" style="padding:5px 12px;font-size:11px" onclick="addInstanceBlock('block')">+ Block</button>
+    <button class="btn btn-surface" style="padding:5px 12px;font-size:11px" onclick="addInstanceBlock('allow')">+ Allow</button>
+  </div>
+  <div id="ib-msg" style="font-size:11px;color:var(--muted);margin-top:5px;min-height:15px"></div>
+</div>
+
+<!-- Row 6b2: Bridge exclusions -->
+<div class="card-full">
+  <h2>Bridge Exclusions</h2>
+  <div style="font-size:12px;color:var(--muted);margin-bottom:10px">Posts and reposts from these accounts are not bridged to Nostr. You keep following them on their own network; already bridged posts stay.</div>
+  <div id="bridge-exclusions-list"><span class="empty">loading…</span></div>
+  <div style="display:flex;gap:7px;margin-top:10px;max-width:560px">
+    <input type="text" id="bx-add-input" placeholder="bot@mastodon.social, handle.bsky.social or did:plc:…"
+      style="flex:1;background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:5px 9px;color:var(--text);font-size:11px;font-family:monospace"
+      onkeydown="if(event.key==='Enter')addBridgeExclusion()">
+    <button class="btn btn-surface" style="padding:5px 12px;font-size:11px" onclick="addBridgeExclusion()">+ Exclude</button>
+  </div>
+  <div id="bx-msg" style="font-size:11px;color:var(--muted);margin-top:5px;min-height:15px"></div>
+</div>
+
+<!-- Row 6b3: Content filters -->
+<div class="card-full">
+  <h2>Content Filters</h2>
+  <div style="font-size:12px;color:var(--muted);margin-bottom:10px">Inbound Fediverse and Bluesky posts whose text matches a keyword (case-insensitive) or regular expression are skipped, or bridged behind a content warning. Already bridged posts stay.</div>
+  <div id="content-filters-list"><span class="empty">loading…</span></div>
+  <div style="display:flex;gap:7px;margin-top:10px;max-width:560px;align-items:center">
+    <input type="text" id="cf-add-input" placeholder="keyword or regex" maxlength="256"
+      style="flex:1;background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:5px 9px;color:var(--text);font-size:11px;font-family:monospace"
+      onkeydown="if(event.key==='Enter')addContentFilter()">
+    <label style="font-size:11px;color:var(--muted);display:flex;align-items:center;gap:4px"><input type="checkbox" id="cf-regex"> regex</label>
+    <select id="cf-action" style="background:var(--surface2);border:1px solid var(--border);border-radius:5px;padding:4px 6px;color:var(--text);font-size:11px">
+      <option value="skip">skip</option>
+      <option value="cw">content warning</option>
+    </select>
+    <button class="btn btn-surface" style="padding:5px 12px;font-size:11px" onclick="addContentFilter()">+ Filter</button>
+  </div>
+  <div id="cf-msg" style="font-size:11px;color:var(--muted);margin-top:5px;min-height:15px"></div>
+</div>
+
+<!-- Row 6c: Federation delivery health -->
+<div class="card-full">
+  <h2>Delivery Health</h2>
+  <div id="fed-hosts-list"><span class="empty">loading…</span></div>
+</div>
+
+<!-- Row 6d: Pending follow requests -->
+<div class="card-full">
+  <h2>Pending Follow Requests</h2>
+  <p style="color:var(--muted);font-size:12px;margin-bottom:12px">
+    Inbound follows held by the follow spam filter (FOLLOW_MIN_FOLLOWERS, FOLLOW_MAX_RATIO, FOLLOW_MIN_ACCOUNT_AGE).
+  </p>
+  <div id="pending-follows-list" class="followers-list"><span class="empty">loading…</span></div>
+</div>
+
+<!-- Row 6e: Failed activities -->
+<div class="card-full">
+  <h2>Failed Activities</h2>
+  <p style="color:var(--muted);font-size:12px;margin-bottom:12px">
+    Inbound Fediverse activities that failed to bridge (most recent 500). Retry re-processes the stored activity.
+  </p>
+  <div id="failures-list" class="followers-list"><span class="empty">loading…</span></div>
+</div>
+
+<!-- Row 6f: Audit log -->
+<div class="card-full">
+  <h2>Audit Log</h2>
+  <div class="log-toolbar">
+    <button class="btn btn-surface" style="padding:4px 12px;font-size:11px" onclick="loadAuditLog()">
+      <svg width="11" height="11" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2.5"><polyline points="23 4 23 10 17 10"/><polyline points="1 20 1 14 7 14"/><path d="M3.51 9a9 9 0 0 1 14.85-3.36L23 10M1 14l4.64 4.36A9 9 0 0 0 20.49 15"/></svg>
+      Refresh
+    </button>
+  </div>
+  <div id="audit-list" class="followers-list"><span class="empty">loading…</span></div>
+</div>
+
 <!-- Row 7: Log -->
 <div class="card-full">
   <h2>Log</h2>
@@ -663,6 +876,13 @@ details.danger-zone .danger-body{padding:0 20px 20px;border-top:1px solid rgba(2
       <button class="btn rbtn-blue" id="btn-force-refollow" onclick="forceRefollowAll()">Force Fediverse Re-sync</button>
       <span id="force-refollow-msg" class="action-msg" style="margin-left: 12px"></span>
     </div>
+    <div class="field" style="margin-bottom: 20px;">
+      <p style="color: var(--muted); font-size: 13px; line-height: 1.5; margin-bottom: 12px; max-width: 600px;">
+        <b>Rotate Signing Key:</b> Replaces your RSA signing key in two steps. <b>Prepare</b> generates a new key and publishes it on your actors next to the current <code>#main-key</code>; <b>Promote</b>, once followers' servers have refetched your actor (a day is plenty), makes it the <code>#main-key</code>. The old public key stays published for <code>KEY_ROTATION_GRACE</code> so earlier signatures still verify. Each step sends an actor <code>Update</code> to your followers.
+      </p>
+      <button class="btn rbtn-blue" id="btn-rotate-key" onclick="rotateSigningKey()">Prepare New Signing Key</button>
+      <span id="rotate-key-msg" class="action-msg" style="margin-left: 12px"></span>
+    </div>
     <div class="field">
       <p style="color: var(--muted); font-size: 13px; line-height: 1.5; margin-bottom: 12px; max-width: 600px;">
         <b>Wipe Fediverse Follows:</b> This will permanently delete your entire Fediverse following list from the database, publish an empty kind-3 contact list to Nostr, and broadcast an <code>Undo Follow</code> to all remote servers.
@@ -849,6 +1069,12 @@ async function loadStatus() {
 }
 
 // ── Stats ────────────────────────────────────────────────────────────────────
+function cacheSummary(c) {
+  if (!c) return '—';
+  const rate = (c.hits + c.misses) > 0 ? ' · ' + Math.round(c.hit_rate * 100) + '%' : '';
+  return c.entries + '/' + c.max_entries + rate;
+}
+
 async function loadStats() {
   const r = await fetch('/web/api/stats');
   const d = await r.json();
@@ -857,6 +1083,12 @@ async function loadStats() {
   document.getElementById('bp-ap-followers').textContent = d.fediverse_followers ?? '—';
   document.getElementById('bp-ap-actors').textContent    = d.fediverse_actors    ?? '—';
   document.getElementById('bp-ap-objects').textContent   = d.fediverse_objects   ?? '—';
+  document.getElementById('bp-ap-queue').textContent     = d.delivery_queue_depth ?? '—';
+  document.getElementById('bp-total-echo').textContent   = d.echoes_suppressed ?? '—';
+  document.getElementById('bp-total-ignored').textContent = d.activities_ignored ?? '—';
+  document.getElementById('bp-total-busy').textContent    = d.sqlite_busy_retries ?? '—';
+  document.getElementById('bp-ap-objcache').textContent  = cacheSummary(d.object_cache);
+  document.getElementById('bp-ap-wfcache').textContent   = cacheSummary(d.webfinger_cache);
   const resyncEl = document.getElementById('bp-last-resync');
   if (d.last_resync_at) {
     const countSuffix = d.last_resync_count ? ' ('+d.last_resync_count+')' : '';
@@ -865,6 +1097,13 @@ async function loadStats() {
   } else {
     resyncEl.textContent = 'never';
   }
+  const reconcileEl = document.getElementById('bp-last-reconcile');
+  if (d.last_reconcile_at) {
+    reconcileEl.textContent = relativeTime(d.last_reconcile_at);
+    reconcileEl.title = d.last_reconcile_at + (d.last_reconcile_result ? ' — '+d.last_reconcile_result : '');
+  } else {
+    reconcileEl.textContent = 'never';
+  }
 
   // Bluesky panel
   const bskyBody = document.getElementById('bp-bsky-body');
@@ -874,6 +1113,14 @@ async function loadStats() {
       '<div class="bp-row"><span class="bpl">Objects</span><span class="bpv">'+esc(String(d.bsky_objects??'—'))+'</span></div>'+
       '<div class="bp-row"><span class="bpl">Last poll</span><span class="bpv sm">'+esc(relativeTime(d.bsky_last_poll))+'</span></div>'+
       '<div class="bp-row"><span class="bpl">Last resync</span><span class="bpv sm">'+esc(relativeTime(d.bsky_last_seen))+'</span></div>';
+    // With additional accounts, show each account's last poll.
+    const accounts = d.bsky_accounts || [];
+    if (accounts.length > 1) {
+      bskyBody.innerHTML += accounts.map(a =>
+        '<div class="bp-row"><span class="bpl" title="'+esc(a.did)+'">'+esc('@'+a.handle)+(a.primary ? ' (primary)' : '')+'</span>'+
+        '<span class="bpv sm" title="Last new item: '+esc(a.last_seen || 'none')+'">'+esc(relativeTime(a.last_poll))+'</span></div>'
+      ).join('');
+    }
   }
 
   // Total panel
@@ -975,11 +1222,25 @@ async function refreshProfiles() {
   btn.textContent = 'Refreshing…';
   msg.textContent = '';
   try {
+    const before = await fetchResyncReport();
     const r = await apiFetch('/web/api/resync-follows', {method:'POST'});
     const d = await r.json();
     msg.textContent = d.message;
     toast(d.message);
     setTimeout(loadStats, 3000);
+    btn.disabled = false;
+    btn.innerHTML = orig;
+    // The Fediverse resync runs in the background; poll for its report.
+    const since = before ? before.finished_at : '';
+    for (let i = 0; i < 60; i++) {
+      await new Promise(res => setTimeout(res, 5000));
+      const rep = await fetchResyncReport();
+      if (rep && rep.finished_at !== since) {
+        showResyncReport(rep);
+        loadStats();
+        return;
+      }
+    }
   } catch(e) {
     msg.textContent = 'Error: '+e.message;
   } finally {
@@ -988,6 +1249,29 @@ async function refreshProfiles() {
   }
 }
 
+async function fetchResyncReport() {
+  const r = await apiFetch('/web/api/resync-report');
+  const d = await r.json();
+  return d.report;
+}
+
+function showResyncReport(rep) {
+  const el = document.getElementById('resync-report');
+  let html = 'Fediverse resync: '+rep.ok+'/'+rep.total+' refreshed';
+  if (rep.failed) html += ', '+rep.failed+' failed';
+  if (rep.gone) html += ', '+rep.gone+' gone (key removed)';
+  html += '.';
+  const results = rep.results || [];
+  if (results.length) {
+    html += '<ul style="margin:6px 0 0 18px;padding:0">' + results.map(x =>
+      '<li>'+esc(x.actor)+' — '+esc(x.status)+': '+esc(x.error)+'</li>'
+    ).join('') + '</ul>';
+    if (results.length < rep.failed + rep.gone) html += '<div>…and '+(rep.failed + rep.gone - results.length)+' more.</div>';
+  }
+  el.innerHTML = html;
+  el.style.display = '';
+}
+
 async function republishKind0() {
   const btn = document.getElementById('btn-republish-kind0');
   btn.disabled = true;
@@ -1012,8 +1296,12 @@ async function republishKind3() {
   const orig = btn.innerHTML;
   btn.textContent = 'Publishing…';
   try {
-    const r = await apiFetch('/web/api/republish-kind3', {method:'POST'});
-    const d = await r.json();
+    let r = await apiFetch('/web/api/republish-kind3', {method:'POST'});
+    let d = await r.json();
+    if (d.shrink_refused && confirm(d.message + '\n\nPublish anyway?')) {
+      r = await apiFetch('/web/api/republish-kind3?force=true', {method:'POST'});
+      d = await r.json();
+    }
     document.getElementById('action-msg').textContent = d.message;
     toast(d.message);
   } catch(e) {
@@ -1024,8 +1312,108 @@ async function republishKind3() {
   }
 }
 
+async function reconcileFollows() {
+  const btn = document.getElementById('btn-reconcile-follows');
+  btn.disabled = true;
+  try {
+    const r = await apiFetch('/web/api/reconcile-follows', {method:'POST'});
+    const d = await r.json();
+    const msg = d.message || d.error;
+    document.getElementById('action-msg').textContent = msg;
+    toast(msg);
+  } catch(e) {
+    document.getElementById('action-msg').textContent = 'Error: '+e.message;
+  } finally {
+    btn.disabled = false;
+  }
+}
+
+async function backfillHistory() {
+  const btn = document.getElementById('btn-backfill');
+  const msg = document.getElementById('action-msg');
+  const limit = parseInt(document.getElementById('backfill-limit').value, 10) || 200;
+  btn.disabled = true;
+  try {
+    const r = await apiFetch('/web/api/backfill', {
+      method: 'POST',
+      headers: {'Content-Type': 'application/json'},
+      body: JSON.stringify({limit}),
+    });
+    const d = await r.json();
+    msg.textContent = d.message || d.error;
+    if (r.status === 202) pollJob('backfill', msg);
+    else toast(d.message || d.error);
+  } catch(e) {
+    msg.textContent = 'Error: '+e.message;
+  } finally {
+    btn.disabled = false;
+  }
+}
+
+async function bridgeURL() {
+  const input = document.getElementById('bridge-url-input');
+  const msg = document.getElementById('bridge-url-msg');
+  const url = input.value.trim();
+  if (!url) return;
+  const btn = document.getElementById('btn-bridge-url');
+  btn.disabled = true;
+  msg.textContent = 'Bridging…';
+  try {
+    const r = await apiFetch('/web/api/bridge-url', {
+      method: 'POST',
+      headers: {'Content-Type': 'application/json'},
+      body: JSON.stringify({url}),
+    });
+    const d = await r.json();
+    if (!r.ok) {
+      msg.textContent = 'Error: '+(d.error || r.status);
+      return;
+    }
+    msg.textContent = d.nevent || d.event_id;
+    input.value = '';
+    toast('Post bridged');
+  } catch(e) {
+    msg.textContent = 'Error: '+e.message;
+  } finally {
+    btn.disabled = false;
+  }
+}
+
+async function testPost() {
+  const input = document.getElementById('test-post-input');
+  const msg = document.getElementById('test-post-msg');
+  const message = input.value.trim();
+  if (!message) return;
+  if (!confirm('Publish this as a real public post on Nostr, the Fediverse and Bluesky?')) return;
+  const btn = document.getElementById('btn-test-post');
+  btn.disabled = true;
+  msg.textContent = 'Publishing…';
+  try {
+    const r = await apiFetch('/web/api/test-post', {
+      method: 'POST',
+      headers: {'Content-Type': 'application/json'},
+      body: JSON.stringify({message}),
+    });
+    const d = await r.json();
+    if (!r.ok) {
+      msg.textContent = 'Error: '+(d.error || r.status);
+      return;
+    }
+    const parts = ['Nostr: '+(d.nostr_url || d.event_id), 'Fediverse: '+d.ap_id];
+    if (d.bsky_url || d.bsky_uri) parts.push('Bluesky: '+(d.bsky_url || d.bsky_uri));
+    else if (d.bsky_error) parts.push('Bluesky: '+d.bsky_error);
+    msg.textContent = parts.join(' · ');
+    input.value = '';
+    toast('Test post published');
+  } catch(e) {
+    msg.textContent = 'Error: '+e.message;
+  } finally {
+    btn.disabled = false;
+  }
+}
+
 function refreshAll() {
-  loadStats(); loadFollowers(); loadFollowing(); loadRelays();
+  loadStats(); loadFollowers(); loadFollowing(); loadRelays(); loadInstanceBlocks(); loadBridgeExclusions(); loadContentFilters(); loadFederationHosts(); loadPendingFollows(); loadFailures(); loadAuditLog();
   toast('Dashboard refreshed');
 }
 
@@ -1043,8 +1431,11 @@ async function loadFollowing() {
     } else {
       d.fediverse.forEach(item => {
         const div = document.createElement('div'); div.className = 'follower';
+        const pending = item.pending
+          ? ' <span class="badge badge-yellow" title="Follow sent '+esc(item.requested_at)+', awaiting Accept">pending</span>'
+          : '';
         div.innerHTML =
-          '<span class="f-handle">'+esc(item.handle)+'</span>'+
+          '<span class="f-handle">'+esc(item.handle)+pending+'</span>'+
           '<button style="background:none;border:none;cursor:pointer;color:var(--red);font-size:14px;opacity:.7;padding:0 4px" '+
             'title="Unfollow" onclick="removeFollow(\''+esc(item.actor)+'\',\'fediverse\')">✕</button>';
         fedEl.appendChild(div);
@@ -1138,23 +1529,24 @@ async function removeFollow(handle, bridge) {
 
 
 // ── Import Following ─────────────────────────────────────────────────────────
-async function importFollowing() {
+// preview=true resolves handles and shows the kind-3 diff without publishing.
+async function importFollowing(preview, force) {
   const raw = document.getElementById('import-textarea').value;
   const handles = raw.split('\n').map(h => h.trim()).filter(Boolean);
   if (!handles.length) { toast('No handles entered'); return; }
 
-  const btn    = document.getElementById('btn-import');
+  const btn    = document.getElementById(preview ? 'btn-import-preview' : 'btn-import');
   const status = document.getElementById('import-status');
   btn.disabled = true;
   const origHTML = btn.innerHTML;
   btn.textContent = 'Resolving…';
-  status.textContent = 'Fetching existing follows from relay, this may take up to 8s…';
+  status.textContent = 'Fetching existing follows from relays, this may take up to 10s…';
 
   try {
-    const r = await apiFetch('/web/api/import-following', {
+    const r = await apiFetch('/web/api/import-following' + (preview ? '?preview=true' : ''), {
       method: 'POST',
       headers: {'Content-Type': 'application/json'},
-      body: JSON.stringify({handles}),
+      body: JSON.stringify({handles, force: !!force}),
     });
     const d = await r.json();
 
@@ -1163,10 +1555,15 @@ async function importFollowing() {
     const err = (d.results||[]).filter(r => r.status==='error').length;
     let msg = ok + ' resolved';
     if (err) msg += ', ' + err + ' failed';
-    if (d.published) msg += ' — kind-3 published (' + d.total_follows + ' total follows)';
+    if (d.preview) msg += ' — preview: ' + (d.added||[]).length + ' to add, ' + (d.removed||[]).length + ' to remove (' + d.total_follows + ' total follows)';
+    else if (d.published) msg += ' — kind-3 published (' + d.previous_follows + ' → ' + d.total_follows + ' follows)';
     else if (d.error) msg += ' — ' + d.error;
     if (!d.fetched_existing) msg += ' ⚠ no existing kind-3 found on relay';
     status.textContent = msg;
+    if (d.shrink_refused && confirm(d.error + '\n\nPublish anyway?')) {
+      btn.innerHTML = origHTML;
+      return await importFollowing(false, true);
+    }
 
     // Result table
     const el = document.getElementById('import-results');
@@ -1194,6 +1591,16 @@ async function importFollowing() {
         + '</tr>';
     });
     html += '</tbody></table>';
+
+    if (d.preview) {
+      const diffList = (label, color, list) => list.length
+        ? '<div style="margin-top:10px;font-size:12px"><div style="color:'+color+';font-weight:600;margin-bottom:4px">'+label+' ('+list.length+')</div>'
+          + list.map(n => '<div style="font-family:monospace;color:var(--muted)">'+esc(n)+'</div>').join('') + '</div>'
+        : '';
+      html += diffList('+ Added', 'var(--green)', d.added||[]);
+      html += diffList('− Removed', 'var(--red)', d.removed||[]);
+      html += '<button class="btn btn-blue" style="margin-top:12px" onclick="importFollowing(false)">Confirm &amp; Publish</button>';
+    }
     el.innerHTML = html;
 
     if (d.published) { toast('Kind-3 published — ' + ok + ' new follows added'); loadFollowers(); }
@@ -1206,63 +1613,32 @@ async function importFollowing() {
 }
 
 // ── Import Bluesky Following ──────────────────────────────────────────────────
-async function importBskyFollowing() {
+async function importBskyFollowing(preview, force) {
   const raw = document.getElementById('import-bsky-textarea').value;
   const handles = raw.split('\n').map(h => h.trim()).filter(Boolean);
   if (!handles.length) { toast('No handles entered'); return; }
 
-  const btn    = document.getElementById('btn-import-bsky');
+  const btn    = document.getElementById(preview ? 'btn-import-bsky-preview' : 'btn-import-bsky');
   const status = document.getElementById('import-bsky-status');
   btn.disabled = true;
   const origHTML = btn.innerHTML;
-  btn.textContent = 'Following…';
-  status.textContent = 'Resolving handles and following on Bluesky…';
+  btn.textContent = preview ? 'Resolving…' : 'Following…';
+  status.textContent = preview ? 'Resolving lists and starter packs…' : 'Resolving handles and following on Bluesky…';
 
   try {
-    const r = await apiFetch('/web/api/import-bsky-following', {
+    const r = await apiFetch('/web/api/import-bsky-following' + (preview ? '?preview=true' : ''), {
       method: 'POST',
       headers: {'Content-Type': 'application/json'},
-      body: JSON.stringify({handles}),
+      body: JSON.stringify({handles, force: !!force}),
     });
     const d = await r.json();
-
-    const ok  = (d.results||[]).filter(r => r.status==='ok').length;
-    const err = (d.results||[]).filter(r => r.status==='error').length;
-    let msg = ok + ' followed';
-    if (err) msg += ', ' + err + ' failed';
-    if (d.published) msg += ' — kind-3 published (' + d.total_follows + ' total follows)';
-    else if (d.error) msg += ' — ' + d.error;
-    if (!d.fetched_existing) msg += ' ⚠ no existing kind-3 found on relay';
-    status.textContent = msg;
-
-    const el = document.getElementById('import-bsky-results');
-    if (!d.results || d.results.length === 0) { el.innerHTML = ''; return; }
-
-    let html = '<table style="width:100%;border-collapse:collapse;font-size:12px;margin-top:4px">'
-      + '<thead><tr style="color:var(--muted);text-align:left">'
-      + '<th style="padding:5px 8px;border-bottom:1px solid var(--border)">Handle / DID</th>'
-      + '<th style="padding:5px 8px;border-bottom:1px solid var(--border)">Status</th>'
-      + '<th style="padding:5px 8px;border-bottom:1px solid var(--border)">Npub / Error</th>'
-      + '</tr></thead><tbody>';
-
-    (d.results||[]).forEach(r => {
-      const isOk = r.status === 'ok';
-      const statusCell = isOk
-        ? '<span style="color:var(--green);font-weight:600">✓ ok</span>'
-        : '<span style="color:var(--red);font-weight:600">✗ error</span>';
-      const detail = isOk
-        ? '<span style="font-family:monospace;color:var(--muted)">' + esc(r.npub||'') + '</span>'
-        : '<span style="color:var(--red)">' + esc(r.error||'') + '</span>';
-      html += '<tr style="border-bottom:1px solid var(--border)">'
-        + '<td style="padding:5px 8px;font-family:monospace">' + esc(r.handle) + '</td>'
-        + '<td style="padding:5px 8px">' + statusCell + '</td>'
-        + '<td style="padding:5px 8px">' + detail + '</td>'
-        + '</tr>';
-    });
-    html += '</tbody></table>';
-    el.innerHTML = html;
-
-    if (d.published) { toast('Kind-3 published — ' + ok + ' new Bluesky follows added'); loadFollowing(); }
+    if (r.status === 202) {
+      status.textContent = d.message;
+      pollJob('bsky-import', status, job => showBskyImportResult(job.result || {}));
+      return;
+    }
+    if (!r.ok) { status.textContent = 'Error: ' + (d.error || r.statusText); return; }
+    showBskyImportResult(d);
   } catch(e) {
     status.textContent = 'Error: ' + e.message;
   } finally {
@@ -1271,6 +1647,59 @@ async function importBskyFollowing() {
   }
 }
 
+// showBskyImportResult renders a Bluesky import preview or, once the
+// "bsky-import" job has finished, its result.
+function showBskyImportResult(d) {
+  const status = document.getElementById('import-bsky-status');
+  const sets = (d.sets||[]).map(st => (st.name || st.url) + ': ' + st.members + ' of ' + st.total
+    + (st.truncated ? ' (capped)' : '')).join('; ');
+  if (d.preview) {
+    status.textContent = d.accounts + ' account(s) would be followed' + (sets ? ' — ' + sets : '');
+  }
+
+  const ok  = (d.results||[]).filter(r => r.status==='ok').length;
+  const err = (d.results||[]).filter(r => r.status==='error').length;
+  let msg = ok + ' followed';
+  if (sets) msg += ' from ' + sets;
+  if (err) msg += ', ' + err + ' failed';
+  if (d.published) msg += ' — kind-3 published (' + d.previous_follows + ' → ' + d.total_follows + ' follows)';
+  else if (d.error) msg += ' — ' + d.error;
+  if (!d.fetched_existing) msg += ' ⚠ no existing kind-3 found on relay';
+  if (!d.preview) status.textContent = msg;
+  if (d.shrink_refused && confirm(d.error + '\n\nPublish anyway?')) {
+    return importBskyFollowing(false, true);
+  }
+
+  const el = document.getElementById('import-bsky-results');
+  if (!d.results || d.results.length === 0) { el.innerHTML = ''; return; }
+
+  let html = '<table style="width:100%;border-collapse:collapse;font-size:12px;margin-top:4px">'
+    + '<thead><tr style="color:var(--muted);text-align:left">'
+    + '<th style="padding:5px 8px;border-bottom:1px solid var(--border)">Handle / DID</th>'
+    + '<th style="padding:5px 8px;border-bottom:1px solid var(--border)">Status</th>'
+    + '<th style="padding:5px 8px;border-bottom:1px solid var(--border)">Npub / Error</th>'
+    + '</tr></thead><tbody>';
+
+  (d.results||[]).forEach(r => {
+    const isOk = r.status === 'ok';
+    const statusCell = isOk
+      ? '<span style="color:var(--green);font-weight:600">✓ ok</span>'
+      : '<span style="color:var(--red);font-weight:600">✗ error</span>';
+    const detail = isOk
+      ? '<span style="font-family:monospace;color:var(--muted)">' + esc(r.npub||'') + '</span>'
+      : '<span style="color:var(--red)">' + esc(r.error||'') + '</span>';
+    html += '<tr style="border-bottom:1px solid var(--border)">'
+      + '<td style="padding:5px 8px;font-family:monospace">' + esc(r.handle) + '</td>'
+      + '<td style="padding:5px 8px">' + statusCell + '</td>'
+      + '<td style="padding:5px 8px">' + detail + '</td>'
+      + '</tr>';
+  });
+  html += '</tbody></table>';
+  el.innerHTML = html;
+
+  if (d.published) { toast('Kind-3 published — ' + ok + ' new Bluesky follows added'); loadFollowing(); }
+}
+
 // ── Relay management ─────────────────────────────────────────────────────────
 async function loadRelays() {
   try {
@@ -1299,6 +1728,16 @@ async function loadRelays() {
         dotColor = 'var(--yellow)';
         badge = '<span class="relay-cb relay-cb-warn">'+relay.fail_count+' fail(s)</span>';
       }
+      if (!relay.subscribed) {
+        badge += '<span class="relay-cb relay-cb-warn" title="Over RELAY_MAX_CONNECTIONS — not subscribed for reading">not subscribed</span>';
+      } else if (!relay.connected) {
+        badge += '<span class="relay-cb relay-cb-warn" title="Firehose connection is down; reconnecting">disconnected</span>';
+      }
+      if (relay.last_success_at) {
+        const ok = relativeTime(new Date(relay.last_success_at*1000).toISOString());
+        const lat = relay.avg_latency_ms ? relay.avg_latency_ms+'ms · ' : '';
+        badge += '<span class="relay-cb" style="color:var(--muted)" title="Average publish latency · last accepted publish">'+esc(lat+ok)+'</span>';
+      }
       const resetBtn = (relay.circuit_open || relay.fail_count > 0)
         ? '<button class="rbtn rbtn-blue" onclick="resetCircuit(\''+esc(relay.url)+'\')">Reset</button>'
         : '';
@@ -1383,6 +1822,38 @@ async function addRelay() {
   }
 }
 
+function fillRelayBulk() {
+  const ta = document.getElementById('relay-bulk-textarea');
+  if (ta.value.trim()) return;
+  ta.value = Array.from(document.querySelectorAll('#relays-list .relay-row')).map(r => r.dataset.url).join('\n');
+}
+
+async function replaceRelays() {
+  const msg = document.getElementById('relay-msg');
+  const relays = document.getElementById('relay-bulk-textarea').value
+    .split(/[\s,]+/).map(s => s.trim()).filter(Boolean);
+  if (relays.length === 0) return;
+  if (!confirm('Replace the relay list with '+relays.length+' relay(s)?')) return;
+  msg.textContent = 'Replacing…';
+  try {
+    const r = await apiFetch('/web/api/relays', {
+      method: 'PUT',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({relays})
+    });
+    if (!r.ok) {
+      msg.textContent = 'Error: '+(await r.text()).trim();
+      return;
+    }
+    const d = await r.json();
+    msg.textContent = '';
+    toast('Relays: +'+d.added.length+' / −'+d.removed.length);
+    loadRelays();
+  } catch(e) {
+    msg.textContent = 'Error: '+e.message;
+  }
+}
+
 async function removeRelay(url) {
   if (!confirm('Remove relay '+url+'?')) return;
   try {
@@ -1399,6 +1870,401 @@ async function removeRelay(url) {
   }
 }
 
+// ── Federation delivery health ───────────────────────────────────────────────
+async function loadFederationHosts() {
+  try {
+    const r = await fetch('/web/api/federation/hosts');
+    const hosts = await r.json();
+    const el = document.getElementById('fed-hosts-list');
+    el.innerHTML = '';
+    const failing = (hosts||[]).filter(h => h.circuit_open || h.consecutive_failures > 0);
+    if (failing.length === 0) {
+      el.innerHTML = '<span class="empty">All '+(hosts||[]).length+' destination(s) healthy.</span>';
+      return;
+    }
+    failing.forEach(h => {
+      const row = document.createElement('div');
+      row.className = 'relay-row';
+      let badge;
+      if (h.circuit_open) {
+        const secs = h.cooldown_remaining_secs||0;
+        const cd = secs > 60 ? Math.floor(secs/60)+'m '+String(secs%60).padStart(2,'0')+'s' : secs+'s';
+        badge = '<span class="relay-cb relay-cb-open">skipped · '+esc(cd)+'</span>';
+      } else {
+        badge = '<span class="relay-cb relay-cb-warn">'+h.consecutive_failures+' fail(s)</span>';
+      }
+      row.innerHTML =
+        '<span class="relay-url" title="'+esc(h.last_error||'')+'">'+esc(h.host)+'</span>'+
+        badge+
+        '<span class="relay-cb" style="color:var(--muted)">'+h.delivered+' ok / '+h.failed+' failed</span>'+
+        '<div class="relay-acts">'+
+          '<button class="rbtn rbtn-blue" onclick="resetFederationHost(\''+esc(h.host)+'\')">Reset</button>'+
+        '</div>';
+      el.appendChild(row);
+    });
+  } catch(e) {
+    console.warn('loadFederationHosts failed', e);
+  }
+}
+
+async function resetFederationHost(host) {
+  try {
+    const r = await apiFetch('/web/api/federation/hosts/reset', {
+      method: 'POST',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({host})
+    });
+    toast(r.ok ? 'Circuit reset for '+host : 'Error: '+r.statusText);
+    loadFederationHosts();
+  } catch(e) {
+    toast('Error: '+e.message);
+  }
+}
+
+// ── Pending follow requests ──────────────────────────────────────────────────
+async function loadPendingFollows() {
+  try {
+    const r = await fetch('/web/api/pending-follows');
+    const pending = await r.json();
+    const el = document.getElementById('pending-follows-list');
+    el.innerHTML = '';
+    if (!pending || pending.length === 0) {
+      el.innerHTML = '<span class="empty">No pending follow requests.</span>';
+      return;
+    }
+    pending.forEach(f => {
+      const row = document.createElement('div');
+      row.className = 'follower';
+      const ts = new Date(f.ts);
+      row.innerHTML =
+        '<span style="color:var(--muted);font-size:11px;white-space:nowrap;flex-shrink:0">'+esc(isNaN(ts) ? f.ts : ts.toLocaleString())+'</span>'+
+        '<a class="f-handle" href="'+esc(f.actor)+'" target="_blank" rel="noopener" style="flex-shrink:0">'+esc(f.actor)+'</a>'+
+        '<span class="f-handle" style="flex:1;color:var(--muted)">'+esc(f.reason)+'</span>'+
+        '<button class="rbtn rbtn-blue" data-actor="'+esc(f.actor)+'" data-followed="'+esc(f.followed)+'" onclick="decidePendingFollow(this.dataset, \'approve\')">Approve</button>'+
+        '<button class="rbtn rbtn-red" data-actor="'+esc(f.actor)+'" data-followed="'+esc(f.followed)+'" onclick="decidePendingFollow(this.dataset, \'reject\')">Reject</button>';
+      el.appendChild(row);
+    });
+  } catch(e) {
+    console.warn('loadPendingFollows failed', e);
+  }
+}
+
+async function decidePendingFollow(ds, action) {
+  try {
+    const r = await apiFetch('/web/api/pending-follows/'+action, {
+      method: 'POST',
+      headers: {'Content-Type': 'application/json'},
+      body: JSON.stringify({actor: ds.actor, followed: ds.followed})
+    });
+    const d = await r.json();
+    toast(r.ok ? d.message : 'Error: '+(d.error || r.statusText));
+    loadPendingFollows();
+    if (r.ok && action === 'approve') loadFollowers();
+  } catch(e) {
+    toast('Error: '+e.message);
+  }
+}
+
+// ── Failed activities ────────────────────────────────────────────────────────
+async function loadFailures() {
+  try {
+    const r = await fetch('/web/api/failures');
+    const failures = await r.json();
+    const el = document.getElementById('failures-list');
+    el.innerHTML = '';
+    if (!failures || failures.length === 0) {
+      el.innerHTML = '<span class="empty">No failed activities.</span>';
+      return;
+    }
+    failures.forEach(f => {
+      const row = document.createElement('div');
+      row.className = 'follower';
+      const ts = new Date(f.ts);
+      row.innerHTML =
+        '<span style="color:var(--muted);font-size:11px;white-space:nowrap;flex-shrink:0">'+esc(isNaN(ts) ? f.ts : ts.toLocaleString())+'</span>'+
+        '<span class="f-handle" style="flex-shrink:0">'+esc(f.type||'?')+'</span>'+
+        '<span class="f-handle" style="flex:1;color:var(--muted)" title="'+esc(f.id)+'\n'+esc(f.actor)+'">'+esc(f.error)+'</span>'+
+        '<button class="rbtn rbtn-blue" data-id="'+esc(f.id)+'" onclick="retryFailure(this.dataset.id)">Retry</button>';
+      el.appendChild(row);
+    });
+  } catch(e) {
+    console.warn('loadFailures failed', e);
+  }
+}
+
+async function retryFailure(id) {
+  try {
+    const r = await apiFetch('/web/api/failures/retry', {
+      method: 'POST',
+      headers: {'Content-Type': 'application/json'},
+      body: JSON.stringify({id})
+    });
+    const d = await r.json();
+    toast(r.ok ? d.message : 'Retry failed: '+(d.error || r.statusText));
+    loadFailures();
+  } catch(e) {
+    toast('Error: '+e.message);
+  }
+}
+
+// ── Audit log ────────────────────────────────────────────────────────────────
+async function loadAuditLog() {
+  try {
+    const r = await fetch('/web/api/audit?limit=100');
+    const entries = await r.json();
+    const el = document.getElementById('audit-list');
+    el.innerHTML = '';
+    if (!entries || entries.length === 0) {
+      el.innerHTML = '<span class="empty">No admin actions recorded yet.</span>';
+      return;
+    }
+    entries.forEach(e => {
+      const row = document.createElement('div');
+      row.className = 'follower';
+      const ts = new Date(e.ts);
+      row.innerHTML =
+        '<span style="color:var(--muted);font-size:11px;white-space:nowrap;flex-shrink:0">'+esc(isNaN(ts) ? e.ts : ts.toLocaleString())+'</span>'+
+        '<span class="f-handle" style="flex-shrink:0">'+esc(e.action)+'</span>'+
+        '<span class="f-handle" style="flex:1;color:var(--muted)" title="'+esc(e.detail)+'">'+esc(e.detail)+'</span>';
+      el.appendChild(row);
+    });
+  } catch(e) {
+    console.warn('loadAuditLog failed', e);
+  }
+}
+
+// ── Instance blocks ──────────────────────────────────────────────────────────
+async function loadInstanceBlocks() {
+  try {
+    const r = await fetch('/web/api/instance-blocks');
+    const d = await r.json();
+    document.getElementById('ib-allowlist-mode').checked = !!d.allowlist_mode;
+    const el = document.getElementById('instance-blocks-list');
+    el.innerHTML = '';
+    const rules = (d.blocks||[]).concat(d.allows||[]);
+    if (rules.length === 0) {
+      el.innerHTML = '<span class="empty">No instance rules.</span>';
+      return;
+    }
+    rules.forEach(rule => {
+      const row = document.createElement('div');
+      row.className = 'relay-row';
+      const badge = rule.list === 'allow'
+        ? '<span class="relay-cb relay-cb-ok">allow</span>'
+        : '<span class="relay-cb relay-cb-open">block</span>';
+      row.innerHTML =
+        '<span class="relay-url">'+esc(rule.domain)+'</span>'+
+        badge+
+        '<div class="relay-acts">'+
+          '<button class="rbtn rbtn-red" onclick="removeInstanceBlock(\''+esc(rule.domain)+'\',\''+esc(rule.list)+'\')">×</button>'+
+        '</div>';
+      el.appendChild(row);
+    });
+  } catch(e) {
+    console.warn('loadInstanceBlocks failed', e);
+  }
+}
+
+async function addInstanceBlock(list) {
+  const input = document.getElementById('ib-add-input');
+  const msg = document.getElementById('ib-msg');
+  const domain = input.value.trim();
+  if (!domain) return;
+  msg.textContent = 'Adding…';
+  try {
+    const r = await apiFetch('/web/api/instance-blocks', {
+      method: 'POST',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({domain, list})
+    });
+    const d = await r.json();
+    if (r.ok) {
+      input.value = '';
+      msg.textContent = '';
+      toast(d.message || 'Done');
+      loadInstanceBlocks();
+    } else {
+      msg.textContent = 'Error: '+(d.error||r.statusText);
+    }
+  } catch(e) {
+    msg.textContent = 'Error: '+e.message;
+  }
+}
+
+async function removeInstanceBlock(domain, list) {
+  if (!confirm('Remove '+domain+' from the '+list+' list?')) return;
+  try {
+    const r = await apiFetch('/web/api/instance-blocks', {
+      method: 'DELETE',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({domain, list})
+    });
+    const d = await r.json();
+    toast(d.message || (d.removed ? 'Removed' : 'Not found'));
+    loadInstanceBlocks();
+  } catch(e) {
+    toast('Error: '+e.message);
+  }
+}
+
+// ── Bridge exclusions ────────────────────────────────────────────────────────
+async function loadBridgeExclusions() {
+  try {
+    const r = await fetch('/web/api/bridge-exclusions');
+    const list = await r.json();
+    const el = document.getElementById('bridge-exclusions-list');
+    el.innerHTML = '';
+    if (!list || list.length === 0) {
+      el.innerHTML = '<span class="empty">No excluded accounts.</span>';
+      return;
+    }
+    list.forEach(x => {
+      const row = document.createElement('div');
+      row.className = 'relay-row';
+      const label = x.label && x.label !== x.author ? esc(x.label)+' <span style="color:var(--muted)">'+esc(x.author)+'</span>' : esc(x.author);
+      row.innerHTML =
+        '<span class="relay-url" title="'+esc(x.author)+'">'+label+'</span>'+
+        '<div class="relay-acts">'+
+          '<button class="rbtn rbtn-red" onclick="removeBridgeExclusion(\''+esc(x.author)+'\')">×</button>'+
+        '</div>';
+      el.appendChild(row);
+    });
+  } catch(e) {
+    console.warn('loadBridgeExclusions failed', e);
+  }
+}
+
+async function addBridgeExclusion() {
+  const input = document.getElementById('bx-add-input');
+  const msg = document.getElementById('bx-msg');
+  const author = input.value.trim();
+  if (!author) return;
+  msg.textContent = 'Resolving…';
+  try {
+    const r = await apiFetch('/web/api/bridge-exclusions', {
+      method: 'POST',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({author})
+    });
+    const d = await r.json();
+    if (r.ok) {
+      input.value = '';
+      msg.textContent = '';
+      toast(d.message || 'Done');
+      loadBridgeExclusions();
+    } else {
+      msg.textContent = 'Error: '+(d.error||r.statusText);
+    }
+  } catch(e) {
+    msg.textContent = 'Error: '+e.message;
+  }
+}
+
+async function removeBridgeExclusion(author) {
+  if (!confirm('Bridge posts from '+author+' again?')) return;
+  try {
+    const r = await apiFetch('/web/api/bridge-exclusions', {
+      method: 'DELETE',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({author})
+    });
+    const d = await r.json();
+    toast(d.message || (d.removed ? 'Removed' : 'Not found'));
+    loadBridgeExclusions();
+  } catch(e) {
+    toast('Error: '+e.message);
+  }
+}
+
+// ── Content filters ──────────────────────────────────────────────────────────
+async function loadContentFilters() {
+  try {
+    const r = await fetch('/web/api/content-filters');
+    const list = await r.json();
+    const el = document.getElementById('content-filters-list');
+    el.innerHTML = '';
+    if (!list || list.length === 0) {
+      el.innerHTML = '<span class="empty">No content filters.</span>';
+      return;
+    }
+    list.forEach(f => {
+      const row = document.createElement('div');
+      row.className = 'relay-row';
+      const kind = f.regex ? 'regex' : 'keyword';
+      const action = f.action === 'cw' ? 'content warning' : 'skip';
+      row.innerHTML =
+        '<span class="relay-url" title="'+esc(f.pattern)+'">'+esc(f.pattern)+' <span style="color:var(--muted)">'+kind+' · '+action+'</span></span>'+
+        '<div class="relay-acts">'+
+          '<button class="rbtn rbtn-red" data-pattern="'+esc(f.pattern)+'" data-regex="'+(f.regex?'1':'')+'" onclick="removeContentFilter(this.dataset.pattern, !!this.dataset.regex)">×</button>'+
+        '</div>';
+      el.appendChild(row);
+    });
+  } catch(e) {
+    console.warn('loadContentFilters failed', e);
+  }
+}
+
+async function addContentFilter() {
+  const input = document.getElementById('cf-add-input');
+  const msg = document.getElementById('cf-msg');
+  const pattern = input.value.trim();
+  if (!pattern) return;
+  const regex = document.getElementById('cf-regex').checked;
+  const action = document.getElementById('cf-action').value;
+  try {
+    const r = await apiFetch('/web/api/content-filters', {
+      method: 'POST',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({pattern, regex, action})
+    });
+    const d = await r.json();
+    if (r.ok) {
+      input.value = '';
+      msg.textContent = '';
+      toast(d.message || 'Done');
+      loadContentFilters();
+    } else {
+      msg.textContent = 'Error: '+(d.error||r.statusText);
+    }
+  } catch(e) {
+    msg.textContent = 'Error: '+e.message;
+  }
+}
+
+async function removeContentFilter(pattern, regex) {
+  if (!confirm('Remove the filter '+pattern+'?')) return;
+  try {
+    const r = await apiFetch('/web/api/content-filters', {
+      method: 'DELETE',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({pattern, regex})
+    });
+    const d = await r.json();
+    toast(d.message || (d.removed ? 'Removed' : 'Not found'));
+    loadContentFilters();
+  } catch(e) {
+    toast('Error: '+e.message);
+  }
+}
+
+async function setInstanceAllowlistMode(on) {
+  if (on && !confirm('Only allow-listed instances will be able to deliver to the inbox. Continue?')) {
+    document.getElementById('ib-allowlist-mode').checked = false;
+    return;
+  }
+  try {
+    await apiFetch('/web/api/instance-blocks/mode', {
+      method: 'POST',
+      headers: {'Content-Type':'application/json'},
+      body: JSON.stringify({allowlist_mode: on})
+    });
+    toast(on ? 'Allowlist mode enabled' : 'Allowlist mode disabled');
+  } catch(e) {
+    toast('Error: '+e.message);
+  }
+  loadInstanceBlocks();
+}
+
 async function resetCircuit(url) {
   try {
     await apiFetch('/web/api/relays/reset-circuit', {
@@ -1414,6 +2280,8 @@ async function resetCircuit(url) {
 }
 
 // ── Settings ─────────────────────────────────────────────────────────────────
+const KIND_LABELS = {1: 'Notes', 6: 'Reposts', 7: 'Reactions', 9735: 'Zaps', 1068: 'Polls', 30023: 'Articles'};
+
 async function loadSettings() {
   try {
     const r = await fetch('/web/api/settings');
@@ -1427,6 +2295,14 @@ async function loadSettings() {
     document.getElementById('set-external-base-url').value = d.external_base_url || '';
     document.getElementById('set-zap-pubkey').value = d.zap_pubkey || '';
     document.getElementById('set-zap-split').value = d.zap_split != null ? d.zap_split : '';
+    document.getElementById('set-lightning-address').value = d.lightning_address || '';
+    const enabled = d.bridged_kinds || [];
+    document.getElementById('set-bridged-kinds').innerHTML = (d.toggleable_kinds || []).map(k =>
+      '<label style="display:flex;align-items:center;gap:8px;cursor:pointer;font-size:13px;user-select:none">' +
+      '<input type="checkbox" class="set-bridged-kind" value="' + k + '"' + (enabled.includes(k) ? ' checked' : '') +
+      ' style="width:15px;height:15px;accent-color:var(--blue);cursor:pointer">' +
+      esc(KIND_LABELS[k] || 'Kind ' + k) + ' <span style="color:var(--muted);font-size:11px">(' + k + ')</span></label>'
+    ).join('');
   } catch(e) {
     console.warn('loadSettings failed', e);
   }
@@ -1452,7 +2328,12 @@ async function saveSettings() {
       external_base_url: document.getElementById('set-external-base-url').value,
       zap_pubkey:       document.getElementById('set-zap-pubkey').value,
       zap_split:        zapVal !== '' ? parseFloat(zapVal) : 0.1,
+      lightning_address: document.getElementById('set-lightning-address').value,
     };
+    const kindBoxes = document.querySelectorAll('.set-bridged-kind');
+    if (kindBoxes.length) {
+      body.bridged_kinds = [...kindBoxes].filter(cb => cb.checked).map(cb => parseInt(cb.value, 10));
+    }
     const r = await apiFetch('/web/api/settings', {
       method: 'PATCH',
       headers: {'Content-Type': 'application/json'},
@@ -1478,6 +2359,29 @@ async function saveSettings() {
 }
 
 // ── Danger Zone ─────────────────────────────────────────────────────────────
+// pollJob shows the progress of a background bulk job in msg until it finishes.
+function pollJob(name, msg, onDone) {
+  const tick = async () => {
+    try {
+      const r = await fetch('/web/api/jobs');
+      const job = (await r.json())[name];
+      if (!job) return;
+      if (job.running) {
+        msg.textContent = job.done+' / '+job.total+' processed'+(job.failed ? ' ('+job.failed+' failed)' : '')+'…';
+        setTimeout(tick, 2000);
+        return;
+      }
+      msg.textContent = job.message || 'Done.';
+      msg.style.color = job.failed ? 'var(--yellow)' : 'var(--green)';
+      toast(job.message || 'Done.');
+      if (onDone) onDone(job);
+    } catch(e) {
+      console.warn('pollJob failed', e);
+    }
+  };
+  setTimeout(tick, 1000);
+}
+
 async function forceRefollowAll() {
   if (!confirm('Broadcast new Follow activities to all existing Fediverse contacts? This can take several minutes to run in the background.')) return;
   const btn = document.getElementById('btn-force-refollow');
@@ -1494,6 +2398,7 @@ async function forceRefollowAll() {
       msg.textContent = d.message || 'Started.';
       msg.style.color = 'var(--green)';
       toast(d.message || 'Bulk Re-sync initiated.');
+      if (r.status === 202) pollJob('refollow-all', msg);
     } else {
       msg.textContent = 'Error: ' + (d.error || d.message || r.statusText);
       msg.style.color = 'var(--red)';
@@ -1507,6 +2412,59 @@ async function forceRefollowAll() {
   }
 }
 
+let keyRotationPending = false;
+
+async function loadKeyRotation() {
+  try {
+    const r = await apiFetch('/web/api/rotate-key');
+    if (!r.ok) return;
+    const d = await r.json();
+    keyRotationPending = d.pending;
+    const btn = document.getElementById('btn-rotate-key');
+    btn.textContent = d.pending ? 'Promote Prepared Key' : 'Prepare New Signing Key';
+    if (d.pending) {
+      const msg = document.getElementById('rotate-key-msg');
+      msg.textContent = 'Key prepared ' + new Date(d.prepared_at * 1000).toLocaleString() + ', waiting to be promoted.';
+      msg.style.color = '';
+    }
+  } catch(e) {
+    console.error('loadKeyRotation', e);
+  }
+}
+
+async function rotateSigningKey() {
+  const stage = keyRotationPending ? 'promote' : 'prepare';
+  const prompt = stage === 'prepare'
+    ? 'Generate a new signing key and publish it next to the current one?'
+    : 'Make the prepared key your #main-key? Servers that have not refetched your actor since it was prepared may reject deliveries until they do.';
+  if (!confirm(prompt)) return;
+  const btn = document.getElementById('btn-rotate-key');
+  const msg = document.getElementById('rotate-key-msg');
+  btn.disabled = true;
+  msg.textContent = '';
+  msg.style.color = '';
+  try {
+    const r = await apiFetch('/web/api/rotate-key', {
+      method: 'POST',
+      headers: {'Content-Type': 'application/json'},
+      body: JSON.stringify({stage}),
+    });
+    const d = await r.json();
+    msg.textContent = r.ok ? d.message : 'Error: ' + (d.error || r.statusText);
+    msg.style.color = r.ok ? 'var(--green)' : 'var(--red)';
+    if (r.ok) {
+      toast(stage === 'prepare' ? 'Signing key prepared' : 'Signing key rotated');
+      keyRotationPending = stage === 'prepare';
+      btn.textContent = keyRotationPending ? 'Promote Prepared Key' : 'Prepare New Signing Key';
+    }
+  } catch(e) {
+    msg.textContent = 'Error: ' + e.message;
+    msg.style.color = 'var(--red)';
+  } finally {
+    btn.disabled = false;
+  }
+}
+
 async function wipeFediverseFollows() {
   if (!confirm('DANGER: This will permanently delete your entire Fediverse following list and send Undo Follows to remote servers. This CANNOT be undone. Are you absolutely sure?')) return;
   if (!confirm('Final confirmation: Completely WIPE all Fediverse contacts?')) return;
@@ -1525,7 +2483,7 @@ async function wipeFediverseFollows() {
       msg.textContent = d.message || 'Wiped.';
       msg.style.color = 'var(--green)';
       toast(d.message || 'Fediverse contacts wiped.');
-      loadFollowing(); // Refresh the list
+      if (r.status === 202) pollJob('wipe-follows', msg, loadFollowing); // Refresh the list when done
     } else {
       msg.textContent = 'Error: ' + (d.error || d.message || r.statusText);
       msg.style.color = 'var(--red)';
@@ -1542,7 +2500,7 @@ async function wipeFediverseFollows() {
 // ── Init ─────────────────────────────────────────────────────────────────────
 // loadFollowing depends on bskyEnabled (set by loadStatus), so chain it.
 loadStatus().then(() => loadFollowing()).catch(e => console.error('loadFollowing failed', e));
-Promise.all([loadStats(), loadFollowers(), loadRelays(), loadSettings()]).catch(e => console.error('init failed', e));
+Promise.all([loadStats(), loadFollowers(), loadRelays(), loadSettings(), loadInstanceBlocks(), loadBridgeExclusions(), loadContentFilters(), loadFederationHosts(), loadPendingFollows(), loadFailures(), loadAuditLog(), loadKeyRotation()]).catch(e => console.error('init failed', e));
 
 setInterval(loadStats,    30000);
 setInterval(loadRelays,   15000);
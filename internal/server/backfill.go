@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	gonostr "github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/db"
+)
+
+const (
+	// defaultBackfill is the number of posts backfilled when no limit is given.
+	defaultBackfill = 200
+	// maxBackfill caps one backfill run.
+	maxBackfill = 2000
+	// backfillPageSize is the number of events requested per relay query.
+	backfillPageSize = 100
+	// backfillTimeout bounds one backfill run started from the admin UI.
+	backfillTimeout = 10 * time.Minute
+)
+
+// Backfill maps up to limit of the user's most recent Nostr posts to AP
+// objects, so they appear in the outbox and /objects/<id> and replies from
+// the Fediverse thread onto them. Each event is rendered with the same
+// transmuter the live bridge uses; its object ID is stored in the objects
+// table and its hashtags in the tag index. Events already mapped are skipped,
+// so re-running is safe. progress, if non-nil, is called once per event.
+//
+// Used by the -backfill command-line flag and POST /web/api/backfill.
+func Backfill(ctx context.Context, relays []string, pubkey string, store *db.Store, tc *ap.TransmuteContext, limit int, progress func(error)) (string, error) {
+	if limit <= 0 {
+		limit = defaultBackfill
+	}
+	if limit > maxBackfill {
+		limit = maxBackfill
+	}
+
+	mapped, skipped, seen := 0, 0, 0
+	var until *gonostr.Timestamp
+	for seen < limit {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		filter := gonostr.Filter{Kinds: outboxKinds, Limit: min(backfillPageSize, limit-seen), Until: until}
+		events := fetchAuthorEvents(ctx, relays, pubkey, filter)
+		if len(events) == 0 {
+			break
+		}
+		for _, event := range events {
+			if seen >= limit {
+				break
+			}
+			seen++
+			note := localNote(event, tc)
+			if note == nil {
+				skipped++
+				if progress != nil {
+					progress(nil)
+				}
+				continue
+			}
+			if _, ok := store.GetNostrIDForObject(note.ID); ok {
+				skipped++
+				if progress != nil {
+					progress(nil)
+				}
+				continue
+			}
+			err := store.AddObject(note.ID, event.ID)
+			if err != nil {
+				slog.Warn("backfill: failed to store object", "id", event.ID, "error", err)
+			} else {
+				mapped++
+				if tags := eventHashtags(event); len(tags) > 0 {
+					if err := store.AddObjectTags(note.ID, tags); err != nil {
+						slog.Warn("backfill: failed to index hashtags", "id", event.ID, "error", err)
+					}
+				}
+			}
+			if progress != nil {
+				progress(err)
+			}
+		}
+		oldest := events[len(events)-1].CreatedAt - 1
+		if until != nil && oldest >= *until {
+			break
+		}
+		until = &oldest
+	}
+
+	result := fmt.Sprintf("backfilled %d post(s); %d already mapped or not bridgeable (%d scanned)", mapped, skipped, seen)
+	slog.Info("backfill: complete", "mapped", mapped, "skipped", skipped, "scanned", seen)
+	return result, nil
+}
+
+// eventHashtags returns the values of event's "t" tags.
+func eventHashtags(event *gonostr.Event) []string {
+	var tags []string
+	for _, tag := range event.Tags {
+		if len(tag) >= 2 && tag[0] == "t" && tag[1] != "" {
+			tags = append(tags, tag[1])
+		}
+	}
+	return tags
+}
+
+// handleBackfill starts a backfill of the user's recent Nostr posts in the
+// background (see Backfill). Progress and the result are reported under the
+// "backfill" job of GET /web/api/jobs.
+//
+// POST /web/api/backfill
+// Body: {"limit":200}
+func (s *Server) handleBackfill(w http.ResponseWriter, r *http.Request) {
+	if s.tc == nil {
+		jsonResponse(w, map[string]string{"error": "backfill not available (transmute context not configured)"}, http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Limit int `json:"limit"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultBackfill
+	}
+	if limit > maxBackfill {
+		limit = maxBackfill
+	}
+
+	job, ok := s.startJob("backfill", limit)
+	if !ok {
+		jsonResponse(w, map[string]string{"error": "a backfill is already running"}, http.StatusConflict)
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), backfillTimeout)
+		defer cancel()
+		result, err := Backfill(ctx, s.cfg.NostrRelays, s.cfg.NostrPublicKey, s.store, s.tc, limit, job.progress)
+		if err != nil {
+			slog.Warn("backfill: failed", "error", err)
+			result = "failed: " + err.Error()
+		}
+		job.finish(result)
+	}()
+	s.auditLog("backfill", fmt.Sprintf("limit=%d", limit))
+	jsonResponse(w, map[string]string{"message": fmt.Sprintf("Backfilling up to %d posts in the background.", limit)}, http.StatusAccepted)
+}
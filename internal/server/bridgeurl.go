@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr/nip19"
+
+	"github.com/klppl/klistr/internal/bsky"
+)
+
+// bridgeURLTimeout bounds one on-demand bridge, including ancestor fetches.
+const bridgeURLTimeout = 60 * time.Second
+
+// BskyBridger bridges a single Bluesky post on demand. Implemented by
+// bsky.Poller.
+type BskyBridger interface {
+	BridgeURL(ctx context.Context, rawURL string) (eventID, authorDID string, err error)
+}
+
+// SetBskyBridger attaches the Bluesky post bridger used by POST
+// /web/api/bridge-url. Nil leaves only Fediverse URLs supported.
+func (s *Server) SetBskyBridger(b BskyBridger) { s.bskyBridger = b }
+
+// handleBridgeURL bridges one Fediverse or Bluesky post on demand, for posts
+// that failed to bridge on arrival. Fediverse URLs go through
+// APHandler.BridgeURL, bsky.app URLs and AT URIs through the Bluesky poller.
+// Already-bridged posts return their existing event.
+//
+// POST /web/api/bridge-url
+// Body: {"url":"https://mastodon.social/@alice/1234"}
+func (s *Server) handleBridgeURL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	rawURL := strings.TrimSpace(req.URL)
+
+	ctx, cancel := context.WithTimeout(r.Context(), bridgeURLTimeout)
+	defer cancel()
+
+	var eventID, author string
+	var err error
+	switch {
+	case bsky.IsPostURL(rawURL):
+		if s.bskyBridger == nil {
+			jsonResponse(w, map[string]string{"error": "Bluesky bridge not configured"}, http.StatusServiceUnavailable)
+			return
+		}
+		eventID, author, err = s.bskyBridger.BridgeURL(ctx, rawURL)
+	case strings.HasPrefix(rawURL, "https://") || strings.HasPrefix(rawURL, "http://"):
+		eventID, author, err = s.apHandler.BridgeURL(ctx, rawURL)
+	default:
+		jsonResponse(w, map[string]string{"error": "url must be a Fediverse post URL, a bsky.app post URL or an AT URI"}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusUnprocessableEntity)
+		return
+	}
+	s.auditLog("bridge_url", rawURL+" event="+eventID)
+
+	resp := map[string]string{"event_id": eventID}
+	var relays []string
+	if relay := s.cfg.PrimaryRelay(); relay != "" {
+		relays = []string{relay}
+	}
+	var pubkey string
+	if author != "" {
+		pubkey, _ = s.actorResolver.PublicKey(author)
+	}
+	if nevent, err := nip19.EncodeEvent(eventID, relays, pubkey); err == nil {
+		resp["nevent"] = nevent
+	}
+	if pubkey != "" {
+		if npub, err := nip19.EncodePublicKey(pubkey); err == nil {
+			resp["npub"] = npub
+		}
+	}
+	jsonResponse(w, resp, http.StatusOK)
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// bulkJob tracks the progress of a long-running background operation started
+// from the admin dashboard (Danger Zone re-sync and wipe). At most one job of
+// each name runs at a time.
+type bulkJob struct {
+	mu         sync.Mutex
+	name       string
+	total      int
+	done       int
+	failed     int
+	running    bool
+	message    string
+	result     interface{}
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+// bulkJobStatus is the JSON snapshot of a bulkJob returned by GET /web/api/jobs.
+type bulkJobStatus struct {
+	Name       string      `json:"name"`
+	Total      int         `json:"total"`
+	Done       int         `json:"done"`
+	Failed     int         `json:"failed"`
+	Running    bool        `json:"running"`
+	Message    string      `json:"message,omitempty"`
+	Result     interface{} `json:"result,omitempty"` // job-specific outcome, set by finishWithResult
+	StartedAt  string      `json:"started_at"`
+	FinishedAt string      `json:"finished_at,omitempty"`
+}
+
+// progress records one processed item.
+func (j *bulkJob) progress(err error) {
+	j.mu.Lock()
+	j.done++
+	if err != nil {
+		j.failed++
+	}
+	j.mu.Unlock()
+}
+
+// setTotal sets the number of items, for jobs that only learn it once
+// running.
+func (j *bulkJob) setTotal(total int) {
+	j.mu.Lock()
+	j.total = total
+	j.mu.Unlock()
+}
+
+// finish marks the job as complete with a summary message.
+func (j *bulkJob) finish(msg string) {
+	j.finishWithResult(msg, nil)
+}
+
+// finishWithResult marks the job as complete with a summary message and a
+// detailed result for the admin UI.
+func (j *bulkJob) finishWithResult(msg string, result interface{}) {
+	j.mu.Lock()
+	j.running = false
+	j.message = msg
+	j.result = result
+	j.finishedAt = time.Now()
+	j.mu.Unlock()
+}
+
+func (j *bulkJob) status() bulkJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	st := bulkJobStatus{
+		Name:      j.name,
+		Total:     j.total,
+		Done:      j.done,
+		Failed:    j.failed,
+		Running:   j.running,
+		Message:   j.message,
+		Result:    j.result,
+		StartedAt: j.startedAt.UTC().Format(time.RFC3339),
+	}
+	if !j.finishedAt.IsZero() {
+		st.FinishedAt = j.finishedAt.UTC().Format(time.RFC3339)
+	}
+	return st
+}
+
+// startJob registers a new running job. Returns false when a job with the
+// same name is still running.
+func (s *Server) startJob(name string, total int) (*bulkJob, bool) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	if prev, ok := s.jobs[name]; ok {
+		prev.mu.Lock()
+		running := prev.running
+		prev.mu.Unlock()
+		if running {
+			return nil, false
+		}
+	}
+	job := &bulkJob{name: name, total: total, running: true, startedAt: time.Now()}
+	if s.jobs == nil {
+		s.jobs = make(map[string]*bulkJob)
+	}
+	s.jobs[name] = job
+	return job, true
+}
+
+// handleGetJobs returns the status of the most recent run of every bulk job.
+//
+// GET /web/api/jobs
+func (s *Server) handleGetJobs(w http.ResponseWriter, r *http.Request) {
+	s.jobsMu.Lock()
+	out := make(map[string]bulkJobStatus, len(s.jobs))
+	for name, job := range s.jobs {
+		out[name] = job.status()
+	}
+	s.jobsMu.Unlock()
+	jsonResponse(w, out, http.StatusOK)
+}
+
+// forEachAPFollow streams the AP follows of localActorURL from the database in
+// pages of BULK_BATCH_SIZE and calls fn for each one, with at most
+// BULK_CONCURRENCY calls in flight. Each call is recorded on job. Returns
+// early (after in-flight calls finish) when ctx is cancelled.
+func (s *Server) forEachAPFollow(ctx context.Context, job *bulkJob, localActorURL string, fn func(ctx context.Context, actorURL string) error) error {
+	batch := s.cfg.BulkBatchSize
+	if batch <= 0 {
+		batch = 500
+	}
+	workers := s.cfg.BulkConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	after := ""
+	for {
+		page, err := s.store.GetAPFollowingPage(localActorURL, after, batch)
+		if err != nil {
+			return err
+		}
+		for _, actorURL := range page {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case sem <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(actorURL string) {
+				defer func() { <-sem; wg.Done() }()
+				err := fn(ctx, actorURL)
+				if err != nil {
+					slog.Debug("bulk job: item failed", "job", job.name, "actor", actorURL, "error", err)
+				}
+				job.progress(err)
+			}(actorURL)
+		}
+		if len(page) < batch {
+			return nil
+		}
+		after = page[len(page)-1]
+	}
+}
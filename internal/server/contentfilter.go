@@ -0,0 +1,158 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/klppl/klistr/internal/bridge"
+)
+
+// kvContentFilters holds the content filter rules as a JSON array.
+const kvContentFilters = "content_filters"
+
+// maxContentFilters caps the number of rules every inbound post is matched
+// against.
+const maxContentFilters = 200
+
+// SetContentFilter attaches the shared content filter (consulted by the AP
+// handler and Bluesky poller) and loads its rules from the KV store. Updated
+// live by the admin API.
+func (s *Server) SetContentFilter(f *bridge.ContentFilter) {
+	s.contentFilter = f
+	if err := f.Set(s.contentFilterRules()); err != nil {
+		slog.Warn("failed to load content filters", "error", err)
+	}
+}
+
+// contentFilterRules returns the stored content filter rules.
+func (s *Server) contentFilterRules() []bridge.ContentFilterRule {
+	rules := []bridge.ContentFilterRule{}
+	if raw, ok := s.store.GetKV(kvContentFilters); ok && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+			slog.Warn("failed to parse content filters", "error", err)
+		}
+	}
+	return rules
+}
+
+// saveContentFilterRules stores rules and applies them.
+func (s *Server) saveContentFilterRules(rules []bridge.ContentFilterRule) error {
+	raw, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	if err := s.store.SetKV(kvContentFilters, string(raw)); err != nil {
+		return err
+	}
+	if s.contentFilter != nil {
+		if err := s.contentFilter.Set(rules); err != nil {
+			slog.Warn("failed to apply content filters", "error", err)
+		}
+	}
+	return nil
+}
+
+// ─── Handlers ─────────────────────────────────────────────────────────────────
+
+// handleGetContentFilters lists the keyword/regex filters applied to inbound
+// Fediverse and Bluesky posts.
+//
+// GET /web/api/content-filters
+func (s *Server) handleGetContentFilters(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, s.contentFilterRules(), http.StatusOK)
+}
+
+// handleAddContentFilter adds a filter, or changes the action of an existing
+// one with the same pattern and type. Posts already bridged are unaffected.
+//
+// POST /web/api/content-filters
+// Body: {"pattern":"airdrop","regex":false,"action":"skip"} (action "skip" or "cw", default "skip")
+func (s *Server) handleAddContentFilter(w http.ResponseWriter, r *http.Request) {
+	var rule bridge.ContentFilterRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		jsonResponse(w, map[string]string{"error": "invalid JSON body"}, http.StatusBadRequest)
+		return
+	}
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	if rule.Action == "" {
+		rule.Action = bridge.FilterSkip
+	}
+	if _, err := rule.Compile(); err != nil {
+		jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	rules := s.contentFilterRules()
+	updated := false
+	for i, existing := range rules {
+		if existing.Pattern == rule.Pattern && existing.Regex == rule.Regex {
+			rules[i] = rule
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		if len(rules) >= maxContentFilters {
+			jsonResponse(w, map[string]string{"error": fmt.Sprintf("at most %d content filters", maxContentFilters)}, http.StatusBadRequest)
+			return
+		}
+		rules = append(rules, rule)
+	}
+	if err := s.saveContentFilterRules(rules); err != nil {
+		slog.Error("save content filters failed", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("content filter saved via admin", "pattern", rule.Pattern, "regex", rule.Regex, "action", rule.Action)
+	s.auditLog("content_filter_added", rule.Action+" "+rule.Pattern)
+	verb := "will not be bridged"
+	if rule.Action == bridge.FilterWarn {
+		verb = "will be bridged behind a content warning"
+	}
+	jsonResponse(w, map[string]interface{}{
+		"added":   !updated,
+		"rule":    rule,
+		"message": "Posts matching " + rule.Pattern + " " + verb,
+	}, http.StatusOK)
+}
+
+// handleRemoveContentFilter removes the filter with the given pattern.
+//
+// DELETE /web/api/content-filters
+// Body: {"pattern":"airdrop","regex":false}
+func (s *Server) handleRemoveContentFilter(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Pattern string `json:"pattern"`
+		Regex   bool   `json:"regex"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, map[string]string{"error": "invalid JSON body"}, http.StatusBadRequest)
+		return
+	}
+
+	rules := s.contentFilterRules()
+	kept := rules[:0]
+	for _, rule := range rules {
+		if rule.Pattern != req.Pattern || rule.Regex != req.Regex {
+			kept = append(kept, rule)
+		}
+	}
+	removed := len(kept) < len(rules)
+	if removed {
+		if err := s.saveContentFilterRules(kept); err != nil {
+			slog.Error("save content filters failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		slog.Info("content filter removed via admin", "pattern", req.Pattern)
+		s.auditLog("content_filter_removed", req.Pattern)
+	}
+	jsonResponse(w, map[string]interface{}{
+		"removed": removed,
+		"message": map[bool]string{true: req.Pattern + " removed", false: req.Pattern + " was not a filter"}[removed],
+	}, http.StatusOK)
+}
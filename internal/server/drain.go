@@ -0,0 +1,68 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// inboxDrain tracks the inbox activities being processed in the background,
+// so shutdown can stop accepting new ones and wait for those in flight
+// instead of cutting a bridge off mid-publish.
+type inboxDrain struct {
+	mu      sync.Mutex
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// begin registers an activity about to be processed. It returns false once
+// draining has started; the caller must then refuse the delivery.
+func (d *inboxDrain) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	d.pending.Add(1)
+	return true
+}
+
+// done marks an activity registered with begin as finished.
+func (d *inboxDrain) done() { d.pending.Done() }
+
+// draining reports whether shutdown has started.
+func (d *inboxDrain) draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closed
+}
+
+// drain stops new activities from starting and waits up to grace for the
+// in-flight ones to finish. Activities still running after grace are
+// abandoned (and logged).
+func (d *inboxDrain) drain(grace time.Duration) {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		d.pending.Wait()
+		close(finished)
+	}()
+	slog.Info("draining inbox", "grace_period", grace)
+	select {
+	case <-finished:
+		slog.Info("inbox drained")
+	case <-time.After(grace):
+		slog.Warn("inbox drain grace period expired, abandoning in-flight activities")
+	}
+}
+
+// refuseDraining answers an inbox delivery that arrives during shutdown.
+// Senders retry on 503, reaching the next instance in a rolling deploy.
+func refuseDraining(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "30")
+	http.Error(w, "shutting down", http.StatusServiceUnavailable)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/bridge"
+	"github.com/klppl/klistr/internal/db"
+)
+
+// SetBridgeExclusions attaches the shared set of authors whose posts are not
+// bridged (consulted by the AP handler and Bluesky poller) and loads it from
+// the bridge_exclusions table. Updated live by the admin API.
+func (s *Server) SetBridgeExclusions(e *bridge.AuthorExclusions) {
+	s.exclusions = e
+	s.loadBridgeExclusions()
+}
+
+// loadBridgeExclusions (re)loads the excluded authors from the database.
+func (s *Server) loadBridgeExclusions() {
+	if s.exclusions == nil {
+		return
+	}
+	exclusions, err := s.store.GetBridgeExclusions()
+	if err != nil {
+		slog.Warn("failed to load bridge exclusions", "error", err)
+		return
+	}
+	authors := make([]string, 0, len(exclusions))
+	for _, e := range exclusions {
+		authors = append(authors, e.Author)
+	}
+	s.exclusions.Set(authors)
+}
+
+// resolveExclusionAuthor turns a user-supplied author into the key the
+// bridges check: a Bluesky DID or an AP actor URL are used as is, a
+// Fediverse handle (user@domain) is resolved via WebFinger and a Bluesky
+// handle via the Bluesky client. Also returns a display label.
+func (s *Server) resolveExclusionAuthor(ctx context.Context, raw string) (author, label string, err error) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "@")
+	switch {
+	case raw == "":
+		return "", "", fmt.Errorf("author required")
+	case strings.HasPrefix(raw, "did:"):
+		return raw, raw, nil
+	case strings.HasPrefix(raw, "https://") || strings.HasPrefix(raw, "http://"):
+		return raw, apURLToHandle(raw), nil
+	case strings.Contains(raw, "@"):
+		actorURL, err := ap.WebFingerResolve(ctx, raw)
+		if err != nil {
+			return "", "", err
+		}
+		return actorURL, "@" + raw, nil
+	}
+	if s.bskyClient == nil {
+		return "", "", fmt.Errorf("%q is not a DID, actor URL or user@domain handle (Bluesky handles need the Bluesky bridge)", raw)
+	}
+	profile, err := s.bskyClient.GetProfile(ctx, raw)
+	if err != nil {
+		return "", "", err
+	}
+	return profile.DID, "@" + profile.Handle, nil
+}
+
+// ─── Handlers ─────────────────────────────────────────────────────────────────
+
+// handleGetBridgeExclusions lists the authors whose posts are not bridged.
+//
+// GET /web/api/bridge-exclusions
+func (s *Server) handleGetBridgeExclusions(w http.ResponseWriter, r *http.Request) {
+	exclusions, err := s.store.GetBridgeExclusions()
+	if err != nil {
+		slog.Error("bridge exclusions query failed", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if exclusions == nil {
+		exclusions = []db.BridgeExclusion{}
+	}
+	jsonResponse(w, exclusions, http.StatusOK)
+}
+
+// handleAddBridgeExclusion stops bridging an author's posts. Following them
+// on their own network is unaffected, and already bridged posts remain.
+//
+// POST /web/api/bridge-exclusions
+// Body: {"author":"bot@mastodon.social"} (also a DID, actor URL or Bluesky handle)
+func (s *Server) handleAddBridgeExclusion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Author string `json:"author"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, map[string]string{"error": "invalid JSON body"}, http.StatusBadRequest)
+		return
+	}
+	author, label, err := s.resolveExclusionAuthor(r.Context(), req.Author)
+	if err != nil {
+		jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	added, err := s.store.AddBridgeExclusion(author, label)
+	if err != nil {
+		slog.Error("add bridge exclusion failed", "author", author, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if added {
+		s.loadBridgeExclusions()
+		slog.Info("bridge exclusion added via admin", "author", author)
+		s.auditLog("bridge_exclusion_added", author)
+	}
+	jsonResponse(w, map[string]interface{}{
+		"added":   added,
+		"author":  author,
+		"message": map[bool]string{true: label + " will no longer be bridged", false: label + " is already excluded"}[added],
+	}, http.StatusOK)
+}
+
+// handleRemoveBridgeExclusion resumes bridging an author's posts.
+//
+// DELETE /web/api/bridge-exclusions
+// Body: {"author":"https://mastodon.social/users/bot"}
+func (s *Server) handleRemoveBridgeExclusion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Author string `json:"author"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, map[string]string{"error": "invalid JSON body"}, http.StatusBadRequest)
+		return
+	}
+	author, label, err := s.resolveExclusionAuthor(r.Context(), req.Author)
+	if err != nil {
+		jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	removed, err := s.store.RemoveBridgeExclusion(author)
+	if err != nil {
+		slog.Error("remove bridge exclusion failed", "author", author, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if removed {
+		s.loadBridgeExclusions()
+		slog.Info("bridge exclusion removed via admin", "author", author)
+		s.auditLog("bridge_exclusion_removed", author)
+	}
+	jsonResponse(w, map[string]interface{}{
+		"removed": removed,
+		"author":  author,
+		"message": map[bool]string{true: label + " will be bridged again", false: label + " was not excluded"}[removed],
+	}, http.StatusOK)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/klppl/klistr/internal/db"
+)
+
+const (
+	// maxFailedActivities caps the failed_activities table; older entries are
+	// deleted as new ones arrive.
+	maxFailedActivities = 500
+	// maxFailedActivityRaw bounds the stored activity body. Larger bodies are
+	// truncated and can no longer be retried.
+	maxFailedActivityRaw = 64 << 10
+	// failureRetryTimeout bounds one manual retry, matching inbox processing.
+	failureRetryTimeout = 30 * time.Second
+)
+
+// recordFailedActivity stores an inbound activity that HandleActivity
+// rejected, so it can be inspected and retried from the admin UI instead of
+// only being logged.
+func (s *Server) recordFailedActivity(body []byte, handleErr error) {
+	var hdr struct {
+		ID    string          `json:"id"`
+		Type  string          `json:"type"`
+		Actor json.RawMessage `json:"actor"`
+	}
+	_ = json.Unmarshal(body, &hdr)
+
+	f := db.FailedActivity{
+		ID:    hdr.ID,
+		Type:  hdr.Type,
+		Actor: rawID(hdr.Actor),
+		Error: handleErr.Error(),
+		Raw:   string(body),
+	}
+	if f.ID == "" {
+		sum := sha256.Sum256(body)
+		f.ID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	if len(f.Raw) > maxFailedActivityRaw {
+		f.Raw = f.Raw[:maxFailedActivityRaw]
+	}
+	if err := s.store.RecordFailedActivity(f, maxFailedActivities); err != nil {
+		slog.Warn("failed to record failed activity", "id", f.ID, "error", err)
+	}
+}
+
+// rawID returns the id of a JSON-LD reference given as an IRI or an object.
+func rawID(raw json.RawMessage) string {
+	var id string
+	if json.Unmarshal(raw, &id) == nil {
+		return id
+	}
+	var obj struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(raw, &obj)
+	return obj.ID
+}
+
+// handleGetFailures lists the most recent inbound activities that failed to
+// bridge, newest first, without their raw bodies.
+//
+// GET /web/api/failures
+func (s *Server) handleGetFailures(w http.ResponseWriter, r *http.Request) {
+	failures, err := s.store.GetFailedActivities(maxFailedActivities)
+	if err != nil {
+		http.Error(w, "failed to read failed activities", http.StatusInternalServerError)
+		return
+	}
+	if failures == nil {
+		failures = []db.FailedActivity{}
+	}
+	jsonResponse(w, failures, http.StatusOK)
+}
+
+// handleRetryFailure re-runs HandleActivity on a stored activity. On success
+// the entry is removed; on failure its error is updated.
+//
+// POST /web/api/failures/retry
+// Body: {"id":"https://mastodon.social/users/alice/statuses/1/activity"}
+func (s *Server) handleRetryFailure(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	f, ok := s.store.GetFailedActivity(req.ID)
+	if !ok {
+		jsonResponse(w, map[string]string{"error": "failed activity not found"}, http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), failureRetryTimeout)
+	defer cancel()
+	if err := s.apHandler.HandleActivity(ctx, json.RawMessage(f.Raw)); err != nil {
+		f.Error = err.Error()
+		if err := s.store.RecordFailedActivity(f, maxFailedActivities); err != nil {
+			slog.Warn("failed to update failed activity", "id", f.ID, "error", err)
+		}
+		jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusUnprocessableEntity)
+		return
+	}
+	if err := s.store.DeleteFailedActivity(f.ID); err != nil {
+		slog.Warn("failed to delete retried activity", "id", f.ID, "error", err)
+	}
+	s.auditLog("retry_failed_activity", f.Type+" "+f.ID)
+	jsonResponse(w, map[string]string{"message": "Activity processed."}, http.StatusOK)
+}
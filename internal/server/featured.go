@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	gonostr "github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/ap"
+)
+
+const (
+	// kindPinList is the NIP-51 pinned notes list.
+	kindPinList = 10001
+	// featuredCacheTTL is how long the rendered featured collection is reused.
+	featuredCacheTTL = 5 * time.Minute
+	// maxFeaturedPosts caps the number of pinned posts listed.
+	maxFeaturedPosts = 20
+)
+
+// featuredCache holds the rendered featured collection items.
+type featuredCache struct {
+	mu      sync.Mutex
+	items   []interface{}
+	expires time.Time
+}
+
+// handleFeatured serves the actor's featured collection: the posts pinned to
+// the profile, which Mastodon shows at the top of it. The pinned set is
+// FEATURED_POSTS when configured, otherwise the local user's NIP-51
+// kind-10001 pin list. Additional users get an empty collection.
+//
+// GET /users/{username}/collections/featured
+func (s *Server) handleFeatured(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	if _, ok := s.cfg.LocalUser(username); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	s.robotsHint(w, s.cfg.ActorIndexable)
+
+	items := []interface{}{}
+	if username == s.cfg.NostrUsername && s.tc != nil {
+		items = s.renderFeatured(r.Context())
+	}
+	apResponse(w, map[string]interface{}{
+		"@context":     ap.DefaultContext,
+		"id":           s.cfg.BaseURL("/users/" + username + "/collections/featured"),
+		"type":         "OrderedCollection",
+		"totalItems":   len(items),
+		"orderedItems": items,
+	})
+}
+
+// renderFeatured returns the pinned posts as AP objects, most recently pinned
+// first, cached for featuredCacheTTL. The collection is public, so pinned
+// followers-only notes are left out.
+func (s *Server) renderFeatured(ctx context.Context) []interface{} {
+	c := &s.featuredCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.items != nil && time.Now().Before(c.expires) {
+		return c.items
+	}
+
+	ids := s.cfg.FeaturedPosts
+	if len(ids) == 0 {
+		ids = s.fetchPinnedIDs(ctx)
+	}
+	// NIP-51 lists append new items, so the most recent pin comes last.
+	pinned := make([]string, 0, len(ids))
+	for i := len(ids) - 1; i >= 0 && len(pinned) < maxFeaturedPosts; i-- {
+		pinned = append(pinned, ids[i])
+	}
+
+	items := []interface{}{}
+	if len(pinned) > 0 {
+		byID := make(map[string]*gonostr.Event)
+		for _, event := range s.fetchLocalEvents(ctx, gonostr.Filter{IDs: pinned, Kinds: outboxKinds}) {
+			byID[event.ID] = event
+		}
+		for _, id := range pinned {
+			event, ok := byID[id]
+			if !ok {
+				continue
+			}
+			if note := localNote(event, s.tc); note != nil && !ap.IsFollowersOnly(note) {
+				note.Context = nil
+				items = append(items, note)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		slog.Debug("featured: relay fetch cut short, not caching")
+		return items
+	}
+	c.items, c.expires = items, time.Now().Add(featuredCacheTTL)
+	return items
+}
+
+// fetchPinnedIDs returns the event IDs on the local user's latest
+// kind-10001 pin list, in list order.
+func (s *Server) fetchPinnedIDs(ctx context.Context) []string {
+	events := s.fetchLocalEvents(ctx, gonostr.Filter{Kinds: []int{kindPinList}, Limit: 1})
+	if len(events) == 0 {
+		return nil
+	}
+	var ids []string
+	for _, tag := range events[0].Tags {
+		if len(tag) >= 2 && tag[0] == "e" && gonostr.IsValid32ByteHex(tag[1]) {
+			ids = append(ids, tag[1])
+		}
+	}
+	return ids
+}
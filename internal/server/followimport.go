@@ -3,9 +3,12 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +16,7 @@ import (
 	gonostr "github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip19"
 	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/bsky"
 )
 
 // FollowPublisher can sign and publish Nostr events.
@@ -38,8 +42,18 @@ type importResult struct {
 // each one on Bluesky, derives their deterministic Nostr pubkeys, and publishes
 // a kind-3 contact list merged with the user's existing follows.
 //
-// POST /web/api/import-bsky-following
-// Body: {"handles":["alice.bsky.social","did:plc:xxx"]}
+// Entries may also be list or starter-pack URLs (bsky.app or AT URI); these
+// are expanded into their members, capped at maxFollowSetMembers per set.
+// With ?preview=true nothing is followed: the response reports each set's
+// resolved member count and the number of accounts that would be followed.
+//
+// An import can follow up to maxBskyImportAccounts accounts, far longer than
+// a request may take, so it runs in the background: progress and the
+// bskyImportResult are reported under the "bsky-import" job of
+// GET /web/api/jobs.
+//
+// POST /web/api/import-bsky-following[?preview=true]
+// Body: {"handles":["alice.bsky.social","https://bsky.app/starter-pack/bob.bsky.social/3k..."]}
 func (s *Server) handleImportBskyFollowing(w http.ResponseWriter, r *http.Request) {
 	if s.bskyClient == nil {
 		http.Error(w, "Bluesky bridge not configured", http.StatusServiceUnavailable)
@@ -52,32 +66,83 @@ func (s *Server) handleImportBskyFollowing(w http.ResponseWriter, r *http.Reques
 
 	var req struct {
 		Handles []string `json:"handles"`
+		Force   bool     `json:"force"` // publish even if the kind-3 shrinks beyond KIND3_MAX_SHRINK
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid JSON body", http.StatusBadRequest)
 		return
 	}
 
-	// Normalize: strip leading @, trim whitespace, deduplicate, skip blanks.
-	seen := make(map[string]bool)
-	var handles []string
-	for _, h := range req.Handles {
-		h = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(h), "@"))
-		if h == "" || seen[h] {
-			continue
-		}
-		seen[h] = true
-		handles = append(handles, h)
-	}
-	if len(handles) == 0 {
+	entries := normalizeImportHandles(req.Handles)
+	if len(entries) == 0 {
 		http.Error(w, "no handles provided", http.StatusBadRequest)
 		return
 	}
-	if len(handles) > 100 {
+	if len(entries) > 100 {
 		http.Error(w, "max 100 handles per import", http.StatusBadRequest)
 		return
 	}
 
+	if r.URL.Query().Get("preview") == "true" {
+		ctx, cancel := context.WithTimeout(r.Context(), bskyImportPreviewTimeout)
+		defer cancel()
+		handles, sets, setErrors := s.expandBskyFollowSets(ctx, entries)
+		type previewResponse struct {
+			Results  []importResult     `json:"results"`
+			Preview  bool               `json:"preview"`
+			Sets     []followSetSummary `json:"sets"`
+			Accounts int                `json:"accounts"`
+		}
+		jsonResponse(w, previewResponse{
+			Results:  setErrors,
+			Preview:  true,
+			Sets:     sets,
+			Accounts: len(handles),
+		}, http.StatusOK)
+		return
+	}
+
+	job, ok := s.startJob("bsky-import", 0)
+	if !ok {
+		jsonResponse(w, map[string]string{"error": "a Bluesky import is already running"}, http.StatusConflict)
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), bskyImportTimeout)
+		defer cancel()
+		res := s.importBskyFollowing(ctx, job, entries, req.Force)
+		msg := fmt.Sprintf("Followed %d of %d account(s).", res.Followed, res.Accounts)
+		if res.Error != "" {
+			msg += " Kind-3 not published: " + res.Error
+		}
+		job.finishWithResult(msg, res)
+	}()
+	jsonResponse(w, map[string]string{"message": fmt.Sprintf("Importing %d entries in the background.", len(entries))}, http.StatusAccepted)
+}
+
+// bskyImportResult is the outcome of a Bluesky follow import, reported as the
+// result of the "bsky-import" job.
+type bskyImportResult struct {
+	Results         []importResult     `json:"results"`
+	Sets            []followSetSummary `json:"sets,omitempty"`
+	Accounts        int                `json:"accounts"`
+	Followed        int                `json:"followed"`
+	Published       bool               `json:"published"`
+	TotalFollows    int                `json:"total_follows"`
+	PreviousFollows int                `json:"previous_follows"`
+	FetchedExisting bool               `json:"fetched_existing"`
+	ShrinkRefused   bool               `json:"shrink_refused,omitempty"`
+	Error           string             `json:"error,omitempty"`
+}
+
+// importBskyFollowing expands entries (see expandBskyFollowSets), follows
+// every resulting account on Bluesky, importConcurrency at a time, recording
+// each on job, and publishes the merged kind-3.
+func (s *Server) importBskyFollowing(ctx context.Context, job *bulkJob, entries []string, force bool) bskyImportResult {
+	// ── Step 0: Expand lists and starter packs into their members ────────────
+	handles, sets, setErrors := s.expandBskyFollowSets(ctx, entries)
+	job.setTotal(len(handles))
+
 	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
 
 	// ── Step 1: Resolve and follow concurrently ───────────────────────────────
@@ -88,11 +153,19 @@ func (s *Server) handleImportBskyFollowing(w http.ResponseWriter, r *http.Reques
 	outs := make([]bskyOut, len(handles))
 	var mu sync.Mutex
 	var wg sync.WaitGroup
+	sem := make(chan struct{}, importConcurrency)
 	for i, handle := range handles {
 		wg.Add(1)
 		go func(i int, handle string) {
 			defer wg.Done()
-			res, pubkey := s.resolveBskyFollowHandle(r.Context(), handle, localActorURL)
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			res, pubkey := s.resolveBskyFollowHandle(ctx, handle, localActorURL)
+			if pubkey == "" {
+				job.progress(errors.New(res.Error))
+			} else {
+				job.progress(nil)
+			}
 			mu.Lock()
 			outs[i] = bskyOut{res: res, pubkey: pubkey}
 			mu.Unlock()
@@ -101,18 +174,21 @@ func (s *Server) handleImportBskyFollowing(w http.ResponseWriter, r *http.Reques
 	wg.Wait()
 
 	// ── Step 2: Collect pubkeys and publish kind-3 ────────────────────────────
-	results := make([]importResult, len(handles))
+	results := append(make([]importResult, 0, len(setErrors)+len(handles)), setErrors...)
 	var addPubkeys []string
-	for i, out := range outs {
-		results[i] = out.res
+	for _, out := range outs {
+		results = append(results, out.res)
 		if out.pubkey != "" {
 			addPubkeys = append(addPubkeys, out.pubkey)
 		}
 	}
 
-	totalFollows, fetchedExisting, err := s.mergeAndPublishKind3(r.Context(), addPubkeys, nil)
+	merge, err := s.mergeAndPublishKind3(ctx, addPubkeys, nil, force)
+	totalFollows := merge.After
 	var publishErr string
+	var shrinkErr *kind3ShrinkError
 	published := err == nil
+	shrinkRefused := errors.As(err, &shrinkErr)
 	if err != nil {
 		publishErr = err.Error()
 		slog.Warn("import bsky following: publish failed", "error", err)
@@ -122,23 +198,98 @@ func (s *Server) handleImportBskyFollowing(w http.ResponseWriter, r *http.Reques
 			"new_handles", len(handles))
 		ok := len(addPubkeys)
 		s.auditLog("bsky_following_imported",
-			fmt.Sprintf("submitted=%d ok=%d total_follows=%d", len(handles), ok, totalFollows))
+			fmt.Sprintf("submitted=%d sets=%d ok=%d total_follows=%d", len(handles), len(sets), ok, totalFollows))
 	}
 
-	type response struct {
-		Results         []importResult `json:"results"`
-		Published       bool           `json:"published"`
-		TotalFollows    int            `json:"total_follows"`
-		FetchedExisting bool           `json:"fetched_existing"`
-		Error           string         `json:"error,omitempty"`
-	}
-	jsonResponse(w, response{
+	return bskyImportResult{
 		Results:         results,
+		Sets:            sets,
+		Accounts:        len(handles),
+		Followed:        len(addPubkeys),
 		Published:       published,
 		TotalFollows:    totalFollows,
-		FetchedExisting: fetchedExisting,
+		PreviousFollows: merge.Before,
+		FetchedExisting: merge.FetchedExisting,
+		ShrinkRefused:   shrinkRefused,
 		Error:           publishErr,
-	}, http.StatusOK)
+	}
+}
+
+const (
+	// maxFollowSetMembers caps how many members of one list or starter pack
+	// are imported. Starter packs hold at most 150 accounts; larger curate
+	// lists are truncated.
+	maxFollowSetMembers = 150
+	// maxBskyImportAccounts caps the accounts followed by one Bluesky import
+	// after lists and starter packs have been expanded.
+	maxBskyImportAccounts = 500
+	// bskyImportTimeout bounds a background Bluesky import.
+	bskyImportTimeout = 30 * time.Minute
+	// bskyImportPreviewTimeout bounds the list lookups of a preview, which
+	// answers within the request; sets not resolved in time are reported as
+	// errors.
+	bskyImportPreviewTimeout = 10 * time.Second
+)
+
+// followSetSummary describes one expanded list or starter pack in the Bluesky
+// import response.
+type followSetSummary struct {
+	URL       string `json:"url"`
+	Name      string `json:"name"`
+	Total     int    `json:"total"`     // members reported by Bluesky
+	Members   int    `json:"members"`   // members that will be followed
+	Truncated bool   `json:"truncated"` // members were dropped by a cap
+}
+
+// expandBskyFollowSets replaces list and starter-pack URLs in entries with
+// their members' handles (or DIDs for accounts without a valid handle),
+// deduplicating against the other entries. Sets that cannot be resolved are
+// returned as error results. The expanded list is capped at
+// maxBskyImportAccounts; sets cut short by the cap are marked truncated.
+func (s *Server) expandBskyFollowSets(ctx context.Context, entries []string) ([]string, []followSetSummary, []importResult) {
+	seen := make(map[string]bool)
+	var handles []string
+	var sets []followSetSummary
+	var errs []importResult
+	add := func(h string) bool {
+		if seen[h] {
+			return true
+		}
+		if len(handles) >= maxBskyImportAccounts {
+			return false
+		}
+		seen[h] = true
+		handles = append(handles, h)
+		return true
+	}
+	for _, entry := range entries {
+		if !bsky.IsFollowSetURL(entry) {
+			if !add(entry) {
+				errs = append(errs, importResult{Handle: entry, Status: "error",
+					Error: fmt.Sprintf("skipped: import is capped at %d accounts", maxBskyImportAccounts)})
+			}
+			continue
+		}
+		set, err := s.bskyClient.GetFollowSet(ctx, entry, maxFollowSetMembers)
+		if err != nil {
+			errs = append(errs, importResult{Handle: entry, Status: "error", Error: "list lookup failed: " + err.Error()})
+			continue
+		}
+		sum := followSetSummary{URL: entry, Name: set.Name, Total: set.Total, Truncated: set.Truncated}
+		for _, m := range set.Members {
+			h := m.Handle
+			if h == "" || h == "handle.invalid" {
+				h = m.DID
+			}
+			if !add(h) {
+				sum.Truncated = true
+				break
+			}
+			sum.Members++
+		}
+		sets = append(sets, sum)
+	}
+	return handles, sets, errs
 }
 
 // resolveBskyFollowHandle fetches the Bluesky profile for handle (a handle or
@@ -200,7 +351,10 @@ func (s *Server) resolveBskyFollowHandle(ctx context.Context, handle, localActor
 // via WebFinger, derives deterministic Nostr pubkeys, and publishes a kind-3
 // contact-list event that merges the new follows with the user's existing ones.
 //
-// POST /web/api/import-following
+// With ?preview=true nothing is published: the response instead lists the
+// npubs that would be added to / removed from the current kind-3.
+//
+// POST /web/api/import-following[?preview=true]
 // Body: {"handles":["alice@mastodon.social","bob@hachyderm.io"]}
 func (s *Server) handleImportFollowing(w http.ResponseWriter, r *http.Request) {
 	if s.followPublisher == nil {
@@ -210,23 +364,14 @@ func (s *Server) handleImportFollowing(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		Handles []string `json:"handles"`
+		Force   bool     `json:"force"` // publish even if the kind-3 shrinks beyond KIND3_MAX_SHRINK
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid JSON body", http.StatusBadRequest)
 		return
 	}
 
-	// Normalize: strip leading @, trim whitespace, deduplicate, skip blanks.
-	seen := make(map[string]bool)
-	var handles []string
-	for _, h := range req.Handles {
-		h = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(h), "@"))
-		if h == "" || seen[h] {
-			continue
-		}
-		seen[h] = true
-		handles = append(handles, h)
-	}
+	handles := normalizeImportHandles(req.Handles)
 	if len(handles) == 0 {
 		http.Error(w, "no handles provided", http.StatusBadRequest)
 		return
@@ -236,36 +381,39 @@ func (s *Server) handleImportFollowing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// ── Step 1: Resolve handles concurrently ─────────────────────────────────
-	results := make([]importResult, len(handles))
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-	for i, handle := range handles {
-		wg.Add(1)
-		go func(i int, handle string) {
-			defer wg.Done()
-			res := s.resolveFollowHandle(r.Context(), handle)
-			mu.Lock()
-			results[i] = res
-			mu.Unlock()
-		}(i, handle)
-	}
-	wg.Wait()
-
-	// ── Step 2: Collect newly resolved pubkeys ────────────────────────────────
-	var addPubkeys []string
-	for _, res := range results {
-		if res.Status == "ok" && res.Actor != "" {
-			if pk, err := s.actorResolver.PublicKey(res.Actor); err == nil {
-				addPubkeys = append(addPubkeys, pk)
-			}
+	// ── Step 1: Resolve handles and derive pubkeys ───────────────────────────
+	results, addPubkeys := s.resolveFollowHandles(r.Context(), handles)
+
+	// Preview mode: report the diff against the current kind-3 without
+	// publishing anything, so the admin can review before committing.
+	if r.URL.Query().Get("preview") == "true" {
+		all, existing := s.buildKind3Set(r.Context(), addPubkeys, nil)
+		type previewResponse struct {
+			Results         []importResult `json:"results"`
+			Preview         bool           `json:"preview"`
+			Added           []string       `json:"added"`
+			Removed         []string       `json:"removed"`
+			TotalFollows    int            `json:"total_follows"`
+			FetchedExisting bool           `json:"fetched_existing"`
 		}
+		jsonResponse(w, previewResponse{
+			Results:         results,
+			Preview:         true,
+			Added:           npubDiff(all, existing),
+			Removed:         npubDiff(existing, all),
+			TotalFollows:    len(all),
+			FetchedExisting: len(existing) > 0,
+		}, http.StatusOK)
+		return
 	}
 
-	// ── Step 3: Merge and publish kind-3 ─────────────────────────────────────
-	totalFollows, fetchedExisting, err := s.mergeAndPublishKind3(r.Context(), addPubkeys, nil)
+	// ── Step 2: Merge and publish kind-3 ─────────────────────────────────────
+	merge, err := s.mergeAndPublishKind3(r.Context(), addPubkeys, nil, req.Force)
+	totalFollows := merge.After
 	var publishErr string
+	var shrinkErr *kind3ShrinkError
 	published := err == nil
+	shrinkRefused := errors.As(err, &shrinkErr)
 	if err != nil {
 		publishErr = err.Error()
 		slog.Warn("import following: publish failed", "error", err)
@@ -282,21 +430,109 @@ func (s *Server) handleImportFollowing(w http.ResponseWriter, r *http.Request) {
 		Results         []importResult `json:"results"`
 		Published       bool           `json:"published"`
 		TotalFollows    int            `json:"total_follows"`
+		PreviousFollows int            `json:"previous_follows"`
 		FetchedExisting bool           `json:"fetched_existing"`
+		ShrinkRefused   bool           `json:"shrink_refused,omitempty"`
 		Error           string         `json:"error,omitempty"`
 	}
 	jsonResponse(w, response{
 		Results:         results,
 		Published:       published,
 		TotalFollows:    totalFollows,
-		FetchedExisting: fetchedExisting,
+		PreviousFollows: merge.Before,
+		FetchedExisting: merge.FetchedExisting,
+		ShrinkRefused:   shrinkRefused,
 		Error:           publishErr,
 	}, http.StatusOK)
 }
 
+// normalizeImportHandles strips leading @, trims whitespace, deduplicates and
+// skips blank entries, preserving the submitted order.
+func normalizeImportHandles(raw []string) []string {
+	seen := make(map[string]bool)
+	var handles []string
+	for _, h := range raw {
+		h = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(h), "@"))
+		if h == "" || seen[h] {
+			continue
+		}
+		seen[h] = true
+		handles = append(handles, h)
+	}
+	return handles
+}
+
+const (
+	// importConcurrency caps how many instances are queried in parallel while
+	// resolving imported handles.
+	importConcurrency = 5
+	// importRetryBackoff is the delay before the single retry of a transient
+	// WebFinger failure.
+	importRetryBackoff = 2 * time.Second
+)
+
+// resolveFollowHandles resolves Fediverse handles and returns the per-handle
+// results (in input order) plus the derived pubkeys of every handle that
+// resolved successfully. Shared by the preview and publish paths. Up to
+// importConcurrency instances are queried at once, but handles on the same
+// instance are resolved one after another so no single server is hammered.
+func (s *Server) resolveFollowHandles(ctx context.Context, handles []string) ([]importResult, []string) {
+	results := make([]importResult, len(handles))
+
+	byDomain := make(map[string][]int)
+	for i, handle := range handles {
+		_, domain, _ := strings.Cut(handle, "@")
+		domain = strings.ToLower(domain)
+		byDomain[domain] = append(byDomain[domain], i)
+	}
+
+	sem := make(chan struct{}, importConcurrency)
+	var wg sync.WaitGroup
+	for _, indices := range byDomain {
+		wg.Add(1)
+		go func(indices []int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			for _, i := range indices {
+				results[i] = s.resolveFollowHandle(ctx, handles[i])
+			}
+		}(indices)
+	}
+	wg.Wait()
+
+	var pubkeys []string
+	for _, res := range results {
+		if res.Status == "ok" && res.Actor != "" {
+			if pk, err := s.actorResolver.PublicKey(res.Actor); err == nil {
+				pubkeys = append(pubkeys, pk)
+			}
+		}
+	}
+	return results, pubkeys
+}
+
+// npubDiff returns the npubs of pubkeys present in a but not in b, sorted.
+func npubDiff(a, b map[string]struct{}) []string {
+	out := []string{}
+	for pk := range a {
+		if _, ok := b[pk]; ok {
+			continue
+		}
+		npub, err := nip19.EncodePublicKey(pk)
+		if err != nil {
+			npub = pk
+		}
+		out = append(out, npub)
+	}
+	sort.Strings(out)
+	return out
+}
+
 // handleRepublishKind3 re-publishes the user's kind-3 contact list to all relays by
 // merging the current relay state with all bridged follows from the local DB.
 // Useful after adding a new relay — the relay won't have your contact list until it's re-published.
+// A list that would shrink beyond KIND3_MAX_SHRINK is only published with ?force=true.
 //
 // POST /web/api/republish-kind3
 func (s *Server) handleRepublishKind3(w http.ResponseWriter, r *http.Request) {
@@ -304,34 +540,124 @@ func (s *Server) handleRepublishKind3(w http.ResponseWriter, r *http.Request) {
 		jsonResponse(w, map[string]string{"message": "Follow publisher not configured."}, http.StatusOK)
 		return
 	}
-	totalFollows, fetchedExisting, err := s.mergeAndPublishKind3(r.Context(), nil, nil)
+	merge, err := s.mergeAndPublishKind3(r.Context(), nil, nil, r.URL.Query().Get("force") == "true")
+	var shrinkErr *kind3ShrinkError
+	if errors.As(err, &shrinkErr) {
+		jsonResponse(w, map[string]interface{}{
+			"message":        "Not published: " + err.Error(),
+			"shrink_refused": true,
+		}, http.StatusOK)
+		return
+	}
 	if err != nil {
 		jsonResponse(w, map[string]string{"message": "Publish failed: " + err.Error()}, http.StatusOK)
 		return
 	}
-	msg := fmt.Sprintf("Kind-3 published to all relays — %d follow(s).", totalFollows)
-	if !fetchedExisting {
+	s.auditLog("kind3_republished", fmt.Sprintf("count=%d previous=%d", merge.After, merge.Before))
+	msg := fmt.Sprintf("Kind-3 published to all relays — %d follow(s), previously %d.", merge.After, merge.Before)
+	if !merge.FetchedExisting {
 		msg += " ⚠ No existing kind-3 found on relay."
 	}
 	jsonResponse(w, map[string]string{"message": msg}, http.StatusOK)
 }
 
+// minKind3Shrink is the smallest number of lost follows the shrink guard of
+// mergeAndPublishKind3 reacts to, so small lists can still be edited freely.
+const minKind3Shrink = 5
+
+// kind3Merge reports the outcome of mergeAndPublishKind3.
+type kind3Merge struct {
+	Before          int  // follows in the existing kind-3, or the last known count when none was fetched
+	After           int  // follows in the merged kind-3
+	FetchedExisting bool // whether an existing kind-3 was found on the relays
+}
+
+// kind3ShrinkError is returned by mergeAndPublishKind3 when the merged list
+// would drop more follows than KIND3_MAX_SHRINK allows.
+type kind3ShrinkError struct {
+	Before, After int
+}
+
+func (e *kind3ShrinkError) Error() string {
+	return fmt.Sprintf("refusing to replace a contact list of %d follows with %d; "+
+		"the existing kind-3 may not have been fetched from the relays (publish with force to override)", e.Before, e.After)
+}
+
 // mergeAndPublishKind3 builds a new kind-3 contact list by:
-//  1. Fetching the user's existing kind-3 from relays (to preserve current follows).
+//  1. Fetching the user's newest kind-3 from the relays (to preserve current follows).
 //  2. Including all AP- and Bluesky-bridged follows tracked in the local DB.
 //  3. Adding addPubkeys and removing removePubkeys.
 //  4. Signing and publishing the resulting kind-3 event.
 //
-// Returns the total number of follows in the published event, whether an
-// existing kind-3 was found on the relay, and any publish error.
-func (s *Server) mergeAndPublishKind3(ctx context.Context, addPubkeys, removePubkeys []string) (int, bool, error) {
+// Unless force is set, a list that loses more than KIND3_MAX_SHRINK of the
+// existing one — beyond removePubkeys — is not published and a
+// *kind3ShrinkError is returned: a relay that answered with a stale or no
+// kind-3 must not clobber the real contact list. When no kind-3 is fetched at
+// all, the size of the last one seen (kvKind3FollowCount) is the reference.
+// Publishing the same set twice yields the same list, so a failed call can
+// simply be retried.
+func (s *Server) mergeAndPublishKind3(ctx context.Context, addPubkeys, removePubkeys []string, force bool) (kind3Merge, error) {
 	if s.followPublisher == nil {
-		return 0, false, fmt.Errorf("follow publisher not configured")
+		return kind3Merge{}, fmt.Errorf("follow publisher not configured")
+	}
+
+	allPubkeys, existingPubkeys := s.buildKind3Set(ctx, addPubkeys, removePubkeys)
+	merge := kind3Merge{
+		Before:          len(existingPubkeys),
+		After:           len(allPubkeys),
+		FetchedExisting: len(existingPubkeys) > 0,
+	}
+	if !merge.FetchedExisting {
+		if v, ok := s.store.GetKV(kvKind3FollowCount); ok {
+			merge.Before, _ = strconv.Atoi(v)
+		}
+	}
+	if !force && s.kind3Shrinks(merge.Before, merge.After, len(removePubkeys)) {
+		slog.Warn("mergeAndPublishKind3: refusing to shrink kind-3", "before", merge.Before, "after", merge.After)
+		return merge, &kind3ShrinkError{Before: merge.Before, After: merge.After}
+	}
+
+	tags := make(gonostr.Tags, 0, len(allPubkeys))
+	for pk := range allPubkeys {
+		tags = append(tags, gonostr.Tag{"p", pk})
+	}
+
+	kind3 := &gonostr.Event{
+		Kind:      3,
+		Tags:      tags,
+		Content:   "",
+		CreatedAt: gonostr.Now(),
+	}
+
+	if err := s.followPublisher.SignAsUser(kind3); err != nil {
+		return merge, fmt.Errorf("sign failed: %w", err)
 	}
+	if err := s.followPublisher.Publish(ctx, kind3); err != nil {
+		return merge, fmt.Errorf("publish failed: %w", err)
+	}
+
+	// Lets the follow reconciler tell the bridge's own kind-3 from the user's.
+	_ = s.store.SetKV(kvKind3PublishedID, kind3.ID)
+	_ = s.store.SetKV(kvKind3FollowCount, strconv.Itoa(len(tags)))
+
+	slog.Info("mergeAndPublishKind3: published kind-3", "before", merge.Before, "total_follows", len(tags), "id", kind3.ID[:8])
+	return merge, nil
+}
+
+// kind3Shrinks reports whether replacing a contact list of before follows with
+// one of after loses more than KIND3_MAX_SHRINK of it, not counting the
+// removed follows the caller asked for.
+func (s *Server) kind3Shrinks(before, after, removed int) bool {
+	lost := before - after - removed
+	return lost >= minKind3Shrink && float64(lost) > s.cfg.Kind3MaxShrink*float64(before)
+}
 
+// buildKind3Set computes the merged contact set described on
+// mergeAndPublishKind3 without publishing it. Returns the merged set and the
+// pubkeys of the existing kind-3 fetched from relays (empty if none found).
+func (s *Server) buildKind3Set(ctx context.Context, addPubkeys, removePubkeys []string) (map[string]struct{}, map[string]struct{}) {
 	// Fetch existing kind-3 from relay (preserves non-bridge follows).
 	existingPubkeys := s.fetchExistingKind3(ctx)
-	fetchedExisting := len(existingPubkeys) > 0
 
 	allPubkeys := make(map[string]struct{})
 	for pk := range existingPubkeys {
@@ -369,32 +695,13 @@ func (s *Server) mergeAndPublishKind3(ctx context.Context, addPubkeys, removePub
 		delete(allPubkeys, pk)
 	}
 
-	tags := make(gonostr.Tags, 0, len(allPubkeys))
-	for pk := range allPubkeys {
-		tags = append(tags, gonostr.Tag{"p", pk})
-	}
-
-	kind3 := &gonostr.Event{
-		Kind:      3,
-		Tags:      tags,
-		Content:   "",
-		CreatedAt: gonostr.Now(),
-	}
-
-	if err := s.followPublisher.SignAsUser(kind3); err != nil {
-		return 0, fetchedExisting, fmt.Errorf("sign failed: %w", err)
-	}
-	if err := s.followPublisher.Publish(ctx, kind3); err != nil {
-		return 0, fetchedExisting, fmt.Errorf("publish failed: %w", err)
-	}
-
-	slog.Info("mergeAndPublishKind3: published kind-3", "total_follows", len(tags), "id", kind3.ID[:8])
-	return len(tags), fetchedExisting, nil
+	return allPubkeys, existingPubkeys
 }
 
-// resolveFollowHandle WebFingers a handle, derives its Nostr pubkey, and stores
-// the actor_key mapping so that handleKind3 can later resolve it when the
-// published kind-3 event is picked up by the relay subscription.
+// resolveFollowHandle WebFingers a handle (retrying once on a transient
+// failure), derives its Nostr pubkey, and stores the actor_key mapping so that
+// handleKind3 can later resolve it when the published kind-3 event is picked
+// up by the relay subscription.
 func (s *Server) resolveFollowHandle(ctx context.Context, handle string) importResult {
 	res := importResult{Handle: handle}
 
@@ -405,6 +712,15 @@ func (s *Server) resolveFollowHandle(ctx context.Context, handle string) importR
 	}
 
 	actorURL, err := ap.WebFingerResolve(ctx, handle)
+	if ap.IsRetryableWebFinger(err) {
+		// One retry for timeouts, 5xx and rate limiting.
+		slog.Debug("import following: WebFinger failed, retrying", "handle", handle, "error", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(importRetryBackoff):
+			actorURL, err = ap.WebFingerResolve(ctx, handle)
+		}
+	}
 	if err != nil {
 		res.Status = "error"
 		res.Error = "WebFinger failed: " + err.Error()
@@ -438,40 +754,17 @@ func (s *Server) resolveFollowHandle(ctx context.Context, handle string) importR
 	return res
 }
 
-// fetchExistingKind3 queries the configured relays for the user's most recent
-// kind-3 event and returns the set of pubkeys they currently follow. Returns
-// an empty map if no kind-3 is found within the timeout (8 s).
+// fetchExistingKind3 returns the set of pubkeys the user currently follows,
+// taken from the newest kind-3 across all relays (see fetchLatestKind3).
+// Returns an empty map if no kind-3 is found within the timeout.
 // This preserves the user's existing follows when building the new kind-3.
-func (s *Server) fetchExistingKind3(parentCtx context.Context) map[string]struct{} {
-	pubkeys := make(map[string]struct{})
-
-	ctx, cancel := context.WithTimeout(parentCtx, 8*time.Second)
-	defer cancel()
-
-	pool := gonostr.NewSimplePool(ctx)
-	filters := gonostr.Filters{{
-		Kinds:   []int{3},
-		Authors: []string{s.cfg.NostrPublicKey},
-		Limit:   1,
-	}}
-
-	for ev := range pool.SubMany(ctx, s.cfg.NostrRelays, filters) {
-		if ev.Event == nil {
-			continue
-		}
-		for _, tag := range ev.Event.Tags {
-			if len(tag) >= 2 && tag[0] == "p" {
-				pubkeys[tag[1]] = struct{}{}
-			}
-		}
-		cancel() // got the latest kind-3, stop subscribing
-		break
-	}
-
-	if len(pubkeys) > 0 {
-		slog.Debug("import following: fetched existing kind-3", "follows", len(pubkeys))
-	} else {
+func (s *Server) fetchExistingKind3(ctx context.Context) map[string]struct{} {
+	latest := s.fetchLatestKind3(ctx)
+	if latest == nil {
 		slog.Debug("import following: no existing kind-3 found on relays")
+		return make(map[string]struct{})
 	}
+	pubkeys := kind3Pubkeys(latest)
+	slog.Debug("import following: fetched existing kind-3", "follows", len(pubkeys))
 	return pubkeys
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/klppl/klistr/internal/ap"
@@ -21,13 +22,16 @@ type BskyClient interface {
 	FollowActor(ctx context.Context, did string) (string, error)
 	DeleteRecord(ctx context.Context, repo, collection, rkey string) error
 	GetProfile(ctx context.Context, actor string) (*bsky.Profile, error)
+	GetFollowSet(ctx context.Context, rawURL string, limit int) (*bsky.FollowSet, error)
 	DID() string
 }
 
 // fedFollowItem is one Fediverse entry in the GET /web/api/following response.
 type fedFollowItem struct {
-	Handle string `json:"handle"` // @user@domain
-	Actor  string `json:"actor"`  // full AP actor URL
+	Handle      string `json:"handle"`                 // @user@domain
+	Actor       string `json:"actor"`                  // full AP actor URL
+	Pending     bool   `json:"pending"`                // Follow sent, no Accept yet
+	RequestedAt string `json:"requested_at,omitempty"` // RFC3339Nano time the pending Follow was sent
 }
 
 // bskyFollowItem is one Bluesky entry in the GET /web/api/following response.
@@ -37,20 +41,42 @@ type bskyFollowItem struct {
 }
 
 // handleGetFollowing returns the current following lists for both bridges.
+// Fediverse follows whose Follow has not been accepted yet are marked
+// pending; ?status=pending or ?status=active returns only those Fediverse
+// follows (Bluesky follows are never pending and are left out).
 //
 // GET /web/api/following
 func (s *Server) handleGetFollowing(w http.ResponseWriter, r *http.Request) {
 	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "pending" && status != "active" {
+		jsonResponse(w, map[string]string{"error": "status must be pending or active"}, http.StatusBadRequest)
+		return
+	}
 
 	// Fediverse follows.
 	apFollows, _ := s.store.GetAPFollowing(localActorURL)
+	requests, err := s.store.GetFollowRequests(localActorURL)
+	if err != nil {
+		slog.Warn("following: failed to load follow requests", "error", err)
+	}
 	fedItems := make([]fedFollowItem, 0, len(apFollows))
 	for _, actorURL := range apFollows {
+		requestedAt, pending := requests[actorURL]
+		if (status == "pending" && !pending) || (status == "active" && pending) {
+			continue
+		}
 		fedItems = append(fedItems, fedFollowItem{
-			Handle: apURLToHandle(actorURL),
-			Actor:  actorURL,
+			Handle:      apURLToHandle(actorURL),
+			Actor:       actorURL,
+			Pending:     pending,
+			RequestedAt: requestedAt,
 		})
 	}
+	if status != "" {
+		jsonResponse(w, map[string]interface{}{"fediverse": fedItems}, http.StatusOK)
+		return
+	}
 
 	// Bluesky follows.
 	bskyFollows, _ := s.store.GetBskyFollowing(localActorURL)
@@ -201,7 +227,7 @@ func (s *Server) addFediverseFollow(ctx context.Context, handle, localActorURL s
 		slog.Warn("add fediverse follow: failed to store actor key", "error", err)
 	}
 
-	_, _, err = s.mergeAndPublishKind3(ctx, []string{pubkey}, nil)
+	_, err = s.mergeAndPublishKind3(ctx, []string{pubkey}, nil, false)
 	if err != nil {
 		return err
 	}
@@ -228,7 +254,7 @@ func (s *Server) removeFediverseFollow(ctx context.Context, handleOrURL, localAc
 		return err
 	}
 
-	_, _, err = s.mergeAndPublishKind3(ctx, nil, []string{pubkey})
+	_, err = s.mergeAndPublishKind3(ctx, nil, []string{pubkey}, false)
 	if err != nil {
 		return err
 	}
@@ -272,7 +298,7 @@ func (s *Server) addBskyFollow(ctx context.Context, handle, localActorURL string
 	// display their name, avatar, bio, and a link back to their Bluesky profile.
 	s.publishBskyProfileKind0(ctx, profile)
 
-	_, _, err = s.mergeAndPublishKind3(ctx, []string{pubkey}, nil)
+	_, err = s.mergeAndPublishKind3(ctx, []string{pubkey}, nil, false)
 	if err != nil {
 		return err
 	}
@@ -317,7 +343,7 @@ func (s *Server) removeBskyFollow(ctx context.Context, handleOrDID, localActorUR
 		slog.Warn("remove bsky follow: failed to remove from db", "error", err)
 	}
 
-	_, _, err = s.mergeAndPublishKind3(ctx, nil, []string{pubkey})
+	_, err = s.mergeAndPublishKind3(ctx, nil, []string{pubkey}, false)
 	if err != nil {
 		return err
 	}
@@ -445,9 +471,27 @@ func (s *Server) handleResyncFollowProfiles(w http.ResponseWriter, r *http.Reque
 	}
 
 	slog.Info("resync-follows: done", "bsky_synced", bskySynced, "bsky_errors", bskyErrors, "fediverse_queued", fedQueued)
+	s.auditLog("follow_profiles_resynced", fmt.Sprintf("bsky=%d errors=%d fediverse_queued=%t", bskySynced, bskyErrors, fedQueued))
 	jsonResponse(w, map[string]string{"message": msg}, http.StatusOK)
 }
 
+// handleResyncReport returns the outcome of the latest Fediverse account
+// resync: per-status counts and the actors that failed or were gone. "report"
+// is null when no resync has completed yet.
+//
+// GET /web/api/resync-report
+func (s *Server) handleResyncReport(w http.ResponseWriter, r *http.Request) {
+	var report *ap.ResyncReport
+	if raw, ok := s.store.GetKV(ap.KVResyncReport); ok {
+		report = &ap.ResyncReport{}
+		if err := json.Unmarshal([]byte(raw), report); err != nil {
+			slog.Warn("resync-report: stored report is unreadable", "error", err)
+			report = nil
+		}
+	}
+	jsonResponse(w, map[string]interface{}{"report": report}, http.StatusOK)
+}
+
 // apURLToHandle converts an AP actor URL like https://mastodon.social/users/alice
 // into a @alice@mastodon.social display string.
 func apURLToHandle(actorURL string) string {
@@ -466,22 +510,25 @@ func apURLToHandle(actorURL string) string {
 
 // ─── Danger Zone ─────────────────────────────────────────────────────────────
 
+// bulkJobTimeout bounds a single Danger Zone background job.
+const bulkJobTimeout = 2 * time.Hour
+
 // handleRefollowAll forces a re-sync of all inbound Fediverse follows by
-// iterating over the local database of followed AP actors and broadcasting
-// a fresh Follow activity to each of them. Long timeout used for large lists.
+// streaming the local database of followed AP actors and broadcasting a fresh
+// Follow activity to each of them from a bounded pool of background workers.
+// Progress is reported under the "refollow-all" job of GET /web/api/jobs.
 //
 // POST /web/api/refollow-all
 func (s *Server) handleRefollowAll(w http.ResponseWriter, r *http.Request) {
-	// We don't need a request context because we immediately detach to a background goroutine.
 	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
-	apFollows, err := s.store.GetAPFollowing(localActorURL)
+	count, err := s.store.CountAPFollowing(localActorURL)
 	if err != nil {
-		slog.Error("refollow-all: failed to read follows", "error", err)
+		slog.Error("refollow-all: failed to count follows", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	if len(apFollows) == 0 {
+	if count == 0 {
 		jsonResponse(w, map[string]string{"message": "No Fediverse follows found to re-sync."}, http.StatusOK)
 		return
 	}
@@ -491,108 +538,112 @@ func (s *Server) handleRefollowAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	job, ok := s.startJob("refollow-all", count)
+	if !ok {
+		jsonResponse(w, map[string]string{"error": "a re-sync is already running"}, http.StatusConflict)
+		return
+	}
+
 	// Federate the 'Follow' activities in the background so the HTTP request
-	// doesn't block waiting for hundreds of network calls.
+	// doesn't block waiting for thousands of network calls.
 	go func() {
-		// Replace the short context with a detached long-running one.
-		bgCtx, bgCancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		bgCtx, bgCancel := context.WithTimeout(context.Background(), bulkJobTimeout)
 		defer bgCancel()
 
-		slog.Info("refollow-all: starting bulk follow broadcast", "count", len(apFollows))
-		for _, targetActorURL := range apFollows {
-			follow := ap.BuildFollow(localActorURL, targetActorURL)
-			s.apHandler.Federator.Federate(bgCtx, follow)
+		slog.Info("refollow-all: starting bulk follow broadcast", "count", count)
+		err := s.forEachAPFollow(bgCtx, job, localActorURL, func(ctx context.Context, targetActorURL string) error {
+			s.apHandler.Federator.Federate(ctx, ap.BuildFollow(localActorURL, targetActorURL))
+			return nil
+		})
+		st := job.status()
+		if err != nil {
+			slog.Error("refollow-all: aborted", "done", st.Done, "error", err)
+			job.finish(fmt.Sprintf("Aborted after %d of %d contacts: %v", st.Done, st.Total, err))
+			return
 		}
-		slog.Info("refollow-all: completed bulk follow broadcast")
+		slog.Info("refollow-all: completed bulk follow broadcast", "done", st.Done)
+		job.finish(fmt.Sprintf("Re-sent Follow to %d Fediverse contacts.", st.Done))
 	}()
 
-	s.auditLog("refollow_all", "count="+fmt.Sprint(len(apFollows)))
+	s.auditLog("refollow_all", "count="+fmt.Sprint(count))
 	jsonResponse(w, map[string]string{
-		"message": fmt.Sprintf("Re-sync initiated for %d Fediverse contacts in the background.", len(apFollows)),
-	}, http.StatusOK)
+		"message": fmt.Sprintf("Re-sync initiated for %d Fediverse contacts in the background.", count),
+	}, http.StatusAccepted)
 }
 
 // handleWipeFollows permanently deletes all Fediverse contacts from the local
-// database and publishes an empty contact list (kind-3) to Nostr, triggering
-// 'Undo Follow' activities to all remote peers.
+// database, sends 'Undo Follow' activities to all remote peers, and publishes
+// a kind-3 contact list without them. The follow list is streamed from the
+// database in pages and processed by a bounded pool of background workers;
+// progress is reported under the "wipe-follows" job of GET /web/api/jobs.
 //
 // POST /web/api/wipe-follows
 func (s *Server) handleWipeFollows(w http.ResponseWriter, r *http.Request) {
 	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
-	apFollows, err := s.store.GetAPFollowing(localActorURL)
+	count, err := s.store.CountAPFollowing(localActorURL)
 	if err != nil {
-		slog.Error("wipe-follows: failed to read follows", "error", err)
+		slog.Error("wipe-follows: failed to count follows", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	count := len(apFollows)
 	if count == 0 {
 		jsonResponse(w, map[string]string{"message": "There are no Fediverse follows to wipe."}, http.StatusOK)
 		return
 	}
 
-	// 1. Unfollow from the DB directly.
-	for _, targetActorURL := range apFollows {
-		if err := s.store.RemoveFollow(localActorURL, targetActorURL); err != nil {
-			slog.Warn("wipe-follows: failed to remove from db", "actor", targetActorURL, "error", err)
-		}
+	job, ok := s.startJob("wipe-follows", count)
+	if !ok {
+		jsonResponse(w, map[string]string{"error": "a wipe is already running"}, http.StatusConflict)
+		return
 	}
 
-	// 2. Publish a merged kind-3 with empty desired additions but all current
-	// known pubkeys marked as removals. By omitting pubkeys from the new kind-3,
-	// the relay will echo it and our handleKind3 will issue Undo Follow for
-	// everyone who was in the previous list.
-	// Since we already deleted them from the DB, we just rely on `handleKind3`
-	// processing the removal and doing the AP cleanup. Actually, handleKind3 reads
-	// from the DB to see who to Undo. Since we just deleted them, it won't know!
-	//
-	// Better approach: just let handleKind3 do the work. We publish an empty kind-3
-	// (or a kind-3 containing ONLY the Bluesky follows without the AP follows).
-
-	// Get current Bluesky follows to preserve them in the kind-3.
-	bskyKeys := []string{}
-	if bskyFollows, err := s.store.GetBskyFollowing(localActorURL); err == nil {
-		for _, bskyID := range bskyFollows {
-			did := strings.TrimPrefix(bskyID, "bsky:")
-			if pubkey, err := s.actorResolver.PublicKey(did); err == nil {
-				bskyKeys = append(bskyKeys, pubkey)
+	go func() {
+		bgCtx, bgCancel := context.WithTimeout(context.Background(), bulkJobTimeout)
+		defer bgCancel()
+
+		// Undo each follow and delete it from the DB, collecting the derived
+		// pubkeys so they can be dropped from the kind-3 in a single publish.
+		// The rows are gone before handleKind3 sees the new list, so it does
+		// not send a second Undo.
+		var mu sync.Mutex
+		var removeKeys []string
+		slog.Info("wipe-follows: starting", "count", count)
+		err := s.forEachAPFollow(bgCtx, job, localActorURL, func(ctx context.Context, targetActorURL string) error {
+			if pubkey, err := s.actorResolver.PublicKey(targetActorURL); err == nil {
+				mu.Lock()
+				removeKeys = append(removeKeys, pubkey)
+				mu.Unlock()
+			}
+			if s.apHandler != nil && s.apHandler.Federator != nil {
+				s.apHandler.Federator.Federate(ctx, ap.BuildUndoFollow(localActorURL, targetActorURL))
 			}
+			return s.store.RemoveFollow(localActorURL, targetActorURL)
+		})
+		st := job.status()
+		if err != nil {
+			slog.Error("wipe-follows: aborted", "done", st.Done, "error", err)
 		}
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// mergeAndPublishKind3 will construct a new list using only bskyKeys.
-	// It replaces the old kind-3. When handleKind3 sees the new list is missing
-	// the AP keys, it will cross-reference with the DB, send Undo Follow to them,
-	// and THEN delete them from the DB.
-	_, _, err = s.mergeAndPublishKind3(ctx, bskyKeys, nil) // use Set semantics, actually mergeAndPublishKind3 ADDS keys.
-	if err != nil {
-		slog.Error("wipe-follows: failed to publish kind-3", "error", err)
-		http.Error(w, "internal error", http.StatusInternalServerError)
-		return
-	}
 
-	// Wait, mergeAndPublishKind3(ctx, add, remove) takes lists.
-	// We want to REMOVE all AP keys.
-	apKeysToRemove := []string{}
-	for _, targetActorURL := range apFollows {
-		if pubkey, err := s.actorResolver.PublicKey(targetActorURL); err == nil {
-			apKeysToRemove = append(apKeysToRemove, pubkey)
+		// Publish whatever was removed, even after an abort, so the kind-3
+		// stays consistent with the DB.
+		pubCtx, pubCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer pubCancel()
+		if _, perr := s.mergeAndPublishKind3(pubCtx, nil, removeKeys, false); perr != nil {
+			slog.Error("wipe-follows: failed to publish kind-3 removals", "error", perr)
+			job.finish(fmt.Sprintf("Removed %d of %d contacts, but publishing the contact list failed: %v", st.Done-st.Failed, st.Total, perr))
+			return
 		}
-	}
-
-	_, _, err = s.mergeAndPublishKind3(ctx, nil, apKeysToRemove)
-	if err != nil {
-		slog.Error("wipe-follows: failed to publish kind-3 removals", "error", err)
-		http.Error(w, "internal error", http.StatusInternalServerError)
-		return
-	}
+		if err != nil {
+			job.finish(fmt.Sprintf("Aborted after %d of %d contacts: %v", st.Done, st.Total, err))
+			return
+		}
+		slog.Info("wipe-follows: done", "removed", st.Done-st.Failed, "failed", st.Failed)
+		job.finish(fmt.Sprintf("Wiped %d Fediverse contacts. Unfollow requests sent.", st.Done-st.Failed))
+	}()
 
 	s.auditLog("wipe_follows", "count="+fmt.Sprint(count))
 	jsonResponse(w, map[string]string{
-		"message": fmt.Sprintf("Wiped %d Fediverse contacts. Unfollow requests sent.", count),
-	}, http.StatusOK)
+		"message": fmt.Sprintf("Wiping %d Fediverse contacts in the background.", count),
+	}, http.StatusAccepted)
 }
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/klppl/klistr/internal/bsky"
+)
+
+const (
+	// healthDBTimeout bounds the database ping of one health check.
+	healthDBTimeout = 3 * time.Second
+	// minBskyPollStaleness is the shortest time without a successful Bluesky
+	// poll that counts as degraded. The poller backs off up to 15 minutes
+	// while rate limited, which must not look unhealthy.
+	minBskyPollStaleness = 20 * time.Minute
+)
+
+// Subsystem health states. A "down" database makes the whole check fail;
+// "degraded" is reported but still answers 200.
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+)
+
+// healthCheck is the status of one subsystem.
+type healthCheck struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// handleHealthcheck reports per-subsystem health: the database (ping), the
+// Nostr relays (at least one circuit closed), the inbox (concurrency
+// headroom) and, when enabled, the Bluesky poller (a recent successful poll).
+// Only the database is critical: if it is down the response is 503. Relays
+// with every circuit open are "degraded", since a restart cannot fix a relay
+// outage. Otherwise 200, with "degraded" when a subsystem is unhealthy. The
+// endpoint is public, so details never include error text. ?quick=true skips
+// the checks and only confirms the HTTP server answers: that is the liveness
+// probe, used by the Docker healthcheck.
+//
+// GET /api/healthcheck[?quick=true]
+func (s *Server) handleHealthcheck(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("quick") == "true" {
+		jsonResponse(w, map[string]string{"status": healthOK}, http.StatusOK)
+		return
+	}
+
+	checks := map[string]healthCheck{
+		"database": s.checkDatabase(r.Context()),
+		"inbox":    s.checkInbox(),
+	}
+	if s.relayManager != nil {
+		checks["relays"] = s.checkRelays()
+	}
+	if s.cfg.BskyEnabled() {
+		checks["bluesky"] = s.checkBskyPoll()
+	}
+
+	status, code := healthOK, http.StatusOK
+	for name, c := range checks {
+		switch {
+		case c.Status == healthDown && name == "database":
+			status, code = healthDown, http.StatusServiceUnavailable
+		case c.Status != healthOK && status == healthOK:
+			status = healthDegraded
+		}
+	}
+	jsonResponse(w, map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	}, code)
+}
+
+func (s *Server) checkDatabase(ctx context.Context) healthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+	if err := s.store.Ping(ctx); err != nil {
+		slog.Warn("healthcheck: database ping failed", "error", err)
+		return healthCheck{Status: healthDown, Detail: "ping failed"}
+	}
+	return healthCheck{Status: healthOK}
+}
+
+func (s *Server) checkRelays() healthCheck {
+	statuses := s.relayManager.RelayStatuses()
+	closed := 0
+	for _, st := range statuses {
+		if !st.CircuitOpen {
+			closed++
+		}
+	}
+	detail := fmt.Sprintf("%d/%d relay circuits closed", closed, len(statuses))
+	if closed < len(statuses) {
+		return healthCheck{Status: healthDegraded, Detail: detail}
+	}
+	return healthCheck{Status: healthOK, Detail: detail}
+}
+
+func (s *Server) checkInbox() healthCheck {
+	inFlight := len(s.inboxSem)
+	detail := fmt.Sprintf("%d/%d activities in flight", inFlight, cap(s.inboxSem))
+	if inFlight >= cap(s.inboxSem) {
+		return healthCheck{Status: healthDegraded, Detail: detail}
+	}
+	return healthCheck{Status: healthOK, Detail: detail}
+}
+
+// BskyAccount is a bridged Bluesky account's poller. *bsky.Poller satisfies
+// this interface directly.
+type BskyAccount interface {
+	PollState() bsky.PollState
+}
+
+// checkBskyPoll reports degraded when a Bluesky poller has not completed a
+// successful poll within max(3 poll intervals, 2 max intervals,
+// minBskyPollStaleness). With several accounts each is checked, and the
+// detail names them.
+func (s *Server) checkBskyPoll() healthCheck {
+	if len(s.bskyAccounts) == 0 {
+		last, _ := s.store.GetKV("bsky_last_poll_at")
+		return s.checkBskyLastPoll(last)
+	}
+	if len(s.bskyAccounts) == 1 {
+		return s.checkBskyLastPoll(s.bskyAccounts[0].PollState().LastPoll)
+	}
+	result := healthCheck{Status: healthOK}
+	var details []string
+	for _, a := range s.bskyAccounts {
+		st := a.PollState()
+		c := s.checkBskyLastPoll(st.LastPoll)
+		if c.Status != healthOK {
+			result.Status = c.Status
+		}
+		details = append(details, st.Handle+": "+c.Detail)
+	}
+	result.Detail = strings.Join(details, "; ")
+	return result
+}
+
+// checkBskyLastPoll checks one account's last successful poll (RFC3339).
+func (s *Server) checkBskyLastPoll(last string) healthCheck {
+	staleAfter := max(3*s.cfg.BskyPollInterval, 2*s.cfg.BskyPollMaxInterval, minBskyPollStaleness)
+	t, err := time.Parse(time.RFC3339, last)
+	if err != nil || t.Before(s.startedAt.Truncate(time.Second)) {
+		if time.Since(s.startedAt) < staleAfter {
+			return healthCheck{Status: healthOK, Detail: "no poll yet"}
+		}
+		return healthCheck{Status: healthDegraded, Detail: "no successful poll since startup"}
+	}
+	age := time.Since(t).Truncate(time.Second)
+	detail := fmt.Sprintf("last successful poll %s ago", age)
+	if age > staleAfter {
+		return healthCheck{Status: healthDegraded, Detail: detail}
+	}
+	return healthCheck{Status: healthOK, Detail: detail}
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/klppl/klistr/internal/db"
+)
+
+// kvInstanceAllowlistMode stores "true" when only allow-listed instances may
+// deliver to the inbox.
+const kvInstanceAllowlistMode = "instance_allowlist_mode"
+
+// instanceFilter is the in-memory copy of the instance_rules table consulted
+// on every inbox delivery. Patterns are lowercase; "*.example.com" matches
+// example.com and all of its subdomains, anything else matches exactly.
+type instanceFilter struct {
+	mu            sync.RWMutex
+	blocks        []string
+	allows        []string
+	allowlistMode bool
+}
+
+// set replaces the filter contents.
+func (f *instanceFilter) set(rules []db.InstanceRule, allowlistMode bool) {
+	var blocks, allows []string
+	for _, r := range rules {
+		if r.List == "allow" {
+			allows = append(allows, r.Domain)
+		} else {
+			blocks = append(blocks, r.Domain)
+		}
+	}
+	f.mu.Lock()
+	f.blocks, f.allows, f.allowlistMode = blocks, allows, allowlistMode
+	f.mu.Unlock()
+}
+
+// allowed reports whether host (optionally with a port) may deliver to the
+// inbox. Blocks always win; in allowlist mode the host must also match an
+// allow entry.
+func (f *instanceFilter) allowed(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, p := range f.blocks {
+		if domainMatches(p, host) {
+			return false
+		}
+	}
+	if !f.allowlistMode {
+		return true
+	}
+	for _, p := range f.allows {
+		if domainMatches(p, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// InstanceAllowed reports whether content from host may be bridged under the
+// instance block/allow lists, for bridging paths outside the inbox.
+func (s *Server) InstanceAllowed(host string) bool { return s.instances.allowed(host) }
+
+// domainMatches reports whether host matches pattern.
+func domainMatches(pattern, host string) bool {
+	if base, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == base || strings.HasSuffix(host, "."+base)
+	}
+	return host == pattern
+}
+
+// normalizeInstanceDomain validates and canonicalises a user-supplied domain
+// pattern. Accepts bare hosts, "*.host" wildcards and full URLs.
+func normalizeInstanceDomain(raw string) (string, error) {
+	d := strings.ToLower(strings.TrimSpace(raw))
+	if i := strings.Index(d, "://"); i != -1 {
+		d = d[i+3:]
+	}
+	d, _, _ = strings.Cut(d, "/")
+	d = strings.TrimSuffix(d, ".")
+	base := strings.TrimPrefix(d, "*.")
+	if base == "" || strings.ContainsAny(base, "*@ ") || !strings.Contains(base, ".") {
+		return "", fmt.Errorf("invalid domain %q", raw)
+	}
+	return d, nil
+}
+
+// loadInstanceFilter (re)loads the instance rules from the database.
+func (s *Server) loadInstanceFilter() {
+	rules, err := s.store.GetInstanceRules()
+	if err != nil {
+		slog.Warn("failed to load instance rules", "error", err)
+		return
+	}
+	mode, _ := s.store.GetKV(kvInstanceAllowlistMode)
+	s.instances.set(rules, mode == "true")
+}
+
+// ─── Handlers ─────────────────────────────────────────────────────────────────
+
+// handleGetInstanceBlocks returns the block and allow lists and the mode.
+//
+// GET /web/api/instance-blocks
+func (s *Server) handleGetInstanceBlocks(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.store.GetInstanceRules()
+	if err != nil {
+		slog.Error("instance rules query failed", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	blocks, allows := []db.InstanceRule{}, []db.InstanceRule{}
+	for _, r := range rules {
+		if r.List == "allow" {
+			allows = append(allows, r)
+		} else {
+			blocks = append(blocks, r)
+		}
+	}
+	mode, _ := s.store.GetKV(kvInstanceAllowlistMode)
+	jsonResponse(w, map[string]interface{}{
+		"blocks":         blocks,
+		"allows":         allows,
+		"allowlist_mode": mode == "true",
+	}, http.StatusOK)
+}
+
+// instanceRuleRequest is the body of POST/DELETE /web/api/instance-blocks.
+type instanceRuleRequest struct {
+	Domain string `json:"domain"`
+	List   string `json:"list"` // "block" (default) | "allow"
+}
+
+// parse validates the request, returning the normalised domain and list.
+func (req *instanceRuleRequest) parse() (domain, list string, err error) {
+	list = req.List
+	if list == "" {
+		list = "block"
+	}
+	if list != "block" && list != "allow" {
+		return "", "", fmt.Errorf("list must be 'block' or 'allow'")
+	}
+	domain, err = normalizeInstanceDomain(req.Domain)
+	return domain, list, err
+}
+
+// handleAddInstanceBlock adds a domain to the block or allow list.
+//
+// POST /web/api/instance-blocks
+// Body: {"domain":"*.spam.example","list":"block"}
+func (s *Server) handleAddInstanceBlock(w http.ResponseWriter, r *http.Request) {
+	var req instanceRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, map[string]string{"error": "invalid JSON body"}, http.StatusBadRequest)
+		return
+	}
+	domain, list, err := req.parse()
+	if err != nil {
+		jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	added, err := s.store.AddInstanceRule(domain, list)
+	if err != nil {
+		slog.Error("add instance rule failed", "domain", domain, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if added {
+		s.loadInstanceFilter()
+		slog.Info("instance rule added via admin", "domain", domain, "list", list)
+		s.auditLog("instance_"+list+"_added", domain)
+	}
+	jsonResponse(w, map[string]interface{}{
+		"added":   added,
+		"domain":  domain,
+		"message": map[bool]string{true: domain + " added to " + list + " list", false: domain + " already on " + list + " list"}[added],
+	}, http.StatusOK)
+}
+
+// handleRemoveInstanceBlock removes a domain from the block or allow list.
+//
+// DELETE /web/api/instance-blocks
+// Body: {"domain":"*.spam.example","list":"block"}
+func (s *Server) handleRemoveInstanceBlock(w http.ResponseWriter, r *http.Request) {
+	var req instanceRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, map[string]string{"error": "invalid JSON body"}, http.StatusBadRequest)
+		return
+	}
+	domain, list, err := req.parse()
+	if err != nil {
+		jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	removed, err := s.store.RemoveInstanceRule(domain, list)
+	if err != nil {
+		slog.Error("remove instance rule failed", "domain", domain, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if removed {
+		s.loadInstanceFilter()
+		slog.Info("instance rule removed via admin", "domain", domain, "list", list)
+		s.auditLog("instance_"+list+"_removed", domain)
+	}
+	jsonResponse(w, map[string]interface{}{
+		"removed": removed,
+		"domain":  domain,
+		"message": map[bool]string{true: domain + " removed from " + list + " list", false: domain + " not on " + list + " list"}[removed],
+	}, http.StatusOK)
+}
+
+// handleSetInstanceAllowlistMode toggles strict allowlist mode. While enabled,
+// only instances on the allow list may deliver to the inbox.
+//
+// POST /web/api/instance-blocks/mode
+// Body: {"allowlist_mode":true}
+func (s *Server) handleSetInstanceAllowlistMode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AllowlistMode bool `json:"allowlist_mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, map[string]string{"error": "invalid JSON body"}, http.StatusBadRequest)
+		return
+	}
+	if err := s.store.SetKV(kvInstanceAllowlistMode, fmt.Sprint(req.AllowlistMode)); err != nil {
+		slog.Error("failed to persist allowlist mode", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.loadInstanceFilter()
+	s.auditLog("instance_allowlist_mode", fmt.Sprint(req.AllowlistMode))
+	jsonResponse(w, map[string]interface{}{"allowlist_mode": req.AllowlistMode}, http.StatusOK)
+}
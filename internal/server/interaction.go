@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/bridge"
+)
+
+// apActorTypes are the AP object types treated as followable actors.
+var apActorTypes = map[string]bool{
+	"Person":       true,
+	"Service":      true,
+	"Application":  true,
+	"Group":        true,
+	"Organization": true,
+}
+
+// handleAuthorizeInteraction implements the OStatus subscribe template that
+// handleWebFinger advertises. A remote server redirects here when the local
+// user asks to follow (or interact with) something from their home instance.
+// Actors get a confirmation page whose form POSTs back to this route; any
+// other object is redirected to its Nostr view (or its original URL when it
+// has not been bridged).
+//
+// GET /authorize_interaction?uri=<actor, object or user@domain>
+func (s *Server) handleAuthorizeInteraction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	target, err := resolveInteractionURI(ctx, r.URL.Query().Get("uri"))
+	if err != nil {
+		renderInteractionPage(w, http.StatusBadRequest, "Cannot resolve", html.EscapeString(err.Error()))
+		return
+	}
+
+	obj, err := ap.FetchObject(ctx, target)
+	if err != nil {
+		renderInteractionPage(w, http.StatusBadGateway, "Cannot fetch",
+			"Could not fetch <code>"+html.EscapeString(target)+"</code>: "+html.EscapeString(err.Error()))
+		return
+	}
+
+	objType, _ := obj["type"].(string)
+	if !apActorTypes[objType] {
+		// Objects: show the bridged Nostr event if we have one.
+		if nostrID, ok := s.store.GetNostrIDForObject(target); ok {
+			http.Redirect(w, r, strings.TrimRight(s.cfg.ExternalBaseURL, "/")+"/"+nostrID, http.StatusFound)
+			return
+		}
+		dest := target
+		if u, _ := obj["url"].(string); strings.HasPrefix(u, "http") {
+			dest = u
+		}
+		http.Redirect(w, r, dest, http.StatusFound)
+		return
+	}
+
+	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
+	name, _ := obj["preferredUsername"].(string)
+	handle := apURLToHandle(target)
+	if name != "" {
+		if host := bridge.ExtractHost(target); host != "" {
+			handle = "@" + name + "@" + host
+		}
+	}
+
+	following, _ := s.store.GetAPFollowing(localActorURL)
+	for _, f := range following {
+		if f == target {
+			renderInteractionPage(w, http.StatusOK, "Already following",
+				"You already follow <strong>"+html.EscapeString(handle)+"</strong>.")
+			return
+		}
+	}
+
+	body := fmt.Sprintf(`<p>Follow <strong>%s</strong> from <strong>%s</strong>?</p>
+<form method="post" action="/authorize_interaction">
+<input type="hidden" name="uri" value="%s">
+<input type="hidden" name="csrf" value="%s">
+<button type="submit">Follow</button>
+</form>`, html.EscapeString(handle), html.EscapeString(s.cfg.NostrUsername),
+		html.EscapeString(target), html.EscapeString(s.csrfToken))
+	renderInteractionPage(w, http.StatusOK, "Follow", body)
+}
+
+// handleAuthorizeInteractionConfirm performs the follow confirmed on the
+// page rendered by handleAuthorizeInteraction.
+//
+// POST /authorize_interaction (form: uri, csrf)
+func (s *Server) handleAuthorizeInteractionConfirm(w http.ResponseWriter, r *http.Request) {
+	// The page is a plain HTML form, so the CSRF token travels as a form
+	// field rather than the X-CSRF-Token header used by the dashboard.
+	if subtle.ConstantTimeCompare([]byte(r.PostFormValue("csrf")), []byte(s.csrfToken)) != 1 {
+		http.Error(w, "invalid CSRF token", http.StatusForbidden)
+		return
+	}
+	if s.followPublisher == nil {
+		renderInteractionPage(w, http.StatusServiceUnavailable, "Unavailable", "Follow publisher not configured.")
+		return
+	}
+
+	ctx := r.Context()
+	actorURL, err := resolveInteractionURI(ctx, r.PostFormValue("uri"))
+	if err != nil {
+		renderInteractionPage(w, http.StatusBadRequest, "Cannot resolve", html.EscapeString(err.Error()))
+		return
+	}
+
+	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
+	if err := s.followRemoteActor(ctx, actorURL, localActorURL); err != nil {
+		slog.Warn("authorize_interaction: follow failed", "actor", actorURL, "error", err)
+		renderInteractionPage(w, http.StatusBadGateway, "Follow failed", html.EscapeString(err.Error()))
+		return
+	}
+
+	handle := apURLToHandle(actorURL)
+	s.auditLog("follow_added", "bridge=fediverse handle="+handle+" via=authorize_interaction")
+	renderInteractionPage(w, http.StatusOK, "Following",
+		"Follow request sent to <strong>"+html.EscapeString(handle)+"</strong>.")
+}
+
+// followRemoteActor follows an AP actor on behalf of the local user: the
+// Follow activity is federated immediately and the actor's derived pubkey is
+// merged into the kind-3 contact list so Nostr and the DB stay consistent.
+// handleKind3 sees the follow already recorded and does not send it twice.
+func (s *Server) followRemoteActor(ctx context.Context, actorURL, localActorURL string) error {
+	pubkey, err := s.actorResolver.PublicKey(actorURL)
+	if err != nil {
+		return err
+	}
+	if err := s.actorKeyStore.StoreActorKey(pubkey, actorURL); err != nil {
+		slog.Warn("follow remote actor: failed to store actor key", "error", err)
+	}
+
+	if s.apHandler != nil && s.apHandler.Federator != nil {
+		if err := s.store.AddFollow(localActorURL, actorURL); err != nil {
+			slog.Warn("follow remote actor: failed to store follow", "actor", actorURL, "error", err)
+		}
+		go s.apHandler.Federator.Federate(context.Background(), ap.BuildFollow(localActorURL, actorURL))
+	}
+
+	if _, err := s.mergeAndPublishKind3(ctx, []string{pubkey}, nil, false); err != nil {
+		return err
+	}
+	slog.Info("follow remote actor: followed", "actor", actorURL)
+	return nil
+}
+
+// resolveInteractionURI normalises the uri parameter of an interaction
+// request to an AP object URL. Accepts http(s) URLs and user@domain handles,
+// optionally prefixed with "acct:" or "@".
+func resolveInteractionURI(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("missing uri parameter")
+	}
+	if strings.HasPrefix(raw, "https://") || strings.HasPrefix(raw, "http://") {
+		return raw, nil
+	}
+	handle := strings.TrimPrefix(strings.TrimPrefix(raw, "acct:"), "@")
+	if !strings.Contains(handle, "@") {
+		return "", fmt.Errorf("unsupported uri %q", raw)
+	}
+	return ap.WebFingerResolve(ctx, handle)
+}
+
+// renderInteractionPage writes a minimal standalone HTML page. body must
+// already be HTML-escaped where it contains untrusted text.
+func renderInteractionPage(w http.ResponseWriter, status int, title, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="en"><head><meta charset="utf-8"><meta name="viewport" content="width=device-width,initial-scale=1">
+<title>%s — klistr</title>
+<style>body{font-family:system-ui,sans-serif;background:#0f1117;color:#e2e8f0;max-width:32rem;margin:4rem auto;padding:0 1rem}
+h1{font-size:1.2rem}code{word-break:break-all}button{background:#3b82f6;color:#fff;border:0;border-radius:6px;padding:.5rem 1.2rem;font-size:1rem;cursor:pointer}
+a{color:#60a5fa}</style></head>
+<body><h1>%s</h1>%s<p><a href="/web/">Admin dashboard</a></p></body></html>`,
+		html.EscapeString(title), html.EscapeString(title), body)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/klppl/klistr/internal/ap"
+)
+
+// kvRetiredKeys holds the JSON list of ap.RetiredKey still advertised after a
+// key rotation. Written here, loaded into the KeyPair at startup by main.
+const kvRetiredKeys = "rsa_retired_keys"
+
+// rotateKeyUpdateTimeout bounds the background broadcast of actor Updates
+// after each rotation stage.
+const rotateKeyUpdateTimeout = 10 * time.Minute
+
+// handleRotateKeyStatus reports the state of the RSA key rotation.
+//
+// GET /web/api/rotate-key
+func (s *Server) handleRotateKeyStatus(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]interface{}{
+		"pending":      false,
+		"retired_keys": len(s.keyPair.Retired()),
+		"grace":        s.cfg.KeyRotationGrace.String(),
+	}
+	if preparedAt, ok := s.keyPair.Pending(); ok {
+		resp["pending"] = true
+		resp["prepared_at"] = preparedAt.Unix()
+	}
+	jsonResponse(w, resp, http.StatusOK)
+}
+
+// handleRotateKey runs one stage of a rotation of the instance RSA key, which
+// is shared by every local actor and the service actor:
+//
+//   - "prepare" generates the next key and advertises it in the actors'
+//     assertionMethod, while #main-key keeps signing;
+//   - "promote" makes the prepared key the #main-key; the old public key stays
+//     in assertionMethod for KEY_ROTATION_GRACE.
+//
+// After each stage an Update of each local actor is broadcast to its
+// followers so their servers refetch the keys instead of relying on a cached
+// copy.
+//
+// POST /web/api/rotate-key  {"stage":"prepare"|"promote"}
+func (s *Server) handleRotateKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Stage string `json:"stage"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, map[string]string{"error": "invalid JSON"}, http.StatusBadRequest)
+		return
+	}
+
+	var message string
+	switch req.Stage {
+	case "prepare":
+		err := s.keyPair.PrepareRotation(s.cfg.RSAPrivateKeyPath, s.cfg.RSAPublicKeyPath)
+		if errors.Is(err, ap.ErrRotationPending) {
+			jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.Error("key rotation: prepare failed", "error", err)
+			jsonResponse(w, map[string]string{"error": "prepare key: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		s.auditLog("rsa_key_prepared", "")
+		message = "New key is advertised next to #main-key. Promote it once followers' servers have refetched your actor (a day is plenty)."
+
+	case "promote":
+		retired, err := s.keyPair.PromoteNext(s.cfg.RSAPrivateKeyPath, s.cfg.RSAPublicKeyPath)
+		if errors.Is(err, ap.ErrNoPendingRotation) {
+			jsonResponse(w, map[string]string{"error": err.Error()}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.Error("key rotation: promote failed", "error", err)
+			jsonResponse(w, map[string]string{"error": "promote key: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		data, _ := json.Marshal(retired)
+		if err := s.store.SetKV(kvRetiredKeys, string(data)); err != nil {
+			slog.Warn("key rotation: failed to persist retired keys", "error", err)
+		}
+		s.auditLog("rsa_key_rotated", fmt.Sprintf("retired_keys=%d", len(retired)))
+		message = fmt.Sprintf("New key is now #main-key; the previous key stays advertised for %s.", s.cfg.KeyRotationGrace)
+
+	default:
+		jsonResponse(w, map[string]string{"error": `stage must be "prepare" or "promote"`}, http.StatusBadRequest)
+		return
+	}
+
+	if s.apHandler != nil && s.apHandler.Federator != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), rotateKeyUpdateTimeout)
+			defer cancel()
+			s.apHandler.Federator.Federate(ctx, ap.BuildUpdate(s.localActor(s.cfg.Primary())))
+			slog.Info("key rotation: broadcast actor update", "stage", req.Stage)
+		}()
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"message": message + " Actor updates are being sent to followers.",
+	}, http.StatusOK)
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	gonostr "github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/config"
+)
+
+// lnurlHTTPClient fetches LNURL-pay parameters and invoices from the local
+// user's Lightning address provider.
+var lnurlHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// lnurlPayParams is an LNURL-pay (LUD-06) payRequest response with the
+// NIP-57 extensions.
+type lnurlPayParams struct {
+	Tag            string `json:"tag"`
+	Callback       string `json:"callback"`
+	MinSendable    int64  `json:"minSendable"`
+	MaxSendable    int64  `json:"maxSendable"`
+	Metadata       string `json:"metadata"`
+	CommentAllowed int    `json:"commentAllowed,omitempty"`
+	AllowsNostr    bool   `json:"allowsNostr,omitempty"`
+	NostrPubkey    string `json:"nostrPubkey,omitempty"`
+}
+
+// zapSignerTTL is how long the receipt signer of a Lightning address is cached.
+const zapSignerTTL = time.Hour
+
+// zapSigners caches the nostrPubkey of each Lightning address provider,
+// keyed by address.
+var zapSigners sync.Map // address → cachedZapSigner
+
+type cachedZapSigner struct {
+	pubkey  string
+	expires time.Time
+}
+
+// ZapReceiptSigner returns a lookup of the nostrPubkey advertised by a local
+// user's Lightning address provider: the key that signs the NIP-57 receipts of
+// zaps to that user, and so the only one whose receipts are genuine. Users
+// without a Lightning address have none.
+func ZapReceiptSigner(cfg *config.Config) func(ctx context.Context, pubkey string) (string, error) {
+	return func(ctx context.Context, pubkey string) (string, error) {
+		var addr string
+		if user := cfg.Primary(); user.PublicKey == pubkey {
+			addr = user.LightningAddress
+		}
+		if addr == "" {
+			return "", fmt.Errorf("no lightning address configured")
+		}
+		if c, ok := zapSigners.Load(addr); ok && time.Now().Before(c.(cachedZapSigner).expires) {
+			return c.(cachedZapSigner).pubkey, nil
+		}
+		params, err := fetchLNURLPayParams(ctx, addr)
+		if err != nil {
+			return "", err
+		}
+		if !params.AllowsNostr || !gonostr.IsValidPublicKey(params.NostrPubkey) {
+			return "", fmt.Errorf("%s does not support zaps", addr)
+		}
+		zapSigners.Store(addr, cachedZapSigner{pubkey: params.NostrPubkey, expires: time.Now().Add(zapSignerTTL)})
+		return params.NostrPubkey, nil
+	}
+}
+
+// lnurlError writes an LNURL error response ({"status":"ERROR","reason":…}).
+func lnurlError(w http.ResponseWriter, reason string, status int) {
+	jsonResponse(w, map[string]string{"status": "ERROR", "reason": reason}, status)
+}
+
+// lightningAddressURL converts a Lightning address (name@host, LUD-16) to its
+// LNURL-pay endpoint.
+func lightningAddressURL(addr string) (string, error) {
+	name, host, ok := strings.Cut(strings.TrimSpace(addr), "@")
+	if !ok || name == "" || host == "" || strings.ContainsAny(host, "/@ ") {
+		return "", fmt.Errorf("invalid lightning address %q", addr)
+	}
+	return "https://" + host + "/.well-known/lnurlp/" + url.PathEscape(name), nil
+}
+
+// fetchLNURLPayParams fetches the payRequest parameters for a Lightning address.
+func fetchLNURLPayParams(ctx context.Context, addr string) (*lnurlPayParams, error) {
+	endpoint, err := lightningAddressURL(addr)
+	if err != nil {
+		return nil, err
+	}
+	var params lnurlPayParams
+	if err := lnurlGet(ctx, endpoint, &params); err != nil {
+		return nil, err
+	}
+	if params.Tag != "payRequest" || params.Callback == "" {
+		return nil, fmt.Errorf("%s is not an LNURL-pay endpoint", endpoint)
+	}
+	return &params, nil
+}
+
+// lnurlGet performs a GET against an LNURL service and decodes the JSON body
+// into out. LNURL-level errors ({"status":"ERROR"}) are returned as errors.
+func lnurlGet(ctx context.Context, rawURL string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := lnurlHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return err
+	}
+	var status struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(body, &status) == nil && strings.EqualFold(status.Status, "ERROR") {
+		return fmt.Errorf("lnurl error: %s", status.Reason)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL.Host)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// handleLNURLP serves the LNURL-pay endpoint for a local user, so the
+// Lightning address username@LOCAL_DOMAIN resolves through the bridge. The
+// user's configured Lightning address supplies the limits, metadata and
+// NIP-57 receipt signer; the callback is replaced with our own.
+//
+// GET /.well-known/lnurlp/{username}
+func (s *Server) handleLNURLP(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.cfg.LocalUser(chi.URLParam(r, "username"))
+	if !ok || user.LightningAddress == "" {
+		lnurlError(w, "user not found", http.StatusNotFound)
+		return
+	}
+	params, err := fetchLNURLPayParams(r.Context(), user.LightningAddress)
+	if err != nil {
+		slog.Warn("lnurlp: upstream lookup failed", "user", user.Username, "error", err)
+		lnurlError(w, "lightning address unavailable", http.StatusBadGateway)
+		return
+	}
+	params.Callback = s.cfg.BaseURL("/lnurlp/" + url.PathEscape(user.Username) + "/callback")
+	jsonResponse(w, params, http.StatusOK)
+}
+
+// handleLNURLCallback requests an invoice from the local user's Lightning
+// address provider. A zap request supplied in the nostr parameter is checked
+// against NIP-57 and forwarded; when the payer has none (a Fediverse or
+// Bluesky follower paying from a plain wallet) and the provider supports zaps,
+// an anonymous zap request is generated so the payment still shows up on
+// Nostr as a zap.
+//
+// GET /lnurlp/{username}/callback?amount=<msats>[&nostr=<zap request>][&comment=<text>]
+func (s *Server) handleLNURLCallback(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.cfg.LocalUser(chi.URLParam(r, "username"))
+	if !ok || user.LightningAddress == "" {
+		lnurlError(w, "user not found", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	amount, err := strconv.ParseInt(q.Get("amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		lnurlError(w, "invalid amount", http.StatusBadRequest)
+		return
+	}
+
+	params, err := fetchLNURLPayParams(r.Context(), user.LightningAddress)
+	if err != nil {
+		slog.Warn("lnurlp: upstream lookup failed", "user", user.Username, "error", err)
+		lnurlError(w, "lightning address unavailable", http.StatusBadGateway)
+		return
+	}
+	if amount < params.MinSendable || (params.MaxSendable > 0 && amount > params.MaxSendable) {
+		lnurlError(w, fmt.Sprintf("amount must be between %d and %d msats", params.MinSendable, params.MaxSendable), http.StatusBadRequest)
+		return
+	}
+
+	comment := q.Get("comment")
+	zapRequest := q.Get("nostr")
+	if zapRequest != "" {
+		if err := validateZapRequest(zapRequest, user.PublicKey, amount); err != nil {
+			lnurlError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if params.AllowsNostr {
+		if zapRequest, err = s.anonZapRequest(user.PublicKey, amount, comment); err != nil {
+			slog.Warn("lnurlp: failed to build zap request", "user", user.Username, "error", err)
+			zapRequest = ""
+		}
+	}
+
+	upstream, err := url.Parse(params.Callback)
+	if err != nil {
+		lnurlError(w, "lightning address unavailable", http.StatusBadGateway)
+		return
+	}
+	uq := upstream.Query()
+	uq.Set("amount", strconv.FormatInt(amount, 10))
+	if zapRequest != "" && params.AllowsNostr {
+		uq.Set("nostr", zapRequest)
+	}
+	if comment != "" && params.CommentAllowed > 0 {
+		// LUD-12 comment, trimmed to what the provider accepts. The limit
+		// is in characters, so cut on a rune boundary.
+		if runes := []rune(comment); len(runes) > params.CommentAllowed {
+			comment = string(runes[:params.CommentAllowed])
+		}
+		uq.Set("comment", comment)
+	}
+	upstream.RawQuery = uq.Encode()
+
+	var invoice map[string]interface{}
+	if err := lnurlGet(r.Context(), upstream.String(), &invoice); err != nil {
+		slog.Warn("lnurlp: invoice request failed", "user", user.Username, "error", err)
+		lnurlError(w, "failed to create invoice", http.StatusBadGateway)
+		return
+	}
+	slog.Info("lnurlp: invoice issued", "user", user.Username, "msats", amount, "zap", zapRequest != "")
+	jsonResponse(w, invoice, http.StatusOK)
+}
+
+// validateZapRequest checks a kind-9734 zap request per NIP-57 appendix D:
+// valid signature, exactly one p tag naming the recipient, and a matching
+// amount tag when present.
+func validateZapRequest(raw, recipient string, amount int64) error {
+	var ev gonostr.Event
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		return fmt.Errorf("invalid zap request")
+	}
+	if ev.Kind != 9734 {
+		return fmt.Errorf("zap request must be kind 9734")
+	}
+	if ok, err := ev.CheckSignature(); err != nil || !ok {
+		return fmt.Errorf("invalid zap request signature")
+	}
+	pTags := ev.Tags.GetAll([]string{"p"})
+	if len(pTags) != 1 || pTags[0].Value() != recipient {
+		return fmt.Errorf("zap request must tag the recipient once")
+	}
+	if t := ev.Tags.GetFirst([]string{"amount"}); t != nil && t.Value() != strconv.FormatInt(amount, 10) {
+		return fmt.Errorf("zap request amount does not match")
+	}
+	return nil
+}
+
+// anonZapRequest builds an anonymous (NIP-57 "anon" tag, ephemeral key) zap
+// request for a payer without a Nostr identity. ZAP_PUBKEY / ZAP_SPLIT are not
+// applied: the provider issues one invoice paying the whole amount to the
+// Lightning address, so there is nothing to split.
+func (s *Server) anonZapRequest(recipient string, amount int64, comment string) (string, error) {
+	relays := gonostr.Tag{"relays"}
+	relays = append(relays, s.cfg.NostrRelays...)
+	ev := gonostr.Event{
+		Kind:      9734,
+		Content:   comment,
+		CreatedAt: gonostr.Now(),
+		Tags: gonostr.Tags{
+			{"p", recipient},
+			{"amount", strconv.FormatInt(amount, 10)},
+			relays,
+			{"anon"},
+		},
+	}
+	if err := ev.Sign(gonostr.GeneratePrivateKey()); err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(ev)
+	return string(raw), err
+}
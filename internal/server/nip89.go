@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	gonostr "github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+
+	"github.com/klppl/klistr/internal/ap"
+)
+
+// handleNostrRedirect is the NIP-89 web handler advertised in klistr's
+// kind-31990 (nostr.PublishHandlerInfo): it sends a NIP-19 entity to its
+// source — a bridged event to the original Fediverse post or bsky.app page, a
+// bridged Fediverse author to their profile. Anything else, including the
+// user's own notes, goes to EXTERNAL_BASE_URL.
+//
+// GET /nostr/{entity}
+func (s *Server) handleNostrRedirect(w http.ResponseWriter, r *http.Request) {
+	entity := strings.TrimPrefix(chi.URLParam(r, "entity"), "nostr:")
+	prefix, value, err := nip19.Decode(entity)
+	if err != nil {
+		http.Error(w, "invalid NIP-19 entity", http.StatusBadRequest)
+		return
+	}
+
+	var source string
+	switch prefix {
+	case "note":
+		id, _ := value.(string)
+		source = s.eventSource(id)
+	case "nevent":
+		if ep, ok := value.(gonostr.EventPointer); ok && ep.Author != s.cfg.NostrPublicKey {
+			source = s.eventSource(ep.ID)
+		}
+	case "npub":
+		pubkey, _ := value.(string)
+		source = s.profileSource(pubkey)
+	case "nprofile":
+		if pp, ok := value.(gonostr.ProfilePointer); ok {
+			source = s.profileSource(pp.PublicKey)
+		}
+	}
+	if source == "" {
+		source = strings.TrimRight(s.cfg.ExternalBaseURL, "/") + "/" + entity
+	}
+	http.Redirect(w, r, source, http.StatusFound)
+}
+
+// eventSource returns the web URL of the post a bridged event was made from,
+// or "" when the event was not bridged from the Fediverse or Bluesky.
+func (s *Server) eventSource(eventID string) string {
+	apID, ok := s.store.GetAPIDForObject(eventID)
+	if !ok {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(apID, "at://"); ok {
+		parts := strings.SplitN(rest, "/", 3)
+		if len(parts) == 3 && parts[1] == "app.bsky.feed.post" {
+			return "https://bsky.app/profile/" + parts[0] + "/post/" + parts[2]
+		}
+		return ""
+	}
+	if !strings.HasPrefix(apID, "https://") || ap.IsLocalID(apID, s.cfg.LocalDomain) {
+		return ""
+	}
+	return apID
+}
+
+// profileSource returns the Fediverse actor URL a derived pubkey belongs to,
+// or "".
+func (s *Server) profileSource(pubkey string) string {
+	if s.actorKeyStore == nil || pubkey == s.cfg.NostrPublicKey {
+		return ""
+	}
+	actorURL, _ := s.actorKeyStore.GetActorForKey(pubkey)
+	return actorURL
+}
@@ -0,0 +1,301 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	gonostr "github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/nostr"
+)
+
+const (
+	// outboxFetchTimeout bounds the relay query behind one outbox page or
+	// object lookup.
+	outboxFetchTimeout = 8 * time.Second
+	// outboxCacheTTL is how long a rendered outbox page is reused, so a burst
+	// of crawlers backfilling a new follow costs one relay query.
+	outboxCacheTTL = time.Minute
+	// maxOutboxCachePages caps the number of cached pages.
+	maxOutboxCachePages = 32
+	// objectCacheTTL is how long an /objects/{id} relay lookup is reused,
+	// found or not, so repeated GETs of one ID cost one relay query.
+	objectCacheTTL = 5 * time.Minute
+	// maxObjectCacheEntries caps the number of cached object lookups.
+	maxObjectCacheEntries = 1024
+	// maxObjectLookups caps the concurrent /objects/{id} and outbox page
+	// relay lookups; requests beyond it are answered without one.
+	maxObjectLookups = 4
+)
+
+// outboxKinds are the event kinds nostr.Handler federates as posts.
+var outboxKinds = []int{1, 6, 1063, 1068, 30023}
+
+// SetTransmuteContext attaches the local user's transmute context, used to
+// render outbox pages and /objects/{id} from the events on the relays. Nil
+// leaves both serving bare references.
+func (s *Server) SetTransmuteContext(tc *ap.TransmuteContext) { s.tc = tc }
+
+// outboxPage is one cached rendering of the outbox.
+type outboxPage struct {
+	items []interface{}
+	next  int64 // until value of the next page, 0 when this is the last
+}
+
+// renderCache holds values rendered from the relays for a fixed TTL, bounded
+// to max entries. When full, expired entries are dropped first, then the one
+// closest to expiry.
+type renderCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[K]renderCacheEntry[V]
+}
+
+type renderCacheEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+func (c *renderCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *renderCache[K, V]) put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[K]renderCacheEntry[V])
+	}
+	now := time.Now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.max {
+		var oldest K
+		var oldestExpiry time.Time
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			} else if oldestExpiry.IsZero() || e.expires.Before(oldestExpiry) {
+				oldest, oldestExpiry = k, e.expires
+			}
+		}
+		if len(c.entries) >= c.max {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[key] = renderCacheEntry[V]{value: value, expires: now.Add(c.ttl)}
+}
+
+// newOutboxCache returns the cache of rendered outbox pages, keyed by their
+// until parameter (0 for the first page).
+func newOutboxCache() *renderCache[int64, outboxPage] {
+	return &renderCache[int64, outboxPage]{ttl: outboxCacheTTL, max: maxOutboxCachePages}
+}
+
+// objectLookup is the cached result of an /objects/{id} lookup: the rendered
+// note, nil for a miss, or hidden for a followers-only note.
+type objectLookup struct {
+	note   *ap.Note
+	hidden bool
+}
+
+// newObjectCache returns the cache of /objects/{id} lookups, keyed by event
+// ID.
+func newObjectCache() *renderCache[string, objectLookup] {
+	return &renderCache[string, objectLookup]{ttl: objectCacheTTL, max: maxObjectCacheEntries}
+}
+
+var (
+	readPoolOnce sync.Once
+	readPool     *gonostr.SimplePool
+)
+
+// sharedReadPool returns the pool relay queries go through. It is shared so
+// that public endpoints reuse relay connections instead of dialing every
+// relay per request.
+func sharedReadPool() *gonostr.SimplePool {
+	readPoolOnce.Do(func() {
+		readPool = gonostr.NewSimplePool(context.Background())
+	})
+	return readPool
+}
+
+// fetchLocalEvents queries the relays for the local user's events matching
+// filter and returns them newest first (see fetchAuthorEvents).
+func (s *Server) fetchLocalEvents(ctx context.Context, filter gonostr.Filter) []*gonostr.Event {
+	return fetchAuthorEvents(ctx, s.cfg.NostrRelays, s.cfg.NostrPublicKey, filter)
+}
+
+// fetchAuthorEvents queries relays for pubkey's events matching filter and
+// returns them newest first, deduplicated. Drafts and events the bridge
+// itself published on someone's behalf (proxy-tagged) are dropped.
+func fetchAuthorEvents(parentCtx context.Context, relays []string, pubkey string, filter gonostr.Filter) []*gonostr.Event {
+	ctx, cancel := context.WithTimeout(parentCtx, outboxFetchTimeout)
+	defer cancel()
+
+	filter.Authors = []string{pubkey}
+	seen := make(map[string]bool)
+	var events []*gonostr.Event
+	for ev := range sharedReadPool().SubManyEose(ctx, relays, gonostr.Filters{filter}) {
+		if ev.Event == nil || seen[ev.Event.ID] || ev.Event.PubKey != pubkey {
+			continue
+		}
+		seen[ev.Event.ID] = true
+		if nostr.IsDraft(ev.Event) || ap.IsProxyEvent(ev.Event) {
+			continue
+		}
+		if ok, err := ev.Event.CheckSignature(); err != nil || !ok {
+			continue
+		}
+		events = append(events, ev.Event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt > events[j].CreatedAt })
+	return events
+}
+
+// localNote renders a post event as the AP object nostr.Handler federated
+// for it. Returns nil for reposts and unsupported kinds.
+func localNote(event *gonostr.Event, tc *ap.TransmuteContext) *ap.Note {
+	switch event.Kind {
+	case 1:
+		if ap.IsRepost(event) {
+			return nil
+		}
+		return ap.ToNote(event, tc)
+	case 1063:
+		return ap.ToFileNote(event, tc)
+	case 1068:
+		return ap.ToQuestion(event, tc)
+	case 30023:
+		return ap.ToArticle(event, tc)
+	}
+	return nil
+}
+
+// fetchLocalNote fetches the local user's event id from the relays and
+// renders it as an AP object. The note is nil when it cannot be found or is
+// not a post; hidden is set, with a nil note, for followers-only notes:
+// /objects/{id} is served to anyone, so only public and unlisted notes may
+// be. Lookups are cached for objectCacheTTL, misses included, and at most
+// maxObjectLookups run at once; beyond that nil is returned without querying
+// the relays.
+func (s *Server) fetchLocalNote(ctx context.Context, id string) (note *ap.Note, hidden bool) {
+	if s.tc == nil || !gonostr.IsValid32ByteHex(id) {
+		return nil, false
+	}
+	if cached, ok := s.objectCache.get(id); ok {
+		return cached.note, cached.hidden
+	}
+	select {
+	case s.objectLookups <- struct{}{}:
+		defer func() { <-s.objectLookups }()
+	default:
+		slog.Debug("objects: too many relay lookups in flight, serving stub", "id", id)
+		return nil, false
+	}
+
+	var lookup objectLookup
+	events := s.fetchLocalEvents(ctx, gonostr.Filter{IDs: []string{id}, Kinds: outboxKinds})
+	if len(events) > 0 {
+		if n := localNote(events[0], s.tc); n != nil && ap.IsFollowersOnly(n) {
+			lookup.hidden = true
+		} else if n != nil {
+			n.Context = ap.DefaultContext
+			lookup.note = n
+		}
+	}
+	if ctx.Err() == nil {
+		s.objectCache.put(id, lookup)
+	}
+	return lookup.note, lookup.hidden
+}
+
+// localActivity renders a post event as an outbox item: a Create embedding
+// the object, or an Announce for reposts and quote-only notes. Followers-only
+// notes are left out, since the outbox is public.
+func (s *Server) localActivity(event *gonostr.Event) map[string]interface{} {
+	if note := localNote(event, s.tc); note != nil {
+		if ap.IsFollowersOnly(note) {
+			return nil
+		}
+		note.Context = nil
+		activity := ap.BuildCreate(note, s.tc.LocalDomain)
+		delete(activity, "@context")
+		return activity
+	}
+	if event.Kind == 1 || event.Kind == 6 {
+		if announce := ap.ToAnnounce(event, s.tc); announce != nil {
+			activity := ap.ActivityToMap(announce)
+			delete(activity, "@context")
+			return activity
+		}
+	}
+	return nil
+}
+
+// renderOutboxPage returns the outbox items older than until (0 for the
+// newest), rendered from the relays and cached for outboxCacheTTL. until
+// comes from the client, so every value is a potential cache miss: a future
+// until is clamped to 0, and relay fetches share the maxObjectLookups slots
+// with /objects/{id}. ok is false, without querying the relays, when all
+// slots are taken.
+func (s *Server) renderOutboxPage(ctx context.Context, until int64) (page outboxPage, ok bool) {
+	if until < 0 || until > time.Now().Unix() {
+		until = 0
+	}
+	if page, ok := s.outboxCache.get(until); ok {
+		return page, true
+	}
+	select {
+	case s.objectLookups <- struct{}{}:
+		defer func() { <-s.objectLookups }()
+	default:
+		slog.Debug("outbox: too many relay lookups in flight", "until", until)
+		return outboxPage{}, false
+	}
+
+	filter := gonostr.Filter{Kinds: outboxKinds, Limit: outboxPageSize}
+	if until > 0 {
+		ts := gonostr.Timestamp(until)
+		filter.Until = &ts
+	}
+	events := s.fetchLocalEvents(ctx, filter)
+	if len(events) > outboxPageSize {
+		events = events[:outboxPageSize]
+	}
+
+	page = outboxPage{items: make([]interface{}, 0, len(events))}
+	for _, event := range events {
+		if activity := s.localActivity(event); activity != nil {
+			page.items = append(page.items, activity)
+		}
+	}
+	if len(events) == outboxPageSize {
+		page.next = int64(events[len(events)-1].CreatedAt) - 1
+	}
+	if ctx.Err() == nil {
+		s.outboxCache.put(until, page)
+	} else {
+		slog.Debug("outbox: relay fetch cut short, not caching page", "until", until)
+	}
+	return page, true
+}
+
+// outboxPageURL returns the URL of the outbox page ending at until.
+func outboxPageURL(outboxURL string, until int64) string {
+	if until <= 0 {
+		return outboxURL + "?page=true"
+	}
+	return fmt.Sprintf("%s?page=true&until=%d", outboxURL, until)
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/klppl/klistr/internal/db"
+)
+
+// handleGetPendingFollows lists inbound follows held by the follow spam
+// filter (FOLLOW_FILTER_ACTION=hold), newest first.
+//
+// GET /web/api/pending-follows
+func (s *Server) handleGetPendingFollows(w http.ResponseWriter, r *http.Request) {
+	pending, err := s.store.GetPendingFollows()
+	if err != nil {
+		http.Error(w, "failed to read pending follows", http.StatusInternalServerError)
+		return
+	}
+	if pending == nil {
+		pending = []db.PendingFollow{}
+	}
+	jsonResponse(w, pending, http.StatusOK)
+}
+
+// handleApprovePendingFollow accepts a held follow.
+//
+// POST /web/api/pending-follows/approve
+// Body: {"actor":"https://mastodon.social/users/alice","followed":"https://bridge.example/users/me"}
+func (s *Server) handleApprovePendingFollow(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.takePendingFollow(w, r)
+	if !ok {
+		return
+	}
+	s.apHandler.ApproveFollow(f.ActivityID, f.ActorID, f.FollowedID)
+	s.auditLog("approve_follow", f.ActorID)
+	jsonResponse(w, map[string]string{"message": "Follow approved."}, http.StatusOK)
+}
+
+// handleRejectPendingFollow rejects a held follow.
+//
+// POST /web/api/pending-follows/reject
+// Body: {"actor":"https://mastodon.social/users/alice","followed":"https://bridge.example/users/me"}
+func (s *Server) handleRejectPendingFollow(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.takePendingFollow(w, r)
+	if !ok {
+		return
+	}
+	s.apHandler.RejectFollow(f.ActivityID, f.ActorID, f.FollowedID)
+	s.auditLog("reject_follow", f.ActorID)
+	jsonResponse(w, map[string]string{"message": "Follow rejected."}, http.StatusOK)
+}
+
+// takePendingFollow decodes the request body, then loads and deletes the
+// pending follow it names. Writes the error response and returns false when
+// the body is invalid or the follow is not pending.
+func (s *Server) takePendingFollow(w http.ResponseWriter, r *http.Request) (db.PendingFollow, bool) {
+	var req struct {
+		Actor    string `json:"actor"`
+		Followed string `json:"followed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Actor == "" || req.Followed == "" {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return db.PendingFollow{}, false
+	}
+	f, ok := s.store.GetPendingFollow(req.Actor, req.Followed)
+	if !ok {
+		jsonResponse(w, map[string]string{"error": "pending follow not found"}, http.StatusNotFound)
+		return db.PendingFollow{}, false
+	}
+	if err := s.store.DeletePendingFollow(f.ActorID, f.FollowedID); err != nil {
+		slog.Warn("failed to delete pending follow", "actor", f.ActorID, "error", err)
+	}
+	return f, true
+}
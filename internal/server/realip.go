@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// realIPMiddleware sets r.RemoteAddr to the client address. X-Forwarded-For
+// and X-Real-IP are only believed on connections from one of trusted — the
+// reverse proxies in TRUSTED_PROXIES — so a client cannot spoof its origin to
+// get past the inbox rate limiters or into the logs under another address.
+// Other requests keep their socket address.
+func realIPMiddleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := forwardedIP(r, trusted); ip.IsValid() {
+				r.RemoteAddr = ip.String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP returns the client address r was forwarded for, or the zero
+// Addr when r does not come from a trusted proxy or carries no usable header.
+// X-Forwarded-For is walked from the right, skipping trusted hops, so entries
+// a client prepended itself are never picked.
+func forwardedIP(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	peer, ok := remoteIP(r.RemoteAddr)
+	if !ok || !isTrustedProxy(peer, trusted) {
+		return netip.Addr{}
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return netip.Addr{}
+			}
+			ip = ip.Unmap()
+			if i == 0 || !isTrustedProxy(ip, trusted) {
+				return ip
+			}
+		}
+	}
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap()
+	}
+	return netip.Addr{}
+}
+
+// remoteIP parses the IP of a host:port (or bare host) remote address.
+func remoteIP(remoteAddr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+func isTrustedProxy(ip netip.Addr, trusted []netip.Prefix) bool {
+	return slices.ContainsFunc(trusted, func(p netip.Prefix) bool { return p.Contains(ip) })
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestForwardedIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("::1/128"),
+	}
+	tests := []struct {
+		name   string
+		peer   string
+		xff    []string // X-Forwarded-For header values
+		realIP string
+		want   string // "" for the zero Addr
+	}{
+		{"no headers", "10.0.0.1:4000", nil, "", ""},
+		{"untrusted peer", "203.0.113.9:4000", []string{"198.51.100.7"}, "198.51.100.7", ""},
+		{"single hop", "10.0.0.1:4000", []string{"198.51.100.7"}, "", "198.51.100.7"},
+		{"spoofed leftmost hop", "10.0.0.1:4000", []string{"1.2.3.4, 198.51.100.7"}, "", "198.51.100.7"},
+		{"chain of trusted proxies", "10.0.0.1:4000", []string{"198.51.100.7, 10.0.0.3, 10.0.0.2"}, "", "198.51.100.7"},
+		{"spoofed hop behind trusted chain", "10.0.0.1:4000", []string{"1.2.3.4, 198.51.100.7, 10.0.0.2"}, "", "198.51.100.7"},
+		{"split header values", "10.0.0.1:4000", []string{"1.2.3.4", "198.51.100.7"}, "", "198.51.100.7"},
+		{"all hops trusted", "10.0.0.1:4000", []string{"10.0.0.3, 10.0.0.2"}, "", "10.0.0.3"},
+		{"IPv4-mapped IPv6 hop", "10.0.0.1:4000", []string{"::ffff:198.51.100.7"}, "", "198.51.100.7"},
+		{"IPv6 trusted peer", "[::1]:4000", []string{"2001:db8::1"}, "", "2001:db8::1"},
+		{"malformed hop", "10.0.0.1:4000", []string{"1.2.3.4, not-an-ip"}, "", ""},
+		{"malformed hop with port", "10.0.0.1:4000", []string{"198.51.100.7:1234"}, "", ""},
+		{"empty hop", "10.0.0.1:4000", []string{"198.51.100.7, "}, "", ""},
+		{"malformed hop left of client", "10.0.0.1:4000", []string{"garbage, 198.51.100.7"}, "", "198.51.100.7"},
+		{"X-Real-IP fallback", "10.0.0.1:4000", nil, "198.51.100.7", "198.51.100.7"},
+		{"malformed X-Real-IP", "10.0.0.1:4000", nil, "nope", ""},
+		{"unparsable peer", "unix-socket", []string{"198.51.100.7"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.peer
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			got := forwardedIP(r, trusted)
+			if tt.want == "" {
+				if got.IsValid() {
+					t.Errorf("forwardedIP = %s, want none", got)
+				}
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("forwardedIP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
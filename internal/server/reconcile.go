@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	gonostr "github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/ap"
+)
+
+const (
+	// maxReconcileActions caps the Follow/Undo activities one reconciliation
+	// sends; anything left over is picked up by the next run.
+	maxReconcileActions = 50
+	// reconcileTimeout bounds a single reconciliation run.
+	reconcileTimeout = 5 * time.Minute
+
+	// kvKind3PublishedID records the ID of the last kind-3 the bridge itself
+	// published (mergeAndPublishKind3).
+	kvKind3PublishedID = "kind3_published_id"
+	// kvKind3ReconciledID records the ID of the last kind-3 fully applied by
+	// the reconciler.
+	kvKind3ReconciledID = "kind3_reconciled_id"
+	// kvKind3FollowCount records the size of the newest kind-3 seen or
+	// published, the reference for the shrink guard when no relay answers.
+	kvKind3FollowCount = "kind3_follow_count"
+)
+
+// RunFollowReconciler periodically reconciles the kind-3 contact list with the
+// bridged follows in the database (see reconcileFollows). Blocks until ctx is
+// cancelled. Like AccountResyncer, the first run is after one interval.
+func (s *Server) RunFollowReconciler(ctx context.Context, interval time.Duration) {
+	slog.Info("follow reconciler started", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("follow reconciler stopped")
+			return
+		case <-ticker.C:
+			s.startReconcile(ctx)
+		}
+	}
+}
+
+// startReconcile runs one reconciliation under the "reconcile-follows" job,
+// so periodic and manual runs never overlap. Returns false when one is
+// already running.
+func (s *Server) startReconcile(parent context.Context) bool {
+	job, ok := s.startJob("reconcile-follows", 0)
+	if !ok {
+		slog.Debug("reconcile: already in progress, skipping")
+		return false
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(parent, reconcileTimeout)
+		defer cancel()
+		result, err := s.reconcileFollows(ctx)
+		if err != nil {
+			slog.Warn("reconcile: failed", "error", err)
+			result = "failed: " + err.Error()
+		} else {
+			slog.Info("reconcile: complete", "result", result)
+		}
+		_ = s.store.SetKV("last_reconcile_at", time.Now().UTC().Format(time.RFC3339))
+		_ = s.store.SetKV("last_reconcile_result", result)
+		job.finish(result)
+	}()
+	return true
+}
+
+// reconcileFollows repairs drift between the latest kind-3 on the relays and
+// the AP and Bluesky follows in the database. Which side wins depends on who
+// published that kind-3:
+//
+//   - A kind-3 from the user's own Nostr client that has not been reconciled
+//     yet is authoritative, as in nostr.Handler.handleKind3: AP actors it adds
+//     get a Follow, AP actors it drops get an Undo.
+//   - Otherwise (the bridge published it, or it was already applied) the
+//     database is authoritative, and bridged follows missing from the kind-3
+//     are restored by republishing it.
+//
+// The first run only adopts the current kind-3 and never sends Undos, so
+// enabling the reconciler cannot unfollow anyone on its own.
+func (s *Server) reconcileFollows(ctx context.Context) (string, error) {
+	if s.followPublisher == nil {
+		return "", fmt.Errorf("follow publisher not configured")
+	}
+	latest := s.fetchLatestKind3(ctx)
+	if latest == nil {
+		return "skipped: no kind-3 found on relays", nil
+	}
+	kind3 := kind3Pubkeys(latest)
+
+	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
+	apFollows, err := s.store.GetAPFollowing(localActorURL)
+	if err != nil {
+		return "", fmt.Errorf("load AP follows: %w", err)
+	}
+	bskyFollows, err := s.store.GetBskyFollowing(localActorURL)
+	if err != nil {
+		return "", fmt.Errorf("load Bluesky follows: %w", err)
+	}
+
+	publishedID, _ := s.store.GetKV(kvKind3PublishedID)
+	reconciledID, _ := s.store.GetKV(kvKind3ReconciledID)
+	if latest.ID != publishedID && latest.ID != reconciledID && reconciledID != "" {
+		return s.applyKind3(ctx, latest, kind3, localActorURL, apFollows)
+	}
+
+	// Database is authoritative: find bridged follows the kind-3 lacks.
+	missing := 0
+	for _, apURL := range apFollows {
+		if pk, err := s.actorResolver.PublicKey(apURL); err == nil {
+			if _, ok := kind3[pk]; !ok {
+				missing++
+			}
+		}
+	}
+	for _, id := range bskyFollows {
+		if pk, err := s.actorResolver.PublicKey(strings.TrimPrefix(id, "bsky:")); err == nil {
+			if _, ok := kind3[pk]; !ok {
+				missing++
+			}
+		}
+	}
+	if missing == 0 {
+		_ = s.store.SetKV(kvKind3ReconciledID, latest.ID)
+		return "in sync", nil
+	}
+	merge, err := s.mergeAndPublishKind3(ctx, nil, nil, false)
+	if err != nil {
+		return "", fmt.Errorf("republish kind-3: %w", err)
+	}
+	s.auditLog("follows_reconciled", fmt.Sprintf("republished kind-3 missing=%d total=%d", missing, merge.After))
+	return fmt.Sprintf("republished kind-3 with %d missing follow(s)", missing), nil
+}
+
+// applyKind3 brings the AP follows in the database in line with a
+// user-published kind-3, sending at most maxReconcileActions activities.
+// The kind-3 is only marked reconciled once every difference is handled.
+func (s *Server) applyKind3(ctx context.Context, latest *gonostr.Event, kind3 map[string]struct{}, localActorURL string, apFollows []string) (string, error) {
+	if s.apHandler == nil || s.apHandler.Federator == nil {
+		return "", fmt.Errorf("federator not configured")
+	}
+	current := make(map[string]struct{}, len(apFollows))
+	for _, apURL := range apFollows {
+		current[apURL] = struct{}{}
+	}
+	wanted := make(map[string]struct{})
+	for pk := range kind3 {
+		if apURL, ok := s.store.GetActorForKey(pk); ok && strings.HasPrefix(apURL, "http") {
+			wanted[apURL] = struct{}{}
+		}
+	}
+
+	followed, undone, pending := 0, 0, 0
+	for apURL := range wanted {
+		if _, ok := current[apURL]; ok {
+			continue
+		}
+		if followed+undone >= maxReconcileActions {
+			pending++
+			continue
+		}
+		s.apHandler.Federator.Federate(ctx, ap.BuildFollow(localActorURL, apURL))
+		if err := s.store.AddFollow(localActorURL, apURL); err != nil {
+			slog.Warn("reconcile: failed to store follow", "actor", apURL, "error", err)
+		}
+		followed++
+	}
+	for apURL := range current {
+		if _, ok := wanted[apURL]; ok {
+			continue
+		}
+		if followed+undone >= maxReconcileActions {
+			pending++
+			continue
+		}
+		s.apHandler.Federator.Federate(ctx, ap.BuildUndoFollow(localActorURL, apURL))
+		if err := s.store.RemoveFollow(localActorURL, apURL); err != nil {
+			slog.Warn("reconcile: failed to remove follow", "actor", apURL, "error", err)
+		}
+		undone++
+	}
+
+	if pending == 0 {
+		_ = s.store.SetKV(kvKind3ReconciledID, latest.ID)
+	}
+	if followed+undone == 0 {
+		return "in sync", nil
+	}
+	result := fmt.Sprintf("followed %d, unfollowed %d", followed, undone)
+	if pending > 0 {
+		result += fmt.Sprintf(", %d pending", pending)
+	}
+	s.auditLog("follows_reconciled", result)
+	return result, nil
+}
+
+// fetchLatestKind3 returns the newest kind-3 of the local user across all
+// relays, or nil when none answers in time. It waits for every relay's EOSE so
+// a stale copy on a fast relay does not win, and records the size of the list
+// it found under kvKind3FollowCount.
+func (s *Server) fetchLatestKind3(parentCtx context.Context) *gonostr.Event {
+	ctx, cancel := context.WithTimeout(parentCtx, 10*time.Second)
+	defer cancel()
+
+	pool := gonostr.NewSimplePool(ctx)
+	filters := gonostr.Filters{{
+		Kinds:   []int{3},
+		Authors: []string{s.cfg.NostrPublicKey},
+		Limit:   1,
+	}}
+	var latest *gonostr.Event
+	for ev := range pool.SubManyEose(ctx, s.kind3Relays(), filters) {
+		if ev.Event != nil && (latest == nil || ev.Event.CreatedAt > latest.CreatedAt) {
+			latest = ev.Event
+		}
+	}
+	if latest != nil {
+		_ = s.store.SetKV(kvKind3FollowCount, strconv.Itoa(len(kind3Pubkeys(latest))))
+	}
+	return latest
+}
+
+// kind3Relays returns the relays the contact list is read from: the live list
+// when a RelayManager is attached, else the configured one.
+func (s *Server) kind3Relays() []string {
+	if s.relayManager != nil {
+		if relays := s.relayManager.Relays(); len(relays) > 0 {
+			return relays
+		}
+	}
+	return s.cfg.NostrRelays
+}
+
+// kind3Pubkeys returns the set of pubkeys p-tagged by a kind-3.
+func kind3Pubkeys(event *gonostr.Event) map[string]struct{} {
+	pubkeys := make(map[string]struct{})
+	for _, tag := range event.Tags {
+		if len(tag) >= 2 && tag[0] == "p" {
+			pubkeys[tag[1]] = struct{}{}
+		}
+	}
+	return pubkeys
+}
+
+// handleReconcileFollows starts a follow reconciliation in the background.
+// Progress and the result are reported under the "reconcile-follows" job of
+// GET /web/api/jobs.
+//
+// POST /web/api/reconcile-follows
+func (s *Server) handleReconcileFollows(w http.ResponseWriter, r *http.Request) {
+	if !s.startReconcile(context.Background()) {
+		jsonResponse(w, map[string]string{"error": "a reconciliation is already running"}, http.StatusConflict)
+		return
+	}
+	s.auditLog("reconcile_follows", "manual")
+	jsonResponse(w, map[string]string{"message": "Follow reconciliation started in the background."}, http.StatusAccepted)
+}
@@ -3,30 +3,42 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/klppl/klistr/internal/ap"
 )
 
-// RelayStatus describes a relay and its circuit-breaker state (used in the admin API response).
+// RelayStatus describes a relay, its circuit-breaker state and its firehose
+// connection state (used in the admin API response).
 type RelayStatus struct {
 	URL               string `json:"url"`
 	CircuitOpen       bool   `json:"circuit_open"`
 	FailCount         int    `json:"fail_count"`
 	CooldownRemaining int    `json:"cooldown_remaining_secs,omitempty"`
+	Subscribed        bool   `json:"subscribed"`                // within RELAY_MAX_CONNECTIONS
+	Connected         bool   `json:"connected"`                 // firehose websocket currently open
+	LastEventAt       int64  `json:"last_event_at,omitempty"`   // unix ts of last event or (re)subscribe
+	LastSuccessAt     int64  `json:"last_success_at,omitempty"` // unix ts of last accepted publish
+	AvgLatencyMs      int64  `json:"avg_latency_ms,omitempty"`  // moving average publish round-trip time
 }
 
 // RelayManager provides relay management for the /web admin API.
 type RelayManager interface {
 	// Relays returns the current relay list.
 	Relays() []string
-	// RelayStatuses returns circuit-breaker state for all configured relays.
+	// RelayStatuses returns circuit-breaker and connection state for all configured relays.
 	RelayStatuses() []RelayStatus
 	// AddRelay adds a relay. Returns false if already present.
 	AddRelay(url string) bool
 	// RemoveRelay removes a relay. Returns false if not found.
 	RemoveRelay(url string) bool
+	// SetRelays replaces the relay list, returning the relays added and
+	// removed. Retained relays keep their circuit-breaker state.
+	SetRelays(urls []string) (added, removed []string)
 	// ResetCircuit clears the circuit-breaker failure state for a relay.
 	ResetCircuit(url string)
 	// TestRelay attempts to establish a WebSocket connection to the relay.
@@ -101,6 +113,64 @@ func (s *Server) handleRemoveRelay(w http.ResponseWriter, r *http.Request) {
 	}, http.StatusOK)
 }
 
+// handleSetRelays replaces the whole relay list in one call, for switching
+// relay sets wholesale. Relays are validated, trimmed and de-duplicated; the
+// response lists what was added and removed.
+//
+// PUT /web/api/relays
+// Body: {"relays":["wss://relay.damus.io","wss://nos.lol"]}
+func (s *Server) handleSetRelays(w http.ResponseWriter, r *http.Request) {
+	if s.relayManager == nil {
+		http.Error(w, "relay manager not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Relays []string `json:"relays"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	var urls []string
+	seen := make(map[string]struct{}, len(req.Relays))
+	for _, u := range req.Relays {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+		if !strings.HasPrefix(u, "wss://") && !strings.HasPrefix(u, "ws://") {
+			http.Error(w, "invalid relay URL: "+u+" (must start with wss:// or ws://)", http.StatusBadRequest)
+			return
+		}
+		if _, dup := seen[u]; dup {
+			continue
+		}
+		seen[u] = struct{}{}
+		urls = append(urls, u)
+	}
+	if len(urls) == 0 {
+		http.Error(w, "invalid request: at least one relay required", http.StatusBadRequest)
+		return
+	}
+	added, removed := s.relayManager.SetRelays(urls)
+	if len(added) > 0 || len(removed) > 0 {
+		slog.Info("relay list replaced via admin", "added", len(added), "removed", len(removed))
+		s.auditLog("relays_replaced", fmt.Sprintf("added=%s removed=%s",
+			strings.Join(added, ","), strings.Join(removed, ",")))
+	}
+	if added == nil {
+		added = []string{}
+	}
+	if removed == nil {
+		removed = []string{}
+	}
+	jsonResponse(w, map[string]interface{}{
+		"added":   added,
+		"removed": removed,
+		"relays":  urls,
+	}, http.StatusOK)
+}
+
 func (s *Server) handleTestRelay(w http.ResponseWriter, r *http.Request) {
 	if s.relayManager == nil {
 		http.Error(w, "relay manager not available", http.StatusServiceUnavailable)
@@ -150,3 +220,40 @@ func (s *Server) handleResetRelayCircuit(w http.ResponseWriter, r *http.Request)
 	s.auditLog("relay_circuit_reset", url)
 	jsonResponse(w, map[string]interface{}{"ok": true, "url": url}, http.StatusOK)
 }
+
+// ─── Federation delivery health ───────────────────────────────────────────────
+
+// handleGetFederationHosts returns per-destination delivery health from the
+// Federator's circuit breakers, unhealthiest first.
+//
+// GET /web/api/federation/hosts
+func (s *Server) handleGetFederationHosts(w http.ResponseWriter, r *http.Request) {
+	hosts := []ap.HostHealth{}
+	if s.apHandler != nil && s.apHandler.Federator != nil {
+		if h := s.apHandler.Federator.HostHealth(); h != nil {
+			hosts = h
+		}
+	}
+	jsonResponse(w, hosts, http.StatusOK)
+}
+
+// handleResetFederationHost clears the delivery circuit breaker for a host.
+//
+// POST /web/api/federation/hosts/reset
+// Body: {"host":"https://example.com"}
+func (s *Server) handleResetFederationHost(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Host string `json:"host"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Host) == "" {
+		http.Error(w, "invalid request: host required", http.StatusBadRequest)
+		return
+	}
+	host := strings.TrimSpace(req.Host)
+	if s.apHandler == nil || s.apHandler.Federator == nil || !s.apHandler.Federator.ResetHost(host) {
+		http.Error(w, "unknown host", http.StatusNotFound)
+		return
+	}
+	s.auditLog("federation_circuit_reset", host)
+	jsonResponse(w, map[string]interface{}{"ok": true, "host": host}, http.StatusOK)
+}
@@ -15,6 +15,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -22,11 +23,14 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	gonostr "github.com/nbd-wtf/go-nostr"
 	"golang.org/x/time/rate"
 
 	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/bridge"
 	"github.com/klppl/klistr/internal/config"
 	"github.com/klppl/klistr/internal/db"
+	"github.com/klppl/klistr/internal/nostr"
 )
 
 const (
@@ -169,6 +173,11 @@ type Server struct {
 	inboxSem       chan struct{}  // global concurrency cap for inbox processing
 	inboxLimiter   *inboxLimiter  // per-origin concurrency cap
 	inboxIPLimiter *ipRateLimiter // per-remote-IP token-bucket rate limiter
+	inboxDrain     inboxDrain     // in-flight inbox activities, waited on at shutdown
+	instances      instanceFilter // inbox block/allow lists (instance_rules table)
+
+	jobsMu sync.Mutex
+	jobs   map[string]*bulkJob // latest run of each Danger Zone background job, by name
 
 	// Optional — set before Start() is called.
 	logBroadcaster    *LogBroadcaster
@@ -176,13 +185,33 @@ type Server struct {
 	resyncTrigger     chan struct{}
 	followPublisher   FollowPublisher
 	bskyClient        BskyClient
+	bskyBridger       BskyBridger
+	bskyAccounts      []BskyAccount
 	relayManager      RelayManager
 	showSourceLink    *atomic.Bool
 	autoAcceptFollows *atomic.Bool
-
-	// nip05Cache caches NIP-05 remote handle lookups (lowercase name → pubkey).
-	// Eliminates repeated WebFinger calls for the same handle across concurrent
-	// requests. NIP-05 names are case-insensitive so the key is lowercased.
+	bridgedKinds      *nostr.BridgedKinds
+	tc                *ap.TransmuteContext
+	exclusions        *bridge.AuthorExclusions // authors not bridged (bridge_exclusions table)
+	contentFilter     *bridge.ContentFilter    // keyword/regex filters on inbound posts (content_filters KV key)
+
+	// outboxCache holds outbox pages rendered from the relays (outbox.go).
+	outboxCache *renderCache[int64, outboxPage]
+	// objectCache holds /objects/{id} relay lookups, and objectLookups
+	// bounds how many of them and of the outbox page renders run at once
+	// (outbox.go).
+	objectCache   *renderCache[string, objectLookup]
+	objectLookups chan struct{}
+	// userStatuses caches the users' NIP-38 statuses (userstatus.go).
+	userStatuses userStatusCache
+	// featuredCache holds the pinned posts rendered from the relays
+	// (featured.go).
+	featuredCache featuredCache
+
+	// nip05Cache caches NIP-05 remote handle lookups (lowercase name →
+	// nip05CacheEntry), both successful and failed, with a TTL. Eliminates
+	// repeated WebFinger calls for the same handle across concurrent requests.
+	// NIP-05 names are case-insensitive so the key is lowercased.
 	nip05Cache sync.Map
 
 	// csrfToken is a random 32-hex-character token generated at startup.
@@ -211,7 +240,12 @@ func New(cfg *config.Config, store *db.Store, keyPair *ap.KeyPair, apHandler *ap
 		showSourceLink:    &atomic.Bool{},
 		autoAcceptFollows: func() *atomic.Bool { b := &atomic.Bool{}; b.Store(true); return b }(),
 		csrfToken:         hex.EncodeToString(tokenBytes),
+		outboxCache:       newOutboxCache(),
+		objectCache:       newObjectCache(),
+		objectLookups:     make(chan struct{}, maxObjectLookups),
 	}
+	s.loadInstanceFilter()
+	go s.sweepNIP05Cache()
 	s.router = s.buildRouter()
 	return s
 }
@@ -234,6 +268,10 @@ func (s *Server) SetResyncTrigger(ch chan struct{}) { s.resyncTrigger = ch }
 // Nil disables the Bluesky follow/unfollow endpoints.
 func (s *Server) SetBskyClient(c BskyClient) { s.bskyClient = c }
 
+// AddBskyAccount registers the poller of a bridged Bluesky account, primary
+// first, for the per-account poll state in the dashboard and healthcheck.
+func (s *Server) AddBskyAccount(a BskyAccount) { s.bskyAccounts = append(s.bskyAccounts, a) }
+
 // SetRelayManager attaches the relay manager for the /web relay management endpoints.
 func (s *Server) SetRelayManager(rm RelayManager) { s.relayManager = rm }
 
@@ -245,6 +283,10 @@ func (s *Server) SetShowSourceLink(b *atomic.Bool) { s.showSourceLink = b }
 // incoming AP follows are auto-accepted. Updated live by the admin settings API.
 func (s *Server) SetAutoAcceptFollows(b *atomic.Bool) { s.autoAcceptFollows = b }
 
+// SetBridgedKinds attaches the shared set of event kinds the Nostr handler
+// federates. Updated live by the admin settings API; nil hides the setting.
+func (s *Server) SetBridgedKinds(k *nostr.BridgedKinds) { s.bridgedKinds = k }
+
 // Start runs the HTTP server until ctx is cancelled.
 func (s *Server) Start(ctx context.Context) {
 	addr := ":" + s.cfg.Port
@@ -258,8 +300,13 @@ func (s *Server) Start(ctx context.Context) {
 
 	slog.Info("starting HTTP server", "addr", addr, "domain", s.cfg.LocalDomain)
 
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-ctx.Done()
+		// Refuse new inbox deliveries (senders retry them later) and let the
+		// accepted ones finish bridging before the listener goes away.
+		s.inboxDrain.drain(s.cfg.ShutdownGracePeriod)
 		shutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		if err := srv.Shutdown(shutCtx); err != nil {
@@ -269,27 +316,31 @@ func (s *Server) Start(ctx context.Context) {
 
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		slog.Error("server error", "error", err)
+		return
 	}
+	<-stopped
 }
 
 func (s *Server) buildRouter() *chi.Mux {
 	r := chi.NewRouter()
 
-	r.Use(middleware.RealIP)
+	r.Use(realIPMiddleware(s.cfg.TrustedProxies))
 	r.Use(loggingMiddleware)
 	r.Use(middleware.Recoverer)
 	r.Use(corsMiddleware)
 
 	// Health check.
-	r.Get("/api/healthcheck", func(w http.ResponseWriter, r *http.Request) {
-		jsonResponse(w, map[string]string{"status": "ok"}, http.StatusOK)
-	})
+	r.Get("/api/healthcheck", s.handleHealthcheck)
 
 	// Discovery endpoints.
 	r.Get("/.well-known/webfinger", s.handleWebFinger)
 	r.Get("/.well-known/host-meta", s.handleHostMeta)
 	r.Get("/.well-known/nodeinfo", s.handleNodeInfo)
 	r.Get("/.well-known/nostr.json", s.handleNIP05)
+	r.Get("/.well-known/lnurlp/{username}", s.handleLNURLP)
+
+	// LNURL-pay callback (NIP-57 zaps).
+	r.Get("/lnurlp/{username}/callback", s.handleLNURLCallback)
 
 	// NodeInfo schema.
 	r.Get("/nodeinfo/{version}", s.handleNodeInfoSchema)
@@ -299,6 +350,7 @@ func (s *Server) buildRouter() *chi.Mux {
 	r.Get("/users/{username}/followers", s.handleFollowers)
 	r.Get("/users/{username}/following", s.handleFollowing)
 	r.Get("/users/{username}/outbox", s.handleOutbox)
+	r.Get("/users/{username}/collections/featured", s.handleFeatured)
 	r.Post("/users/{username}/inbox", s.handleInbox)
 
 	// ActivityPub object endpoints.
@@ -312,15 +364,20 @@ func (s *Server) buildRouter() *chi.Mux {
 
 	r.Get("/tags/{tag}", s.handleTag)
 
-	// Root — basic info page.
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain")
-		fmt.Fprintf(w, "klistr - a self-hosted personal bridge that connects your Nostr identity to the Fediverse and Bluesky.\nhttps://github.com/klppl/klistr\n\nRunning on %s\n", s.cfg.LocalDomain)
-	})
+	// NIP-89 web handler: bridged Nostr entities → their source.
+	r.Get("/nostr/{entity}", s.handleNostrRedirect)
+
+	// Root — basic info page, or a JSON status for monitors.
+	r.Get("/", s.handleRoot)
 
 	// Web admin UI — only mounted when WEB_ADMIN password is configured.
 	if s.cfg.WebAdminPassword != "" {
+		// Remote-follow handshake advertised by the WebFinger subscribe template.
+		r.With(s.adminCORS, s.adminAuth).Get("/authorize_interaction", s.handleAuthorizeInteraction)
+		r.With(s.adminCORS, s.adminAuth).Post("/authorize_interaction", s.handleAuthorizeInteractionConfirm)
+
 		r.Route("/web", func(r chi.Router) {
+			r.Use(s.adminCORS)
 			r.Use(s.adminAuth)
 			r.Use(s.csrfMiddleware)
 			r.Get("/", s.handleAdminDashboard)
@@ -336,18 +393,45 @@ func (s *Server) buildRouter() *chi.Mux {
 			r.Post("/api/follow", s.handleAddFollow)
 			r.Post("/api/unfollow", s.handleRemoveFollow)
 			r.Post("/api/resync-follows", s.handleResyncFollowProfiles)
+			r.Get("/api/resync-report", s.handleResyncReport)
 			r.Get("/api/relays", s.handleGetRelays)
 			r.Post("/api/relays", s.handleAddRelay)
 			r.Delete("/api/relays", s.handleRemoveRelay)
+			r.Put("/api/relays", s.handleSetRelays)
 			r.Post("/api/relays/test", s.handleTestRelay)
 			r.Post("/api/relays/reset-circuit", s.handleResetRelayCircuit)
+			r.Get("/api/federation/hosts", s.handleGetFederationHosts)
+			r.Post("/api/federation/hosts/reset", s.handleResetFederationHost)
 			r.Get("/api/settings", s.handleGetSettings)
 			r.Patch("/api/settings", s.handleUpdateSettings)
 			r.Post("/api/republish-kind0", s.handleRepublishKind0)
 			r.Post("/api/republish-kind3", s.handleRepublishKind3)
+			r.Post("/api/reconcile-follows", s.handleReconcileFollows)
+			r.Post("/api/backfill", s.handleBackfill)
+			r.Post("/api/bridge-url", s.handleBridgeURL)
+			r.Post("/api/test-post", s.handleTestPost)
 			r.Post("/api/refollow-all", s.handleRefollowAll)
 			r.Post("/api/wipe-follows", s.handleWipeFollows)
-			r.Get("/api/audit-log", s.handleGetAuditLog)
+			r.Get("/api/rotate-key", s.handleRotateKeyStatus)
+			r.Post("/api/rotate-key", s.handleRotateKey)
+			r.Get("/api/jobs", s.handleGetJobs)
+			r.Get("/api/audit", s.handleGetAuditLog)
+			r.Get("/api/audit-log", s.handleGetAuditLog) // legacy path
+			r.Get("/api/failures", s.handleGetFailures)
+			r.Post("/api/failures/retry", s.handleRetryFailure)
+			r.Get("/api/pending-follows", s.handleGetPendingFollows)
+			r.Post("/api/pending-follows/approve", s.handleApprovePendingFollow)
+			r.Post("/api/pending-follows/reject", s.handleRejectPendingFollow)
+			r.Get("/api/instance-blocks", s.handleGetInstanceBlocks)
+			r.Post("/api/instance-blocks", s.handleAddInstanceBlock)
+			r.Delete("/api/instance-blocks", s.handleRemoveInstanceBlock)
+			r.Post("/api/instance-blocks/mode", s.handleSetInstanceAllowlistMode)
+			r.Get("/api/bridge-exclusions", s.handleGetBridgeExclusions)
+			r.Post("/api/bridge-exclusions", s.handleAddBridgeExclusion)
+			r.Delete("/api/bridge-exclusions", s.handleRemoveBridgeExclusion)
+			r.Get("/api/content-filters", s.handleGetContentFilters)
+			r.Post("/api/content-filters", s.handleAddContentFilter)
+			r.Delete("/api/content-filters", s.handleRemoveContentFilter)
 		})
 	}
 
@@ -357,45 +441,54 @@ func (s *Server) buildRouter() *chi.Mux {
 // ─── ActivityPub Handlers ─────────────────────────────────────────────────────
 
 func (s *Server) handleActor(w http.ResponseWriter, r *http.Request) {
-	username := chi.URLParam(r, "username")
-	if username != s.cfg.NostrUsername {
+	user, ok := s.cfg.LocalUser(chi.URLParam(r, "username"))
+	if !ok {
 		http.NotFound(w, r)
 		return
 	}
+	s.robotsHint(w, s.cfg.ActorDiscoverable)
+	actor := s.localActor(user)
+	s.applyUserStatus(r.Context(), actor, user)
+	apResponse(w, ap.WithContext(actor))
+}
 
+// localActor renders the AP actor of a local user, with the profile as last
+// saved in the admin settings.
+func (s *Server) localActor(user config.LocalUser) *ap.Actor {
+	user = s.localProfile(user)
+	username := user.Username
 	actorURL := s.cfg.BaseURL("/users/" + username)
 	actor := &ap.Actor{
 		ID:                actorURL,
 		Type:              "Person",
 		PreferredUsername: username,
-		Name:              s.cfg.NostrDisplayName,
-		Summary:           s.cfg.NostrSummary,
+		Name:              user.DisplayName,
+		Summary:           user.Summary,
 		Inbox:             actorURL + "/inbox",
 		Outbox:            actorURL + "/outbox",
 		Followers:         actorURL + "/followers",
 		Following:         actorURL + "/following",
-		PublicKey: &ap.PublicKey{
-			ID:           actorURL + "#main-key",
-			Owner:        actorURL,
-			PublicKeyPem: s.keyPair.PublicPEM,
-		},
+		Featured:          actorURL + "/collections/featured",
+		PublicKey:         s.keyPair.ActorPublicKey(actorURL),
+		AssertionMethod:   s.keyPair.AssertionMethod(actorURL),
 		Endpoints: &ap.Endpoints{
 			SharedInbox: s.cfg.BaseURL("/inbox"),
 		},
 		ProxyOf: []ap.Proxy{{
 			Protocol:      ap.NostrProtocolURI,
-			Proxied:       s.cfg.NostrNpub,
+			Proxied:       user.Npub,
 			Authoritative: true,
 		}},
+		Discoverable: &s.cfg.ActorDiscoverable,
+		Indexable:    &s.cfg.ActorIndexable,
 	}
-	if s.cfg.NostrPicture != "" {
-		actor.Icon = &ap.Image{Type: "Image", URL: s.cfg.NostrPicture}
+	if user.Picture != "" {
+		actor.Icon = &ap.Image{Type: "Image", URL: user.Picture}
 	}
-	if s.cfg.NostrBanner != "" {
-		actor.Image = &ap.Image{Type: "Image", URL: s.cfg.NostrBanner}
+	if user.Banner != "" {
+		actor.Image = &ap.Image{Type: "Image", URL: user.Banner}
 	}
-
-	apResponse(w, ap.WithContext(actor))
+	return actor
 }
 
 func (s *Server) handleObject(w http.ResponseWriter, r *http.Request) {
@@ -405,106 +498,189 @@ func (s *Server) handleObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return a minimal note — in a full implementation we'd fetch from relay.
-	note := map[string]interface{}{
+	s.robotsHint(w, s.cfg.ActorIndexable)
+
+	// Render the local user's event from the relays when it can be found;
+	// otherwise fall back to a minimal stub. Followers-only notes are not
+	// served at all.
+	note, hidden := s.fetchLocalNote(r.Context(), id)
+	if hidden {
+		http.NotFound(w, r)
+		return
+	}
+	if note != nil {
+		apResponse(w, note)
+		return
+	}
+	apResponse(w, map[string]interface{}{
 		"@context":     ap.DefaultContext,
 		"id":           s.cfg.BaseURL("/objects/" + id),
 		"type":         "Note",
 		"attributedTo": s.cfg.BaseURL("/users/" + s.cfg.NostrUsername),
 		"content":      "",
-	}
-	apResponse(w, note)
+	})
 }
 
+const collectionPageSize = 50
+
 func (s *Server) handleFollowers(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
-	if username != s.cfg.NostrUsername {
+	if _, ok := s.cfg.LocalUser(username); !ok {
 		http.NotFound(w, r)
 		return
 	}
 
 	// Only AP followers (http URLs) belong in the ActivityPub followers collection.
-	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
-	followers, err := s.store.GetAPFollowers(localActorURL)
-	if err != nil {
-		slog.Error("get followers", "error", err)
-		followers = []string{}
-	}
-
-	collection := ap.OrderedCollection{
-		Context:      ap.DefaultContext,
-		ID:           localActorURL + "/followers",
-		Type:         "OrderedCollection",
-		TotalItems:   len(followers),
-		OrderedItems: followers,
-	}
-	apResponse(w, collection)
+	localActorURL := s.cfg.BaseURL("/users/" + username)
+	s.serveActorCollection(w, r, localActorURL+"/followers",
+		func() (int, error) { return s.store.CountAPFollowers(localActorURL) },
+		func(limit, offset int) ([]string, error) {
+			return s.store.GetAPFollowersPage(localActorURL, limit, offset)
+		})
 }
 
 func (s *Server) handleFollowing(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
-	if username != s.cfg.NostrUsername {
+	if _, ok := s.cfg.LocalUser(username); !ok {
 		http.NotFound(w, r)
 		return
 	}
 
-	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
-	following, err := s.store.GetFollowing(localActorURL)
+	localActorURL := s.cfg.BaseURL("/users/" + username)
+	s.serveActorCollection(w, r, localActorURL+"/following",
+		func() (int, error) { return s.store.CountFollowing(localActorURL) },
+		func(limit, offset int) ([]string, error) {
+			return s.store.GetFollowingPage(localActorURL, limit, offset)
+		})
+}
+
+// robotsHint asks search engines and crawlers not to index a response when
+// allowed is false (ACTOR_DISCOVERABLE for the profile and its collections,
+// ACTOR_INDEXABLE for posts).
+func (s *Server) robotsHint(w http.ResponseWriter, allowed bool) {
+	if !allowed {
+		w.Header().Set("X-Robots-Tag", "noindex, noarchive")
+	}
+}
+
+// serveActorCollection serves a paginated followers/following collection.
+// The root reports totalItems and links the first page; ?page=N (1-based)
+// returns an OrderedCollectionPage of collectionPageSize actor IDs with
+// next/prev links.
+func (s *Server) serveActorCollection(w http.ResponseWriter, r *http.Request, collectionURL string,
+	count func() (int, error), page func(limit, offset int) ([]string, error)) {
+	s.robotsHint(w, s.cfg.ActorDiscoverable)
+	total, err := count()
 	if err != nil {
-		following = []string{}
+		slog.Error("count collection", "collection", collectionURL, "error", err)
+		total = 0
 	}
 
-	collection := ap.OrderedCollection{
-		Context:      ap.DefaultContext,
-		ID:           localActorURL + "/following",
-		Type:         "OrderedCollection",
-		TotalItems:   len(following),
-		OrderedItems: following,
+	pageParam := r.URL.Query().Get("page")
+	if pageParam == "" {
+		collection := map[string]interface{}{
+			"@context":   ap.DefaultContext,
+			"id":         collectionURL,
+			"type":       "OrderedCollection",
+			"totalItems": total,
+		}
+		if total > 0 {
+			collection["first"] = collectionURL + "?page=1"
+		}
+		apResponse(w, collection)
+		return
 	}
-	apResponse(w, collection)
+
+	n, err := strconv.Atoi(pageParam)
+	if err != nil || n < 1 {
+		n = 1 // also covers the legacy ?page=true form
+	}
+	items, err := page(collectionPageSize, (n-1)*collectionPageSize)
+	if err != nil {
+		slog.Error("get collection page", "collection", collectionURL, "page", n, "error", err)
+	}
+	if items == nil {
+		items = []string{}
+	}
+
+	result := map[string]interface{}{
+		"@context":     ap.DefaultContext,
+		"id":           fmt.Sprintf("%s?page=%d", collectionURL, n),
+		"type":         "OrderedCollectionPage",
+		"partOf":       collectionURL,
+		"totalItems":   total,
+		"orderedItems": items,
+	}
+	if n*collectionPageSize < total {
+		result["next"] = fmt.Sprintf("%s?page=%d", collectionURL, n+1)
+	}
+	if n > 1 {
+		result["prev"] = fmt.Sprintf("%s?page=%d", collectionURL, n-1)
+	}
+	apResponse(w, result)
 }
 
 const outboxPageSize = 20
 
 func (s *Server) handleOutbox(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
-	if username != s.cfg.NostrUsername {
+	if _, ok := s.cfg.LocalUser(username); !ok {
 		http.NotFound(w, r)
 		return
 	}
 
-	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
+	localActorURL := s.cfg.BaseURL("/users/" + username)
 	outboxURL := localActorURL + "/outbox"
+	s.robotsHint(w, s.cfg.ActorIndexable)
 
 	// Local objects have ap_id starting with our objects URL prefix.
 	objectPrefix := s.cfg.BaseURL("/objects/")
 
 	if r.URL.Query().Get("page") == "true" {
-		// Return a page of Create activities wrapping local object URLs.
-		ids, err := s.store.GetRecentLocalObjects(objectPrefix, outboxPageSize)
-		if err != nil {
-			slog.Warn("outbox: failed to fetch local objects", "error", err)
-			ids = nil
+		until, _ := strconv.ParseInt(r.URL.Query().Get("until"), 10, 64)
+		var items []interface{}
+		var next int64
+		if s.tc != nil {
+			// Embed the notes themselves, rendered from the relays, so a
+			// new follower's server can backfill recent posts.
+			rendered, ok := s.renderOutboxPage(r.Context(), until)
+			if !ok {
+				w.Header().Set("Retry-After", "10")
+				http.Error(w, "too many outbox requests", http.StatusServiceUnavailable)
+				return
+			}
+			items, next = rendered.items, rendered.next
+		} else {
+			// Without a transmute context, wrap local object URLs instead.
+			ids, err := s.store.GetRecentLocalObjects(objectPrefix, outboxPageSize)
+			if err != nil {
+				slog.Warn("outbox: failed to fetch local objects", "error", err)
+				ids = nil
+			}
+			for _, apID := range ids {
+				items = append(items, map[string]interface{}{
+					"type":   "Create",
+					"id":     apID + "#create",
+					"actor":  localActorURL,
+					"object": apID,
+					"to":     []string{ap.PublicURI},
+				})
+			}
 		}
-
-		items := make([]interface{}, 0, len(ids))
-		for _, apID := range ids {
-			items = append(items, map[string]interface{}{
-				"type":   "Create",
-				"id":     apID + "#create",
-				"actor":  localActorURL,
-				"object": apID,
-				"to":     []string{ap.PublicURI},
-			})
+		if items == nil {
+			items = []interface{}{}
 		}
 
 		page := map[string]interface{}{
 			"@context":     ap.DefaultContext,
-			"id":           outboxURL + "?page=true",
+			"id":           outboxPageURL(outboxURL, until),
 			"type":         "OrderedCollectionPage",
 			"partOf":       outboxURL,
 			"orderedItems": items,
 		}
+		if next > 0 {
+			page["next"] = outboxPageURL(outboxURL, next)
+		}
 		apResponse(w, page)
 		return
 	}
@@ -526,19 +702,45 @@ func (s *Server) handleOutbox(w http.ResponseWriter, r *http.Request) {
 	apResponse(w, collection)
 }
 
+// handleTag lists the local user's bridged notes carrying a hashtag, newest
+// first. Items are the notes' IDs; remote servers dereference each one, so
+// followers-only notes stay hidden behind handleObject. totalItems counts
+// every tagged note, not only the page shown.
 func (s *Server) handleTag(w http.ResponseWriter, r *http.Request) {
 	tag := chi.URLParam(r, "tag")
+	ids, err := s.store.GetObjectsByTag(tag, outboxPageSize)
+	if err != nil {
+		slog.Warn("tag collection: failed to fetch tagged objects", "tag", tag, "error", err)
+		ids = nil
+	}
+	total, err := s.store.CountObjectsByTag(tag)
+	if err != nil {
+		slog.Warn("tag collection: failed to count tagged objects", "tag", tag, "error", err)
+		total = len(ids)
+	}
+
+	items := make([]interface{}, 0, len(ids))
+	for _, apID := range ids {
+		items = append(items, apID)
+	}
+
 	collection := ap.OrderedCollection{
 		Context:      ap.DefaultContext,
 		ID:           s.cfg.BaseURL("/tags/" + tag),
 		Type:         "OrderedCollection",
-		TotalItems:   0,
-		OrderedItems: []interface{}{},
+		TotalItems:   total,
+		OrderedItems: items,
 	}
+	s.robotsHint(w, s.cfg.ActorIndexable)
 	apResponse(w, collection)
 }
 
 func (s *Server) handleInbox(w http.ResponseWriter, r *http.Request) {
+	if s.inboxDrain.draining() {
+		refuseDraining(w)
+		return
+	}
+
 	// Rate-limit by remote IP before doing any expensive work. Signature
 	// verification requires an outbound HTTP call to fetch the actor's public
 	// key; allowing unlimited requests would let an attacker exhaust the actor
@@ -562,11 +764,30 @@ func (s *Server) handleInbox(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Derive the origin hostname for instance rules and per-actor rate
+	// limiting from the signature's keyId: with signatures verified, that
+	// host served the signing key, while the body's actor is only a claim
+	// (and must match the key owner, see below). Unsigned requests fall back
+	// to the actor URL from the body, then the remote IP.
+	origin := keyIDOrigin(ap.SignatureKeyID(r))
+	if origin == "" {
+		origin = actorOrigin(body, r.RemoteAddr)
+	}
+
+	// Refuse blocked (or, in allowlist mode, unlisted) instances outright,
+	// before any signature fetch or concurrency slot is spent on them.
+	if !s.instances.allowed(origin) {
+		slog.Info("inbox delivery refused by instance rules", "origin", origin)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
 	// Verify HTTP signature and Digest header.
 	// actorGone is set when the signing actor returned HTTP 410. We defer the
 	// accept/reject decision until after we know the activity type: only
 	// "Delete" activities may be accepted without a verifiable signature.
 	var actorGone bool
+	var keyID string
 	if s.cfg.SignFetch {
 		// Verify Digest header: confirms body was not modified in transit after signing.
 		if err := ap.VerifyDigest(body, r.Header.Get("Digest")); err != nil {
@@ -574,7 +795,7 @@ func (s *Server) handleInbox(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "digest mismatch", http.StatusUnauthorized)
 			return
 		}
-		_, err := ap.VerifySignature(r)
+		keyID, err = ap.VerifySignature(r)
 		if err != nil {
 			if errors.Is(err, ap.ErrActorGone) {
 				actorGone = true
@@ -587,12 +808,23 @@ func (s *Server) handleInbox(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Now that we have the body, enforce the Gone-actor restriction: only
-	// Delete activities may proceed without a verified signature.
-	if actorGone {
-		var peek struct {
-			Type string `json:"type"`
+	// Delete activities may proceed without a verified signature. Either
+	// way, the activity's actor must own the signing key, so nobody can act
+	// (or delete an account) in another actor's name.
+	var peek struct {
+		Type  string      `json:"type"`
+		Actor interface{} `json:"actor"`
+	}
+	_ = json.Unmarshal(body, &peek)
+	if s.cfg.SignFetch {
+		if actor := activityActor(peek.Actor); actor == "" || actor != ap.KeyOwner(keyID) {
+			slog.Warn("rejecting activity not signed by its actor",
+				"actor", actor, "keyId", keyID, "remote", r.RemoteAddr)
+			http.Error(w, "signature does not match actor", http.StatusUnauthorized)
+			return
 		}
-		_ = json.Unmarshal(body, &peek)
+	}
+	if actorGone {
 		if peek.Type != "Delete" {
 			slog.Warn("rejecting non-Delete activity from gone actor",
 				"type", peek.Type, "remote", r.RemoteAddr)
@@ -602,10 +834,6 @@ func (s *Server) handleInbox(w http.ResponseWriter, r *http.Request) {
 		slog.Debug("accepting Delete from gone actor", "remote", r.RemoteAddr)
 	}
 
-	// Derive the origin hostname for per-actor rate limiting.
-	// Prefer the actor URL from the body; fall back to the remote IP.
-	origin := actorOrigin(body, r.RemoteAddr)
-
 	// Per-origin concurrency check (before the global semaphore).
 	if !s.inboxLimiter.acquire(origin) {
 		slog.Warn("per-origin inbox rate limit exceeded", "origin", origin)
@@ -623,13 +851,22 @@ func (s *Server) handleInbox(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.inboxDrain.begin() {
+		<-s.inboxSem
+		s.inboxLimiter.release(origin)
+		refuseDraining(w)
+		return
+	}
+
 	go func() {
+		defer s.inboxDrain.done()
 		defer s.inboxLimiter.release(origin)
 		defer func() { <-s.inboxSem }()
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		if err := s.apHandler.HandleActivity(ctx, json.RawMessage(body)); err != nil {
 			slog.Warn("failed to handle activity", "error", err)
+			s.recordFailedActivity(body, err)
 		}
 	}()
 
@@ -644,12 +881,9 @@ func (s *Server) handleServiceActor(w http.ResponseWriter, r *http.Request) {
 		PreferredUsername: "klistr",
 		Inbox:             s.cfg.BaseURL("/inbox"),
 		Outbox:            s.cfg.BaseURL("/actor/outbox"),
-		PublicKey: &ap.PublicKey{
-			ID:           s.cfg.BaseURL("/actor#main-key"),
-			Owner:        s.cfg.BaseURL("/actor"),
-			PublicKeyPem: s.keyPair.PublicPEM,
-		},
-		URL: "https://github.com/klppl/klistr",
+		PublicKey:         s.keyPair.ActorPublicKey(s.cfg.BaseURL("/actor")),
+		AssertionMethod:   s.keyPair.AssertionMethod(s.cfg.BaseURL("/actor")),
+		URL:               "https://github.com/klppl/klistr",
 	}
 	apResponse(w, ap.WithContext(actor))
 }
@@ -681,12 +915,12 @@ func (s *Server) handleWebFinger(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Only resolve the configured username.
-	if user != s.cfg.NostrUsername {
+	if _, ok := s.cfg.LocalUser(user); !ok {
 		http.NotFound(w, r)
 		return
 	}
 
-	actorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
+	actorURL := s.cfg.BaseURL("/users/" + user)
 
 	resp := ap.WebFingerResponse{
 		Subject: resource,
@@ -717,14 +951,25 @@ func (s *Server) handleNIP05(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Local user.
-	if name == s.cfg.NostrUsername {
+	// Local users.
+	if user, ok := s.cfg.LocalUser(name); ok {
 		jsonResponse(w, map[string]interface{}{
-			"names": map[string]string{s.cfg.NostrUsername: s.cfg.NostrPublicKey},
+			"names": map[string]string{user.Username: user.PublicKey},
 		}, http.StatusOK)
 		return
 	}
 
+	// Bridged actors: a derived pubkey queried by its hex, or the exact
+	// "alice_at_mastodon.social" name published in a bridged kind-0.
+	if s.actorKeyStore != nil && s.actorResolver != nil {
+		if pubkey, ok := s.resolveBridgedName(name); ok {
+			jsonResponse(w, map[string]interface{}{
+				"names": map[string]string{name: pubkey},
+			}, http.StatusOK)
+			return
+		}
+	}
+
 	// Fediverse handle lookup: "alice_at_mastodon.social" → "alice@mastodon.social"
 	if s.actorKeyStore != nil && s.actorResolver != nil {
 		if pubkey, ok := s.resolveRemoteHandle(r.Context(), name); ok {
@@ -748,6 +993,30 @@ func (s *Server) handleNIP05(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, map[string]interface{}{"names": map[string]string{}}, http.StatusOK)
 }
 
+// resolveBridgedName resolves a NIP-05 name to the derived pubkey of an
+// already-bridged AP actor without a network call: a hex pubkey with a row in
+// actor_keys resolves to itself, and a "user_at_host" name to the actor
+// recorded when its kind-0 was published (nip05_names). The pubkey
+// is only returned when actor_keys maps it back to that actor.
+func (s *Server) resolveBridgedName(name string) (string, bool) {
+	if gonostr.IsValid32ByteHex(name) {
+		_, ok := s.actorKeyStore.GetActorForKey(name)
+		return name, ok
+	}
+	actorURL, ok := s.store.GetNIP05Name(name)
+	if !ok || actorURL == "" {
+		return "", false
+	}
+	pubkey, err := s.actorResolver.PublicKey(actorURL)
+	if err != nil {
+		return "", false
+	}
+	if stored, ok := s.actorKeyStore.GetActorForKey(pubkey); !ok || stored != actorURL {
+		return "", false
+	}
+	return pubkey, true
+}
+
 // resolveBskyHandle looks up the DID stored for a Bluesky handle (written by
 // the Poller when it first bridges the author's profile) and returns the
 // derived Nostr pubkey.
@@ -765,10 +1034,44 @@ func (s *Server) resolveBskyHandle(handle string) (string, bool) {
 	return pubkey, true
 }
 
+// NIP-05 remote handle cache lifetimes. Failed lookups are remembered briefly
+// so a client polling an unresolvable handle doesn't trigger a WebFinger
+// request each time; successful ones are refreshed daily so handles that move
+// servers eventually pick up the new actor.
+const (
+	nip05PositiveTTL   = 24 * time.Hour
+	nip05NegativeTTL   = 5 * time.Minute
+	nip05SweepInterval = 10 * time.Minute
+)
+
+// nip05CacheEntry is a cached remote handle lookup. pubkey is "" for a
+// negative (failed) result.
+type nip05CacheEntry struct {
+	pubkey  string
+	expires time.Time
+}
+
+// sweepNIP05Cache periodically evicts expired nip05Cache entries so the cache
+// doesn't grow unbounded with many distinct names. Runs for the process lifetime.
+func (s *Server) sweepNIP05Cache() {
+	ticker := time.NewTicker(nip05SweepInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		s.nip05Cache.Range(func(k, v any) bool {
+			if now.After(v.(nip05CacheEntry).expires) {
+				s.nip05Cache.Delete(k)
+			}
+			return true
+		})
+	}
+}
+
 // resolveRemoteHandle converts a name like "alice_at_mastodon.social" into a
 // Fediverse handle, resolves it via WebFinger, and returns the derived Nostr pubkey.
 // Results are cached in memory (keyed on lowercase name) so repeated NIP-05
 // lookups for the same handle — including case variants — skip the network call.
+// Failures are cached too, for nip05NegativeTTL.
 func (s *Server) resolveRemoteHandle(ctx context.Context, name string) (string, bool) {
 	handle := remoteHandleToFediverse(name)
 	if handle == "" {
@@ -779,18 +1082,27 @@ func (s *Server) resolveRemoteHandle(ctx context.Context, name string) (string,
 	// "FruH_at_mastodonsweden.se" and "fruh_at_mastodonsweden.se" share one entry.
 	cacheKey := strings.ToLower(name)
 	if cached, ok := s.nip05Cache.Load(cacheKey); ok {
-		return cached.(string), true
+		entry := cached.(nip05CacheEntry)
+		if time.Now().Before(entry.expires) {
+			return entry.pubkey, entry.pubkey != ""
+		}
+		s.nip05Cache.Delete(cacheKey)
 	}
 
 	actorURL, err := ap.WebFingerResolve(ctx, handle)
 	if err != nil {
 		slog.Debug("NIP-05: WebFinger failed", "handle", handle, "error", err)
+		// Don't remember a failure caused by the client going away.
+		if ctx.Err() == nil {
+			s.nip05Cache.Store(cacheKey, nip05CacheEntry{expires: time.Now().Add(nip05NegativeTTL)})
+		}
 		return "", false
 	}
 
 	pubkey, err := s.actorResolver.PublicKey(actorURL)
 	if err != nil {
 		slog.Warn("NIP-05: failed to derive pubkey", "actor", actorURL, "error", err)
+		s.nip05Cache.Store(cacheKey, nip05CacheEntry{expires: time.Now().Add(nip05NegativeTTL)})
 		return "", false
 	}
 
@@ -798,7 +1110,7 @@ func (s *Server) resolveRemoteHandle(ctx context.Context, name string) (string,
 		slog.Warn("NIP-05: failed to store actor key", "error", err)
 	}
 
-	s.nip05Cache.Store(cacheKey, pubkey)
+	s.nip05Cache.Store(cacheKey, nip05CacheEntry{pubkey: pubkey, expires: time.Now().Add(nip05PositiveTTL)})
 	slog.Info("NIP-05: resolved remote handle", "name", name, "handle", handle, "actor", actorURL, "pubkey", pubkey[:8])
 	return pubkey, true
 }
@@ -863,9 +1175,17 @@ func (s *Server) handleNodeInfoSchema(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, info, http.StatusOK)
 }
 
+// keyIDOrigin returns the host of a signature keyId, or "" if it has none.
+func keyIDOrigin(keyID string) string {
+	if u, err := url.Parse(keyID); err == nil {
+		return u.Host
+	}
+	return ""
+}
+
 // actorOrigin extracts the hostname of the AP actor from the raw activity body.
 // Falls back to the remote IP address if the actor field is absent or unparseable.
-// Used as the key for per-origin inbox rate limiting.
+// Used as the key for per-origin inbox rate limiting of unsigned requests.
 func actorOrigin(body []byte, remoteAddr string) string {
 	var a struct {
 		Actor string `json:"actor"`
@@ -883,6 +1203,19 @@ func actorOrigin(body []byte, remoteAddr string) string {
 	return host
 }
 
+// activityActor returns the ID of an activity's actor, given as an IRI or an
+// embedded object.
+func activityActor(actor interface{}) string {
+	switch a := actor.(type) {
+	case string:
+		return a
+	case map[string]interface{}:
+		id, _ := a["id"].(string)
+		return id
+	}
+	return ""
+}
+
 // ─── Audit log ────────────────────────────────────────────────────────────────
 
 // auditLog records an admin mutation in the persistent audit log.
@@ -894,11 +1227,27 @@ func (s *Server) auditLog(action, detail string) {
 	}
 }
 
-// handleGetAuditLog returns the 200 most recent admin audit log entries.
+// Bounds for the audit log endpoint's limit parameter.
+const (
+	defaultAuditLimit = 200
+	maxAuditLimit     = 1000
+)
+
+// handleGetAuditLog returns the most recent admin audit log entries, newest
+// first (default 200, at most 1000).
 //
-// GET /web/api/audit-log
+// GET /web/api/audit?limit=N
 func (s *Server) handleGetAuditLog(w http.ResponseWriter, r *http.Request) {
-	entries, err := s.store.GetAuditLog(200)
+	limit := defaultAuditLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxAuditLimit)
+	}
+	entries, err := s.store.GetAuditLog(limit)
 	if err != nil {
 		http.Error(w, "failed to read audit log", http.StatusInternalServerError)
 		return
@@ -947,9 +1296,15 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// corsMiddleware adds CORS headers for fediverse compatibility.
+// corsMiddleware adds wildcard CORS headers for fediverse compatibility:
+// browser-based clients fetch actors, notes and discovery documents from any
+// origin. The admin routes are skipped; adminCORS handles them.
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isAdminPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
@@ -961,6 +1316,11 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// isAdminPath reports whether path belongs to the web admin routes.
+func isAdminPath(path string) bool {
+	return path == "/web" || strings.HasPrefix(path, "/web/") || path == "/authorize_interaction"
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	status int
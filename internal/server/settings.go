@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
 	gonostr "github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/config"
+	"github.com/klppl/klistr/internal/nostr"
 )
 
 // KV keys used to persist admin settings across restarts.
@@ -22,6 +26,8 @@ const (
 	kvExternalBaseURL   = "setting_external_base_url"
 	kvZapPubkey         = "setting_zap_pubkey"
 	kvZapSplit          = "setting_zap_split"
+	kvLightningAddress  = "setting_lightning_address"
+	kvBridgedKinds      = "setting_bridged_kinds"
 )
 
 type settingsResponse struct {
@@ -34,21 +40,28 @@ type settingsResponse struct {
 	ExternalBaseURL   string  `json:"external_base_url"`
 	ZapPubkey         string  `json:"zap_pubkey"`
 	ZapSplit          float64 `json:"zap_split"`
+	LightningAddress  string  `json:"lightning_address"`
+	BridgedKinds      []int   `json:"bridged_kinds"`
+	ToggleableKinds   []int   `json:"toggleable_kinds"`
 }
 
 // handleGetSettings returns all user-configurable settings.
 // GET /web/api/settings
 func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	profile := s.localProfile(s.cfg.Primary())
 	jsonResponse(w, settingsResponse{
 		ShowSourceLink:    s.showSourceLink.Load(),
 		AutoAcceptFollows: s.autoAcceptFollows.Load(),
-		DisplayName:       s.cfg.NostrDisplayName,
-		Summary:         s.cfg.NostrSummary,
-		Picture:         s.cfg.NostrPicture,
-		Banner:          s.cfg.NostrBanner,
+		DisplayName:       profile.DisplayName,
+		Summary:         profile.Summary,
+		Picture:         profile.Picture,
+		Banner:          profile.Banner,
 		ExternalBaseURL: s.cfg.ExternalBaseURL,
 		ZapPubkey:       s.cfg.ZapPubkey,
 		ZapSplit:        s.cfg.ZapSplit,
+		LightningAddress: s.cfg.LightningAddress,
+		BridgedKinds:     s.bridgedKinds.List(),
+		ToggleableKinds:  nostr.ToggleableKinds,
 	}, http.StatusOK)
 }
 
@@ -65,6 +78,8 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 		ExternalBaseURL *string  `json:"external_base_url"`
 		ZapPubkey       *string  `json:"zap_pubkey"`
 		ZapSplit        *float64 `json:"zap_split"`
+		LightningAddress *string `json:"lightning_address"`
+		BridgedKinds     *[]int  `json:"bridged_kinds"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid JSON body", http.StatusBadRequest)
@@ -94,7 +109,6 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.DisplayName != nil {
-		s.cfg.NostrDisplayName = *req.DisplayName
 		if err := s.store.SetKV(kvDisplayName, *req.DisplayName); err != nil {
 			slog.Warn("settings: failed to persist display_name", "error", err)
 		}
@@ -103,7 +117,6 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Summary != nil {
-		s.cfg.NostrSummary = *req.Summary
 		if err := s.store.SetKV(kvSummary, *req.Summary); err != nil {
 			slog.Warn("settings: failed to persist summary", "error", err)
 		}
@@ -112,7 +125,6 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Picture != nil {
-		s.cfg.NostrPicture = *req.Picture
 		if err := s.store.SetKV(kvPicture, *req.Picture); err != nil {
 			slog.Warn("settings: failed to persist picture", "error", err)
 		}
@@ -121,7 +133,6 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Banner != nil {
-		s.cfg.NostrBanner = *req.Banner
 		if err := s.store.SetKV(kvBanner, *req.Banner); err != nil {
 			slog.Warn("settings: failed to persist banner", "error", err)
 		}
@@ -153,6 +164,37 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 		changed = append(changed, "zap_split="+strconv.FormatFloat(*req.ZapSplit, 'f', -1, 64))
 	}
 
+	if req.LightningAddress != nil {
+		addr := strings.TrimSpace(*req.LightningAddress)
+		if addr != "" {
+			if _, err := lightningAddressURL(addr); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		s.cfg.LightningAddress = addr
+		if err := s.store.SetKV(kvLightningAddress, addr); err != nil {
+			slog.Warn("settings: failed to persist lightning_address", "error", err)
+		}
+		changed = append(changed, "lightning_address="+addr)
+	}
+
+	if req.BridgedKinds != nil && s.bridgedKinds != nil {
+		for _, k := range *req.BridgedKinds {
+			if !slices.Contains(nostr.ToggleableKinds, k) {
+				http.Error(w, "bridged_kinds: kind "+strconv.Itoa(k)+" cannot be toggled", http.StatusBadRequest)
+				return
+			}
+		}
+		s.bridgedKinds.Set(*req.BridgedKinds)
+		kinds := nostr.FormatKinds(s.bridgedKinds.List())
+		if err := s.store.SetKV(kvBridgedKinds, kinds); err != nil {
+			slog.Warn("settings: failed to persist bridged_kinds", "error", err)
+		}
+		slog.Info("settings: bridged_kinds updated", "kinds", kinds)
+		changed = append(changed, "bridged_kinds="+kinds)
+	}
+
 	if profileChanged && s.followPublisher != nil {
 		s.publishLocalKind0(r.Context())
 	}
@@ -174,11 +216,33 @@ func (s *Server) handleRepublishKind0(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s.publishLocalKind0(r.Context())
+	s.auditLog("kind0_republished", "")
 	jsonResponse(w, map[string]string{"message": "Kind-0 profile published to all relays."}, http.StatusOK)
 }
 
+// localProfile returns user with the profile fields saved through the admin
+// settings API applied, so edits take effect on every read without a restart.
+// Only the local user's profile is editable; the config values are the
+// defaults for fields that were never saved.
+func (s *Server) localProfile(user config.LocalUser) config.LocalUser {
+	if user.Username != s.cfg.NostrUsername {
+		return user
+	}
+	for key, field := range map[string]*string{
+		kvDisplayName: &user.DisplayName,
+		kvSummary:     &user.Summary,
+		kvPicture:     &user.Picture,
+		kvBanner:      &user.Banner,
+	} {
+		if v, ok := s.store.GetKV(key); ok {
+			*field = v
+		}
+	}
+	return user
+}
+
 // publishLocalKind0 signs and publishes a kind-0 metadata event for the local
-// user using the current profile settings in cfg.
+// user using the current profile settings.
 func (s *Server) publishLocalKind0(ctx context.Context) {
 	type profileContent struct {
 		Name        string `json:"name"`
@@ -188,12 +252,13 @@ func (s *Server) publishLocalKind0(ctx context.Context) {
 		Banner      string `json:"banner,omitempty"`
 	}
 
+	profile := s.localProfile(s.cfg.Primary())
 	content, err := json.Marshal(profileContent{
-		Name:        s.cfg.NostrUsername,
-		DisplayName: s.cfg.NostrDisplayName,
-		About:       s.cfg.NostrSummary,
-		Picture:     s.cfg.NostrPicture,
-		Banner:      s.cfg.NostrBanner,
+		Name:        profile.Username,
+		DisplayName: profile.DisplayName,
+		About:       profile.Summary,
+		Picture:     profile.Picture,
+		Banner:      profile.Banner,
 	})
 	if err != nil {
 		slog.Warn("settings: failed to marshal kind-0 content", "error", err)
@@ -213,5 +278,5 @@ func (s *Server) publishLocalKind0(ctx context.Context) {
 		slog.Warn("settings: failed to publish kind-0", "error", err)
 		return
 	}
-	slog.Info("settings: published kind-0 profile update", "display_name", s.cfg.NostrDisplayName)
+	slog.Info("settings: published kind-0 profile update", "display_name", profile.DisplayName)
 }
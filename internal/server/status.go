@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// publicStatus is the machine-readable form of the root page. It holds
+// nothing that is not already public: no keys, relay URLs or account names
+// beyond the domain.
+type publicStatus struct {
+	Software      string       `json:"software"`
+	Version       string       `json:"version"`
+	Domain        string       `json:"domain"`
+	StartedAt     int64        `json:"started_at"` // unix timestamp
+	UptimeSeconds int64        `json:"uptime_seconds"`
+	Bridges       []string     `json:"bridges"`
+	Relays        publicRelays `json:"relays"`
+	Followers     int          `json:"followers"` // Fediverse followers of the local user
+	Following     int          `json:"following"` // bridged follows of the local user
+}
+
+type publicRelays struct {
+	Configured int  `json:"configured"`
+	Connected  *int `json:"connected,omitempty"` // omitted without a RelayManager
+}
+
+// handleRoot serves the root page: a plain-text blurb for browsers, or a
+// publicStatus JSON document for monitors that send Accept: application/json
+// or ?format=json. Unlike GET /web/api/status it needs no authentication.
+//
+// GET /
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if r.URL.Query().Get("format") != "json" && !strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "klistr - a self-hosted personal bridge that connects your Nostr identity to the Fediverse and Bluesky.\nhttps://github.com/klppl/klistr\n\nRunning on %s\n", s.cfg.LocalDomain)
+		return
+	}
+
+	localActorURL := s.cfg.BaseURL("/users/" + s.cfg.NostrUsername)
+	status := publicStatus{
+		Software:      "klistr",
+		Version:       version,
+		Domain:        s.cfg.LocalDomain,
+		StartedAt:     s.startedAt.Unix(),
+		UptimeSeconds: int64(time.Since(s.startedAt).Seconds()),
+		Bridges:       []string{"activitypub"},
+		Relays:        publicRelays{Configured: len(s.cfg.NostrRelays)},
+	}
+	if s.cfg.BskyEnabled() {
+		status.Bridges = append(status.Bridges, "bluesky")
+	}
+	if s.relayManager != nil {
+		statuses := s.relayManager.RelayStatuses()
+		connected := 0
+		for _, st := range statuses {
+			if st.Connected {
+				connected++
+			}
+		}
+		status.Relays = publicRelays{Configured: len(statuses), Connected: &connected}
+	}
+	status.Followers, _ = s.store.CountAPFollowers(localActorURL)
+	status.Following, _ = s.store.CountFollowing(localActorURL)
+	jsonResponse(w, status, http.StatusOK)
+}
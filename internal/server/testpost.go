@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	gonostr "github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+const (
+	// maxTestPostLength caps the message of a test post.
+	maxTestPostLength = 500
+	// testPostBskyWait is how long handleTestPost waits for the Bluesky
+	// cross-post to be recorded before answering without it. Together with
+	// the relay publish it must stay under the server's 15s WriteTimeout.
+	testPostBskyWait = 10 * time.Second
+)
+
+// handleTestPost publishes a real, public kind-1 note as the local user, to
+// check the whole outbound pipeline after a relay or config change. The note
+// takes exactly the path of one posted from a Nostr client: it is signed and
+// published to the relays, the relay subscription hands it to nostr.Handler,
+// which federates it (ToNote + BuildCreate) to the Fediverse followers and
+// cross-posts it to Bluesky when the bridge is enabled.
+//
+// The response carries the note's ID on each network. The AP object ID is
+// known up front; the Bluesky URI is included when the cross-post is recorded
+// within testPostBskyWait.
+//
+// POST /web/api/test-post
+// Body: {"message":"Testing my bridge"}
+func (s *Server) handleTestPost(w http.ResponseWriter, r *http.Request) {
+	if s.followPublisher == nil {
+		jsonResponse(w, map[string]string{"error": "publisher not configured"}, http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		jsonResponse(w, map[string]string{"error": "message is required"}, http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(message) > maxTestPostLength {
+		jsonResponse(w, map[string]string{"error": "message is too long"}, http.StatusBadRequest)
+		return
+	}
+
+	event := &gonostr.Event{
+		Kind:      1,
+		Content:   message,
+		CreatedAt: gonostr.Now(),
+		Tags:      gonostr.Tags{},
+	}
+	if err := s.followPublisher.SignAsUser(event); err != nil {
+		jsonResponse(w, map[string]string{"error": "sign: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if err := s.followPublisher.Publish(r.Context(), event); err != nil {
+		jsonResponse(w, map[string]string{"error": "publish to relays: " + err.Error()}, http.StatusBadGateway)
+		return
+	}
+	s.auditLog("test_post", "event="+event.ID)
+	slog.Info("test post published", "id", event.ID)
+
+	resp := map[string]string{
+		"event_id": event.ID,
+		"ap_id":    s.cfg.BaseURL("/objects/" + event.ID),
+	}
+	var relays []string
+	if relay := s.cfg.PrimaryRelay(); relay != "" {
+		relays = []string{relay}
+	}
+	if nevent, err := nip19.EncodeEvent(event.ID, relays, event.PubKey); err == nil {
+		resp["nevent"] = nevent
+		resp["nostr_url"] = strings.TrimRight(s.cfg.ExternalBaseURL, "/") + "/" + nevent
+	}
+	if s.bskyClient != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), testPostBskyWait)
+		defer cancel()
+		if uri := s.waitForBskyPost(ctx, event.ID); uri != "" {
+			resp["bsky_uri"] = uri
+			if parts := strings.SplitN(strings.TrimPrefix(uri, "at://"), "/", 3); len(parts) == 3 {
+				resp["bsky_url"] = "https://bsky.app/profile/" + parts[0] + "/post/" + parts[2]
+			}
+		} else {
+			resp["bsky_error"] = "cross-post not recorded yet; check the log"
+		}
+	}
+	jsonResponse(w, resp, http.StatusOK)
+}
+
+// waitForBskyPost polls the object mappings until bsky.Poster has recorded the
+// AT URI of eventID's cross-post, returning "" when ctx expires first.
+func (s *Server) waitForBskyPost(ctx context.Context, eventID string) string {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if uri, ok := s.store.GetAPIDForObject(eventID); ok && strings.HasPrefix(uri, "at://") {
+			return uri
+		}
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-ticker.C:
+		}
+	}
+}
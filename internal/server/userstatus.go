@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"html"
+	"strconv"
+	"sync"
+	"time"
+
+	gonostr "github.com/nbd-wtf/go-nostr"
+
+	"github.com/klppl/klistr/internal/ap"
+	"github.com/klppl/klistr/internal/config"
+)
+
+const (
+	// userStatusFetchTimeout bounds the relay query for a user's status, which
+	// runs while a remote server waits for the actor document.
+	userStatusFetchTimeout = 3 * time.Second
+	// userStatusCacheTTL is how long a fetched status (or its absence) is
+	// reused before the relays are asked again.
+	userStatusCacheTTL = 2 * time.Minute
+)
+
+// userStatusKind is the NIP-38 user status kind.
+const userStatusKind = 30315
+
+// userStatus is one cached status lookup; text is empty when none is set.
+type userStatus struct {
+	text    string
+	expires time.Time
+}
+
+// userStatusCache holds the latest status per user pubkey. Concurrent misses
+// for the same pubkey share one relay query.
+type userStatusCache struct {
+	mu       sync.Mutex
+	statuses map[string]userStatus
+	pending  map[string]chan struct{} // closed when the query of pubkey is done
+}
+
+func (c *userStatusCache) put(pubkey, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.statuses == nil {
+		c.statuses = make(map[string]userStatus)
+	}
+	c.statuses[pubkey] = userStatus{text: text, expires: time.Now().Add(userStatusCacheTTL)}
+}
+
+// lookup returns the cached status of pubkey, or runs fetch and caches its
+// result. While a fetch for pubkey is running, other callers wait for it
+// instead of starting their own; a caller whose ctx ends first gets "".
+func (c *userStatusCache) lookup(ctx context.Context, pubkey string, fetch func() string) string {
+	for {
+		c.mu.Lock()
+		if st, ok := c.statuses[pubkey]; ok && time.Now().Before(st.expires) {
+			c.mu.Unlock()
+			return st.text
+		}
+		done, waiting := c.pending[pubkey]
+		if !waiting {
+			break // c.mu still held
+		}
+		c.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ""
+		}
+	}
+	if c.pending == nil {
+		c.pending = make(map[string]chan struct{})
+	}
+	done := make(chan struct{})
+	c.pending[pubkey] = done
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, pubkey)
+		c.mu.Unlock()
+		close(done)
+	}()
+	text := fetch()
+	c.put(pubkey, text)
+	return text
+}
+
+// applyUserStatus adds the user's current NIP-38 status to actor, as set by
+// NOSTR_STATUS: appended to the summary ("summary") or as a "Status" profile
+// field ("field"). Mastodon has no notion of a status, but shows both on the
+// profile. Does nothing when the mode is off or no status is set.
+func (s *Server) applyUserStatus(ctx context.Context, actor *ap.Actor, user config.LocalUser) {
+	if s.cfg.NostrStatus != "summary" && s.cfg.NostrStatus != "field" {
+		return
+	}
+	status := s.userStatus(ctx, user.PublicKey)
+	if status == "" {
+		return
+	}
+	if s.cfg.NostrStatus == "field" {
+		actor.Attachment = append(actor.Attachment, ap.PropertyValue{
+			Type:  "PropertyValue",
+			Name:  "Status",
+			Value: html.EscapeString(status),
+		})
+		return
+	}
+	actor.Summary += "<p>" + html.EscapeString(status) + "</p>"
+}
+
+// userStatus returns the text of pubkey's current status from the relays,
+// cached for userStatusCacheTTL. General statuses and music statuses (shown
+// with a 🎵 prefix) are considered; the newest unexpired one wins, and a
+// status with empty content means it was cleared.
+func (s *Server) userStatus(ctx context.Context, pubkey string) string {
+	return s.userStatuses.lookup(ctx, pubkey, func() string {
+		// Detached from ctx: the result is shared with the callers waiting on
+		// this query, so one requester going away must not cut it short.
+		return s.fetchUserStatus(context.WithoutCancel(ctx), pubkey)
+	})
+}
+
+// fetchUserStatus queries the relays for pubkey's current status.
+func (s *Server) fetchUserStatus(parentCtx context.Context, pubkey string) string {
+	ctx, cancel := context.WithTimeout(parentCtx, userStatusFetchTimeout)
+	defer cancel()
+	events := fetchAuthorEvents(ctx, s.cfg.NostrRelays, pubkey, gonostr.Filter{
+		Kinds: []int{userStatusKind},
+		Tags:  gonostr.TagMap{"d": []string{"general", "music"}},
+	})
+
+	text := ""
+	now := time.Now().Unix()
+	for _, ev := range events { // newest first
+		if exp := ev.Tags.GetFirst([]string{"expiration", ""}); exp != nil {
+			if ts, err := strconv.ParseInt((*exp)[1], 10, 64); err == nil && ts <= now {
+				continue
+			}
+		}
+		text = ev.Content
+		if text != "" && ev.Tags.GetD() == "music" {
+			text = "🎵 " + text
+		}
+		break
+	}
+	// Cached by lookup even when the query timed out, so slow relays cost at
+	// most one wait per userStatusCacheTTL.
+	return text
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUserStatusCacheCollapsesMisses(t *testing.T) {
+	var c userStatusCache
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func() string {
+		fetches.Add(1)
+		<-release
+		return "away"
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.lookup(context.Background(), "pk", fetch)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond) // let every caller reach the cache
+	close(release)
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetch ran %d times, want 1", n)
+	}
+	for i, got := range results {
+		if got != "away" {
+			t.Errorf("caller %d got %q, want %q", i, got, "away")
+		}
+	}
+	if got := c.lookup(context.Background(), "pk", fetch); got != "away" || fetches.Load() != 1 {
+		t.Errorf("cached lookup = %q after %d fetches, want a cache hit", got, fetches.Load())
+	}
+}
+
+func TestUserStatusCacheWaiterCancelled(t *testing.T) {
+	var c userStatusCache
+	release := make(chan struct{})
+	go c.lookup(context.Background(), "pk", func() string {
+		<-release
+		return "away"
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if got := c.lookup(ctx, "pk", func() string { t.Error("second fetch started"); return "" }); got != "" {
+		t.Errorf("cancelled waiter got %q, want none", got)
+	}
+	close(release)
+}